@@ -1,5 +1,9 @@
 package action
 
+import (
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+)
+
 type ProtocolVersion int
 
 type Action interface {
@@ -22,3 +26,21 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// OutputAction is implemented by asynchronous actions
+// whose output can be read while they run
+type OutputAction interface {
+	Output(since boshtask.OutputOffsets) (boshtask.Output, error)
+}
+
+// ProgressAction is implemented by asynchronous actions
+// that report progress while they run
+type ProgressAction interface {
+	Progress() interface{}
+}
+
+// FailedValue is implemented by values that describe failure of an action
+// in more detail than its error; such values are returned with the error
+type FailedValue interface {
+	Failed() bool
+}
@@ -7,6 +7,7 @@ import (
 
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
@@ -23,6 +24,7 @@ type ApplyAction struct {
 	applier         boshappl.Applier
 	specService     boshas.V1Service
 	settingsService boshsettings.Service
+	firewallManager boshfirewall.Manager
 	instanceDir     string
 	fs              boshsys.FileSystem
 }
@@ -31,12 +33,14 @@ func NewApply(
 	applier boshappl.Applier,
 	specService boshas.V1Service,
 	settingsService boshsettings.Service,
+	firewallManager boshfirewall.Manager,
 	dirProvider directories.Provider,
 	fs boshsys.FileSystem,
 ) (action ApplyAction) {
 	action.applier = applier
 	action.specService = specService
 	action.settingsService = settingsService
+	action.firewallManager = firewallManager
 	action.instanceDir = dirProvider.InstanceDir()
 	action.fs = fs
 	return
@@ -72,6 +76,11 @@ func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 		if err != nil {
 			return "", bosherr.WrapError(err, "Applying")
 		}
+
+		err = a.firewallManager.SetupFirewall(settings.Env.Bosh.Firewall, resolvedDesiredSpec.FirewallRules())
+		if err != nil {
+			return "", bosherr.WrapError(err, "Setting up firewall")
+		}
 	}
 
 	err = a.specService.Set(resolvedDesiredSpec)
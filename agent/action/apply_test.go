@@ -11,6 +11,7 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
+	fakefirewall "github.com/cloudfoundry/bosh-agent/platform/firewall/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
@@ -23,6 +24,7 @@ var _ = Describe("ApplyAction", func() {
 		applier         *fakeappl.FakeApplier
 		specService     *fakeas.FakeV1Service
 		settingsService *fakesettings.FakeSettingsService
+		firewallManager *fakefirewall.FakeManager
 		dirProvider     boshdir.Provider
 		action          ApplyAction
 		fs              boshsys.FileSystem
@@ -32,9 +34,10 @@ var _ = Describe("ApplyAction", func() {
 		applier = fakeappl.NewFakeApplier()
 		specService = fakeas.NewFakeV1Service()
 		settingsService = &fakesettings.FakeSettingsService{}
+		firewallManager = &fakefirewall.FakeManager{}
 		dirProvider = boshdir.NewProvider("/var/vcap")
 		fs = fakesys.NewFakeFileSystem()
-		action = NewApply(applier, specService, settingsService, dirProvider, fs)
+		action = NewApply(applier, specService, settingsService, firewallManager, dirProvider, fs)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -83,6 +86,34 @@ var _ = Describe("ApplyAction", func() {
 					})
 
 					Context("when applier succeeds applying desired spec", func() {
+						It("sets up firewall with baseline rules and ports of desired jobs", func() {
+							settingsService.Settings.Env.Bosh.Firewall = boshsettings.Firewall{DefaultPolicy: "drop"}
+							specService.PopulateDHCPNetworksResultSpec = boshas.V1ApplySpec{
+								ConfigurationHash: "fake-populated-desired-config-hash",
+								JobSpec: boshas.JobSpec{
+									JobTemplateSpecs: []boshas.JobTemplateSpec{
+										{Name: "fake-job-name", Ports: []boshas.PortSpec{{Port: 443}}},
+									},
+								},
+							}
+
+							_, err := action.Run(desiredApplySpec)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(firewallManager.SetupFirewallBaseline).To(Equal(boshsettings.Firewall{DefaultPolicy: "drop"}))
+							Expect(firewallManager.SetupFirewallJobRules).To(Equal([]boshsettings.FirewallRule{{Ports: []int{443}}}))
+						})
+
+						Context("when setting up firewall fails", func() {
+							It("returns error and does not save desired spec as current spec", func() {
+								firewallManager.SetupFirewallErr = errors.New("fake-firewall-error")
+
+								_, err := action.Run(desiredApplySpec)
+								Expect(err).To(HaveOccurred())
+								Expect(err.Error()).To(ContainSubstring("fake-firewall-error"))
+								Expect(specService.Spec).To(Equal(currentApplySpec))
+							})
+						})
+
 						Context("when saving desires spec as current spec succeeds", func() {
 							It("returns 'applied' after setting populated desired spec as current spec", func() {
 								value, err := action.Run(desiredApplySpec)
@@ -245,6 +276,11 @@ var _ = Describe("ApplyAction", func() {
 						Expect(err).ToNot(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
 					})
+					It("does not set up firewall since there are no jobs", func() {
+						_, err := action.Run(desiredApplySpec)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(firewallManager.SetupFirewallCalled).To(BeFalse())
+					})
 				})
 
 				Context("when saving desires spec as current spec fails", func() {
@@ -0,0 +1,96 @@
+package action
+
+import (
+	"errors"
+
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	auditLogDefaultLimit = 100
+	auditLogMaxLimit     = 1000
+)
+
+// AuditLogOptions are arguments of audit_log action
+type AuditLogOptions struct {
+	// From is sequence of first returned record
+	From uint64 `json:"from"`
+
+	// Limit is max number of returned records
+	Limit int `json:"limit"`
+}
+
+type AuditLogResult struct {
+	Records []boshaudit.Record `json:"records"`
+
+	// Verified is false when any record in the journal breaks hash chain
+	Verified    bool   `json:"verified"`
+	VerifyError string `json:"verify_error,omitempty"`
+
+	// Head should be kept by API consumer to detect journal being
+	// replaced on the VM together with its head since head was returned
+	Head boshaudit.Head `json:"head"`
+}
+
+type AuditLogAction struct {
+	auditJournal boshaudit.Journal
+}
+
+func NewAuditLog(auditJournal boshaudit.Journal) AuditLogAction {
+	return AuditLogAction{auditJournal: auditJournal}
+}
+
+func (a AuditLogAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a AuditLogAction) IsPersistent() bool {
+	return false
+}
+
+func (a AuditLogAction) IsLoggable() bool {
+	return true
+}
+
+func (a AuditLogAction) Run(options ...AuditLogOptions) (AuditLogResult, error) {
+	var opts AuditLogOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = auditLogDefaultLimit
+	} else if opts.Limit > auditLogMaxLimit {
+		opts.Limit = auditLogMaxLimit
+	}
+
+	records, err := a.auditJournal.Read(opts.From, opts.Limit)
+	if err != nil {
+		return AuditLogResult{}, bosherr.WrapError(err, "Reading audit log")
+	}
+
+	head, err := a.auditJournal.Head()
+	if err != nil {
+		return AuditLogResult{}, bosherr.WrapError(err, "Reading audit log head")
+	}
+
+	result := AuditLogResult{Records: records, Verified: true, Head: head}
+
+	err = a.auditJournal.Verify()
+	if err != nil {
+		result.Verified = false
+		result.VerifyError = err.Error()
+	}
+
+	return result, nil
+}
+
+func (a AuditLogAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a AuditLogAction) Cancel() error {
+	return errors.New("not supported")
+}
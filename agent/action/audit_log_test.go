@@ -0,0 +1,91 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
+	fakeaudit "github.com/cloudfoundry/bosh-agent/agent/audit/fakes"
+)
+
+var _ = Describe("AuditLogAction", func() {
+	var (
+		auditJournal *fakeaudit.FakeJournal
+		action       AuditLogAction
+	)
+
+	BeforeEach(func() {
+		auditJournal = &fakeaudit.FakeJournal{}
+		action = NewAuditLog(auditJournal)
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("returns range of verified records", func() {
+			auditJournal.ReadRecords = []boshaudit.Record{
+				{Sequence: 5, Method: "apply", Outcome: boshaudit.OutcomeSucceeded},
+			}
+			auditJournal.HeadHead = boshaudit.Head{Sequence: 7, Hash: "fake-hash"}
+
+			result, err := action.Run(AuditLogOptions{From: 5, Limit: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(AuditLogResult{
+				Records:  auditJournal.ReadRecords,
+				Verified: true,
+				Head:     boshaudit.Head{Sequence: 7, Hash: "fake-hash"},
+			}))
+
+			Expect(auditJournal.ReadFrom).To(Equal(uint64(5)))
+			Expect(auditJournal.ReadLimit).To(Equal(1))
+		})
+
+		It("returns first records when options are not given", func() {
+			_, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(auditJournal.ReadFrom).To(Equal(uint64(0)))
+			Expect(auditJournal.ReadLimit).To(Equal(100))
+		})
+
+		It("limits number of returned records", func() {
+			_, err := action.Run(AuditLogOptions{Limit: 5000})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(auditJournal.ReadLimit).To(Equal(1000))
+		})
+
+		It("returns records with verify error when journal was tampered with", func() {
+			auditJournal.VerifyErr = errors.New("fake-verify-err")
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Verified).To(BeFalse())
+			Expect(result.VerifyError).To(Equal("fake-verify-err"))
+		})
+
+		It("returns error when journal cannot be read", func() {
+			auditJournal.ReadErr = errors.New("fake-read-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-read-err"))
+		})
+
+		It("returns error when journal head cannot be read", func() {
+			auditJournal.HeadErr = errors.New("fake-head-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-head-err"))
+		})
+	})
+})
@@ -1,18 +1,29 @@
 package action
 
 import (
+	"code.cloudfoundry.org/clock"
+
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshlogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshnetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type concreteFactory struct {
@@ -31,13 +42,31 @@ func NewFactory(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
+	logRotator boshlogrotator.Rotator,
+	logStreamer boshlogtail.Streamer,
+	mbusHandler boshhandler.Handler,
+	auditJournal boshaudit.Journal,
 	logger boshlog.Logger,
 ) (factory Factory) {
-	compressor := platform.GetCompressor()
-	copier := platform.GetCopier()
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
+	timeService := clock.NewClock()
+
+	// Shared by drain and stop so that pre-stop scripts run once
+	preStop := NewPreStop(jobScriptProvider, logger)
+
+	// Shared by tail_logs and stop_tail_logs so that streams can be stopped by their ids
+	tailLogs := NewTailLogs(logStreamer, mbusHandler, boshuuid.NewGenerator(), logger)
+
+	networkChecker := boshnetcheck.NewChecker(
+		platform.GetRunner(),
+		boshnetcheck.NewDNSResolver(boshnetcheck.Timeout),
+		bosharp.NewArpingDuplicateAddressDetector(platform.GetRunner(), logger, boshplatform.ArpDuplicateProbes),
+		boship.NewSystemInterfaceAddressesProvider(),
+		boshnetcheck.Timeout,
+		logger,
+	)
 
 	factory = concreteFactory{
 		availableActions: map[string]Action{
@@ -51,18 +80,26 @@ func NewFactory(
 
 			// VM admin
 			"ssh":             NewSSH(settingsService, platform, dirProvider, logger),
-			"fetch_logs":      NewFetchLogs(compressor, copier, blobstore, dirProvider),
+			"fetch_logs":      NewFetchLogs(boshlogbundler.NewBundler(platform.GetFs(), logger), blobstore, platform.GetFs(), dirProvider),
+			"tail_logs":       tailLogs,
+			"stop_tail_logs":  NewStopTailLogs(tailLogs),
 			"update_settings": NewUpdateSettings(settingsService, platform, certManager, logger),
+			"audit_log":       NewAuditLog(auditJournal),
 
 			// Job management
-			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
-			"start":      NewStart(jobSupervisor, applier, specService),
-			"stop":       NewStop(jobSupervisor),
-			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService),
-			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
-			"run_script": NewRunScript(jobScriptProvider, specService, logger),
+			"prepare":     NewPrepare(applier),
+			"apply":       NewApply(applier, specService, settingsService, platform.GetFirewallManager(), dirProvider, platform.GetFs()),
+			"start":       NewStart(jobSupervisor, applier, specService),
+			"stop":        NewStop(jobSupervisor, specService, preStop),
+			"start_job":   NewStartJob(jobSupervisor, specService),
+			"stop_job":    NewStopJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+			"restart_job": NewRestartJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+			"drain":       NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, preStop, logger),
+			"get_state":   NewGetState(settingsService, specService, jobSupervisor, vitalsService, platform.GetFirewallManager(), logRotator),
+			"run_errand":  NewRunErrand(specService, dirProvider.JobsDir(), dirProvider.LogsDir(), platform.GetFs(), platform.GetCompressor(), blobstore, platform.GetRunner(), logger),
+			"run_script":  NewRunScript(jobScriptProvider, specService, logger),
+
+			"run_job_script": NewRunJobScript(specService, dirProvider, platform.GetFs(), platform.GetCompressor(), blobstore, platform.GetRunner(), timeService, logger),
 
 			// Compilation
 			"compile_package":    NewCompilePackage(compiler),
@@ -84,6 +121,7 @@ func NewFactory(
 			"prepare_network_change":     NewPrepareNetworkChange(platform.GetFs(), settingsService, NewAgentKiller()),
 			"prepare_configure_networks": NewPrepareConfigureNetworks(platform, settingsService),
 			"configure_networks":         NewConfigureNetworks(NewAgentKiller()),
+			"network_check":              NewNetworkCheck(settingsService, networkChecker),
 
 			// DNS
 			"sync_dns": NewSyncDNS(blobstore, settingsService, platform, logger),
@@ -1,21 +1,27 @@
 package action_test
 
 import (
+	"code.cloudfoundry.org/clock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 
+	boshlogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
+	fakeaudit "github.com/cloudfoundry/bosh-agent/agent/audit/fakes"
 	fakecomp "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
+	fakelogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator/fakes"
+	fakelogtail "github.com/cloudfoundry/bosh-agent/agent/logtail/fakes"
 	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/notification/fakes"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
@@ -37,6 +43,10 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
 		specService       *fakeas.FakeV1Service
 		jobScriptProvider boshscript.JobScriptProvider
+		logRotator        *fakelogrotator.FakeRotator
+		logStreamer       *fakelogtail.FakeStreamer
+		mbusHandler       *fakembus.FakeHandler
+		auditJournal      *fakeaudit.FakeJournal
 		factory           Factory
 		logger            boshlog.Logger
 	)
@@ -53,6 +63,10 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		logRotator = &fakelogrotator.FakeRotator{}
+		logStreamer = &fakelogtail.FakeStreamer{}
+		mbusHandler = fakembus.NewFakeHandler()
+		auditJournal = &fakeaudit.FakeJournal{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
 		factory = NewFactory(
@@ -67,6 +81,10 @@ var _ = Describe("concreteFactory", func() {
 			jobSupervisor,
 			specService,
 			jobScriptProvider,
+			logRotator,
+			logStreamer,
+			mbusHandler,
+			auditJournal,
 			logger,
 		)
 	})
@@ -80,7 +98,7 @@ var _ = Describe("concreteFactory", func() {
 	It("apply", func() {
 		action, err := factory.Create("apply")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewApply(applier, specService, settingsService, boshdir.NewProvider("/var/vcap"), platform.GetFs())))
+		Expect(action).To(Equal(NewApply(applier, specService, settingsService, platform.GetFirewallManager(), boshdir.NewProvider("/var/vcap"), platform.GetFs())))
 	})
 
 	It("drain", func() {
@@ -93,7 +111,30 @@ var _ = Describe("concreteFactory", func() {
 	It("fetch_logs", func() {
 		action, err := factory.Create("fetch_logs")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewFetchLogs(platform.GetCompressor(), platform.GetCopier(), blobstore, platform.GetDirProvider())))
+		Expect(action).To(Equal(NewFetchLogs(boshlogbundler.NewBundler(platform.GetFs(), logger), blobstore, platform.GetFs(), platform.GetDirProvider())))
+	})
+
+	It("tail_logs", func() {
+		action, err := factory.Create("tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since map of streams is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(TailLogsAction{}))
+	})
+
+	It("stop_tail_logs", func() {
+		action, err := factory.Create("stop_tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(BeAssignableToTypeOf(StopTailLogsAction{}))
+
+		tailLogs, err := factory.Create("tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewStopTailLogs(tailLogs.(TailLogsAction))))
+	})
+
+	It("audit_log", func() {
+		action, err := factory.Create("audit_log")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewAuditLog(auditJournal)))
 	})
 
 	It("get_task", func() {
@@ -111,7 +152,7 @@ var _ = Describe("concreteFactory", func() {
 	It("get_state", func() {
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService())))
+		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), platform.GetFirewallManager(), logRotator)))
 	})
 
 	It("list_disk", func() {
@@ -177,7 +218,25 @@ var _ = Describe("concreteFactory", func() {
 	It("stop", func() {
 		action, err := factory.Create("stop")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewStop(jobSupervisor)))
+		Expect(action).To(Equal(NewStop(jobSupervisor, specService, NewPreStop(jobScriptProvider, logger))))
+	})
+
+	It("start_job", func() {
+		action, err := factory.Create("start_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewStartJob(jobSupervisor, specService)))
+	})
+
+	It("stop_job", func() {
+		action, err := factory.Create("stop_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewStopJob(jobSupervisor, specService, jobScriptProvider, clock.NewClock(), logger)))
+	})
+
+	It("restart_job", func() {
+		action, err := factory.Create("restart_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewRestartJob(jobSupervisor, specService, jobScriptProvider, clock.NewClock(), logger)))
 	})
 
 	It("unmount_disk", func() {
@@ -206,12 +265,26 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewRunScript(jobScriptProvider, specService, logger)))
 	})
 
+	It("run_job_script", func() {
+		action, err := factory.Create("run_job_script")
+		Expect(err).ToNot(HaveOccurred())
+
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(RunJobScriptAction{}))
+	})
+
 	It("prepare", func() {
 		action, err := factory.Create("prepare")
 		Expect(err).ToNot(HaveOccurred())
 		Expect(action).To(Equal(NewPrepare(applier)))
 	})
 
+	It("network_check", func() {
+		action, err := factory.Create("network_check")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(BeAssignableToTypeOf(NetworkCheckAction{}))
+	})
+
 	It("delete_arp_entries", func() {
 		action, err := factory.Create("delete_arp_entries")
 		Expect(err).ToNot(HaveOccurred())
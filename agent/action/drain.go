@@ -2,6 +2,7 @@ package action
 
 import (
 	"errors"
+	"sync"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
@@ -17,10 +18,25 @@ type DrainAction struct {
 	notifier          boshnotif.Notifier
 	specService       boshas.V1Service
 	jobSupervisor     boshjobsuper.JobSupervisor
+	preStop           PreStop
 
 	logTag   string
 	logger   boshlog.Logger
 	cancelCh chan struct{}
+
+	// Shared by copies of the action since Progress() is called while it runs
+	running *drainScripts
+}
+
+// DrainJobStatus is last status reported by drain script of a job
+type DrainJobStatus struct {
+	Job string `json:"job"`
+	boshdrain.Status
+}
+
+type drainScripts struct {
+	lock    sync.RWMutex
+	scripts []boshscript.Script
 }
 
 type DrainType string
@@ -36,6 +52,7 @@ func NewDrain(
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	preStop PreStop,
 	logger boshlog.Logger,
 ) DrainAction {
 	return DrainAction{
@@ -43,10 +60,13 @@ func NewDrain(
 		specService:       specService,
 		jobScriptProvider: jobScriptProvider,
 		jobSupervisor:     jobSupervisor,
+		preStop:           preStop,
 
 		logTag:   "Drain Action",
 		logger:   logger,
 		cancelCh: make(chan struct{}, 1),
+
+		running: &drainScripts{},
 	}
 }
 
@@ -81,6 +101,13 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 	}
 	//TODO write health.json
 
+	a.logger.Debug(a.logTag, "Running pre-stop scripts")
+
+	err = a.preStop.RunBeforeDrain(currentSpec.Jobs(), params, a.cancelCh)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Running pre-stop scripts")
+	}
+
 	var scripts []boshscript.Script
 
 	for _, job := range currentSpec.Jobs() {
@@ -88,6 +115,9 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 		scripts = append(scripts, script)
 	}
 
+	a.setRunning(scripts)
+	defer a.setRunning(nil)
+
 	script := a.jobScriptProvider.NewParallelScript("drain", scripts)
 
 	resultsCh := make(chan error, 1)
@@ -95,13 +125,53 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 	select {
 	case result := <-resultsCh:
 		a.logger.Debug(a.logTag, "Got a result")
+		if result != nil {
+			a.preStop.DrainFailed()
+		}
 		return 0, result
 	case <-a.cancelCh:
 		a.logger.Debug(a.logTag, "Got a cancel request")
+		a.preStop.DrainFailed()
 		return 0, script.Cancel()
 	}
 }
 
+// Progress returns statuses reported by drain scripts that print JSON
+func (a DrainAction) Progress() interface{} {
+	a.running.lock.RLock()
+	defer a.running.lock.RUnlock()
+
+	var statuses []DrainJobStatus
+
+	for _, script := range a.running.scripts {
+		statusScript, ok := script.(boshscript.StatusScript)
+		if !ok {
+			continue
+		}
+
+		status := statusScript.Status()
+		if status.Message == "" && status.Progress == nil {
+			continue
+		}
+
+		statuses = append(statuses, DrainJobStatus{Job: script.Tag(), Status: status})
+	}
+
+	// Avoid returning typed nil so that get_task omits progress
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	return statuses
+}
+
+func (a DrainAction) setRunning(scripts []boshscript.Script) {
+	a.running.lock.Lock()
+	defer a.running.lock.Unlock()
+
+	a.running.scripts = scripts
+}
+
 func (a DrainAction) determineParams(drainType DrainType, currentSpec boshas.V1ApplySpec, newSpecs []boshas.V1ApplySpec) (boshdrain.ScriptParams, error) {
 	var newSpec *boshas.V1ApplySpec
 	var params boshdrain.ScriptParams
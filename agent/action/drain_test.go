@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
@@ -16,6 +17,7 @@ import (
 	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	fakenotif "github.com/cloudfoundry/bosh-agent/notification/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -27,6 +29,7 @@ var _ = Describe("DrainAction", func() {
 		jobScriptProvider *fakescript.FakeJobScriptProvider
 		fakeScripts       map[string]*fakedrain.FakeScript
 		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		preStop           *fakeaction.FakePreStop
 		action            DrainAction
 		logger            boshlog.Logger
 	)
@@ -38,7 +41,8 @@ var _ = Describe("DrainAction", func() {
 		specService = fakeas.NewFakeV1Service()
 		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-		action = NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger)
+		preStop = &fakeaction.FakePreStop{}
+		action = NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, preStop, logger)
 	})
 
 	BeforeEach(func() {
@@ -188,6 +192,104 @@ var _ = Describe("DrainAction", func() {
 						Expect(value).To(Equal(0))
 					})
 				})
+
+				Context("when jobs have pre-stop scripts", func() {
+					It("runs pre-stop scripts with update params before drain scripts", func() {
+						preStop.RunBeforeDrainStub = func(_ <-chan struct{}) error {
+							Expect(jobScriptProvider.NewDrainScriptCallCount()).To(Equal(0))
+							return nil
+						}
+
+						_, err := act()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(preStop.RunBeforeDrainCallCount()).To(Equal(1))
+						Expect(preStop.RunBeforeDrainJobs).To(Equal(currentSpec.Jobs()))
+						Expect(preStop.RunBeforeDrainParams).To(Equal(boshdrain.NewUpdateParams(currentSpec, newSpec)))
+						Expect(parallelScript.RunCallCount()).To(Equal(1))
+						Expect(preStop.DrainFailedCallCount()).To(Equal(0))
+					})
+
+					It("makes pre-stop scripts run again before stop when drain scripts fail", func() {
+						parallelScript.RunReturns(errors.New("fake-drain-error"))
+
+						_, err := act()
+						Expect(err).To(HaveOccurred())
+
+						Expect(preStop.RunBeforeDrainCallCount()).To(Equal(1))
+						Expect(preStop.DrainFailedCallCount()).To(Equal(1))
+					})
+
+					It("returns error and does not run drain scripts when pre-stop scripts fail", func() {
+						preStop.RunBeforeDrainErr = errors.New("fake-pre-stop-error")
+
+						_, err := act()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(Equal("Running pre-stop scripts: fake-pre-stop-error"))
+
+						Expect(parallelScript.RunCallCount()).To(Equal(0))
+					})
+
+					It("stops pre-stop scripts when drain is cancelled", func() {
+						preStop.RunBeforeDrainStub = func(cancelCh <-chan struct{}) error {
+							<-cancelCh
+							return errors.New("fake-cancelled-error")
+						}
+
+						Expect(action.Cancel()).To(Succeed())
+
+						_, err := act()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-cancelled-error"))
+						Expect(parallelScript.RunCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when drain scripts report status", func() {
+					It("returns statuses of jobs that reported them as progress while drain scripts run", func() {
+						progress := 40
+
+						var runningProgress interface{}
+
+						parallelScript.RunStub = func() error {
+							fakeScripts["foo"].StatusValue = boshdrain.Status{Message: "draining 120 connections", Progress: &progress}
+							runningProgress = action.Progress()
+							return nil
+						}
+
+						_, err := act()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(runningProgress).To(Equal([]DrainJobStatus{
+							{Job: "foo", Status: boshdrain.Status{Message: "draining 120 connections", Progress: &progress}},
+						}))
+
+						boshassert.MatchesJSONString(GinkgoT(), runningProgress,
+							`[{"job":"foo","message":"draining 120 connections","progress":40}]`)
+					})
+
+					It("returns nil progress when no job reported status", func() {
+						var runningProgress interface{} = "fake-progress"
+
+						parallelScript.RunStub = func() error {
+							runningProgress = action.Progress()
+							return nil
+						}
+
+						_, err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(runningProgress).To(BeNil())
+					})
+
+					It("returns nil progress once drain finishes", func() {
+						fakeScripts["foo"] = fakedrain.NewFakeScript("foo")
+						fakeScripts["foo"].StatusValue = boshdrain.Status{Message: "drained"}
+
+						_, err := act()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(action.Progress()).To(BeNil())
+					})
+				})
 			})
 
 			Context("when current agent spec does not have a job spec template", func() {
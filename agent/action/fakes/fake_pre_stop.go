@@ -0,0 +1,80 @@
+package fakes
+
+import (
+	"sync"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+)
+
+type FakePreStop struct {
+	RunBeforeDrainJobs   []boshmodels.Job
+	RunBeforeDrainParams boshdrain.ScriptParams
+	RunBeforeDrainErr    error
+
+	// Called instead of returning RunBeforeDrainErr when set
+	RunBeforeDrainStub func(cancelCh <-chan struct{}) error
+
+	RunBeforeStopJobs   []boshmodels.Job
+	RunBeforeStopParams boshdrain.ScriptParams
+	RunBeforeStopErr    error
+
+	lock                    sync.Mutex
+	runBeforeDrainCallCount int
+	runBeforeStopCallCount  int
+	drainFailedCallCount    int
+}
+
+func (p *FakePreStop) RunBeforeDrain(jobs []boshmodels.Job, params boshdrain.ScriptParams, cancelCh <-chan struct{}) error {
+	p.lock.Lock()
+	p.runBeforeDrainCallCount++
+	p.RunBeforeDrainJobs = jobs
+	p.RunBeforeDrainParams = params
+	stub := p.RunBeforeDrainStub
+	p.lock.Unlock()
+
+	if stub != nil {
+		return stub(cancelCh)
+	}
+
+	return p.RunBeforeDrainErr
+}
+
+func (p *FakePreStop) RunBeforeDrainCallCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.runBeforeDrainCallCount
+}
+
+func (p *FakePreStop) RunBeforeStop(jobs []boshmodels.Job, params boshdrain.ScriptParams) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.runBeforeStopCallCount++
+	p.RunBeforeStopJobs = jobs
+	p.RunBeforeStopParams = params
+
+	return p.RunBeforeStopErr
+}
+
+func (p *FakePreStop) RunBeforeStopCallCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.runBeforeStopCallCount
+}
+
+func (p *FakePreStop) DrainFailed() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.drainFailedCallCount++
+}
+
+func (p *FakePreStop) DrainFailedCallCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.drainFailedCallCount
+}
@@ -2,29 +2,53 @@ package action
 
 import (
 	"errors"
+	"time"
 
+	boshlogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type FetchLogsAction struct {
-	compressor  boshcmd.Compressor
-	copier      boshcmd.Copier
+	bundler     boshlogbundler.Bundler
 	blobstore   boshblob.DigestBlobstore
+	fs          boshsys.FileSystem
 	settingsDir boshdirs.Provider
 }
 
+// FetchLogsOptions are optional arguments of fetch_logs action
+type FetchLogsOptions struct {
+	// Since and Until select logs by their modification time;
+	// lines of selected logs are not filtered by their timestamps
+	Since *time.Time `json:"since"`
+	Until *time.Time `json:"until"`
+
+	// MaxBytes limits uncompressed size of fetched logs; newest logs are kept
+	MaxBytes uint64 `json:"max_bytes"`
+
+	// TailLines limits number of last lines fetched from each log
+	TailLines int `json:"tail_lines"`
+}
+
+type FetchLogsResult struct {
+	BlobstoreID string `json:"blobstore_id"`
+	SHA1        string `json:"sha1"`
+
+	// Manifest lists fetched logs and whether they were truncated
+	Manifest []boshlogbundler.Entry `json:"manifest"`
+}
+
 func NewFetchLogs(
-	compressor boshcmd.Compressor,
-	copier boshcmd.Copier,
+	bundler boshlogbundler.Bundler,
 	blobstore boshblob.DigestBlobstore,
+	fs boshsys.FileSystem,
 	settingsDir boshdirs.Provider,
 ) (action FetchLogsAction) {
-	action.compressor = compressor
-	action.copier = copier
+	action.bundler = bundler
 	action.blobstore = blobstore
+	action.fs = fs
 	action.settingsDir = settingsDir
 	return
 }
@@ -41,51 +65,69 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logType string, filters []string) (value map[string]string, err error) {
+func (a FetchLogsAction) Run(logType string, filters []string, options ...FetchLogsOptions) (FetchLogsResult, error) {
 	var logsDir string
 
 	switch logType {
 	case "job":
-		if len(filters) == 0 {
-			filters = []string{"**/*"}
-		}
 		logsDir = a.settingsDir.LogsDir()
 	case "agent":
-		if len(filters) == 0 {
-			filters = []string{"**/*"}
-		}
 		logsDir = a.settingsDir.AgentLogsDir()
 	default:
-		err = bosherr.Error("Invalid log type")
-		return
+		return FetchLogsResult{}, bosherr.Error("Invalid log type")
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
-	if err != nil {
-		err = bosherr.WrapError(err, "Copying filtered files to temp directory")
-		return
+	if len(filters) == 0 {
+		filters = []string{"**/*"}
 	}
 
-	defer a.copier.CleanUp(tmpDir)
+	var bundleOptions boshlogbundler.Options
+
+	if len(options) > 0 {
+		if options[0].Since != nil && options[0].Until != nil && options[0].Until.Before(*options[0].Since) {
+			return FetchLogsResult{}, bosherr.Error("Fetching logs until time before since time")
+		}
+
+		bundleOptions = boshlogbundler.Options{
+			Since:     options[0].Since,
+			Until:     options[0].Until,
+			MaxBytes:  options[0].MaxBytes,
+			TailLines: options[0].TailLines,
+		}
+	}
 
-	tarball, err := a.compressor.CompressFilesInDir(tmpDir)
+	// Blobstore uploads and digests files so logs are bundled
+	// straight into the file that is passed to it
+	tarball, err := a.fs.TempFile("bosh-agent-logs")
 	if err != nil {
-		err = bosherr.WrapError(err, "Making logs tarball")
-		return
+		return FetchLogsResult{}, bosherr.WrapError(err, "Creating logs tarball")
 	}
 
 	defer func() {
-		_ = a.compressor.CleanUp(tarball)
+		_ = a.fs.RemoveAll(tarball.Name())
 	}()
 
-	blobID, multidigestSha, err := a.blobstore.Create(tarball)
+	manifest, err := a.bundler.Bundle(logsDir, filters, bundleOptions, tarball)
+
+	closeErr := tarball.Close()
+	if err == nil && closeErr != nil {
+		err = bosherr.WrapError(closeErr, "Closing logs tarball")
+	}
+
 	if err != nil {
-		err = bosherr.WrapError(err, "Create file on blobstore")
-		return
+		return FetchLogsResult{}, bosherr.WrapError(err, "Making logs tarball")
 	}
 
-	value = map[string]string{"blobstore_id": blobID, "sha1": multidigestSha.String()}
-	return
+	blobID, multidigestSha, err := a.blobstore.Create(tarball.Name())
+	if err != nil {
+		return FetchLogsResult{}, bosherr.WrapError(err, "Create file on blobstore")
+	}
+
+	return FetchLogsResult{
+		BlobstoreID: blobID,
+		SHA1:        multidigestSha.String(),
+		Manifest:    manifest,
+	}, nil
 }
 
 func (a FetchLogsAction) Resume() (interface{}, error) {
@@ -1,34 +1,39 @@
 package action_test
 
 import (
+	"errors"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshlogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler"
+	fakelogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler/fakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
-	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("FetchLogsAction", func() {
 	var (
-		compressor  *fakecmd.FakeCompressor
-		copier      *fakecmd.FakeCopier
+		bundler     *fakelogbundler.FakeBundler
 		blobstore   *fakeblobstore.FakeDigestBlobstore
+		fs          *fakesys.FakeFileSystem
 		dirProvider boshdirs.Provider
 		action      FetchLogsAction
 	)
 
 	BeforeEach(func() {
-		compressor = fakecmd.NewFakeCompressor()
+		bundler = &fakelogbundler.FakeBundler{}
 		blobstore = &fakeblobstore.FakeDigestBlobstore{}
+		fs = fakesys.NewFakeFileSystem()
+		fs.ReturnTempFile = fakesys.NewFakeFile("/fake-tmp/logs.tgz", fs)
 		dirProvider = boshdirs.NewProvider("/fake/dir")
-		copier = fakecmd.NewFakeCopier()
-		action = NewFetchLogs(compressor, copier, blobstore, dirProvider)
+		action = NewFetchLogs(bundler, blobstore, fs, dirProvider)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -40,8 +45,10 @@ var _ = Describe("FetchLogsAction", func() {
 
 	Describe("Run", func() {
 		testLogs := func(logType string, filters []string, expectedFilters []string) {
-			copier.FilteredCopyToTempTempDir = "/fake-temp-dir"
-			compressor.CompressFilesInDirTarballPath = "logs_test.tar"
+			bundler.BundleEntries = []boshlogbundler.Entry{
+				{Path: "fake-job/fake-job.log", Size: 12, OriginalSize: 24, Truncated: true},
+			}
+
 			multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "sec_dep_sha1"))
 			sha1 := multidigestSha.String()
 			blobstore.CreateStub = func(fileName string) (blobID string, digest boshcrypto.MultipleDigest, err error) {
@@ -59,15 +66,14 @@ var _ = Describe("FetchLogsAction", func() {
 				expectedPath = filepath.Join("/fake", "dir", "bosh", "log")
 			}
 
-			Expect(copier.FilteredCopyToTempDir).To(boshassert.MatchPath(expectedPath))
-			Expect(copier.FilteredCopyToTempFilters).To(Equal(expectedFilters))
+			Expect(bundler.BundleDir).To(boshassert.MatchPath(expectedPath))
+			Expect(bundler.BundleFilters).To(Equal(expectedFilters))
+			Expect(bundler.BundleOptions).To(Equal(boshlogbundler.Options{}))
 
-			Expect(copier.FilteredCopyToTempTempDir).To(Equal(compressor.CompressFilesInDirDir))
-			Expect(copier.CleanUpTempDir).To(Equal(compressor.CompressFilesInDirDir))
+			Expect(blobstore.CreateArgsForCall(0)).To(Equal("/fake-tmp/logs.tgz"))
 
-			Expect(compressor.CompressFilesInDirTarballPath).To(Equal(blobstore.CreateArgsForCall(0)))
-
-			boshassert.MatchesJSONString(GinkgoT(), logs, `{"blobstore_id":"my-blob-id","sha1":"`+sha1+`"}`)
+			boshassert.MatchesJSONString(GinkgoT(), logs, `{"blobstore_id":"my-blob-id","sha1":"`+sha1+`",`+
+				`"manifest":[{"path":"fake-job/fake-job.log","size":12,"original_size":24,"truncated":true}]}`)
 		}
 
 		It("logs errs if given invalid log type", func() {
@@ -99,26 +105,81 @@ var _ = Describe("FetchLogsAction", func() {
 			testLogs("job", filters, expectedFilters)
 		})
 
-		It("cleans up compressed package after uploading it to blobstore", func() {
-			var beforeCleanUpTarballPath, afterCleanUpTarballPath string
+		It("bundles logs with given time window, max size and number of lines", func() {
+			since := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
+			until := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+			_, err := action.Run("job", []string{}, FetchLogsOptions{
+				Since:     &since,
+				Until:     &until,
+				MaxBytes:  1024,
+				TailLines: 100,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundler.BundleOptions).To(Equal(boshlogbundler.Options{
+				Since:     &since,
+				Until:     &until,
+				MaxBytes:  1024,
+				TailLines: 100,
+			}))
+		})
 
-			compressor.CompressFilesInDirTarballPath = "/fake-compressed-logs.tar"
+		It("returns error when until is before since", func() {
+			since := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+			until := time.Date(2026, time.October, 18, 10, 0, 0, 0, time.UTC)
 
-			blobstore.CreateStub = func(fileName string) (blobID string, digest boshcrypto.MultipleDigest, err error) {
-				beforeCleanUpTarballPath = compressor.CleanUpTarballPath
+			_, err := action.Run("job", []string{}, FetchLogsOptions{Since: &since, Until: &until})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("until time before since time"))
+
+			Expect(bundler.BundleDir).To(BeEmpty())
+		})
+
+		It("returns error when logs cannot be bundled", func() {
+			bundler.BundleErr = errors.New("fake-bundle-err")
 
-				return "my-blob-id", boshcrypto.MultipleDigest{}, nil
+			_, err := action.Run("job", []string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-bundle-err"))
+
+			Expect(blobstore.CreateCallCount()).To(Equal(0))
+		})
+
+		It("returns error when tarball cannot be created", func() {
+			fs.TempFileError = errors.New("fake-temp-file-err")
+
+			_, err := action.Run("job", []string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-temp-file-err"))
+
+			Expect(bundler.BundleDir).To(BeEmpty())
+		})
+
+		It("uploads bundled logs and removes tarball afterwards", func() {
+			bundler.BundleContent = "fake-tarball"
+
+			var uploadedContent string
+
+			blobstore.CreateStub = func(fileName string) (blobID string, digest boshcrypto.MultipleDigest, err error) {
+				uploadedContent, err = fs.ReadFileString(fileName)
+				return "my-blob-id", boshcrypto.MultipleDigest{}, err
 			}
 
 			_, err := action.Run("job", []string{})
 			Expect(err).ToNot(HaveOccurred())
 
-			// Logs are not cleaned up before blobstore upload
-			Expect(beforeCleanUpTarballPath).To(Equal(""))
+			Expect(uploadedContent).To(Equal("fake-tarball"))
+			Expect(fs.FileExists("/fake-tmp/logs.tgz")).To(BeFalse())
+		})
+
+		It("removes tarball when logs cannot be bundled", func() {
+			bundler.BundleErr = errors.New("fake-bundle-err")
+
+			_, err := action.Run("job", []string{})
+			Expect(err).To(HaveOccurred())
 
-			// Deleted after it was uploaded
-			afterCleanUpTarballPath = compressor.CleanUpTarballPath
-			Expect(afterCleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
+			Expect(fs.FileExists("/fake-tmp/logs.tgz")).To(BeFalse())
 		})
 	})
 })
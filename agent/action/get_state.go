@@ -4,7 +4,9 @@ import (
 	"errors"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -15,6 +17,8 @@ type GetStateAction struct {
 	specService     boshas.V1Service
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
+	firewallManager boshfirewall.Manager
+	logRotator      boshlogrotator.Rotator
 }
 
 func NewGetState(
@@ -22,11 +26,15 @@ func NewGetState(
 	specService boshas.V1Service,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
+	firewallManager boshfirewall.Manager,
+	logRotator boshlogrotator.Rotator,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
+	action.firewallManager = firewallManager
+	action.logRotator = logRotator
 	return
 }
 
@@ -50,6 +58,8 @@ type GetStateV1ApplySpec struct {
 	Vitals    *boshvitals.Vitals     `json:"vitals,omitempty"`
 	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 	VM        boshsettings.VM        `json:"vm"`
+	Firewall  string                 `json:"firewall,omitempty"`
+	LogUsage  []boshlogrotator.Usage `json:"log_usage,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -60,6 +70,8 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 
 	var vitals boshvitals.Vitals
 	var vitalsReference *boshvitals.Vitals
+	var firewallRuleset string
+	var logUsage []boshlogrotator.Usage
 
 	if len(filters) > 0 && filters[0] == "full" {
 		vitals, err = a.vitalsService.Get()
@@ -67,6 +79,16 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
 		}
 		vitalsReference = &vitals
+
+		firewallRuleset, err = a.firewallManager.ActiveRuleset()
+		if err != nil {
+			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting active firewall ruleset")
+		}
+
+		logUsage, err = a.logRotator.Usage()
+		if err != nil {
+			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting log usage")
+		}
 	}
 
 	processes, err := a.jobSupervisor.Processes()
@@ -83,6 +105,8 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		processes,
 		settings.VM,
+		firewallRuleset,
+		logUsage,
 	}
 
 	if value.NetworkSpecs == nil {
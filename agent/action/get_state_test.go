@@ -9,8 +9,11 @@ import (
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	fakelogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator/fakes"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	fakefirewall "github.com/cloudfoundry/bosh-agent/platform/firewall/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	fakevitals "github.com/cloudfoundry/bosh-agent/platform/vitals/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -24,6 +27,8 @@ var _ = Describe("GetState", func() {
 		specService     *fakeas.FakeV1Service
 		jobSupervisor   *fakejobsuper.FakeJobSupervisor
 		vitalsService   *fakevitals.FakeService
+		firewallManager *fakefirewall.FakeManager
+		logRotator      *fakelogrotator.FakeRotator
 		action          GetStateAction
 	)
 
@@ -32,7 +37,9 @@ var _ = Describe("GetState", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		vitalsService = fakevitals.NewFakeService()
-		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService)
+		firewallManager = &fakefirewall.FakeManager{}
+		logRotator = &fakelogrotator.FakeRotator{}
+		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, firewallManager, logRotator)
 	})
 
 	AssertActionIsNotAsynchronous(action)
@@ -74,6 +81,8 @@ var _ = Describe("GetState", func() {
 					Expect(state.JobState).To(Equal(expectedSpec.JobState))
 					Expect(state.Deployment).To(Equal(expectedSpec.Deployment))
 					boshassert.LacksJSONKey(GinkgoT(), state, "vitals")
+					boshassert.LacksJSONKey(GinkgoT(), state, "firewall")
+					boshassert.LacksJSONKey(GinkgoT(), state, "log_usage")
 
 					Expect(state).To(Equal(expectedSpec))
 				})
@@ -103,6 +112,10 @@ var _ = Describe("GetState", func() {
 					}
 
 					vitalsService.GetVitals = expectedVitals
+					firewallManager.ActiveRulesetRuleset = "fake-active-ruleset"
+					logRotator.UsageUsages = []boshlogrotator.Usage{
+						{Job: "fake-job", Bytes: 100, RotatedBytes: 20, RotatedFiles: 1},
+					}
 					expectedVM := map[string]interface{}{"name": "vm-abc-def"}
 
 					expectedProcesses := []boshjobsuper.Process{
@@ -125,6 +138,10 @@ var _ = Describe("GetState", func() {
 					Expect(*state.Vitals).To(Equal(expectedVitals))
 					Expect(state.Processes).To(Equal(expectedProcesses))
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
+					Expect(state.Firewall).To(Equal("fake-active-ruleset"))
+					Expect(state.LogUsage).To(Equal([]boshlogrotator.Usage{
+						{Job: "fake-job", Bytes: 100, RotatedBytes: 20, RotatedFiles: 1},
+					}))
 				})
 
 				Describe("non-populated field formatting", func() {
@@ -186,6 +203,26 @@ var _ = Describe("GetState", func() {
 			})
 		})
 
+		Context("when active firewall ruleset cannot be retrieved", func() {
+			It("returns error", func() {
+				firewallManager.ActiveRulesetErr = errors.New("fake-ruleset-error")
+
+				_, err := action.Run("full")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-ruleset-error"))
+			})
+		})
+
+		Context("when log usage cannot be retrieved", func() {
+			It("returns error", func() {
+				logRotator.UsageErr = errors.New("fake-usage-error")
+
+				_, err := action.Run("full")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Getting log usage: fake-usage-error"))
+			})
+		})
+
 		Context("when current spec cannot be retrieved", func() {
 			It("without current spec", func() {
 				specService.GetErr = errors.New("fake-spec-get-error")
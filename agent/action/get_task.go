@@ -7,6 +7,12 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// GetTaskOptions are arguments of get_task action
+type GetTaskOptions struct {
+	// OutputOffsets requests output written by running task after offsets
+	OutputOffsets *boshtask.OutputOffsets `json:"output_offsets"`
+}
+
 type GetTaskAction struct {
 	taskService boshtask.Service
 }
@@ -28,17 +34,32 @@ func (a GetTaskAction) IsLoggable() bool {
 	return true
 }
 
-func (a GetTaskAction) Run(taskID string) (interface{}, error) {
+func (a GetTaskAction) Run(taskID string, options ...GetTaskOptions) (interface{}, error) {
 	task, found := a.taskService.FindTaskWithID(taskID)
 	if !found {
 		return nil, bosherr.Errorf("Task with id %s could not be found", taskID)
 	}
 
 	if task.State == boshtask.StateRunning {
-		return boshtask.StateValue{
+		value := boshtask.StateValue{
 			AgentTaskID: task.ID,
 			State:       task.State,
-		}, nil
+		}
+
+		if len(options) > 0 && options[0].OutputOffsets != nil && task.OutputFunc != nil {
+			output, err := task.OutputFunc(*options[0].OutputOffsets)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Reading output of task %s", taskID)
+			}
+
+			value.Output = &output
+		}
+
+		if task.ProgressFunc != nil {
+			value.Progress = task.ProgressFunc()
+		}
+
+		return value, nil
 	}
 
 	if task.Error != nil {
@@ -44,6 +44,89 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	Context("when task output can be read while it runs", func() {
+		var (
+			since boshtask.OutputOffsets
+		)
+
+		BeforeEach(func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:    "fake-task-id",
+				State: boshtask.StateRunning,
+				OutputFunc: func(s boshtask.OutputOffsets) (boshtask.Output, error) {
+					since = s
+					return boshtask.Output{
+						Stdout:  "fake-stdout",
+						Offsets: boshtask.OutputOffsets{Stdout: s.Stdout + 11, Stderr: s.Stderr},
+					}, nil
+				},
+			}
+		})
+
+		It("returns output written after requested offsets", func() {
+			taskValue, err := action.Run("fake-task-id", GetTaskOptions{
+				OutputOffsets: &boshtask.OutputOffsets{Stdout: 5, Stderr: 3},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(since).To(Equal(boshtask.OutputOffsets{Stdout: 5, Stderr: 3}))
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running","output":{"stdout":"fake-stdout","stderr":"","offsets":{"stdout":16,"stderr":3}}}`)
+		})
+
+		It("does not return output unless it is requested", func() {
+			taskValue, err := action.Run("fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running"}`)
+		})
+
+		It("returns error when output cannot be read", func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:    "fake-task-id",
+				State: boshtask.StateRunning,
+				OutputFunc: func(_ boshtask.OutputOffsets) (boshtask.Output, error) {
+					return boshtask.Output{}, errors.New("fake-output-err")
+				},
+			}
+
+			_, err := action.Run("fake-task-id", GetTaskOptions{OutputOffsets: &boshtask.OutputOffsets{}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-output-err"))
+		})
+	})
+
+	Context("when task reports progress while it runs", func() {
+		It("returns progress of task", func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:           "fake-task-id",
+				State:        boshtask.StateRunning,
+				ProgressFunc: func() interface{} { return map[string]int{"fake-key": 40} },
+			}
+
+			taskValue, err := action.Run("fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running","progress":{"fake-key":40}}`)
+		})
+
+		It("omits progress when task has not reported any", func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:           "fake-task-id",
+				State:        boshtask.StateRunning,
+				ProgressFunc: func() interface{} { return nil },
+			}
+
+			taskValue, err := action.Run("fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running"}`)
+		})
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
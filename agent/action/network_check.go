@@ -0,0 +1,46 @@
+package action
+
+import (
+	"errors"
+
+	boshnetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type NetworkCheckAction struct {
+	settingsService boshsettings.Service
+	checker         boshnetcheck.Checker
+}
+
+func NewNetworkCheck(
+	settingsService boshsettings.Service,
+	checker boshnetcheck.Checker,
+) (action NetworkCheckAction) {
+	action.settingsService = settingsService
+	action.checker = checker
+	return
+}
+
+func (a NetworkCheckAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a NetworkCheckAction) IsPersistent() bool {
+	return false
+}
+
+func (a NetworkCheckAction) IsLoggable() bool {
+	return true
+}
+
+func (a NetworkCheckAction) Run() (boshnetcheck.Report, error) {
+	return a.checker.Check(a.settingsService.GetSettings()), nil
+}
+
+func (a NetworkCheckAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a NetworkCheckAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,49 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshnetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck"
+	fakenetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+var _ = Describe("NetworkCheck", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		checker         *fakenetcheck.FakeChecker
+		action          NetworkCheckAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		checker = &fakenetcheck.FakeChecker{}
+		action = NewNetworkCheck(settingsService, checker)
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotCancelable(action)
+	AssertActionIsNotResumable(action)
+
+	It("runs checks against current settings and returns report", func() {
+		settingsService.Settings = boshsettings.Settings{Mbus: "fake-mbus"}
+
+		checker.CheckReport = boshnetcheck.Report{
+			Passed: false,
+			Checks: []boshnetcheck.Result{
+				{Check: "mbus", Target: "fake-mbus", Status: "failed", Message: "fake-err"},
+			},
+		}
+
+		report, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report).To(Equal(checker.CheckReport))
+		Expect(checker.CheckSettings).To(Equal(boshsettings.Settings{Mbus: "fake-mbus"}))
+	})
+})
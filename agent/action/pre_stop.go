@@ -0,0 +1,109 @@
+package action
+
+import (
+	"sync"
+
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// PreStop runs pre-stop scripts of jobs before they are drained or stopped.
+// Director drains jobs before stopping them so scripts are run only once.
+type PreStop interface {
+	// RunBeforeDrain runs scripts in parallel until they finish or cancelCh receives
+	RunBeforeDrain(jobs []boshmodels.Job, params boshdrain.ScriptParams, cancelCh <-chan struct{}) error
+
+	// RunBeforeStop runs scripts unless they already ran before drain
+	RunBeforeStop(jobs []boshmodels.Job, params boshdrain.ScriptParams) error
+
+	// DrainFailed makes next RunBeforeStop run scripts again
+	// since stop may not be part of deploy that ran them
+	DrainFailed()
+}
+
+type concretePreStop struct {
+	jobScriptProvider boshscript.JobScriptProvider
+
+	logTag string
+	logger boshlog.Logger
+
+	lock           sync.Mutex
+	ranBeforeDrain bool
+}
+
+func NewPreStop(jobScriptProvider boshscript.JobScriptProvider, logger boshlog.Logger) PreStop {
+	return &concretePreStop{
+		jobScriptProvider: jobScriptProvider,
+
+		logTag: "PreStop",
+		logger: logger,
+	}
+}
+
+func (p *concretePreStop) RunBeforeDrain(jobs []boshmodels.Job, params boshdrain.ScriptParams, cancelCh <-chan struct{}) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ranBeforeDrain = false
+
+	err := p.run(jobs, params, cancelCh)
+	if err != nil {
+		return err
+	}
+
+	p.ranBeforeDrain = true
+
+	return nil
+}
+
+func (p *concretePreStop) RunBeforeStop(jobs []boshmodels.Job, params boshdrain.ScriptParams) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.ranBeforeDrain {
+		p.logger.Debug(p.logTag, "Skipping pre-stop scripts since they ran before drain")
+		p.ranBeforeDrain = false
+		return nil
+	}
+
+	return p.run(jobs, params, nil)
+}
+
+func (p *concretePreStop) DrainFailed() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.ranBeforeDrain = false
+}
+
+func (p *concretePreStop) run(jobs []boshmodels.Job, params boshdrain.ScriptParams, cancelCh <-chan struct{}) error {
+	var scripts []boshscript.Script
+
+	for _, job := range jobs {
+		scripts = append(scripts, p.jobScriptProvider.NewPreStopScript(job.BundleName(), params))
+	}
+
+	script := p.jobScriptProvider.NewParallelScript("pre-stop", scripts)
+
+	resultsCh := make(chan error, 1)
+	go func() { resultsCh <- script.Run() }()
+
+	select {
+	case err := <-resultsCh:
+		return err
+
+	case <-cancelCh:
+		p.logger.Debug(p.logTag, "Got a cancel request")
+
+		err := script.Cancel()
+		if err != nil {
+			return bosherr.WrapError(err, "Cancelling pre-stop scripts")
+		}
+
+		// Cancelled scripts return an error once they are terminated
+		return <-resultsCh
+	}
+}
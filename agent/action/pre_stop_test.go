@@ -0,0 +1,144 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	fakedrain "github.com/cloudfoundry/bosh-agent/agent/script/drain/fakes"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("PreStop", func() {
+	var (
+		jobScriptProvider *fakescript.FakeJobScriptProvider
+		parallelScript    *fakescript.FakeCancellableScript
+		params            *fakedrain.FakeScriptParams
+		jobs              []boshmodels.Job
+		preStop           PreStop
+	)
+
+	BeforeEach(func() {
+		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		jobScriptProvider.NewPreStopScriptStub = func(jobName string, _ boshdrain.ScriptParams) boshscript.CancellableScript {
+			script := &fakescript.FakeCancellableScript{}
+			script.TagReturns(jobName)
+			return script
+		}
+
+		parallelScript = &fakescript.FakeCancellableScript{}
+		jobScriptProvider.NewParallelScriptReturns(parallelScript)
+
+		params = &fakedrain.FakeScriptParams{}
+		jobs = []boshmodels.Job{{Name: "fake-job1"}, {Name: "fake-job2"}}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		preStop = NewPreStop(jobScriptProvider, logger)
+	})
+
+	Describe("RunBeforeStop", func() {
+		It("runs pre-stop scripts of all jobs in parallel", func() {
+			Expect(preStop.RunBeforeStop(jobs, params)).To(Succeed())
+
+			Expect(jobScriptProvider.NewPreStopScriptCallCount()).To(Equal(2))
+
+			jobName, scriptParams := jobScriptProvider.NewPreStopScriptArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job1"))
+			Expect(scriptParams).To(Equal(params))
+
+			jobName, _ = jobScriptProvider.NewPreStopScriptArgsForCall(1)
+			Expect(jobName).To(Equal("fake-job2"))
+
+			scriptName, scripts := jobScriptProvider.NewParallelScriptArgsForCall(0)
+			Expect(scriptName).To(Equal("pre-stop"))
+			Expect(scripts).To(HaveLen(2))
+			Expect(scripts[0].Tag()).To(Equal("fake-job1"))
+			Expect(scripts[1].Tag()).To(Equal("fake-job2"))
+
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+		})
+
+		It("returns error when pre-stop scripts fail", func() {
+			parallelScript.RunReturns(errors.New("fake-run-error"))
+
+			err := preStop.RunBeforeStop(jobs, params)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-run-error"))
+		})
+
+		It("does not run pre-stop scripts again when they already ran before drain", func() {
+			Expect(preStop.RunBeforeDrain(jobs, params, nil)).To(Succeed())
+			Expect(preStop.RunBeforeStop(jobs, params)).To(Succeed())
+
+			Expect(parallelScript.RunCallCount()).To(Equal(1))
+
+			// Next stop is not preceded by drain
+			Expect(preStop.RunBeforeStop(jobs, params)).To(Succeed())
+			Expect(parallelScript.RunCallCount()).To(Equal(2))
+		})
+
+		It("runs pre-stop scripts again when drain failed after they ran", func() {
+			Expect(preStop.RunBeforeDrain(jobs, params, nil)).To(Succeed())
+			preStop.DrainFailed()
+
+			Expect(preStop.RunBeforeStop(jobs, params)).To(Succeed())
+			Expect(parallelScript.RunCallCount()).To(Equal(2))
+		})
+
+		It("runs pre-stop scripts again when they failed before drain", func() {
+			parallelScript.RunReturns(errors.New("fake-run-error"))
+			Expect(preStop.RunBeforeDrain(jobs, params, nil)).ToNot(Succeed())
+
+			parallelScript.RunReturns(nil)
+			Expect(preStop.RunBeforeStop(jobs, params)).To(Succeed())
+			Expect(parallelScript.RunCallCount()).To(Equal(2))
+		})
+	})
+
+	Describe("RunBeforeDrain", func() {
+		It("cancels pre-stop scripts when cancel channel receives", func() {
+			doneCh := make(chan struct{})
+
+			parallelScript.RunStub = func() error {
+				<-doneCh
+				return errors.New("fake-cancelled-error")
+			}
+			parallelScript.CancelStub = func() error {
+				close(doneCh)
+				return nil
+			}
+
+			cancelCh := make(chan struct{}, 1)
+			cancelCh <- struct{}{}
+
+			err := preStop.RunBeforeDrain(jobs, params, cancelCh)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-cancelled-error"))
+			Expect(parallelScript.CancelCallCount()).To(Equal(1))
+		})
+
+		It("returns error when pre-stop scripts cannot be cancelled", func() {
+			doneCh := make(chan struct{})
+			defer close(doneCh)
+
+			parallelScript.RunStub = func() error {
+				<-doneCh
+				return nil
+			}
+			parallelScript.CancelReturns(errors.New("fake-cancel-error"))
+
+			cancelCh := make(chan struct{}, 1)
+			cancelCh <- struct{}{}
+
+			err := preStop.RunBeforeDrain(jobs, params, cancelCh)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Cancelling pre-stop scripts: fake-cancel-error"))
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package action
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type RestartJobAction struct {
+	stopJob  StopJobAction
+	startJob StartJobAction
+}
+
+func NewRestartJob(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) RestartJobAction {
+	return RestartJobAction{
+		stopJob:  NewStopJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+		startJob: NewStartJob(jobSupervisor, specService),
+	}
+}
+
+func (a RestartJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RestartJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a RestartJobAction) IsLoggable() bool {
+	return true
+}
+
+// Run stops processes of the job, waits for them to exit and starts them again
+func (a RestartJobAction) Run(jobName string, options ...JobOptions) (string, error) {
+	_, err := a.stopJob.Run(jobName, options...)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Restarting job %s", jobName)
+	}
+
+	_, err = a.startJob.Run(jobName)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Restarting job %s", jobName)
+	}
+
+	return "restarted", nil
+}
+
+func (a RestartJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RestartJobAction) Cancel() error {
+	return errors.New("not supported")
+}
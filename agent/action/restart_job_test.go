@@ -0,0 +1,71 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("RestartJob", func() {
+	var (
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		specService   *fakeas.FakeV1Service
+		action        RestartJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		jobScriptProvider := &fakescript.FakeJobScriptProvider{}
+		timeService := fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		action = NewRestartJob(jobSupervisor, specService, jobScriptProvider, timeService, logger)
+
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+		}
+
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-proc", Job: "fake-job", State: "stopped"},
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("stops and starts processes of the job and returns restarted", func() {
+		restarted, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restarted).To(Equal("restarted"))
+
+		Expect(jobSupervisor.StoppedProcesses()).To(Equal([]string{"fake-proc"}))
+		Expect(jobSupervisor.StartedProcesses()).To(Equal([]string{"fake-proc"}))
+	})
+
+	It("does not start processes if stopping them fails", func() {
+		jobSupervisor.StopProcessErr = errors.New("fake-stop-error")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Restarting job fake-job"))
+		Expect(jobSupervisor.StartedProcesses()).To(BeEmpty())
+	})
+})
@@ -3,11 +3,14 @@ package action
 import (
 	"errors"
 	"path"
-	"time"
 
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	"github.com/cloudfoundry/bosh-agent/agent/script/cmd"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
@@ -17,27 +20,38 @@ const runErrandActionLogTag = "runErrandAction"
 type RunErrandAction struct {
 	specService boshas.V1Service
 	jobsDir     string
+	logsDir     string
 	cmdRunner   boshsys.CmdRunner
 	logger      boshlog.Logger
 
 	cancelCh chan struct{}
+
+	// Shared by all runs since same action handles all requests
+	output *scriptOutput
 }
 
 func NewRunErrand(
 	specService boshas.V1Service,
 	jobsDir string,
+	logsDir string,
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	blobstore boshblob.DigestBlobstore,
 	cmdRunner boshsys.CmdRunner,
 	logger boshlog.Logger,
 ) RunErrandAction {
 	return RunErrandAction{
 		specService: specService,
 		jobsDir:     jobsDir,
+		logsDir:     logsDir,
 		cmdRunner:   cmdRunner,
 		logger:      logger,
 
 		// Initialize channel in a constructor to avoid race
 		// between initializing in Run()/Cancel()
 		cancelCh: make(chan struct{}, 1),
+
+		output: newScriptOutput(fs, compressor, blobstore, logger),
 	}
 }
 
@@ -53,10 +67,17 @@ func (a RunErrandAction) IsLoggable() bool {
 	return true
 }
 
+// ErrandResult is also returned by run_job_script
+// since job scripts are run same way as errands
 type ErrandResult struct {
 	Stdout     string `json:"stdout"`
 	Stderr     string `json:"stderr"`
 	ExitStatus int    `json:"exit_code"`
+
+	// Set when stdout or stderr above is truncated;
+	// blob is a tarball with full stdout and stderr
+	OutputBlobstoreID string `json:"output_blobstore_id,omitempty"`
+	OutputSHA1        string `json:"output_sha1,omitempty"`
 }
 
 func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
@@ -90,36 +111,40 @@ func (a RunErrandAction) Run(errandName ...string) (ErrandResult, error) {
 
 	command := cmd.BuildCommand(path.Join(a.jobsDir, templateName, "bin", "run"))
 
+	// Full output is kept in job's log dir
+	stdoutFile, stderrFile, err := a.output.open(path.Join(a.logsDir, templateName), "errand")
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Opening errand output")
+	}
+
+	defer a.output.close(stdoutFile, stderrFile)
+
+	command.Stdout = stdoutFile
+	command.Stderr = stderrFile
+
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Running errand script")
 	}
 
-	var result boshsys.Result
-
-	// Can only wait once on a process but cancelling can happen multiple times
-	for processExitedCh := process.Wait(); processExitedCh != nil; {
-		select {
-		case result = <-processExitedCh:
-			processExitedCh = nil
-		case <-a.cancelCh:
-			// Ignore possible TerminateNicely error since we cannot return it
-			err := process.TerminateNicely(10 * time.Second)
-			if err != nil {
-				a.logger.Error(runErrandActionLogTag, "Failed to terminate %s", err.Error())
-			}
-		}
-	}
+	// Errands run without timeout until they are cancelled
+	result := boshscript.WaitOrTerminate(process, nil, a.cancelCh, runErrandActionLogTag, a.logger).Result
 
 	if result.Error != nil && result.ExitStatus == -1 {
 		return ErrandResult{}, bosherr.WrapError(result.Error, "Running errand script")
 	}
 
-	return ErrandResult{
-		Stdout:     result.Stdout,
-		Stderr:     result.Stderr,
-		ExitStatus: result.ExitStatus,
-	}, nil
+	errandResult, err := a.output.result(stdoutFile, stderrFile, result.ExitStatus)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Getting errand output")
+	}
+
+	return errandResult, nil
+}
+
+// Output returns output written by running errand after given offsets
+func (a RunErrandAction) Output(since boshtask.OutputOffsets) (boshtask.Output, error) {
+	return a.output.read(since)
 }
 
 func (a RunErrandAction) Resume() (interface{}, error) {
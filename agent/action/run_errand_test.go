@@ -2,6 +2,12 @@ package action_test
 
 import (
 	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -12,26 +18,72 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	boshenv "github.com/cloudfoundry/bosh-agent/agent/script/pathenv"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
-	"runtime"
 )
 
+// outputCmdRunner writes output to command's stdout and stderr
+// since fake processes do not
+type outputCmdRunner struct {
+	*fakesys.FakeCmdRunner
+
+	Stdout string
+	Stderr string
+
+	// Called after output is written but before process is started
+	StartedCallBack func()
+}
+
+func (r *outputCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	if cmd.Stdout != nil {
+		_, _ = io.WriteString(cmd.Stdout, r.Stdout)
+	}
+
+	if cmd.Stderr != nil {
+		_, _ = io.WriteString(cmd.Stderr, r.Stderr)
+	}
+
+	if r.StartedCallBack != nil {
+		r.StartedCallBack()
+	}
+
+	return r.FakeCmdRunner.RunComplexCommandAsync(cmd)
+}
+
 var _ = Describe("RunErrand", func() {
 	var (
 		specService *fakeas.FakeV1Service
-		cmdRunner   *fakesys.FakeCmdRunner
+		cmdRunner   *outputCmdRunner
+		compressor  *fakecmd.FakeCompressor
+		blobstore   *fakeblobstore.FakeDigestBlobstore
+		logsDir     string
 		action      RunErrandAction
 		errandName  string
 		fullCommand string
 	)
 
 	BeforeEach(func() {
+		var err error
+
+		logsDir, err = ioutil.TempDir("", "run-errand-logs")
+		Expect(err).ToNot(HaveOccurred())
+
 		specService = fakeas.NewFakeV1Service()
-		cmdRunner = fakesys.NewFakeCmdRunner()
+		cmdRunner = &outputCmdRunner{
+			FakeCmdRunner: fakesys.NewFakeCmdRunner(),
+			Stdout:        "fake-stdout",
+			Stderr:        "fake-stderr",
+		}
+		compressor = fakecmd.NewFakeCompressor()
+		blobstore = &fakeblobstore.FakeDigestBlobstore{}
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		action = NewRunErrand(specService, "/fake-jobs-dir", cmdRunner, logger)
+		fs := boshsys.NewOsFileSystem(logger)
+		action = NewRunErrand(specService, "/fake-jobs-dir", logsDir, fs, compressor, blobstore, cmdRunner, logger)
 		errandName = "fake-job-name"
 		if runtime.GOOS == "windows" {
 			fullCommand = "powershell /fake-jobs-dir/fake-job-name/bin/run"
@@ -40,6 +92,10 @@ var _ = Describe("RunErrand", func() {
 		}
 	})
 
+	AfterEach(func() {
+		Expect(os.RemoveAll(logsDir)).To(Succeed())
+	})
+
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
@@ -163,6 +219,88 @@ var _ = Describe("RunErrand", func() {
 						Expect(result).To(Equal(ErrandResult{}))
 					})
 				})
+
+				Context("when errand output is written", func() {
+					BeforeEach(func() {
+						cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+					})
+
+					It("keeps full output in job's log dir", func() {
+						_, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+
+						stdout, err := ioutil.ReadFile(filepath.Join(logsDir, "fake-job-name", "errand.stdout.log"))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(stdout)).To(Equal("fake-stdout"))
+
+						stderr, err := ioutil.ReadFile(filepath.Join(logsDir, "fake-job-name", "errand.stderr.log"))
+						Expect(err).ToNot(HaveOccurred())
+						Expect(string(stderr)).To(Equal("fake-stderr"))
+					})
+
+					It("replaces output of previous run", func() {
+						_, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+
+						cmdRunner.Stdout = "new"
+						cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+						result, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.Stdout).To(Equal("new"))
+					})
+
+					It("does not upload output when it is not truncated", func() {
+						result, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.OutputBlobstoreID).To(BeEmpty())
+						Expect(blobstore.CreateCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when errand output is longer than result can include", func() {
+					BeforeEach(func() {
+						cmdRunner.Stdout = strings.Repeat("x", 200*1024) + "fake-last-line\n"
+						cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+						compressor.CompressSpecificFilesInDirTarballPath = "/fake-tarball.tgz"
+						blobstore.CreateReturns("fake-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+					})
+
+					It("returns truncated output and uploads full output to blobstore", func() {
+						result, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(len(result.Stdout)).To(BeNumerically("<=", 128*1024))
+						Expect(result.Stdout).To(HaveSuffix("fake-last-line\n"))
+						Expect(result.Stderr).To(Equal("fake-stderr"))
+						Expect(result.OutputBlobstoreID).To(Equal("fake-blob-id"))
+						Expect(result.OutputSHA1).To(Equal("fake-sha1"))
+
+						Expect(compressor.CompressSpecificFilesInDirDir).To(Equal(filepath.Join(logsDir, "fake-job-name")))
+						Expect(compressor.CompressSpecificFilesInDirFiles).To(Equal([]string{"errand.stdout.log", "errand.stderr.log"}))
+						Expect(blobstore.CreateArgsForCall(0)).To(Equal("/fake-tarball.tgz"))
+						Expect(compressor.CleanUpTarballPath).To(Equal("/fake-tarball.tgz"))
+					})
+
+					It("returns errand result without blob when output cannot be uploaded", func() {
+						blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
+
+						result, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.Stdout).To(HaveSuffix("fake-last-line\n"))
+						Expect(result.OutputBlobstoreID).To(BeEmpty())
+						Expect(result.OutputSHA1).To(BeEmpty())
+					})
+
+					It("returns errand result without blob when output cannot be compressed", func() {
+						compressor.CompressSpecificFilesInDirErr = errors.New("fake-compress-err")
+
+						result, err := action.Run(errandName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.OutputBlobstoreID).To(BeEmpty())
+						Expect(blobstore.CreateCallCount()).To(Equal(0))
+					})
+				})
 			})
 
 			Context("when current agent spec does not have a job spec template", func() {
@@ -203,6 +341,80 @@ var _ = Describe("RunErrand", func() {
 		})
 	})
 
+	Describe("Output", func() {
+		BeforeEach(func() {
+			currentSpec := boshas.V1ApplySpec{}
+			currentSpec.JobSpec.Template = "fake-job-name"
+			specService.Spec = currentSpec
+
+			cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+		})
+
+		runWhileRunning := func(outputFunc func()) {
+			cmdRunner.StartedCallBack = outputFunc
+
+			_, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("returns output written since given offsets while errand runs", func() {
+			runWhileRunning(func() {
+				output, err := action.Output(boshtask.OutputOffsets{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(boshtask.Output{
+					Stdout:  "fake-stdout",
+					Stderr:  "fake-stderr",
+					Offsets: boshtask.OutputOffsets{Stdout: 11, Stderr: 11},
+				}))
+
+				output, err = action.Output(boshtask.OutputOffsets{Stdout: 5, Stderr: 11})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(boshtask.Output{
+					Stdout:  "stdout",
+					Offsets: boshtask.OutputOffsets{Stdout: 11, Stderr: 11},
+				}))
+			})
+		})
+
+		It("returns at most 64KB of each stream at once", func() {
+			cmdRunner.Stdout = strings.Repeat("x", 100*1024)
+
+			runWhileRunning(func() {
+				output, err := action.Output(boshtask.OutputOffsets{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.Stdout).To(HaveLen(64 * 1024))
+				Expect(output.Offsets.Stdout).To(Equal(int64(64 * 1024)))
+
+				output, err = action.Output(output.Offsets)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.Stdout).To(HaveLen(36 * 1024))
+				Expect(output.Offsets.Stdout).To(Equal(int64(100 * 1024)))
+			})
+		})
+
+		It("does not split multi-byte characters", func() {
+			cmdRunner.Stdout = strings.Repeat("x", 64*1024-1) + "\u00e9"
+
+			runWhileRunning(func() {
+				output, err := action.Output(boshtask.OutputOffsets{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.Offsets.Stdout).To(Equal(int64(64*1024 - 1)))
+
+				output, err = action.Output(output.Offsets)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output.Stdout).To(Equal("\u00e9"))
+			})
+		})
+
+		It("returns no output when errand is not running", func() {
+			runWhileRunning(nil)
+
+			output, err := action.Output(boshtask.OutputOffsets{Stdout: 3})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(Equal(boshtask.Output{Offsets: boshtask.OutputOffsets{Stdout: 3}}))
+		})
+	})
+
 	Describe("Cancel", func() {
 		BeforeEach(func() {
 			currentSpec := boshas.V1ApplySpec{
@@ -0,0 +1,230 @@
+package action
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	"github.com/cloudfoundry/bosh-agent/agent/script/cmd"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	runJobScriptActionLogTag = "runJobScriptAction"
+
+	runJobScriptDefaultTimeout = 10 * time.Minute
+)
+
+var (
+	// Each segment of script name must not start with a dot
+	// so that script name cannot point outside of job's bin dir
+	jobScriptNameSegmentRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+	jobScriptEnvNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// RunJobScriptOptions are optional arguments of run_job_script action
+type RunJobScriptOptions struct {
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env"`
+
+	// Timeout is number of seconds after which script is terminated
+	Timeout int `json:"timeout"`
+}
+
+// RunJobScriptAction runs named script from job's bin dir
+// (e.g. bin/ops/rotate-keys) on a single job
+type RunJobScriptAction struct {
+	specService boshas.V1Service
+	dirProvider boshdirs.Provider
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	cancelCh chan struct{}
+
+	// Output of script that is running at the moment
+	// so that get_task can return it before script finishes
+	output *scriptOutput
+}
+
+func NewRunJobScript(
+	specService boshas.V1Service,
+	dirProvider boshdirs.Provider,
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	blobstore boshblob.DigestBlobstore,
+	cmdRunner boshsys.CmdRunner,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) RunJobScriptAction {
+	return RunJobScriptAction{
+		specService: specService,
+		dirProvider: dirProvider,
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		timeService: timeService,
+		logger:      logger,
+
+		// Initialize channel in a constructor to avoid race
+		// between initializing in Run()/Cancel()
+		cancelCh: make(chan struct{}, 1),
+
+		output: newScriptOutput(fs, compressor, blobstore, logger),
+	}
+}
+
+func (a RunJobScriptAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RunJobScriptAction) IsPersistent() bool {
+	return false
+}
+
+func (a RunJobScriptAction) IsLoggable() bool {
+	return true
+}
+
+func (a RunJobScriptAction) Run(jobName string, scriptName string, options ...RunJobScriptOptions) (ErrandResult, error) {
+	var opts RunJobScriptOptions
+
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	err := a.validate(scriptName, opts)
+	if err != nil {
+		return ErrandResult{}, err
+	}
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	foundJob := false
+	for _, v := range currentSpec.JobSpec.JobTemplateSpecs {
+		if v.Name == jobName {
+			foundJob = true
+		}
+	}
+
+	if !foundJob {
+		return ErrandResult{}, bosherr.Errorf("Could not find job %s", jobName)
+	}
+
+	scriptPath := filepath.Join(a.dirProvider.JobBinDir(jobName), filepath.FromSlash(scriptName)+boshscript.ScriptExt)
+
+	if !a.fs.FileExists(scriptPath) {
+		return ErrandResult{}, bosherr.Errorf("Job %s does not have script %s", jobName, scriptName)
+	}
+
+	command := cmd.BuildCommand(scriptPath)
+	command.Args = append(command.Args, opts.Args...)
+
+	for name, value := range opts.Env {
+		// Keep agent's PATH so that scripts find what other job scripts do
+		if _, found := command.Env[name]; !found {
+			command.Env[name] = value
+		}
+	}
+
+	// Full output is kept in job's log dir next to output of other job scripts
+	outputDir := filepath.Join(a.dirProvider.LogsDir(), jobName, filepath.FromSlash(path.Dir(scriptName)))
+
+	stdoutFile, stderrFile, err := a.output.open(outputDir, path.Base(scriptName))
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Opening job script output")
+	}
+
+	defer a.output.close(stdoutFile, stderrFile)
+
+	command.Stdout = stdoutFile
+	command.Stderr = stderrFile
+
+	timeout := runJobScriptDefaultTimeout
+	if opts.Timeout > 0 {
+		timeout = time.Duration(opts.Timeout) * time.Second
+	}
+
+	timer := a.timeService.NewTimer(timeout)
+	defer timer.Stop()
+
+	process, err := a.cmdRunner.RunComplexCommandAsync(command)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapErrorf(err, "Running job script %s", scriptName)
+	}
+
+	waitResult := boshscript.WaitOrTerminate(process, timer.C(), a.cancelCh, runJobScriptActionLogTag, a.logger)
+	result := waitResult.Result
+
+	if waitResult.TimedOut {
+		return ErrandResult{}, bosherr.Errorf("Timed out after %s running job script %s", timeout, scriptName)
+	}
+
+	if result.Error != nil && result.ExitStatus == -1 {
+		return ErrandResult{}, bosherr.WrapErrorf(result.Error, "Running job script %s", scriptName)
+	}
+
+	scriptResult, err := a.output.result(stdoutFile, stderrFile, result.ExitStatus)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Getting job script output")
+	}
+
+	return scriptResult, nil
+}
+
+// Output returns output written by running script after given offsets
+func (a RunJobScriptAction) Output(since boshtask.OutputOffsets) (boshtask.Output, error) {
+	return a.output.read(since)
+}
+
+func (a RunJobScriptAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+// Cancel follows same rules as cancelling errands
+func (a RunJobScriptAction) Cancel() error {
+	select {
+	case a.cancelCh <- struct{}{}:
+	default:
+		// Cancel action is already queued up
+	}
+
+	return nil
+}
+
+func (a RunJobScriptAction) validate(scriptName string, opts RunJobScriptOptions) error {
+	for _, segment := range strings.Split(scriptName, "/") {
+		if !jobScriptNameSegmentRegexp.MatchString(segment) {
+			return bosherr.Errorf("Invalid job script name '%s'", scriptName)
+		}
+	}
+
+	for name := range opts.Env {
+		if !jobScriptEnvNameRegexp.MatchString(name) {
+			return bosherr.Errorf("Invalid environment variable name '%s'", name)
+		}
+	}
+
+	if opts.Timeout < 0 {
+		return bosherr.Errorf("Invalid timeout %d", opts.Timeout)
+	}
+
+	return nil
+}
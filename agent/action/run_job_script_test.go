@@ -0,0 +1,259 @@
+package action_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshenv "github.com/cloudfoundry/bosh-agent/agent/script/pathenv"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("RunJobScript", func() {
+	var (
+		specService *fakeas.FakeV1Service
+		cmdRunner   *outputCmdRunner
+		compressor  *fakecmd.FakeCompressor
+		blobstore   *fakeblobstore.FakeDigestBlobstore
+		timeService *fakeclock.FakeClock
+		baseDir     string
+		dirProvider boshdirs.Provider
+		action      RunJobScriptAction
+		scriptPath  string
+		fullCommand string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		baseDir, err = ioutil.TempDir("", "run-job-script")
+		Expect(err).ToNot(HaveOccurred())
+
+		dirProvider = boshdirs.NewProvider(baseDir)
+
+		scriptPath = filepath.Join(dirProvider.JobBinDir("fake-job"), "ops", "rotate-keys"+boshscript.ScriptExt)
+		Expect(os.MkdirAll(filepath.Dir(scriptPath), 0750)).To(Succeed())
+		Expect(ioutil.WriteFile(scriptPath, []byte("fake-script"), 0750)).To(Succeed())
+
+		if runtime.GOOS == "windows" {
+			fullCommand = "powershell " + scriptPath
+		} else {
+			fullCommand = scriptPath
+		}
+
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{
+					{Name: "other-job"},
+					{Name: "fake-job"},
+				},
+			},
+		}
+
+		cmdRunner = &outputCmdRunner{
+			FakeCmdRunner: fakesys.NewFakeCmdRunner(),
+			Stdout:        "fake-stdout",
+			Stderr:        "fake-stderr",
+		}
+		compressor = fakecmd.NewFakeCompressor()
+		blobstore = &fakeblobstore.FakeDigestBlobstore{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		action = NewRunJobScript(specService, dirProvider, fs, compressor, blobstore, cmdRunner, timeService, logger)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+
+	Describe("Run", func() {
+		It("runs job script with arguments and environment", func() {
+			cmdRunner.AddProcess(fullCommand+" --dry-run fake-key", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 3},
+			})
+
+			result, err := action.Run("fake-job", "ops/rotate-keys", RunJobScriptOptions{
+				Args: []string{"--dry-run", "fake-key"},
+				Env:  map[string]string{"FAKE_ENV": "fake-value", "PATH": "fake-path"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ErrandResult{
+				Stdout:     "fake-stdout",
+				Stderr:     "fake-stderr",
+				ExitStatus: 3,
+			}))
+
+			Expect(cmdRunner.RunComplexCommands[0].Env).To(Equal(map[string]string{
+				"PATH":     boshenv.Path(),
+				"FAKE_ENV": "fake-value",
+			}))
+		})
+
+		It("keeps full output in job's log dir", func() {
+			cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+			_, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).ToNot(HaveOccurred())
+
+			stdout, err := ioutil.ReadFile(filepath.Join(dirProvider.LogsDir(), "fake-job", "ops", "rotate-keys.stdout.log"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stdout)).To(Equal("fake-stdout"))
+
+			stderr, err := ioutil.ReadFile(filepath.Join(dirProvider.LogsDir(), "fake-job", "ops", "rotate-keys.stderr.log"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stderr)).To(Equal("fake-stderr"))
+		})
+
+		It("uploads full output when it is truncated", func() {
+			cmdRunner.Stderr = strings.Repeat("x", 200*1024)
+			cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+			blobstore.CreateReturns("fake-blob-id", boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1")), nil)
+
+			result, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(result.Stderr)).To(BeNumerically("<=", 128*1024))
+			Expect(result.OutputBlobstoreID).To(Equal("fake-blob-id"))
+			Expect(result.OutputSHA1).To(Equal("fake-sha1"))
+
+			Expect(compressor.CompressSpecificFilesInDirFiles).To(Equal([]string{"rotate-keys.stdout.log", "rotate-keys.stderr.log"}))
+		})
+
+		It("exposes output while script runs", func() {
+			cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+			cmdRunner.StartedCallBack = func() {
+				output, err := action.Output(boshtask.OutputOffsets{Stdout: 5})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(boshtask.Output{
+					Stdout:  "stdout",
+					Stderr:  "fake-stderr",
+					Offsets: boshtask.OutputOffsets{Stdout: 11, Stderr: 11},
+				}))
+			}
+
+			_, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("terminates script and returns error when it times out", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			}
+			cmdRunner.AddProcess(fullCommand, process)
+			cmdRunner.StartedCallBack = func() {
+				timeService.Increment(30 * time.Second)
+			}
+
+			_, err := action.Run("fake-job", "ops/rotate-keys", RunJobScriptOptions{Timeout: 30})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Timed out after 30s running job script ops/rotate-keys"))
+
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+
+		It("terminates script when action is cancelled", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			}
+			cmdRunner.AddProcess(fullCommand, process)
+
+			Expect(action.Cancel()).To(Succeed())
+
+			result, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ExitStatus).To(Equal(143))
+		})
+
+		It("returns error when script fails to execute", func() {
+			cmdRunner.AddProcess(fullCommand, &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{
+					ExitStatus: -1,
+					Error:      errors.New("fake-bosh-error"),
+				},
+			})
+
+			_, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-bosh-error"))
+		})
+
+		It("returns error when job is not part of current spec", func() {
+			_, err := action.Run("missing-job", "ops/rotate-keys")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Could not find job missing-job"))
+			Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+		})
+
+		It("returns error when job does not have script", func() {
+			_, err := action.Run("fake-job", "ops/reindex")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Job fake-job does not have script ops/reindex"))
+		})
+
+		It("returns error when current spec cannot be retrieved", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := action.Run("fake-job", "ops/rotate-keys")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		DescribeTable("rejects script names outside of job's bin dir",
+			func(scriptName string) {
+				_, err := action.Run("fake-job", scriptName)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Invalid job script name '" + scriptName + "'"))
+				Expect(cmdRunner.RunComplexCommands).To(BeEmpty())
+			},
+			Entry("parent dir", "../other-job/bin/run"),
+			Entry("nested parent dir", "ops/../../../other-job/bin/run"),
+			Entry("absolute path", "/bin/sh"),
+			Entry("hidden file", "ops/.rotate-keys"),
+			Entry("backslash", `ops\rotate-keys`),
+			Entry("empty segment", "ops//rotate-keys"),
+			Entry("empty name", ""),
+		)
+
+		It("rejects invalid environment variable names", func() {
+			_, err := action.Run("fake-job", "ops/rotate-keys", RunJobScriptOptions{
+				Env: map[string]string{"FAKE=ENV": "fake-value"},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Invalid environment variable name 'FAKE=ENV'"))
+		})
+	})
+})
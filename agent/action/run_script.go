@@ -43,13 +43,28 @@ func (a RunScriptAction) IsLoggable() bool {
 	return true
 }
 
-func (a RunScriptAction) Run(scriptName string, options map[string]interface{}) (map[string]string, error) {
-	// May be used in future to return more information
-	emptyResults := map[string]string{}
+// RunScriptResult includes result of script of each job that has the script
+type RunScriptResult struct {
+	Jobs []boshscript.Result `json:"jobs"`
+}
+
+// Failed is true when script of any job failed
+func (r RunScriptResult) Failed() bool {
+	for _, job := range r.Jobs {
+		if job.Error != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a RunScriptAction) Run(scriptName string, options map[string]interface{}) (RunScriptResult, error) {
+	result := RunScriptResult{Jobs: []boshscript.Result{}}
 
 	currentSpec, err := a.specService.Get()
 	if err != nil {
-		return emptyResults, bosherr.WrapError(err, "Getting current spec")
+		return result, bosherr.WrapError(err, "Getting current spec")
 	}
 
 	var scripts []boshscript.Script
@@ -61,7 +76,14 @@ func (a RunScriptAction) Run(scriptName string, options map[string]interface{})
 
 	parallelScript := a.scriptProvider.NewParallelScript(scriptName, scripts)
 
-	return emptyResults, parallelScript.Run()
+	resultsScript, ok := parallelScript.(boshscript.ResultsScript)
+	if !ok {
+		return result, parallelScript.Run()
+	}
+
+	result.Jobs, err = resultsScript.RunWithResults()
+
+	return result, err
 }
 
 func (a RunScriptAction) Resume() (interface{}, error) {
@@ -37,7 +37,7 @@ var _ = Describe("RunScript", func() {
 	AssertActionIsNotCancelable(action)
 
 	Describe("Run", func() {
-		act := func() (map[string]string, error) { return action.Run("run-me", map[string]interface{}{}) }
+		act := func() (RunScriptResult, error) { return action.Run("run-me", map[string]interface{}{}) }
 
 		Context("when current spec can be retrieved", func() {
 			var parallelScript *fakescript.FakeCancellableScript
@@ -77,7 +77,7 @@ var _ = Describe("RunScript", func() {
 
 				results, err := act()
 				Expect(err).ToNot(HaveOccurred())
-				Expect(results).To(Equal(map[string]string{}))
+				Expect(results).To(Equal(RunScriptResult{Jobs: []boshscript.Result{}}))
 
 				Expect(parallelScript.RunCallCount()).To(Equal(1))
 
@@ -92,7 +92,48 @@ var _ = Describe("RunScript", func() {
 				results, err := act()
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-error"))
-				Expect(results).To(Equal(map[string]string{}))
+				Expect(results).To(Equal(RunScriptResult{Jobs: []boshscript.Result{}}))
+			})
+		})
+
+		Context("when parallel script reports results of each job", func() {
+			var parallelScript *fakescript.FakeResultsScript
+
+			BeforeEach(func() {
+				parallelScript = &fakescript.FakeResultsScript{}
+				fakeJobScriptProvider.NewParallelScriptReturns(parallelScript)
+
+				parallelScript.RunWithResultsResults = []boshscript.Result{
+					{Job: "fake-job-1", ExitStatus: 0, Duration: 1.5, Stdout: "fake-stdout"},
+					{Job: "fake-job-2", ExitStatus: 3, Duration: 2, Stderr: "fake-stderr", Error: "fake-error"},
+				}
+			})
+
+			It("returns result of each job", func() {
+				results, err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(Equal(RunScriptResult{Jobs: parallelScript.RunWithResultsResults}))
+				Expect(results.Failed()).To(BeTrue())
+
+				Expect(parallelScript.RunWithResultsCallCount()).To(Equal(1))
+				Expect(parallelScript.RunCallCount()).To(Equal(0))
+			})
+
+			It("returns result of each job together with error when parallel script fails", func() {
+				parallelScript.RunWithResultsErr = errors.New("fake-error")
+
+				results, err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("fake-error"))
+				Expect(results.Jobs).To(HaveLen(2))
+			})
+
+			It("is not failed when all jobs succeed", func() {
+				parallelScript.RunWithResultsResults[1].Error = ""
+
+				results, err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results.Failed()).To(BeFalse())
 			})
 		})
 
@@ -103,7 +144,7 @@ var _ = Describe("RunScript", func() {
 				results, err := act()
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-spec-get-error"))
-				Expect(results).To(Equal(map[string]string{}))
+				Expect(results).To(Equal(RunScriptResult{Jobs: []boshscript.Result{}}))
 			})
 		})
 	})
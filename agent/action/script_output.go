@@ -0,0 +1,219 @@
+package action
+
+import (
+	"io"
+	"os"
+	"path"
+	"sync"
+	"unicode/utf8"
+
+	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	scriptOutputLogTag = "scriptOutput"
+
+	// Results include only last part of output so that they fit into
+	// a response; full output is uploaded to blobstore when they do not
+	scriptOutputTruncateLength = 128 * 1024
+
+	// Max length of output returned at once while script runs
+	scriptOutputChunkLength = 64 * 1024
+)
+
+// scriptOutput keeps output of a running script in files under job's
+// log dir so that it can be read while script runs and uploaded
+// in full after it finishes. Same instance is shared by all runs of an action.
+type scriptOutput struct {
+	fs         boshsys.FileSystem
+	compressor boshcmd.Compressor
+	blobstore  boshblob.DigestBlobstore
+	logger     boshlog.Logger
+
+	lock       sync.Mutex
+	stdoutPath string
+	stderrPath string
+}
+
+func newScriptOutput(
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	blobstore boshblob.DigestBlobstore,
+	logger boshlog.Logger,
+) *scriptOutput {
+	return &scriptOutput{
+		fs:         fs,
+		compressor: compressor,
+		blobstore:  blobstore,
+		logger:     logger,
+	}
+}
+
+// open truncates <name>.stdout.log and <name>.stderr.log in dir
+// and makes them readable via read until close is called
+func (o *scriptOutput) open(dir, name string) (boshsys.File, boshsys.File, error) {
+	err := o.fs.MkdirAll(dir, os.FileMode(0750))
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Creating output dir")
+	}
+
+	stdoutFile, err := o.fs.OpenFile(path.Join(dir, name+".stdout.log"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(0640))
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Opening stdout")
+	}
+
+	stderrFile, err := o.fs.OpenFile(path.Join(dir, name+".stderr.log"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(0640))
+	if err != nil {
+		_ = stdoutFile.Close()
+		return nil, nil, bosherr.WrapError(err, "Opening stderr")
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.stdoutPath = stdoutFile.Name()
+	o.stderrPath = stderrFile.Name()
+
+	return stdoutFile, stderrFile, nil
+}
+
+func (o *scriptOutput) close(stdoutFile, stderrFile boshsys.File) {
+	o.lock.Lock()
+	o.stdoutPath = ""
+	o.stderrPath = ""
+	o.lock.Unlock()
+
+	_ = stdoutFile.Close()
+	_ = stderrFile.Close()
+}
+
+// read returns output written after given offsets;
+// no output is returned when script is not running
+func (o *scriptOutput) read(since boshtask.OutputOffsets) (boshtask.Output, error) {
+	o.lock.Lock()
+	stdoutPath, stderrPath := o.stdoutPath, o.stderrPath
+	o.lock.Unlock()
+
+	output := boshtask.Output{Offsets: since}
+
+	if stdoutPath == "" {
+		return output, nil
+	}
+
+	var err error
+
+	output.Stdout, output.Offsets.Stdout, err = o.readChunk(stdoutPath, since.Stdout)
+	if err != nil {
+		return boshtask.Output{}, bosherr.WrapError(err, "Reading stdout")
+	}
+
+	output.Stderr, output.Offsets.Stderr, err = o.readChunk(stderrPath, since.Stderr)
+	if err != nil {
+		return boshtask.Output{}, bosherr.WrapError(err, "Reading stderr")
+	}
+
+	return output, nil
+}
+
+// readChunk returns output written after offset up to chunk length
+// and offset from which next read should start
+func (o *scriptOutput) readChunk(path string, offset int64) (string, int64, error) {
+	file, err := o.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", offset, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return "", offset, err
+	}
+
+	if offset < 0 || offset > stat.Size() {
+		offset = stat.Size()
+	}
+
+	length := stat.Size() - offset
+	if length > scriptOutputChunkLength {
+		length = scriptOutputChunkLength
+	}
+
+	data := make([]byte, length)
+
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return "", offset, err
+	}
+
+	data = data[:n]
+
+	// Leave incomplete rune at the end for next read
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				data = data[:len(data)-i]
+			}
+			break
+		}
+	}
+
+	return string(data), offset + int64(len(data)), nil
+}
+
+// result returns last part of output with exit status; full output is uploaded
+// to blobstore when it was truncated. Failed upload is only logged since script itself finished.
+func (o *scriptOutput) result(stdoutFile, stderrFile boshsys.File, exitStatus int) (ErrandResult, error) {
+	stdout, isStdoutTruncated, err := boshrunner.TruncatedOutput(stdoutFile, scriptOutputTruncateLength)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Truncating stdout")
+	}
+
+	stderr, isStderrTruncated, err := boshrunner.TruncatedOutput(stderrFile, scriptOutputTruncateLength)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Truncating stderr")
+	}
+
+	result := ErrandResult{
+		Stdout:     string(stdout),
+		Stderr:     string(stderr),
+		ExitStatus: exitStatus,
+	}
+
+	if isStdoutTruncated || isStderrTruncated {
+		result.OutputBlobstoreID, result.OutputSHA1, err = o.upload(stdoutFile.Name(), stderrFile.Name())
+		if err != nil {
+			o.logger.Error(scriptOutputLogTag, "Failed to upload full output: %s", err.Error())
+		}
+	}
+
+	return result, nil
+}
+
+func (o *scriptOutput) upload(stdoutPath, stderrPath string) (string, string, error) {
+	dir := path.Dir(stdoutPath)
+
+	tarball, err := o.compressor.CompressSpecificFilesInDir(dir, []string{path.Base(stdoutPath), path.Base(stderrPath)})
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Making output tarball")
+	}
+
+	defer func() {
+		_ = o.compressor.CleanUp(tarball)
+	}()
+
+	blobID, digest, err := o.blobstore.Create(tarball)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Creating output blob")
+	}
+
+	return blobID, digest.String(), nil
+}
@@ -0,0 +1,93 @@
+package action
+
+import (
+	"errors"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type StartJobAction struct {
+	jobSupervisor boshjobsuper.JobSupervisor
+	specService   boshas.V1Service
+}
+
+func NewStartJob(jobSupervisor boshjobsuper.JobSupervisor, specService boshas.V1Service) StartJobAction {
+	return StartJobAction{
+		jobSupervisor: jobSupervisor,
+		specService:   specService,
+	}
+}
+
+func (a StartJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a StartJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a StartJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a StartJobAction) Run(jobName string) (string, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	processNames, err := jobProcessNames(a.jobSupervisor, currentSpec, jobName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, processName := range processNames {
+		err = a.jobSupervisor.StartProcess(processName)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Starting process %s of job %s", processName, jobName)
+		}
+	}
+
+	return "started", nil
+}
+
+func (a StartJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StartJobAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+// jobProcessNames returns processes that supervisor reports for a job of current spec
+func jobProcessNames(jobSupervisor boshjobsuper.JobSupervisor, currentSpec boshas.V1ApplySpec, jobName string) ([]string, error) {
+	var found bool
+
+	for _, job := range currentSpec.Jobs() {
+		if job.Name == jobName {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, bosherr.Errorf("Job '%s' is not deployed on this instance", jobName)
+	}
+
+	processes, err := jobSupervisor.Processes()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting processes")
+	}
+
+	var processNames []string
+
+	for _, process := range processes {
+		if process.Job == jobName {
+			processNames = append(processNames, process.Name)
+		}
+	}
+
+	return processNames, nil
+}
@@ -0,0 +1,72 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+)
+
+var _ = Describe("StartJob", func() {
+	var (
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		specService   *fakeas.FakeV1Service
+		action        StartJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		action = NewStartJob(jobSupervisor, specService)
+
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}, {Name: "other-job"}},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+		}
+
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-proc-1", Job: "fake-job"},
+			{Name: "other-proc", Job: "other-job"},
+			{Name: "fake-proc-2", Job: "fake-job"},
+		}
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("starts processes of the job and returns started", func() {
+		started, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(started).To(Equal("started"))
+
+		Expect(jobSupervisor.StartedProcesses()).To(Equal([]string{"fake-proc-1", "fake-proc-2"}))
+		Expect(jobSupervisor.Started).To(BeFalse())
+	})
+
+	It("returns error if job is not deployed on this instance", func() {
+		_, err := action.Run("unknown-job")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Job 'unknown-job' is not deployed on this instance"))
+		Expect(jobSupervisor.StartedProcesses()).To(BeEmpty())
+	})
+
+	It("returns error if starting process fails", func() {
+		jobSupervisor.StartProcessErr = errors.New("fake-start-error")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Starting process fake-proc-1 of job fake-job: fake-start-error"))
+	})
+})
@@ -3,17 +3,27 @@ package action
 import (
 	"errors"
 
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type StopAction struct {
 	jobSupervisor boshjobsuper.JobSupervisor
+	specService   boshas.V1Service
+	preStop       PreStop
 }
 
-func NewStop(jobSupervisor boshjobsuper.JobSupervisor) (stop StopAction) {
+func NewStop(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	preStop PreStop,
+) (stop StopAction) {
 	stop = StopAction{
 		jobSupervisor: jobSupervisor,
+		specService:   specService,
+		preStop:       preStop,
 	}
 	return
 }
@@ -31,6 +41,18 @@ func (a StopAction) IsLoggable() bool {
 }
 
 func (a StopAction) Run(protocolVersion ProtocolVersion) (value string, err error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting current spec")
+		return
+	}
+
+	err = a.preStop.RunBeforeStop(currentSpec.Jobs(), boshdrain.NewShutdownParams(currentSpec, nil))
+	if err != nil {
+		err = bosherr.WrapError(err, "Running pre-stop scripts")
+		return
+	}
+
 	if protocolVersion > 2 {
 		err = a.jobSupervisor.StopAndWait()
 	} else {
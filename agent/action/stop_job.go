@@ -0,0 +1,145 @@
+package action
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	stopJobPollInterval = 1 * time.Second
+	stopJobTimeout      = 5 * time.Minute
+)
+
+type StopJobAction struct {
+	jobSupervisor     boshjobsuper.JobSupervisor
+	specService       boshas.V1Service
+	jobScriptProvider boshscript.JobScriptProvider
+	timeService       clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+}
+
+// JobOptions are optional arguments of stop_job and restart_job actions
+type JobOptions struct {
+	// Drain runs drain script of the job before its processes are stopped
+	Drain bool `json:"drain"`
+}
+
+func NewStopJob(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) StopJobAction {
+	return StopJobAction{
+		jobSupervisor:     jobSupervisor,
+		specService:       specService,
+		jobScriptProvider: jobScriptProvider,
+		timeService:       timeService,
+
+		logTag: "Stop Job Action",
+		logger: logger,
+	}
+}
+
+func (a StopJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a StopJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a StopJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a StopJobAction) Run(jobName string, options ...JobOptions) (string, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	processNames, err := jobProcessNames(a.jobSupervisor, currentSpec, jobName)
+	if err != nil {
+		return "", err
+	}
+
+	if len(options) > 0 && options[0].Drain {
+		script := a.jobScriptProvider.NewDrainScript(jobName, boshdrain.NewShutdownParams(currentSpec, nil))
+
+		if script.Exists() {
+			a.logger.Debug(a.logTag, "Draining job %s", jobName)
+
+			err = script.Run()
+			if err != nil {
+				return "", bosherr.WrapErrorf(err, "Draining job %s", jobName)
+			}
+		}
+	}
+
+	for _, processName := range processNames {
+		err = a.jobSupervisor.StopProcess(processName)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Stopping process %s of job %s", processName, jobName)
+		}
+	}
+
+	err = a.waitForStopped(processNames)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Waiting for job %s to stop", jobName)
+	}
+
+	return "stopped", nil
+}
+
+func (a StopJobAction) waitForStopped(processNames []string) error {
+	deadline := a.timeService.Now().Add(stopJobTimeout)
+
+	for {
+		processes, err := a.jobSupervisor.Processes()
+		if err != nil {
+			return bosherr.WrapError(err, "Getting processes")
+		}
+
+		var running []string
+
+		for _, process := range processes {
+			for _, processName := range processNames {
+				if process.Name == processName && (process.State == "running" || process.State == "starting") {
+					running = append(running, processName)
+				}
+			}
+		}
+
+		if len(running) == 0 {
+			return nil
+		}
+
+		if !a.timeService.Now().Before(deadline) {
+			return bosherr.Errorf("Timed out after %s waiting for processes %s", stopJobTimeout, strings.Join(running, ", "))
+		}
+
+		a.timeService.Sleep(stopJobPollInterval)
+	}
+}
+
+func (a StopJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StopJobAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,142 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	fakedrain "github.com/cloudfoundry/bosh-agent/agent/script/drain/fakes"
+	fakescript "github.com/cloudfoundry/bosh-agent/agent/script/fakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("StopJob", func() {
+	var (
+		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		specService       *fakeas.FakeV1Service
+		jobScriptProvider *fakescript.FakeJobScriptProvider
+		drainScript       *fakedrain.FakeScript
+		timeService       *fakeclock.FakeClock
+		action            StopJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		jobScriptProvider = &fakescript.FakeJobScriptProvider{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		action = NewStopJob(jobSupervisor, specService, jobScriptProvider, timeService, logger)
+
+		drainScript = fakedrain.NewFakeScript("fake-job")
+		jobScriptProvider.NewDrainScriptStub = func(jobName string, params boshdrain.ScriptParams) boshscript.CancellableScript {
+			drainScript.Params = params
+			return drainScript
+		}
+
+		specService.Spec = boshas.V1ApplySpec{
+			JobSpec: boshas.JobSpec{
+				JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}, {Name: "other-job"}},
+			},
+			RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+		}
+
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-proc-1", Job: "fake-job", State: "stopped"},
+			{Name: "other-proc", Job: "other-job", State: "running"},
+			{Name: "fake-proc-2", Job: "fake-job", State: "stopped"},
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("stops processes of the job and returns stopped", func() {
+		stopped, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stopped).To(Equal("stopped"))
+
+		Expect(jobSupervisor.StoppedProcesses()).To(Equal([]string{"fake-proc-1", "fake-proc-2"}))
+		Expect(jobSupervisor.Stopped).To(BeFalse())
+		Expect(jobScriptProvider.NewDrainScriptCallCount()).To(Equal(0))
+	})
+
+	It("runs drain script of the job before stopping its processes when asked to", func() {
+		drainScript.RunStub = func() error {
+			Expect(jobSupervisor.StoppedProcesses()).To(BeEmpty())
+			return nil
+		}
+
+		_, err := action.Run("fake-job", JobOptions{Drain: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(drainScript.DidRun).To(BeTrue())
+		Expect(drainScript.Params.JobChange()).To(Equal("job_shutdown"))
+		Expect(jobSupervisor.StoppedProcesses()).To(HaveLen(2))
+	})
+
+	It("does not stop processes if drain script fails", func() {
+		drainScript.RunError = errors.New("fake-drain-error")
+
+		_, err := action.Run("fake-job", JobOptions{Drain: true})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Draining job fake-job: fake-drain-error"))
+		Expect(jobSupervisor.StoppedProcesses()).To(BeEmpty())
+	})
+
+	It("skips drain script if job does not have one", func() {
+		drainScript.ExistsBool = false
+
+		_, err := action.Run("fake-job", JobOptions{Drain: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drainScript.DidRun).To(BeFalse())
+	})
+
+	It("waits for processes of the job to stop", func() {
+		jobSupervisor.ProcessesStatus[2].State = "running"
+
+		errCh := make(chan error)
+		go func() {
+			_, err := action.Run("fake-job")
+			errCh <- err
+		}()
+
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+		Consistently(errCh).ShouldNot(Receive())
+
+		timeService.Increment(5 * time.Minute)
+
+		var err error
+		Eventually(errCh).Should(Receive(&err))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Timed out after 5m0s waiting for processes fake-proc-2"))
+	})
+
+	It("returns error if job is not deployed on this instance", func() {
+		_, err := action.Run("unknown-job")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Job 'unknown-job' is not deployed on this instance"))
+	})
+
+	It("returns error if stopping process fails", func() {
+		jobSupervisor.StopProcessErr = errors.New("fake-stop-error")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Stopping process fake-proc-1 of job fake-job: fake-stop-error"))
+	})
+})
@@ -0,0 +1,43 @@
+package action
+
+import (
+	"errors"
+)
+
+// StopTailLogsAction stops stream started by tail_logs
+type StopTailLogsAction struct {
+	tailLogs TailLogsAction
+}
+
+func NewStopTailLogs(tailLogs TailLogsAction) StopTailLogsAction {
+	return StopTailLogsAction{tailLogs: tailLogs}
+}
+
+func (a StopTailLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a StopTailLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a StopTailLogsAction) IsLoggable() bool {
+	return true
+}
+
+func (a StopTailLogsAction) Run(streamID string) (string, error) {
+	err := a.tailLogs.Stop(streamID)
+	if err != nil {
+		return "", err
+	}
+
+	return "stopped", nil
+}
+
+func (a StopTailLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StopTailLogsAction) Cancel() error {
+	return errors.New("not supported")
+}
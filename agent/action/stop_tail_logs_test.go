@@ -0,0 +1,64 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	fakelogtail "github.com/cloudfoundry/bosh-agent/agent/logtail/fakes"
+	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("StopTailLogsAction", func() {
+	var (
+		stream      *fakelogtail.FakeStream
+		mbusHandler *fakembus.FakeHandler
+		tailLogs    TailLogsAction
+		action      StopTailLogsAction
+	)
+
+	BeforeEach(func() {
+		stream = &fakelogtail.FakeStream{RunUntilStopped: true}
+		mbusHandler = fakembus.NewFakeHandler()
+
+		uuidGen := fakeuuid.NewFakeGenerator()
+		uuidGen.GeneratedUUID = "fake-stream-id"
+
+		tailLogs = NewTailLogs(&fakelogtail.FakeStreamer{OpenStream: stream}, mbusHandler, uuidGen, boshlog.NewLogger(boshlog.LevelNone))
+		action = NewStopTailLogs(tailLogs)
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("stops stream started by tail_logs", func() {
+			_, err := tailLogs.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			value, err := action.Run("fake-stream-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("stopped"))
+
+			Eventually(mbusHandler.PublishInputs).Should(Equal([]fakembus.PublishInput{
+				{Subject: "director.fake-reply-id", Message: TailLogsEnd{
+					StreamID: "fake-stream-id",
+					Summary:  boshlogtail.Summary{},
+				}},
+			}))
+		})
+
+		It("returns error when stream does not exist", func() {
+			_, err := action.Run("fake-unknown-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not exist"))
+		})
+	})
+})
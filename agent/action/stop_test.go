@@ -1,22 +1,32 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 )
 
 var _ = Describe("Stop", func() {
 	var (
 		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		specService   *fakeas.FakeV1Service
+		preStop       *fakeaction.FakePreStop
 		action        StopAction
 	)
 
 	BeforeEach(func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-		action = NewStop(jobSupervisor)
+		specService = fakeas.NewFakeV1Service()
+		preStop = &fakeaction.FakePreStop{}
+		action = NewStop(jobSupervisor, specService, preStop)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -49,4 +59,35 @@ var _ = Describe("Stop", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(jobSupervisor.StoppedAndWaited).To(BeTrue())
 	})
+
+	It("runs pre-stop scripts with shutdown params before stopping services", func() {
+		currentSpec := boshas.V1ApplySpec{RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{}}
+		currentSpec.JobSpec.JobTemplateSpecs = []boshas.JobTemplateSpec{{Name: "fake-job"}}
+		specService.Spec = currentSpec
+
+		_, err := action.Run(ProtocolVersion(3))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(preStop.RunBeforeStopCallCount()).To(Equal(1))
+		Expect(preStop.RunBeforeStopJobs).To(Equal(currentSpec.Jobs()))
+		Expect(preStop.RunBeforeStopParams).To(Equal(boshdrain.NewShutdownParams(currentSpec, nil)))
+	})
+
+	It("returns error and does not stop services when pre-stop scripts fail", func() {
+		preStop.RunBeforeStopErr = errors.New("fake-pre-stop-error")
+
+		_, err := action.Run(ProtocolVersion(3))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Running pre-stop scripts: fake-pre-stop-error"))
+		Expect(jobSupervisor.StoppedAndWaited).To(BeFalse())
+	})
+
+	It("returns error when current spec cannot be retrieved", func() {
+		specService.GetErr = errors.New("fake-get-error")
+
+		_, err := action.Run(ProtocolVersion(3))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		Expect(preStop.RunBeforeStopCallCount()).To(Equal(0))
+	})
 })
@@ -0,0 +1,185 @@
+package action
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// TailLogsOptions are arguments of tail_logs action
+type TailLogsOptions struct {
+	// ReplyTo is subject to which chunks of lines are published
+	ReplyTo string `json:"reply_to"`
+
+	// Lines is number of last lines of each log published before new lines
+	Lines int `json:"lines"`
+
+	// IdleTimeout in seconds ends tailing when no lines are written
+	IdleTimeout int `json:"idle_timeout"`
+}
+
+// TailLogsValue identifies started stream so that it can be stopped with stop_tail_logs
+type TailLogsValue struct {
+	StreamID string `json:"stream_id"`
+}
+
+// TailLogsEnd is last message published to reply subject once stream ends
+type TailLogsEnd struct {
+	StreamID string              `json:"stream_id"`
+	Summary  boshlogtail.Summary `json:"summary"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// TailLogsAction starts streams in their own goroutines instead of running
+// them as tasks so that long running streams do not hold up other tasks
+type TailLogsAction struct {
+	logStreamer boshlogtail.Streamer
+	mbusHandler boshhandler.Handler
+	uuidGen     boshuuid.Generator
+
+	// Stop channels of running streams keyed by their ids
+	streams *tailLogsStreams
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type tailLogsStreams struct {
+	lock    sync.Mutex
+	stopChs map[string]chan struct{}
+}
+
+func NewTailLogs(
+	logStreamer boshlogtail.Streamer,
+	mbusHandler boshhandler.Handler,
+	uuidGen boshuuid.Generator,
+	logger boshlog.Logger,
+) TailLogsAction {
+	return TailLogsAction{
+		logStreamer: logStreamer,
+		mbusHandler: mbusHandler,
+		uuidGen:     uuidGen,
+		streams:     &tailLogsStreams{stopChs: map[string]chan struct{}{}},
+
+		logTag: "Tail Logs Action",
+		logger: logger,
+	}
+}
+
+func (a TailLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a TailLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a TailLogsAction) IsLoggable() bool {
+	return true
+}
+
+func (a TailLogsAction) Run(logType string, paths []string, options TailLogsOptions) (TailLogsValue, error) {
+	if options.ReplyTo == "" {
+		return TailLogsValue{}, bosherr.Error("Must specify subject to reply to with log lines")
+	}
+
+	streamID, err := a.uuidGen.Generate()
+	if err != nil {
+		return TailLogsValue{}, bosherr.WrapError(err, "Generating stream id")
+	}
+
+	stream, err := a.logStreamer.Open(logType, paths, boshlogtail.Options{
+		Lines:       options.Lines,
+		IdleTimeout: time.Duration(options.IdleTimeout) * time.Second,
+	})
+	if err != nil {
+		return TailLogsValue{}, bosherr.WrapError(err, "Tailing logs")
+	}
+
+	stopCh := a.streams.Add(streamID)
+
+	a.logger.Debug(a.logTag, "Publishing lines of %d logs to %s in stream %s", len(paths), options.ReplyTo, streamID)
+
+	go a.run(streamID, stream, options.ReplyTo, stopCh)
+
+	return TailLogsValue{StreamID: streamID}, nil
+}
+
+func (a TailLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a TailLogsAction) Cancel() error {
+	return errors.New("not supported")
+}
+
+// Stop stops running stream with given id; streams that
+// already ended on their own are reported as not existing
+func (a TailLogsAction) Stop(streamID string) error {
+	if !a.streams.Stop(streamID) {
+		return bosherr.Errorf("Log stream '%s' does not exist", streamID)
+	}
+
+	return nil
+}
+
+func (a TailLogsAction) run(streamID string, stream boshlogtail.Stream, replyTo string, stopCh <-chan struct{}) {
+	defer a.logger.HandlePanic("Tail Logs Stream")
+
+	summary, err := stream.Run(func(chunk boshlogtail.Chunk) error {
+		return a.mbusHandler.Publish(replyTo, chunk)
+	}, stopCh)
+
+	a.streams.Remove(streamID)
+
+	end := TailLogsEnd{StreamID: streamID, Summary: summary}
+
+	if err != nil {
+		a.logger.Error(a.logTag, "Failed tailing logs in stream %s: %s", streamID, err.Error())
+		end.Error = bosherr.WrapError(err, "Tailing logs").Error()
+	}
+
+	err = a.mbusHandler.Publish(replyTo, end)
+	if err != nil {
+		a.logger.Warn(a.logTag, "Failed to publish end of stream %s: %s", streamID, err.Error())
+	}
+}
+
+func (s *tailLogsStreams) Add(streamID string) <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stopCh := make(chan struct{})
+	s.stopChs[streamID] = stopCh
+
+	return stopCh
+}
+
+func (s *tailLogsStreams) Remove(streamID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.stopChs, streamID)
+}
+
+// Stop closes stop channel of stream once and returns false when stream is not running
+func (s *tailLogsStreams) Stop(streamID string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stopCh, found := s.stopChs[streamID]
+	if !found {
+		return false
+	}
+
+	close(stopCh)
+	delete(s.stopChs, streamID)
+
+	return true
+}
@@ -0,0 +1,154 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	fakelogtail "github.com/cloudfoundry/bosh-agent/agent/logtail/fakes"
+	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("TailLogsAction", func() {
+	var (
+		logStreamer *fakelogtail.FakeStreamer
+		stream      *fakelogtail.FakeStream
+		mbusHandler *fakembus.FakeHandler
+		uuidGen     *fakeuuid.FakeGenerator
+		action      TailLogsAction
+	)
+
+	BeforeEach(func() {
+		stream = &fakelogtail.FakeStream{}
+		logStreamer = &fakelogtail.FakeStreamer{OpenStream: stream}
+		mbusHandler = fakembus.NewFakeHandler()
+		uuidGen = fakeuuid.NewFakeGenerator()
+		uuidGen.GeneratedUUID = "fake-stream-id"
+		action = NewTailLogs(logStreamer, mbusHandler, uuidGen, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	Describe("Run", func() {
+		It("publishes chunks of tailed logs and summary to reply subject without waiting for stream to end", func() {
+			chunks := []boshlogtail.Chunk{
+				{Path: "fake-job/fake-job.log", Lines: []string{"fake-line-1"}},
+				{Path: "fake-job/fake-job.stderr.log", Lines: []string{"fake-line-2"}, DroppedLines: 1},
+			}
+
+			stream.RunChunks = chunks
+			stream.RunSummary = boshlogtail.Summary{Lines: 2, DroppedLines: 1, EndReason: boshlogtail.EndReasonIdle}
+
+			value, err := action.Run("job", []string{"fake-job/fake-job.log", "fake-job/fake-job.stderr.log"}, TailLogsOptions{
+				ReplyTo:     "director.fake-reply-id",
+				Lines:       10,
+				IdleTimeout: 60,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(TailLogsValue{StreamID: "fake-stream-id"}))
+
+			Expect(logStreamer.OpenLogType).To(Equal("job"))
+			Expect(logStreamer.OpenPaths).To(Equal([]string{"fake-job/fake-job.log", "fake-job/fake-job.stderr.log"}))
+			Expect(logStreamer.OpenOptions).To(Equal(boshlogtail.Options{Lines: 10, IdleTimeout: 60 * time.Second}))
+
+			Eventually(mbusHandler.PublishInputs).Should(Equal([]fakembus.PublishInput{
+				{Subject: "director.fake-reply-id", Message: chunks[0]},
+				{Subject: "director.fake-reply-id", Message: chunks[1]},
+				{Subject: "director.fake-reply-id", Message: TailLogsEnd{StreamID: "fake-stream-id", Summary: stream.RunSummary}},
+			}))
+		})
+
+		It("returns error when reply subject is missing", func() {
+			_, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Must specify subject to reply to"))
+			Expect(logStreamer.OpenLogType).To(BeEmpty())
+		})
+
+		It("returns error when stream id cannot be generated", func() {
+			uuidGen.GenerateError = errors.New("fake-generate-err")
+
+			_, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-generate-err"))
+			Expect(logStreamer.OpenLogType).To(BeEmpty())
+		})
+
+		It("returns error when logs cannot be tailed", func() {
+			logStreamer.OpenErr = errors.New("fake-open-err")
+
+			_, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+		})
+
+		It("publishes error in summary when stream fails", func() {
+			stream.RunSummary = boshlogtail.Summary{Lines: 1}
+			stream.RunErr = errors.New("fake-run-err")
+
+			_, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(mbusHandler.PublishInputs).Should(Equal([]fakembus.PublishInput{
+				{Subject: "director.fake-reply-id", Message: TailLogsEnd{
+					StreamID: "fake-stream-id",
+					Summary:  boshlogtail.Summary{Lines: 1},
+					Error:    "Tailing logs: fake-run-err",
+				}},
+			}))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops only stream with given id", func() {
+			otherStream := &fakelogtail.FakeStream{RunUntilStopped: true}
+			stream.RunUntilStopped = true
+			uuidGen.GeneratedUUID = ""
+
+			firstValue, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id-1"})
+			Expect(err).ToNot(HaveOccurred())
+
+			logStreamer.OpenStream = otherStream
+
+			secondValue, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id-2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secondValue.StreamID).ToNot(Equal(firstValue.StreamID))
+
+			Expect(action.Stop(secondValue.StreamID)).To(Succeed())
+
+			Eventually(mbusHandler.PublishInputs).Should(Equal([]fakembus.PublishInput{
+				{Subject: "director.fake-reply-id-2", Message: TailLogsEnd{StreamID: secondValue.StreamID}},
+			}))
+			Consistently(mbusHandler.PublishInputs).Should(HaveLen(1))
+
+			Expect(action.Stop(firstValue.StreamID)).To(Succeed())
+			Eventually(mbusHandler.PublishInputs).Should(HaveLen(2))
+		})
+
+		It("returns error when stream does not exist", func() {
+			err := action.Stop("fake-unknown-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Log stream 'fake-unknown-id' does not exist"))
+		})
+
+		It("returns error when stream already ended", func() {
+			_, err := action.Run("job", []string{"fake-job/fake-job.log"}, TailLogsOptions{ReplyTo: "director.fake-reply-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(mbusHandler.PublishInputs).Should(HaveLen(1))
+
+			Expect(action.Stop("fake-stream-id")).ToNot(Succeed())
+		})
+	})
+})
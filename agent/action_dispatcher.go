@@ -2,14 +2,44 @@ package agent
 
 import (
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 const actionDispatcherLogTag = "Action Dispatcher"
 
+// Actions that change state of the VM are recorded in audit journal
+var auditedActions = map[string]bool{
+	"ssh":                        true,
+	"update_settings":            true,
+	"prepare":                    true,
+	"apply":                      true,
+	"start":                      true,
+	"stop":                       true,
+	"start_job":                  true,
+	"stop_job":                   true,
+	"restart_job":                true,
+	"drain":                      true,
+	"run_errand":                 true,
+	"run_script":                 true,
+	"run_job_script":             true,
+	"compile_package":            true,
+	"release_apply_spec":         true,
+	"upload_blob":                true,
+	"migrate_disk":               true,
+	"mount_disk":                 true,
+	"unmount_disk":               true,
+	"delete_arp_entries":         true,
+	"prepare_network_change":     true,
+	"prepare_configure_networks": true,
+	"configure_networks":         true,
+	"sync_dns":                   true,
+}
+
 type ActionDispatcher interface {
 	ResumePreviouslyDispatchedTasks()
 	Dispatch(req boshhandler.Request) (resp boshhandler.Response)
@@ -21,6 +51,7 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	auditJournal  boshaudit.Journal
 }
 
 func NewActionDispatcher(
@@ -29,6 +60,7 @@ func NewActionDispatcher(
 	taskManager boshtask.Manager,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	auditJournal boshaudit.Journal,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
@@ -36,6 +68,7 @@ func NewActionDispatcher(
 		taskManager:   taskManager,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		auditJournal:  auditJournal,
 	}
 }
 
@@ -63,9 +96,24 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
 
+		logger := agentlogger.WithFields(dispatcher.logger, agentlogger.Fields{
+			"method":  taskInfo.Method,
+			"task_id": taskID,
+		})
+		logger.Info(actionDispatcherLogTag, "Resuming task %s with action %s", taskID, taskInfo.Method)
+
+		auditRecord := dispatcher.auditRecord(taskInfo.Method, taskInfo.Caller, payload)
+		if auditRecord != nil {
+			auditRecord.TaskID = taskID
+		}
+
 		task := dispatcher.taskService.CreateTaskWithID(
 			taskID,
-			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
+			func() (interface{}, error) {
+				value, err := dispatcher.actionRunner.Resume(action, payload)
+				dispatcher.audit(logger, auditRecord, err)
+				return value, err
+			},
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeInfo,
 		)
@@ -75,35 +123,46 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 }
 
 func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	// Reply subject is unique per request so it identifies request in logs
+	logger := agentlogger.WithFields(dispatcher.logger, agentlogger.Fields{
+		"method":     req.Method,
+		"request_id": req.ReplyTo,
+	})
+
 	action, err := dispatcher.actionFactory.Create(req.Method)
 	if err != nil {
-		dispatcher.logger.Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
+		logger.Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
 		return boshhandler.NewExceptionResponse(bosherr.Errorf("unknown message %s", req.Method))
 	}
 
-	dispatcher.logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
+	logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
 	if action.IsLoggable() {
-		dispatcher.logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
+		logger.DebugWithDetails(actionDispatcherLogTag, "Payload", req.Payload)
 	}
 
 	if action.IsAsynchronous(boshaction.ProtocolVersion(req.ProtocolVersion)) {
-		return dispatcher.dispatchAsynchronousAction(action, req)
+		return dispatcher.dispatchAsynchronousAction(action, req, logger)
 	}
 
-	return dispatcher.dispatchSynchronousAction(action, req)
+	return dispatcher.dispatchSynchronousAction(action, req, logger)
 }
 
 func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
+	logger boshlog.Logger,
 ) boshhandler.Response {
-	dispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
+	logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
 
 	var task boshtask.Task
 	var err error
 
+	auditRecord := dispatcher.auditRecord(req.Method, req.Caller, req.GetPayload())
+
 	runTask := func() (interface{}, error) {
-		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+		value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+		dispatcher.audit(logger, auditRecord, err)
+		return value, err
 	}
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
@@ -112,11 +171,11 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	// after agent restart so that API consumers do not need to know
 	// if agent is restarted midway through the task.
 	if action.IsPersistent() {
-		dispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
+		logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeInfo)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 
@@ -124,23 +183,43 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 			TaskID:  task.ID,
 			Method:  req.Method,
 			Payload: req.GetPayload(),
+			Caller:  req.Caller,
 		}
 
 		err = dispatcher.taskManager.AddInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 	} else {
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 	}
 
+	if outputAction, ok := action.(boshaction.OutputAction); ok {
+		task.OutputFunc = outputAction.Output
+	}
+
+	if progressAction, ok := action.(boshaction.ProgressAction); ok {
+		task.ProgressFunc = progressAction.Progress
+	}
+
+	logger = agentlogger.WithFields(logger, agentlogger.Fields{"task_id": task.ID})
+	logger.Info(actionDispatcherLogTag, "Starting task %s", task.ID)
+
+	if auditRecord != nil {
+		// Task records its outcome once it finishes
+		auditRecord.TaskID = task.ID
+		started := *auditRecord
+		started.Outcome = boshaudit.OutcomeStarted
+		dispatcher.appendAudit(logger, started)
+	}
+
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.StateValue{
@@ -152,13 +231,20 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
+	logger boshlog.Logger,
 ) boshhandler.Response {
-	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
+	logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
 	value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+	dispatcher.audit(logger, dispatcher.auditRecord(req.Method, req.Caller, req.GetPayload()), err)
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
-		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+		logger.Error(actionDispatcherLogTag, err.Error())
+
+		if failedValue, ok := value.(boshaction.FailedValue); ok && failedValue.Failed() {
+			return boshhandler.NewExceptionResponseWithValue(err, value)
+		}
+
 		return boshhandler.NewExceptionResponse(err)
 	}
 
@@ -173,3 +259,40 @@ func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 	}
 }
+
+// auditRecord returns record describing request if its action is audited
+func (dispatcher concreteActionDispatcher) auditRecord(method, caller string, payload []byte) *boshaudit.Record {
+	if !auditedActions[method] {
+		return nil
+	}
+
+	return &boshaudit.Record{
+		Caller:        caller,
+		Method:        method,
+		PayloadDigest: boshaudit.PayloadDigest(payload),
+	}
+}
+
+func (dispatcher concreteActionDispatcher) audit(logger boshlog.Logger, record *boshaudit.Record, err error) {
+	if record == nil {
+		return
+	}
+
+	finished := *record
+	finished.Outcome = boshaudit.OutcomeSucceeded
+
+	if err != nil {
+		finished.Outcome = boshaudit.OutcomeFailed
+		finished.Error = err.Error()
+	}
+
+	dispatcher.appendAudit(logger, finished)
+}
+
+func (dispatcher concreteActionDispatcher) appendAudit(logger boshlog.Logger, record boshaudit.Record) {
+	// Failing to record action should not fail the action itself
+	_, err := dispatcher.auditJournal.Append(record)
+	if err != nil {
+		logger.Error(actionDispatcherLogTag, "Failed to record %s in audit journal: %s", record.Method, err.Error())
+	}
+}
@@ -1,9 +1,11 @@
 package agent_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,11 +13,16 @@ import (
 	. "github.com/cloudfoundry/bosh-agent/agent"
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
+	fakeaudit "github.com/cloudfoundry/bosh-agent/agent/audit/fakes"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	"github.com/cloudfoundry/bosh-agent/logger/fakes"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 func init() {
@@ -26,6 +33,7 @@ func init() {
 			taskManager   *faketask.FakeManager
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
+			auditJournal  *fakeaudit.FakeJournal
 			dispatcher    ActionDispatcher
 		)
 
@@ -35,7 +43,8 @@ func init() {
 			taskManager = faketask.NewFakeManager()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			auditJournal = &fakeaudit.FakeJournal{}
+			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, auditJournal)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -82,6 +91,36 @@ func init() {
 			})
 		})
 
+		Context("when logger supports fields", func() {
+			var (
+				outBuf *bytes.Buffer
+			)
+
+			BeforeEach(func() {
+				outBuf = new(bytes.Buffer)
+				jsonLogger := agentlogger.NewJSONLogger(boshlog.LevelDebug, outBuf)
+				dispatcher = NewActionDispatcher(jsonLogger, taskService, taskManager, actionFactory, actionRunner, auditJournal)
+			})
+
+			It("logs request with action method, request id and task id", func() {
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{Asynchronous: true})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+
+				lines := strings.Split(strings.TrimSpace(outBuf.String()), "\n")
+				Expect(lines).ToNot(BeEmpty())
+
+				for _, line := range lines {
+					var entry map[string]interface{}
+					Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+					Expect(entry).To(HaveKeyWithValue("method", "fake-action"))
+					Expect(entry).To(HaveKeyWithValue("request_id", "fake-reply"))
+				}
+
+				Expect(lines[len(lines)-1]).To(ContainSubstring(`"task_id":"fake-generated-task-id"`))
+			})
+		})
+
 		Context("when request contains protocol version and action is Asynchronous", func() {
 			var (
 				req       boshhandler.Request
@@ -179,6 +218,24 @@ func init() {
 				expectedJSON := fmt.Sprintf("{\"exception\":{\"message\":\"Action Failed %s: fake-run-error\"}}", req.Method)
 				boshassert.MatchesJSONString(GinkgoT(), resp, expectedJSON)
 			})
+
+			It("includes value describing failure in exception", func() {
+				actionRunner.RunValue = action.RunScriptResult{
+					Jobs: []boshscript.Result{{Job: "fake-job", ExitStatus: 1, Error: "fake-error"}},
+				}
+				actionRunner.RunErr = errors.New("fake-run-error")
+
+				resp := dispatcher.Dispatch(req)
+				boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Action Failed fake-action: fake-run-error","value":{"jobs":[{"job":"fake-job","exit_code":1,"duration":0,"stdout":"","stderr":"","error":"fake-error"}]}}}`)
+			})
+
+			It("does not include value in exception when value does not describe failure", func() {
+				actionRunner.RunValue = action.RunScriptResult{}
+				actionRunner.RunErr = errors.New("fake-run-error")
+
+				resp := dispatcher.Dispatch(req)
+				boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Action Failed fake-action: fake-run-error"}}`)
+			})
 		})
 
 		Context("when action is asynchronous", func() {
@@ -275,6 +332,11 @@ func init() {
 					dispatcher.Dispatch(req)
 					Expect(taskService.StartedTasks["fake-generated-task-id"].EndFunc).To(BeNil())
 				})
+
+				It("does not allow reading output of task while it runs", func() {
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].OutputFunc).To(BeNil())
+				})
 			})
 
 			Context("when action is persistent", func() {
@@ -361,6 +423,166 @@ func init() {
 			})
 		})
 
+		Context("when output of asynchronous action can be read while it runs", func() {
+			It("allows reading output of task", func() {
+				action := &fakeaction.TestOutputAction{
+					TestAction:   fakeaction.TestAction{Asynchronous: true},
+					OutputOutput: boshtask.Output{Stdout: "fake-stdout"},
+				}
+				actionFactory.RegisterAction("fake-action", action)
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+
+				output, err := taskService.StartedTasks["fake-generated-task-id"].OutputFunc(boshtask.OutputOffsets{Stdout: 1})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(output).To(Equal(boshtask.Output{Stdout: "fake-stdout"}))
+				Expect(action.OutputSince).To(Equal(boshtask.OutputOffsets{Stdout: 1}))
+			})
+		})
+
+		Context("when asynchronous action reports progress while it runs", func() {
+			It("allows reading progress of task", func() {
+				action := &fakeaction.TestProgressAction{
+					TestAction:    fakeaction.TestAction{Asynchronous: true},
+					ProgressValue: "fake-progress",
+				}
+				actionFactory.RegisterAction("fake-action", action)
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+
+				Expect(taskService.StartedTasks["fake-generated-task-id"].ProgressFunc()).To(Equal("fake-progress"))
+			})
+		})
+
+		Context("when action changes state of the VM", func() {
+			var (
+				req           boshhandler.Request
+				action        *fakeaction.TestAction
+				payloadDigest string
+			)
+
+			BeforeEach(func() {
+				req = boshhandler.NewRequest("fake-reply", "run_script", []byte("fake-payload"), 0)
+				req.Caller = "fake-caller"
+				payloadDigest = boshaudit.PayloadDigest([]byte("fake-payload"))
+
+				action = &fakeaction.TestAction{}
+				actionFactory.RegisterAction("run_script", action)
+			})
+
+			It("records outcome of synchronous action in audit journal", func() {
+				dispatcher.Dispatch(req)
+
+				actionRunner.RunErr = errors.New("fake-run-error")
+				dispatcher.Dispatch(req)
+
+				Expect(auditJournal.AppendedRecords()).To(Equal([]boshaudit.Record{
+					{
+						Sequence:      1,
+						Caller:        "fake-caller",
+						Method:        "run_script",
+						PayloadDigest: payloadDigest,
+						Outcome:       boshaudit.OutcomeSucceeded,
+					},
+					{
+						Sequence:      2,
+						Caller:        "fake-caller",
+						Method:        "run_script",
+						PayloadDigest: payloadDigest,
+						Outcome:       boshaudit.OutcomeFailed,
+						Error:         "fake-run-error",
+					},
+				}))
+			})
+
+			It("records start and outcome of asynchronous action with its task id", func() {
+				action.Asynchronous = true
+				action.Persistent = true
+
+				dispatcher.Dispatch(req)
+
+				Expect(auditJournal.AppendedRecords()).To(Equal([]boshaudit.Record{
+					{
+						Sequence:      1,
+						Caller:        "fake-caller",
+						Method:        "run_script",
+						TaskID:        "fake-generated-task-id",
+						PayloadDigest: payloadDigest,
+						Outcome:       boshaudit.OutcomeStarted,
+					},
+				}))
+
+				_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(auditJournal.AppendedRecords()[1]).To(Equal(boshaudit.Record{
+					Sequence:      2,
+					Caller:        "fake-caller",
+					Method:        "run_script",
+					TaskID:        "fake-generated-task-id",
+					PayloadDigest: payloadDigest,
+					Outcome:       boshaudit.OutcomeSucceeded,
+				}))
+
+				taskInfos, _ := taskManager.GetInfos()
+				Expect(taskInfos[0].Caller).To(Equal("fake-caller"))
+			})
+
+			It("does not fail action when it cannot be recorded", func() {
+				auditJournal.AppendErr = errors.New("fake-append-err")
+				actionRunner.RunValue = "fake-value"
+
+				resp := dispatcher.Dispatch(req)
+				Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+				Expect(logger.ErrorCallCount()).To(Equal(1))
+			})
+
+			for _, method := range []string{"compile_package", "release_apply_spec"} {
+				method := method
+
+				It("records "+method+" since it installs packages or replaces apply spec", func() {
+					actionFactory.RegisterAction(method, &fakeaction.TestAction{})
+
+					dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", method, []byte("fake-payload"), 0))
+					Expect(auditJournal.AppendedRecords()).To(HaveLen(1))
+					Expect(auditJournal.AppendedRecords()[0].Method).To(Equal(method))
+				})
+			}
+
+			It("does not record actions that do not change state", func() {
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{})
+
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0))
+				Expect(auditJournal.AppendedRecords()).To(BeEmpty())
+			})
+
+			It("records outcome of resumed task", func() {
+				err := taskManager.AddInfo(boshtask.Info{
+					TaskID:  "fake-task-id",
+					Method:  "run_script",
+					Payload: []byte("fake-payload"),
+					Caller:  "fake-caller",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+
+				_, err = taskService.StartedTasks["fake-task-id"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(auditJournal.AppendedRecords()).To(Equal([]boshaudit.Record{
+					{
+						Sequence:      1,
+						Caller:        "fake-caller",
+						Method:        "run_script",
+						TaskID:        "fake-task-id",
+						PayloadDigest: payloadDigest,
+						Outcome:       boshaudit.OutcomeSucceeded,
+					},
+				}))
+			})
+		})
+
 		Describe("ResumePreviouslyDispatchedTasks", func() {
 			var firstAction, secondAction *fakeaction.TestAction
 
@@ -1,15 +1,21 @@
 package agent
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshlogforwarder "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -18,6 +24,10 @@ import (
 
 const (
 	agentLogTag = "agent"
+
+	// Clocks that drift further than chrony is allowed
+	// to step at boot are considered to be out of sync
+	clockDriftThreshold = 1 * time.Second
 )
 
 type Agent struct {
@@ -31,6 +41,9 @@ type Agent struct {
 	settingsService   boshsettings.Service
 	uuidGenerator     boshuuid.Generator
 	timeService       clock.Clock
+	alertServer       boshalert.Server
+	logRotator        boshlogrotator.Rotator
+	logForwarder      boshlogforwarder.Forwarder
 }
 
 func New(
@@ -44,6 +57,9 @@ func New(
 	settingsService boshsettings.Service,
 	uuidGenerator boshuuid.Generator,
 	timeService clock.Clock,
+	alertServer boshalert.Server,
+	logRotator boshlogrotator.Rotator,
+	logForwarder boshlogforwarder.Forwarder,
 ) Agent {
 	return Agent{
 		logger:            logger,
@@ -56,6 +72,9 @@ func New(
 		settingsService:   settingsService,
 		uuidGenerator:     uuidGenerator,
 		timeService:       timeService,
+		alertServer:       alertServer,
+		logRotator:        logRotator,
+		logForwarder:      logForwarder,
 	}
 }
 
@@ -68,6 +87,16 @@ func (a Agent) Run() error {
 
 	go a.generateHeartbeats(errCh)
 
+	go a.monitorClockDrift(errCh)
+
+	go a.watchDynamicNetworks(errCh)
+
+	go a.serveLocalAlerts(errCh)
+
+	go a.rotateLogs()
+
+	go a.forwardLogs()
+
 	go func() {
 		err := a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errCh))
 		if err != nil {
@@ -126,6 +155,202 @@ func (a Agent) sendAndRecordHeartbeat(errCh chan error) {
 	}
 }
 
+func (a Agent) monitorClockDrift(errCh chan error) {
+	defer a.logger.HandlePanic("Agent Monitor Clock Drift")
+
+	ntpManager := a.platform.GetNtpManager()
+	handleJobFailure := a.handleJobFailure(errCh)
+	drifted := false
+
+	tickChan := time.Tick(a.heartbeatInterval)
+
+	for {
+		status, err := ntpManager.Status()
+		if err == boshntp.ErrNotSupported {
+			return
+		}
+
+		if err != nil {
+			a.logger.Warn(agentLogTag, "Failed to get time synchronization status: %s", err.Error())
+		} else {
+			offset := status.Offset
+			if offset < 0 {
+				offset = -offset
+			}
+
+			// Only alert once per drift instead of on every check
+			if offset > clockDriftThreshold && !drifted {
+				err = handleJobFailure(a.clockDriftAlert(status))
+				if err != nil {
+					errCh <- bosherr.WrapError(err, "Handling clock drift")
+					return
+				}
+			}
+
+			drifted = offset > clockDriftThreshold
+		}
+
+		<-tickChan
+	}
+}
+
+func (a Agent) clockDriftAlert(status boshntp.Status) boshalert.MonitAlert {
+	id, err := a.uuidGenerator.Generate()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to generate clock drift alert ID: %s", err.Error())
+	}
+
+	return boshalert.MonitAlert{
+		ID:      id,
+		Service: "ntp",
+		Event:   "timestamp failed",
+		Action:  "alert",
+		Date:    a.timeService.Now().Format(time.RFC1123Z),
+		Description: fmt.Sprintf(
+			"System clock is off by %s which exceeds threshold of %s (synchronized: %t)",
+			status.Offset, clockDriftThreshold, status.Synchronized,
+		),
+	}
+}
+
+func (a Agent) watchDynamicNetworks(errCh chan error) {
+	defer a.logger.HandlePanic("Agent Watch Dynamic Networks")
+
+	// Addresses are watched even without dynamic networks since
+	// settings may change; refreshing is a noop for static networks
+
+	changeCh, err := a.platform.GetAddressWatcher().Watch(nil)
+	if err == boshnet.ErrAddressWatchingNotSupported {
+		return
+	}
+
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to watch network addresses: %s", err.Error())
+		return
+	}
+
+	// Save addresses leased before watching started without alerting
+	_, err = a.settingsService.RefreshDynamicNetworks()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to refresh dynamic networks: %s", err.Error())
+	}
+
+	handleJobFailure := a.handleJobFailure(errCh)
+
+	for interfaceName := range changeCh {
+		changedNetworks, err := a.settingsService.RefreshDynamicNetworks()
+		if err != nil {
+			a.logger.Warn(agentLogTag, "Failed to refresh dynamic networks after %s changed: %s", interfaceName, err.Error())
+			continue
+		}
+
+		if len(changedNetworks) == 0 {
+			continue
+		}
+
+		err = handleJobFailure(a.addressChangeAlert(changedNetworks))
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Handling network address change")
+			return
+		}
+	}
+}
+
+func (a Agent) addressChangeAlert(changedNetworks []string) boshalert.MonitAlert {
+	id, err := a.uuidGenerator.Generate()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to generate address change alert ID: %s", err.Error())
+	}
+
+	networks := a.settingsService.GetSettings().Networks
+	descriptions := []string{}
+
+	for _, networkName := range changedNetworks {
+		descriptions = append(descriptions, fmt.Sprintf("%s is now %s", networkName, networks[networkName].IP))
+	}
+
+	return boshalert.MonitAlert{
+		ID:          id,
+		Service:     "network",
+		Event:       "address changed",
+		Action:      "alert",
+		Date:        a.timeService.Now().Format(time.RFC1123Z),
+		Description: fmt.Sprintf("Address of dynamic network changed: %s", strings.Join(descriptions, ", ")),
+	}
+}
+
+func (a Agent) serveLocalAlerts(errCh chan error) {
+	defer a.logger.HandlePanic("Agent Serve Local Alerts")
+
+	// Monit alerts keep arriving over SMTP even if local alerts are unavailable
+	err := a.alertServer.ListenAndServe(a.handleLocalAlert(errCh))
+	if err != nil {
+		a.logger.Error(agentLogTag, "Failed to serve local alerts: %s", err.Error())
+	}
+}
+
+func (a Agent) rotateLogs() {
+	defer a.logger.HandlePanic("Agent Rotate Logs")
+
+	// Jobs keep running even if their logs cannot be rotated
+	err := a.logRotator.Run(nil)
+	if err == boshlogrotator.ErrFileWatchingNotSupported {
+		a.logger.Info(agentLogTag, "Not rotating job logs: %s", err.Error())
+		return
+	}
+
+	if err != nil {
+		a.logger.Error(agentLogTag, "Failed to rotate job logs: %s", err.Error())
+	}
+}
+
+func (a Agent) forwardLogs() {
+	defer a.logger.HandlePanic("Agent Forward Logs")
+
+	err := a.logForwarder.Run(nil)
+	if err == boshlogforwarder.ErrForwardingNotConfigured || err == boshlogrotator.ErrFileWatchingNotSupported {
+		a.logger.Info(agentLogTag, "Not forwarding job logs: %s", err.Error())
+		return
+	}
+
+	if err != nil {
+		a.logger.Error(agentLogTag, "Failed to forward job logs: %s", err.Error())
+	}
+}
+
+func (a Agent) handleLocalAlert(errCh chan error) boshalert.LocalAlertHandler {
+	return func(localAlert boshalert.LocalAlert) error {
+		if localAlert.ID == "" {
+			id, err := a.uuidGenerator.Generate()
+			if err != nil {
+				return bosherr.WrapError(err, "Generating local alert ID")
+			}
+
+			localAlert.ID = id
+		}
+
+		alertAdapter := boshalert.NewLocalAdapter(localAlert, a.settingsService, a.timeService)
+		if alertAdapter.IsIgnorable() {
+			a.logger.Debug(agentLogTag, "Ignored local alert: ", localAlert.Event)
+			return nil
+		}
+
+		alert, err := alertAdapter.Alert()
+		if err != nil {
+			return bosherr.WrapError(err, "Adapting local alert")
+		}
+
+		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		if err != nil {
+			err = bosherr.WrapError(err, "Sending local alert")
+			errCh <- err
+			return err
+		}
+
+		return nil
+	}
+}
+
 func (a Agent) getHeartbeat(status string) (Heartbeat, error) {
 	a.logger.Debug(agentLogTag, "Building heartbeat")
 	vitalsService := a.platform.GetVitalsService()
@@ -11,14 +11,19 @@ import (
 
 	"code.cloudfoundry.org/clock/fakeclock"
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	fakealert "github.com/cloudfoundry/bosh-agent/agent/alert/fakes"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
+	fakelogforwarder "github.com/cloudfoundry/bosh-agent/agent/logforwarder/fakes"
+	fakelogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
@@ -36,6 +41,9 @@ func init() {
 			settingsService  *fakesettings.FakeSettingsService
 			uuidGenerator    *fakeuuid.FakeGenerator
 			timeService      *fakeclock.FakeClock
+			alertServer      *fakealert.FakeServer
+			logRotator       *fakelogrotator.FakeRotator
+			logForwarder     *fakelogforwarder.FakeForwarder
 			agent            Agent
 		)
 
@@ -49,6 +57,9 @@ func init() {
 			settingsService = &fakesettings.FakeSettingsService{}
 			uuidGenerator = &fakeuuid.FakeGenerator{}
 			timeService = fakeclock.NewFakeClock(time.Now())
+			alertServer = &fakealert.FakeServer{}
+			logRotator = &fakelogrotator.FakeRotator{}
+			logForwarder = &fakelogforwarder.FakeForwarder{}
 			agent = New(
 				logger,
 				handler,
@@ -60,6 +71,9 @@ func init() {
 				settingsService,
 				uuidGenerator,
 				timeService,
+				alertServer,
+				logRotator,
+				logForwarder,
 			)
 		})
 
@@ -139,6 +153,9 @@ func init() {
 						settingsService,
 						uuidGenerator,
 						timeService,
+						alertServer,
+						logRotator,
+						logForwarder,
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -211,6 +228,165 @@ func init() {
 				})
 			})
 
+			Context("when system clock drifts", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+					uuidGenerator.GeneratedUUID = "fake-uuid"
+					platform.NtpManager.StatusStatus = boshntp.Status{Offset: -3 * time.Second, Synchronized: true}
+				})
+
+				It("sends clock drift alert to health manager", func() {
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("stop"))
+
+					expectedAlert := boshalert.Alert{
+						ID:        "fake-uuid",
+						Severity:  boshalert.SeverityError,
+						Title:     "ntp - timestamp failed - alert",
+						Summary:   "System clock is off by -3s which exceeds threshold of 1s (synchronized: true)",
+						CreatedAt: timeService.Now().Unix(),
+					}
+
+					Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+						Target:  boshhandler.HealthMonitor,
+						Topic:   boshhandler.Alert,
+						Message: expectedAlert,
+					}))
+				})
+			})
+
+			Context("when address of dynamic network changes", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+					uuidGenerator.GeneratedUUID = "fake-uuid"
+
+					settingsService.Settings = boshsettings.Settings{
+						Networks: boshsettings.Networks{
+							"fake-net": boshsettings.Network{Type: boshsettings.NetworkTypeDynamic, IP: "10.0.0.7"},
+						},
+					}
+					settingsService.RefreshDynamicNetworksChanged = []string{"fake-net"}
+				})
+
+				It("sends address change alert to health manager", func() {
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					go func() {
+						platform.AddressWatcher.WatchChangeCh <- "eth0"
+					}()
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("stop"))
+
+					expectedAlert := boshalert.Alert{
+						ID:        "fake-uuid",
+						Severity:  boshalert.SeverityWarning,
+						Title:     "network (10.0.0.7) - address changed - alert",
+						Summary:   "Address of dynamic network changed: fake-net is now 10.0.0.7",
+						CreatedAt: timeService.Now().Unix(),
+					}
+
+					Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+						Target:  boshhandler.HealthMonitor,
+						Topic:   boshhandler.Alert,
+						Message: expectedAlert,
+					}))
+				})
+
+				It("watches addresses even if there were no dynamic networks when agent started", func() {
+					settingsService.Settings = boshsettings.Settings{
+						Networks: boshsettings.Networks{
+							"fake-net": boshsettings.Network{IP: "10.0.0.7"},
+						},
+					}
+
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					go func() {
+						platform.AddressWatcher.WatchChangeCh <- "eth0"
+					}()
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("stop"))
+
+					Expect(settingsService.RefreshDynamicNetworksCallCount).To(BeNumerically(">=", 2))
+				})
+			})
+
+			It("sends local alerts to health manager", func() {
+				handler.KeepOnRunning()
+				uuidGenerator.GeneratedUUID = "fake-uuid"
+
+				alertServer.LocalAlerts = []boshalert.LocalAlert{
+					{
+						Severity:    "warning",
+						Service:     "fake-sidecar",
+						Event:       "fake-event",
+						Description: "fake-description",
+						CreatedAt:   1306076861,
+					},
+				}
+
+				handler.SendCallback = func(input fakembus.SendInput) {
+					if input.Topic == boshhandler.Alert {
+						handler.SendErr = errors.New("stop")
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				expectedAlert := boshalert.Alert{
+					ID:        "fake-uuid",
+					Severity:  boshalert.SeverityWarning,
+					Title:     "fake-sidecar - fake-event",
+					Summary:   "fake-description",
+					CreatedAt: int64(1306076861),
+				}
+
+				Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+					Target:  boshhandler.HealthMonitor,
+					Topic:   boshhandler.Alert,
+					Message: expectedAlert,
+				}))
+			})
+
+			It("rotates job logs in the background", func() {
+				logRotator.RunErr = errors.New("fake-rotate-err")
+
+				err := agent.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(logRotator.RunCallCount).Should(Equal(1))
+			})
+
+			It("forwards job logs in the background", func() {
+				logForwarder.RunErr = errors.New("fake-forward-err")
+
+				err := agent.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(logForwarder.RunCallCount).Should(Equal(1))
+			})
+
 			It("sends job monitoring alerts to health manager", func() {
 				handler.KeepOnRunning()
 
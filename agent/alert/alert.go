@@ -1,5 +1,13 @@
 package alert
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
 type SeverityLevel int
 
 const (
@@ -23,3 +31,14 @@ type Adapter interface {
 	Alert() (Alert, error)
 	IsIgnorable() bool
 }
+
+func serviceWithIPs(service string, settingsService boshsettings.Service) string {
+	ips := settingsService.GetSettings().Networks.IPs()
+	sort.Strings(ips)
+
+	if len(ips) > 0 {
+		service = fmt.Sprintf("%s (%s)", service, strings.Join(ips, ", "))
+	}
+
+	return service
+}
@@ -0,0 +1,22 @@
+package fakes
+
+import (
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
+type FakeServer struct {
+	LocalAlerts []boshalert.LocalAlert
+
+	ListenAndServeErr error
+}
+
+func (s *FakeServer) ListenAndServe(handler boshalert.LocalAlertHandler) error {
+	for _, localAlert := range s.LocalAlerts {
+		err := handler(localAlert)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.ListenAndServeErr
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type localAdapter struct {
+	localAlert      LocalAlert
+	settingsService boshsettings.Service
+	timeService     clock.Clock
+}
+
+func NewLocalAdapter(localAlert LocalAlert, settingsService boshsettings.Service, timeService clock.Clock) Adapter {
+	return &localAdapter{
+		localAlert:      localAlert,
+		settingsService: settingsService,
+		timeService:     timeService,
+	}
+}
+
+func (l *localAdapter) IsIgnorable() bool {
+	return l.severity() == SeverityIgnored
+}
+
+func (l *localAdapter) Alert() (Alert, error) {
+	if err := l.localAlert.Validate(); err != nil {
+		return Alert{}, err
+	}
+
+	return Alert{
+		ID:        l.localAlert.ID,
+		Severity:  l.severity(),
+		Title:     l.title(),
+		Summary:   l.localAlert.Description,
+		CreatedAt: l.createdAt(),
+	}, nil
+}
+
+func (l *localAdapter) severity() SeverityLevel {
+	severity, err := ParseSeverity(l.localAlert.Severity)
+	if err != nil {
+		return SeverityDefault
+	}
+
+	return severity
+}
+
+func (l *localAdapter) title() string {
+	service := serviceWithIPs(l.localAlert.Service, l.settingsService)
+
+	return fmt.Sprintf("%s - %s", service, l.localAlert.Event)
+}
+
+func (l *localAdapter) createdAt() int64 {
+	if l.localAlert.CreatedAt > 0 {
+		return l.localAlert.CreatedAt
+	}
+
+	return l.timeService.Now().Unix()
+}
@@ -0,0 +1,97 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+func buildLocalAlert() LocalAlert {
+	return LocalAlert{
+		ID:          "some-random-id",
+		Severity:    "error",
+		Service:     "sidecar",
+		Event:       "certificate expiring",
+		Description: "certificate expires in 2 days",
+		CreatedAt:   1306076861,
+	}
+}
+
+var _ = Describe("localAdapter", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+	})
+
+	Describe("IsIgnorable", func() {
+		It("ignores alerts with ignored severity", func() {
+			localAlert := buildLocalAlert()
+			localAlert.Severity = "ignored"
+
+			Expect(NewLocalAdapter(localAlert, settingsService, timeService).IsIgnorable()).To(BeTrue())
+		})
+
+		It("does not ignore other alerts", func() {
+			Expect(NewLocalAdapter(buildLocalAlert(), settingsService, timeService).IsIgnorable()).To(BeFalse())
+		})
+	})
+
+	Describe("Alert", func() {
+		It("converts local alert", func() {
+			settingsService.Settings.Networks = boshsettings.Networks{
+				"fake-net1": boshsettings.Network{IP: "192.168.0.1"},
+				"fake-net2": boshsettings.Network{IP: "10.0.0.1"},
+			}
+
+			builtAlert, err := NewLocalAdapter(buildLocalAlert(), settingsService, timeService).Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builtAlert).To(Equal(Alert{
+				ID:        "some-random-id",
+				Severity:  SeverityError,
+				Title:     "sidecar (10.0.0.1, 192.168.0.1) - certificate expiring",
+				Summary:   "certificate expires in 2 days",
+				CreatedAt: 1306076861,
+			}))
+		})
+
+		It("defaults severity to critical", func() {
+			localAlert := buildLocalAlert()
+			localAlert.Severity = ""
+
+			builtAlert, err := NewLocalAdapter(localAlert, settingsService, timeService).Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builtAlert.Severity).To(Equal(SeverityCritical))
+		})
+
+		It("defaults CreatedAt to time.Now()", func() {
+			localAlert := buildLocalAlert()
+			localAlert.CreatedAt = 0
+
+			builtAlert, err := NewLocalAdapter(localAlert, settingsService, timeService).Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builtAlert.CreatedAt).To(Equal(timeService.Now().Unix()))
+		})
+
+		It("returns error when local alert is not valid", func() {
+			localAlert := buildLocalAlert()
+			localAlert.Severity = "fake-severity"
+			localAlert.Service = ""
+
+			_, err := NewLocalAdapter(localAlert, settingsService, timeService).Alert()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing service"))
+			Expect(err.Error()).To(ContainSubstring("Unknown severity 'fake-severity'"))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// LocalAlert is posted to the agent's local alert endpoint by job
+// supervisors, job scripts or sidecars running on the VM
+type LocalAlert struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Service     string `json:"service"`
+	Event       string `json:"event"`
+	Description string `json:"description"`
+	CreatedAt   int64  `json:"created_at"` // Unix timestamp
+}
+
+var severityNames = map[string]SeverityLevel{
+	"alert":    SeverityAlert,
+	"critical": SeverityCritical,
+	"error":    SeverityError,
+	"warning":  SeverityWarning,
+	"ignored":  SeverityIgnored,
+}
+
+// ParseSeverity converts severity names such as "critical" into severity
+// levels; empty name results in the default severity
+func ParseSeverity(name string) (SeverityLevel, error) {
+	if name == "" {
+		return SeverityDefault, nil
+	}
+
+	severity, found := severityNames[strings.ToLower(name)]
+	if !found {
+		return 0, bosherr.Errorf("Unknown severity '%s'", name)
+	}
+
+	return severity, nil
+}
+
+func (a LocalAlert) Validate() error {
+	errs := []error{}
+
+	if a.Service == "" {
+		errs = append(errs, errors.New("Missing service"))
+	}
+
+	if a.Event == "" {
+		errs = append(errs, errors.New("Missing event"))
+	}
+
+	if _, err := ParseSeverity(a.Severity); err != nil {
+		errs = append(errs, err)
+	}
+
+	if a.CreatedAt < 0 {
+		errs = append(errs, fmt.Errorf("Invalid created_at %d", a.CreatedAt))
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
@@ -2,7 +2,6 @@ package alert
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 
@@ -47,16 +46,7 @@ func (m *monitAdapter) Alert() (Alert, error) {
 }
 
 func (m *monitAdapter) title() string {
-	settings := m.settingsService.GetSettings()
-
-	ips := settings.Networks.IPs()
-	sort.Strings(ips)
-
-	service := m.monitAlert.Service
-
-	if len(ips) > 0 {
-		service = fmt.Sprintf("%s (%s)", service, strings.Join(ips, ", "))
-	}
+	service := serviceWithIPs(m.monitAlert.Service, m.settingsService)
 
 	return fmt.Sprintf("%s - %s - %s", service, m.monitAlert.Event, m.monitAlert.Action)
 }
@@ -160,4 +150,12 @@ var eventToSeverity = map[string]SeverityLevel{
 	"uid succeeded":                SeverityIgnored,
 	"uid changed":                  SeverityWarning,
 	"uid not changed":              SeverityIgnored,
+
+	// Raised by the agent itself rather than by monit
+	"address changed":           SeverityWarning,
+	"crash loop detected":       SeverityCritical,
+	"liveness probe failed":     SeverityAlert,
+	"liveness probe succeeded":  SeverityWarning,
+	"readiness probe failed":    SeverityError,
+	"readiness probe succeeded": SeverityWarning,
 }
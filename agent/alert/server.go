@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	serverLogTag = "alertServer"
+
+	// Alerts are small; anything larger is most likely not an alert
+	maxLocalAlertBytes = 64 * 1024
+)
+
+type LocalAlertHandler func(LocalAlert) error
+
+// Server accepts alerts from processes running on the VM
+// so that they can be forwarded to the health monitor
+type Server interface {
+	ListenAndServe(handler LocalAlertHandler) error
+}
+
+type unixSocketServer struct {
+	socketPath string
+	fs         boshsys.FileSystem
+	logger     boshlog.Logger
+}
+
+// NewUnixSocketServer returns a server that accepts alerts
+// as JSON posted over HTTP to /alerts on a Unix socket
+func NewUnixSocketServer(socketPath string, fs boshsys.FileSystem, logger boshlog.Logger) Server {
+	return unixSocketServer{
+		socketPath: socketPath,
+		fs:         fs,
+		logger:     logger,
+	}
+}
+
+func (s unixSocketServer) ListenAndServe(handler LocalAlertHandler) error {
+	// Socket is left behind when the agent is killed
+	err := s.fs.RemoveAll(s.socketPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing stale socket %s", s.socketPath)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on %s", s.socketPath)
+	}
+
+	defer listener.Close()
+
+	err = s.fs.Chmod(s.socketPath, 0770)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Chmoding %s", s.socketPath)
+	}
+
+	// Jobs running as vcap need to be able to connect
+	err = s.fs.Chown(s.socketPath, "root:vcap")
+	if err != nil {
+		s.logger.Warn(serverLogTag, "Failed to chown %s: %s", s.socketPath, err.Error())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/alerts", s.alertsHandler(handler))
+
+	err = http.Serve(listener, mux)
+	if err != nil {
+		return bosherr.WrapError(err, "Serving alerts")
+	}
+
+	return nil
+}
+
+func (s unixSocketServer) alertsHandler(handler LocalAlertHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var localAlert LocalAlert
+
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxLocalAlertBytes))
+
+		err := decoder.Decode(&localAlert)
+		if err != nil {
+			s.respondWithError(w, http.StatusBadRequest, bosherr.WrapError(err, "Unmarshalling alert"))
+			return
+		}
+
+		err = localAlert.Validate()
+		if err != nil {
+			s.respondWithError(w, http.StatusBadRequest, bosherr.WrapError(err, "Validating alert"))
+			return
+		}
+
+		err = handler(localAlert)
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, bosherr.WrapError(err, "Handling alert"))
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (s unixSocketServer) respondWithError(w http.ResponseWriter, status int, err error) {
+	s.logger.Error(serverLogTag, err.Error())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	response := map[string]map[string]string{
+		"exception": {"message": err.Error()},
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
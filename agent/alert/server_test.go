@@ -0,0 +1,141 @@
+// +build !windows
+
+package alert_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("unixSocketServer", func() {
+	var (
+		tmpDir     string
+		socketPath string
+		client     *http.Client
+
+		handledLock sync.Mutex
+		handled     []LocalAlert
+		handleErr   error
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "alert-server")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath = filepath.Join(tmpDir, "alerts.sock")
+
+		// Stale socket from previous run
+		err = ioutil.WriteFile(socketPath, []byte{}, 0600)
+		Expect(err).ToNot(HaveOccurred())
+
+		handled = nil
+		handleErr = nil
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		server := NewUnixSocketServer(socketPath, boshsys.NewOsFileSystem(logger), logger)
+
+		go server.ListenAndServe(func(localAlert LocalAlert) error {
+			handledLock.Lock()
+			defer handledLock.Unlock()
+
+			handled = append(handled, localAlert)
+			return handleErr
+		})
+
+		client = &http.Client{
+			Transport: &http.Transport{
+				Dial: func(_, _ string) (net.Conn, error) {
+					return net.Dial("unix", socketPath)
+				},
+			},
+		}
+
+		Eventually(func() error {
+			conn, err := net.Dial("unix", socketPath)
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	post := func(body string) *http.Response {
+		resp, err := client.Post("http://unix/alerts", "application/json", strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		return resp
+	}
+
+	It("passes posted alerts to the handler", func() {
+		resp := post(`{"severity":"warning","service":"sidecar","event":"fake-event","description":"fake-description"}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+		handledLock.Lock()
+		defer handledLock.Unlock()
+
+		Expect(handled).To(Equal([]LocalAlert{{
+			Severity:    "warning",
+			Service:     "sidecar",
+			Event:       "fake-event",
+			Description: "fake-description",
+		}}))
+	})
+
+	It("allows group members to connect", func() {
+		info, err := os.Stat(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0770)))
+	})
+
+	It("rejects alerts that cannot be parsed", func() {
+		resp := post(`not-json`)
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(handled).To(BeEmpty())
+	})
+
+	It("rejects invalid alerts", func() {
+		resp := post(`{"severity":"fake-severity","event":"fake-event"}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("Missing service"))
+		Expect(string(body)).To(ContainSubstring("Unknown severity 'fake-severity'"))
+	})
+
+	It("rejects requests other than POST", func() {
+		resp, err := client.Get("http://unix/alerts")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("responds with error when handler fails", func() {
+		handledLock.Lock()
+		handleErr = errors.New("fake-handle-error")
+		handledLock.Unlock()
+
+		resp := post(`{"service":"sidecar","event":"fake-event"}`)
+		Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("fake-handle-error"))
+	})
+})
@@ -2,16 +2,39 @@ package applyspec
 
 import (
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 )
 
 type JobTemplateSpec struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Ports the job listens on; used to open the host firewall
+	Ports []PortSpec `json:"ports,omitempty"`
+
+	// Limits override resource limits declared in job process definitions
+	Limits *cgroup.Limits `json:"limits,omitempty"`
+
+	// DependsOn lists jobs that are started before and stopped after this job
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+type PortSpec struct {
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol,omitempty"`
+	Sources  []string `json:"sources,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
-	return models.Job{
-		Name:    s.Name,
-		Version: s.Version,
+	job := models.Job{
+		Name:      s.Name,
+		Version:   s.Version,
+		DependsOn: s.DependsOn,
+	}
+
+	if s.Limits != nil {
+		job.Limits = *s.Limits
 	}
+
+	return job
 }
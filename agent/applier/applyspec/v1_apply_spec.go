@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 )
 
 type V1ApplySpec struct {
@@ -75,6 +76,22 @@ func (s V1ApplySpec) Packages() []models.Package {
 	return packages
 }
 
+// FirewallRules returns inbound rules for ports
+// declared by the jobs in the apply spec.
+func (s V1ApplySpec) FirewallRules() []boshsettings.FirewallRule {
+	rules := []boshsettings.FirewallRule{}
+	for _, template := range s.JobSpec.JobTemplateSpecs {
+		for _, port := range template.Ports {
+			rules = append(rules, boshsettings.FirewallRule{
+				Protocol: port.Protocol,
+				Ports:    []int{port.Port},
+				Sources:  port.Sources,
+			})
+		}
+	}
+	return rules
+}
+
 func (s V1ApplySpec) MaxLogFileSize() string {
 	fileSize := s.PropertiesSpec.LoggingSpec.MaxLogFileSize
 	if len(fileSize) > 0 {
@@ -8,6 +8,8 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -191,8 +193,10 @@ var _ = Describe("V1ApplySpec", func() {
 							Version: "fake-job1-version",
 						},
 						{
-							Name:    "fake-job2-name",
-							Version: "fake-job2-version",
+							Name:      "fake-job2-name",
+							Version:   "fake-job2-version",
+							Limits:    &cgroup.Limits{Memory: "1G", CPU: 2},
+							DependsOn: []string{"fake-job1-name"},
 						},
 					},
 				},
@@ -262,7 +266,9 @@ var _ = Describe("V1ApplySpec", func() {
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job2-name",
 					},
-					Packages: actualJobs[1].Packages, // tested above
+					Packages:  actualJobs[1].Packages, // tested above
+					Limits:    cgroup.Limits{Memory: "1G", CPU: 2},
+					DependsOn: []string{"fake-job1-name"},
 				},
 			}))
 		})
@@ -304,6 +310,37 @@ var _ = Describe("V1ApplySpec", func() {
 		})
 	})
 
+	Describe("FirewallRules", func() {
+		It("returns a rule for each port declared by jobs", func() {
+			spec := V1ApplySpec{
+				JobSpec: JobSpec{
+					JobTemplateSpecs: []JobTemplateSpec{
+						{
+							Name: "fake-job1-name",
+							Ports: []PortSpec{
+								{Port: 80},
+								{Port: 53, Protocol: "udp", Sources: []string{"10.0.0.0/8"}},
+							},
+						},
+						{Name: "fake-job2-name"},
+						{Name: "fake-job3-name", Ports: []PortSpec{{Port: 8443, Protocol: "tcp"}}},
+					},
+				},
+			}
+
+			Expect(spec.FirewallRules()).To(Equal([]boshsettings.FirewallRule{
+				{Ports: []int{80}},
+				{Protocol: "udp", Ports: []int{53}, Sources: []string{"10.0.0.0/8"}},
+				{Protocol: "tcp", Ports: []int{8443}},
+			}))
+		})
+
+		It("returns no rules when no ports are declared", func() {
+			spec := V1ApplySpec{}
+			Expect(spec.FirewallRules()).To(Equal([]boshsettings.FirewallRule{}))
+		})
+	})
+
 	Describe("MaxLogFileSize", func() {
 		It("returns 50M if size is not provided", func() {
 			spec := V1ApplySpec{}
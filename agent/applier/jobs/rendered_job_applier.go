@@ -10,6 +10,8 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -84,6 +86,10 @@ func (s *renderedJobApplier) Apply(job models.Job) error {
 		return bosherr.WrapError(err, "Preparing job")
 	}
 
+	if err := job.Limits.Validate(); err != nil {
+		return bosherr.WrapErrorf(err, "Validating resource limits of job %s", job.Name)
+	}
+
 	if err := job.CreateDirectories(s.fs, s.dirProvider); err != nil {
 		return bosherr.WrapErrorf(err, "Creating directories for job %s", job.Name)
 	}
@@ -93,11 +99,20 @@ func (s *renderedJobApplier) Apply(job models.Job) error {
 		return bosherr.WrapError(err, "Getting job bundle")
 	}
 
-	_, _, err = jobBundle.Enable()
+	_, enabledPath, err := jobBundle.Enable()
 	if err != nil {
 		return bosherr.WrapError(err, "Enabling job")
 	}
 
+	// Process definitions are validated here so that mistakes
+	// are reported by apply rather than when jobs are started
+	if processesFilePath, found := processdef.FindFile(s.fs, enabledPath); found {
+		_, err = processdef.ParseFile(s.fs, processesFilePath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Loading process definitions of job %s", job.Name)
+		}
+	}
+
 	return s.applyPackages(job)
 }
 
@@ -194,8 +209,44 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		return
 	}
 
+	// Process definitions take precedence over monit files
+	if processesFilePath, found := processdef.FindFile(fs, jobDir); found {
+		config, err := processdef.ParseFile(fs, processesFilePath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Loading process definitions of job %s", job.Name)
+		}
+
+		// Limits from apply spec override limits from job bundle
+		err = s.setJobLimits(job.Name, config.Limits.Merge(job.Limits))
+		if err != nil {
+			return err
+		}
+
+		err = s.setJobDependencies(job.Name, mergeDependencies(config.DependsOn, job.DependsOn))
+		if err != nil {
+			return err
+		}
+
+		err = s.jobSupervisor.AddProcesses(job.Name, jobIndex, config)
+		if err != nil {
+			return bosherr.WrapError(err, "Adding process definitions")
+		}
+
+		return nil
+	}
+
 	monitFilePath := path.Join(jobDir, "monit")
 	if fs.FileExists(monitFilePath) {
+		err = s.setJobLimits(job.Name, job.Limits)
+		if err != nil {
+			return
+		}
+
+		err = s.setJobDependencies(job.Name, job.DependsOn)
+		if err != nil {
+			return
+		}
+
 		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath)
 		if err != nil {
 			err = bosherr.WrapError(err, "Adding monit configuration")
@@ -213,6 +264,19 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		label := strings.Replace(path.Base(monitFilePath), ".monit", "", 1)
 		subJobName := fmt.Sprintf("%s_%s", job.Name, label)
 
+		// Additional monit files are accounted separately without limits
+		// since limits of the job apply to its main monit file
+		err = s.setJobLimits(subJobName, cgroup.Limits{})
+		if err != nil {
+			return
+		}
+
+		// Processes of additional monit files wait for the same jobs
+		err = s.setJobDependencies(subJobName, job.DependsOn)
+		if err != nil {
+			return
+		}
+
 		err = s.jobSupervisor.AddJob(subJobName, jobIndex, monitFilePath)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Adding additional monit configuration %s", label)
@@ -223,6 +287,42 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 	return nil
 }
 
+func (s *renderedJobApplier) setJobLimits(jobName string, limits cgroup.Limits) error {
+	err := s.jobSupervisor.SetJobLimits(jobName, limits)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting resource limits of job %s", jobName)
+	}
+
+	return nil
+}
+
+func (s *renderedJobApplier) setJobDependencies(jobName string, dependsOn []string) error {
+	err := s.jobSupervisor.SetJobDependencies(jobName, dependsOn)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting dependencies of job %s", jobName)
+	}
+
+	return nil
+}
+
+// mergeDependencies joins dependencies from job bundle and apply spec
+func mergeDependencies(bundleDependsOn, specDependsOn []string) []string {
+	var dependsOn []string
+
+	seen := map[string]bool{}
+
+	for _, jobNames := range [][]string{bundleDependsOn, specDependsOn} {
+		for _, jobName := range jobNames {
+			if !seen[jobName] {
+				seen[jobName] = true
+				dependsOn = append(dependsOn, jobName)
+			}
+		}
+	}
+
+	return dependsOn
+}
+
 func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 	s.logger.Debug(logTag, "Keeping only jobs %v", jobs)
 
@@ -13,7 +13,9 @@ import (
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
@@ -114,6 +116,7 @@ func init() {
 
 			BeforeEach(func() {
 				job, bundle = buildJob(jobsBc)
+				bundle.EnablePath = "/fake-enabled-job"
 			})
 
 			ItInstallsJob := func(act func() error) {
@@ -520,6 +523,30 @@ func init() {
 
 					ItUpdatesPackages(act)
 					ItCreatesDirectories(act)
+
+					It("validates process definitions of job", func() {
+						fs.WriteFileString("/fake-enabled-job/config/processes.yml", "processes: [{name: fake-proc}]")
+
+						err := act()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Loading process definitions of job " + job.Name))
+						Expect(err.Error()).To(ContainSubstring("Executable '' must be an absolute path"))
+					})
+
+					It("validates resource limits of job", func() {
+						job.Limits = cgroup.Limits{Pids: -1}
+
+						err := act()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("Validating resource limits of job " + job.Name))
+					})
+
+					It("accepts valid process definitions", func() {
+						fs.WriteFileString("/fake-enabled-job/config/processes.yml", "processes: [{name: fake-proc, executable: /bin/fake}]")
+
+						err := act()
+						Expect(err).ToNot(HaveOccurred())
+					})
 				})
 
 				Context("when job is not installed", func() {
@@ -580,6 +607,157 @@ func init() {
 				}))
 			})
 
+			It("sets limits of job from apply spec before adding it", func() {
+				job, bundle := buildJob(jobsBc)
+				job.Limits = cgroup.Limits{Memory: "1G"}
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+				fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.SetJobLimitsArgs).To(Equal([]fakejobsuper.SetJobLimitsArgs{
+					{Name: job.Name, Limits: cgroup.Limits{Memory: "1G"}},
+					{Name: job.Name + "_subjob", Limits: cgroup.Limits{}},
+				}))
+			})
+
+			It("sets dependencies of job and its additional monit files from apply spec", func() {
+				job, bundle := buildJob(jobsBc)
+				job.DependsOn = []string{"fake-proxy"}
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+				fs.SetGlob("/path/to/job/*.monit", []string{"/path/to/job/subjob.monit"})
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.SetJobDependenciesArgs).To(Equal([]fakejobsuper.SetJobDependenciesArgs{
+					{Name: job.Name, DependsOn: []string{"fake-proxy"}},
+					{Name: job.Name + "_subjob", DependsOn: []string{"fake-proxy"}},
+				}))
+			})
+
+			It("returns error if setting job dependencies fails", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				jobSupervisor.SetJobDependenciesErr = errors.New("fake-set-dependencies-error")
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Setting dependencies of job " + job.Name))
+				Expect(jobSupervisor.AddJobArgs).To(BeEmpty())
+			})
+
+			It("returns error if setting job limits fails", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				jobSupervisor.SetJobLimitsErr = errors.New("fake-set-limits-error")
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Setting resource limits of job " + job.Name))
+				Expect(jobSupervisor.AddJobArgs).To(BeEmpty())
+			})
+
+			It("adds process definitions instead of monit files if job has them", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/monit", "some conf")
+				fs.WriteFileString("/path/to/job/config/processes.yml", "processes: [{name: fake-proc, executable: /bin/fake}]")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 1)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.AddJobArgs).To(BeEmpty())
+				Expect(jobSupervisor.AddProcessesArgs).To(Equal([]fakejobsuper.AddProcessesArgs{{
+					Name:  job.Name,
+					Index: 1,
+					Config: processdef.Config{
+						Processes: []processdef.Process{{Name: "fake-proc", Executable: "/bin/fake"}},
+					},
+				}}))
+			})
+
+			It("merges job limits from process definitions with limits from apply spec", func() {
+				job, bundle := buildJob(jobsBc)
+				job.Limits = cgroup.Limits{CPU: 2}
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.yml", `
+limits: {memory: 512M, cpu: 1}
+processes: [{name: fake-proc, executable: /bin/fake}]`)
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.SetJobLimitsArgs).To(Equal([]fakejobsuper.SetJobLimitsArgs{
+					{Name: job.Name, Limits: cgroup.Limits{Memory: "512M", CPU: 2}},
+				}))
+			})
+
+			It("merges job dependencies from process definitions with dependencies from apply spec", func() {
+				job, bundle := buildJob(jobsBc)
+				job.DependsOn = []string{"fake-db", "fake-proxy"}
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.yml", `
+depends_on: [fake-proxy, fake-dns]
+processes: [{name: fake-proc, executable: /bin/fake}]`)
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(jobSupervisor.SetJobDependenciesArgs).To(Equal([]fakejobsuper.SetJobDependenciesArgs{
+					{Name: job.Name, DependsOn: []string{"fake-proxy", "fake-dns", "fake-db"}},
+				}))
+			})
+
+			It("returns error if process definitions are invalid", func() {
+				job, bundle := buildJob(jobsBc)
+
+				fs := fakesys.NewFakeFileSystem()
+				fs.WriteFileString("/path/to/job/config/processes.json", "{")
+
+				bundle.GetDirPath = "/path/to/job"
+				bundle.GetDirFs = fs
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Loading process definitions"))
+			})
+
 			It("does not require monit script", func() {
 				job, bundle := buildJob(jobsBc)
 
@@ -1,8 +1,10 @@
 package models
 
 import (
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"os"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type Job struct {
@@ -13,6 +15,12 @@ type Job struct {
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
+
+	// Resource limits from apply spec
+	Limits cgroup.Limits
+
+	// Jobs that are started before this job from apply spec
+	DependsOn []string
 }
 
 func (s Job) BundleName() string {
@@ -0,0 +1,60 @@
+package fakes
+
+import (
+	"sync"
+
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
+)
+
+type FakeJournal struct {
+	AppendErr error
+
+	ReadFrom    uint64
+	ReadLimit   int
+	ReadRecords []boshaudit.Record
+	ReadErr     error
+
+	VerifyErr error
+
+	HeadHead boshaudit.Head
+	HeadErr  error
+
+	lock     sync.Mutex
+	appended []boshaudit.Record
+}
+
+func (j *FakeJournal) Append(record boshaudit.Record) (boshaudit.Record, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.AppendErr != nil {
+		return boshaudit.Record{}, j.AppendErr
+	}
+
+	record.Sequence = uint64(len(j.appended) + 1)
+	j.appended = append(j.appended, record)
+
+	return record, nil
+}
+
+func (j *FakeJournal) AppendedRecords() []boshaudit.Record {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return append([]boshaudit.Record{}, j.appended...)
+}
+
+func (j *FakeJournal) Read(from uint64, limit int) ([]boshaudit.Record, error) {
+	j.ReadFrom = from
+	j.ReadLimit = limit
+
+	return j.ReadRecords, j.ReadErr
+}
+
+func (j *FakeJournal) Verify() error {
+	return j.VerifyErr
+}
+
+func (j *FakeJournal) Head() (boshaudit.Head, error) {
+	return j.HeadHead, j.HeadErr
+}
@@ -0,0 +1,349 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	OutcomeStarted   = "started"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+
+	// Records longer than this cannot be read back
+	maxRecordLength = 1024 * 1024
+)
+
+// Record describes single state changing action. Each record includes
+// hash of previous record so that editing or removing records
+// in the middle of the journal breaks the chain.
+type Record struct {
+	Sequence uint64    `json:"sequence"`
+	Time     time.Time `json:"time"`
+
+	// Caller identifies API consumer that requested the action
+	// (reply subject for NATS, user and address for HTTPS)
+	Caller string `json:"caller"`
+
+	Method string `json:"method"`
+	TaskID string `json:"task_id,omitempty"`
+
+	// PayloadDigest is SHA-256 of request payload;
+	// payloads are not recorded since they may contain secrets
+	PayloadDigest string `json:"payload_digest"`
+
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// Head identifies last record appended to the journal. It is saved apart
+// from the journal so that removing records from the end of the journal
+// or replacing whole journal with a consistent chain is detected.
+type Head struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+type Journal interface {
+	// Append fills in sequence, time and hashes of the record and appends it
+	Append(record Record) (Record, error)
+
+	// Read returns at most limit records starting with sequence from
+	Read(from uint64, limit int) ([]Record, error)
+
+	// Verify returns error describing first record that breaks the chain
+	// or describing mismatch between last record and saved head
+	Verify() error
+
+	// Head returns saved head; API consumers may keep heads
+	// off the VM to detect journal being rewritten together with its head
+	Head() (Head, error)
+}
+
+type fileJournal struct {
+	path        string
+	headPath    string
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+
+	lock     sync.Mutex
+	loaded   bool
+	lastSeq  uint64
+	lastHash string
+}
+
+func NewJournal(path, headPath string, fs boshsys.FileSystem, timeService clock.Clock) Journal {
+	return &fileJournal{
+		path:        path,
+		headPath:    headPath,
+		fs:          fs,
+		timeService: timeService,
+	}
+}
+
+func PayloadDigest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (j *fileJournal) Append(record Record) (Record, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if !j.loaded {
+		err := j.each(func(last Record) error {
+			j.lastSeq = last.Sequence
+			j.lastHash = last.Hash
+			return nil
+		})
+		if err != nil {
+			return Record{}, bosherr.WrapError(err, "Reading last audit record")
+		}
+
+		j.loaded = true
+	}
+
+	record.Sequence = j.lastSeq + 1
+	record.Time = j.timeService.Now().UTC()
+	record.PrevHash = j.lastHash
+
+	hash, err := recordHash(record)
+	if err != nil {
+		return Record{}, err
+	}
+
+	record.Hash = hash
+
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return Record{}, bosherr.WrapError(err, "Marshalling audit record")
+	}
+
+	file, err := j.fs.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return Record{}, bosherr.WrapError(err, "Opening audit journal")
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(append(bytes, '\n'))
+	if err != nil {
+		return Record{}, bosherr.WrapError(err, "Writing audit record")
+	}
+
+	if syncer, ok := file.(interface{ Sync() error }); ok {
+		err = syncer.Sync()
+		if err != nil {
+			return Record{}, bosherr.WrapError(err, "Syncing audit journal")
+		}
+	}
+
+	j.lastSeq = record.Sequence
+	j.lastHash = record.Hash
+
+	err = j.saveHead(Head{Sequence: record.Sequence, Hash: record.Hash})
+	if err != nil {
+		return Record{}, err
+	}
+
+	return record, nil
+}
+
+func (j *fileJournal) Read(from uint64, limit int) ([]Record, error) {
+	records := []Record{}
+
+	err := j.each(func(record Record) error {
+		if record.Sequence >= from && len(records) < limit {
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading audit journal")
+	}
+
+	return records, nil
+}
+
+func (j *fileJournal) Verify() error {
+	var (
+		prevSeq  uint64
+		prevHash string
+	)
+
+	err := j.each(func(record Record) error {
+		if record.Sequence != prevSeq+1 {
+			return bosherr.Errorf("Audit record %d follows record %d", record.Sequence, prevSeq)
+		}
+
+		if record.PrevHash != prevHash {
+			return bosherr.Errorf("Audit record %d does not match hash of previous record", record.Sequence)
+		}
+
+		hash, err := recordHash(record)
+		if err != nil {
+			return err
+		}
+
+		if record.Hash != hash {
+			return bosherr.Errorf("Audit record %d does not match its hash", record.Sequence)
+		}
+
+		prevSeq = record.Sequence
+		prevHash = record.Hash
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if prevSeq > 0 && !j.fs.FileExists(j.headPath) {
+		return bosherr.Errorf("Audit journal head is missing")
+	}
+
+	head, err := j.Head()
+	if err != nil {
+		return err
+	}
+
+	if head.Sequence != prevSeq {
+		return bosherr.Errorf("Audit journal ends with record %d but its head is record %d", prevSeq, head.Sequence)
+	}
+
+	if head.Hash != prevHash {
+		return bosherr.Errorf("Audit record %d does not match hash of journal head", prevSeq)
+	}
+
+	return nil
+}
+
+// Head returns empty head when nothing was appended yet
+func (j *fileJournal) Head() (Head, error) {
+	var head Head
+
+	if !j.fs.FileExists(j.headPath) {
+		return head, nil
+	}
+
+	bytes, err := j.fs.ReadFile(j.headPath)
+	if err != nil {
+		return head, bosherr.WrapError(err, "Reading audit journal head")
+	}
+
+	err = json.Unmarshal(bytes, &head)
+	if err != nil {
+		return head, bosherr.WrapError(err, "Unmarshalling audit journal head")
+	}
+
+	return head, nil
+}
+
+// saveHead replaces head by renaming so that
+// head is never left partially written
+func (j *fileJournal) saveHead(head Head) error {
+	bytes, err := json.Marshal(head)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling audit journal head")
+	}
+
+	tmpPath := j.headPath + ".tmp"
+
+	file, err := j.fs.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening audit journal head")
+	}
+
+	_, err = file.Write(bytes)
+	if err == nil {
+		if syncer, ok := file.(interface{ Sync() error }); ok {
+			err = syncer.Sync()
+		}
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Writing audit journal head")
+	}
+
+	err = j.fs.Rename(tmpPath, j.headPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving audit journal head")
+	}
+
+	return nil
+}
+
+// each calls recordFunc for every record in order;
+// missing journal has no records
+func (j *fileJournal) each(recordFunc func(Record) error) error {
+	if !j.fs.FileExists(j.path) {
+		return nil
+	}
+
+	file, err := j.fs.OpenFile(j.path, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening audit journal")
+	}
+
+	defer file.Close()
+
+	return eachRecord(file, recordFunc)
+}
+
+func eachRecord(reader io.Reader, recordFunc func(Record) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordLength)
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		var record Record
+
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling audit record on line %d", line)
+		}
+
+		err = recordFunc(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// recordHash hashes all fields of the record other than its hash
+func recordHash(record Record) (string, error) {
+	record.Hash = ""
+
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling audit record")
+	}
+
+	sum := sha256.Sum256(bytes)
+
+	return hex.EncodeToString(sum[:]), nil
+}
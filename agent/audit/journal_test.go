@@ -0,0 +1,246 @@
+package audit_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/audit"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		journalDir  string
+		journalPath string
+		headPath    string
+		fs          boshsys.FileSystem
+		timeService *fakeclock.FakeClock
+		journal     Journal
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		journalDir, err = ioutil.TempDir("", "audit-journal")
+		Expect(err).ToNot(HaveOccurred())
+
+		journalPath = filepath.Join(journalDir, "audit.log")
+		headPath = filepath.Join(journalDir, "audit.head")
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		timeService = fakeclock.NewFakeClock(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+
+		journal = NewJournal(journalPath, headPath, fs, timeService)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(journalDir)).To(Succeed())
+	})
+
+	appendRecords := func(methods ...string) []Record {
+		var records []Record
+
+		for _, method := range methods {
+			record, err := journal.Append(Record{
+				Caller:        "fake-caller",
+				Method:        method,
+				PayloadDigest: PayloadDigest([]byte(method)),
+				Outcome:       OutcomeSucceeded,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			records = append(records, record)
+			timeService.Increment(time.Second)
+		}
+
+		return records
+	}
+
+	rewriteJournal := func(editFunc func(lines []string) []string) {
+		contents, err := ioutil.ReadFile(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+		lines = editFunc(lines)
+
+		Expect(ioutil.WriteFile(journalPath, []byte(strings.Join(lines, "\n")+"\n"), 0600)).To(Succeed())
+	}
+
+	Describe("Append", func() {
+		It("appends records chained by hashes", func() {
+			records := appendRecords("apply", "ssh")
+
+			Expect(records[0].Sequence).To(Equal(uint64(1)))
+			Expect(records[0].Time).To(Equal(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)))
+			Expect(records[0].PrevHash).To(BeEmpty())
+			Expect(records[0].Hash).To(HaveLen(64))
+
+			Expect(records[1].Sequence).To(Equal(uint64(2)))
+			Expect(records[1].PrevHash).To(Equal(records[0].Hash))
+
+			info, err := os.Stat(journalPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("saves head of journal apart from journal", func() {
+			records := appendRecords("apply", "ssh")
+
+			head, err := journal.Head()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(head).To(Equal(Head{Sequence: 2, Hash: records[1].Hash}))
+
+			info, err := os.Stat(headPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("returns error when head cannot be saved", func() {
+			journal = NewJournal(journalPath, filepath.Join(journalDir, "missing-dir", "audit.head"), fs, timeService)
+
+			_, err := journal.Append(Record{Method: "apply"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("audit journal head"))
+		})
+
+		It("continues chain of existing journal", func() {
+			records := appendRecords("apply")
+
+			journal = NewJournal(journalPath, headPath, fs, timeService)
+			records = append(records, appendRecords("ssh")...)
+
+			Expect(records[1].Sequence).To(Equal(uint64(2)))
+			Expect(records[1].PrevHash).To(Equal(records[0].Hash))
+			Expect(journal.Verify()).To(Succeed())
+		})
+
+		It("returns error when journal cannot be written", func() {
+			journal = NewJournal(filepath.Join(journalDir, "missing-dir", "audit.log"), headPath, fs, timeService)
+
+			_, err := journal.Append(Record{Method: "apply"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Opening audit journal"))
+		})
+	})
+
+	Describe("Read", func() {
+		It("reads records starting with sequence up to limit", func() {
+			records := appendRecords("apply", "ssh", "run_script", "mount_disk")
+
+			read, err := journal.Read(2, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read).To(Equal(records[1:3]))
+		})
+
+		It("reads no records when journal does not exist", func() {
+			read, err := journal.Read(1, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read).To(BeEmpty())
+		})
+
+		It("returns error when record cannot be parsed", func() {
+			appendRecords("apply")
+			rewriteJournal(func(lines []string) []string { return append(lines, "fake-garbage") })
+
+			_, err := journal.Read(1, 10)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling audit record on line 2"))
+		})
+	})
+
+	Describe("Verify", func() {
+		BeforeEach(func() {
+			appendRecords("apply", "ssh", "run_script")
+		})
+
+		It("succeeds when journal is intact", func() {
+			Expect(journal.Verify()).To(Succeed())
+		})
+
+		It("returns error when record is edited", func() {
+			rewriteJournal(func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"outcome":"succeeded"`, `"outcome":"failed"`, 1)
+				return lines
+			})
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit record 2 does not match its hash"))
+		})
+
+		It("returns error when record is removed", func() {
+			rewriteJournal(func(lines []string) []string { return append(lines[:1], lines[2:]...) })
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit record 3 follows record 1"))
+		})
+
+		It("returns error when records are removed from end of journal", func() {
+			rewriteJournal(func(lines []string) []string { return lines[:2] })
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit journal ends with record 2 but its head is record 3"))
+		})
+
+		It("returns error when journal is replaced with other consistent chain", func() {
+			Expect(os.Remove(journalPath)).To(Succeed())
+
+			otherJournal := NewJournal(journalPath, filepath.Join(journalDir, "other.head"), fs, timeService)
+			for _, method := range []string{"apply", "ssh", "stop"} {
+				_, err := otherJournal.Append(Record{Method: method})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(otherJournal.Verify()).To(Succeed())
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit record 3 does not match hash of journal head"))
+		})
+
+		It("returns error when head is missing", func() {
+			Expect(os.Remove(headPath)).To(Succeed())
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit journal head is missing"))
+		})
+
+		It("returns error when edited record is rehashed", func() {
+			rewriteJournal(func(lines []string) []string {
+				var record Record
+				Expect(json.Unmarshal([]byte(lines[0]), &record)).To(Succeed())
+
+				record.Caller = "fake-other-caller"
+				record.Hash = ""
+
+				bytes, err := json.Marshal(record)
+				Expect(err).ToNot(HaveOccurred())
+
+				sum := sha256.Sum256(bytes)
+				record.Hash = hex.EncodeToString(sum[:])
+
+				bytes, err = json.Marshal(record)
+				Expect(err).ToNot(HaveOccurred())
+
+				lines[0] = string(bytes)
+				return lines
+			})
+
+			err := journal.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Audit record 2 does not match hash of previous record"))
+		})
+	})
+})
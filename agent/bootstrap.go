@@ -175,6 +175,10 @@ func (boot bootstrap) Run() (err error) {
 		}
 	}
 
+	if err = boot.platform.GetFirewallManager().SetupFirewall(settings.Env.Bosh.Firewall, v1Spec.FirewallRules()); err != nil {
+		return bosherr.WrapError(err, "Setting up firewall")
+	}
+
 	if err = boot.platform.SetupMonitUser(); err != nil {
 		return bosherr.WrapError(err, "Setting up monit user")
 	}
@@ -33,9 +33,12 @@ import (
 	boshcdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshudev "github.com/cloudfoundry/bosh-agent/platform/udevdevice"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -401,6 +404,29 @@ var _ = Describe("bootstrap", func() {
 			Expect("1.north-america.pool.ntp.org").To(Equal(platform.SetTimeWithNtpServersServers[1]))
 		})
 
+		It("sets up firewall with baseline rules from settings and job ports from spec", func() {
+			settingsService.Settings.Env.Bosh.Firewall = boshsettings.Firewall{
+				DefaultPolicy: "drop",
+				Rules:         []boshsettings.FirewallRule{{Ports: []int{22}}},
+			}
+			specService.Spec.JobSpec.JobTemplateSpecs[0].Ports = []applyspec.PortSpec{{Port: 8080}}
+
+			err := bootstrap()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platform.FirewallManager.SetupFirewallCalled).To(BeTrue())
+			Expect(platform.FirewallManager.SetupFirewallBaseline).To(Equal(settingsService.Settings.Env.Bosh.Firewall))
+			Expect(platform.FirewallManager.SetupFirewallJobRules).To(Equal([]boshsettings.FirewallRule{{Ports: []int{8080}}}))
+		})
+
+		It("returns error if setting up firewall fails", func() {
+			platform.FirewallManager.SetupFirewallErr = errors.New("fake-firewall-err")
+
+			err := bootstrap()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-firewall-err"))
+			Expect(platform.StartMonitStarted).To(BeFalse())
+		})
+
 		It("setups up monit user", func() {
 			err := bootstrap()
 			Expect(err).NotTo(HaveOccurred())
@@ -913,7 +939,7 @@ var _ = Describe("bootstrap", func() {
 
 			sigarCollector := boshsigar.NewSigarStatsCollector(&sigar.ConcreteSigar{})
 
-			vitalsService := boshvitals.NewService(sigarCollector, dirProvider)
+			vitalsService := boshvitals.NewService(sigarCollector, dirProvider, boshntp.NewDummyManager())
 
 			ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -927,7 +953,7 @@ var _ = Describe("bootstrap", func() {
 			kernelIPv6 := boshnet.NewKernelIPv6Impl(fs, runner, logger)
 			fs.WriteFileString("/etc/resolv.conf", "8.8.8.8 4.4.4.4")
 
-			ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, kernelIPv6, logger)
+			ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, &fakearp.FakeDuplicateAddressDetector{}, kernelIPv6, logger)
 			ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, 1, logger)
 
 			monitRetryable := boshplatform.NewMonitRetryable(runner)
@@ -953,6 +979,9 @@ var _ = Describe("bootstrap", func() {
 				diskManager,
 				ubuntuNetManager,
 				ubuntuCertManager,
+				boshfirewall.NewDummyManager(),
+				boshntp.NewDummyManager(),
+				boshnet.NewDummyAddressWatcher(),
 				monitRetryStrategy,
 				devicePathResolver,
 				state,
@@ -100,12 +100,12 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 	// Stdout/stderr are redirected to the files
 	_, _, exitStatus, runErr := f.cmdRunner.RunComplexCommand(cmd)
 
-	stdout, isStdoutTruncated, err := f.getTruncatedOutput(stdoutFile, f.truncateLength)
+	stdout, isStdoutTruncated, err := TruncatedOutput(stdoutFile, f.truncateLength)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Truncating stdout for task %s", taskName)
 	}
 
-	stderr, isStderrTruncated, err := f.getTruncatedOutput(stderrFile, f.truncateLength)
+	stderr, isStderrTruncated, err := TruncatedOutput(stderrFile, f.truncateLength)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Truncating stderr for task %s", taskName)
 	}
@@ -127,7 +127,9 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 	return result, nil
 }
 
-func (f FileLoggingCmdRunner) getTruncatedOutput(file boshsys.File, truncateLength int64) ([]byte, bool, error) {
+// TruncatedOutput returns at most last truncateLength bytes of output written to file,
+// cut at line break or rune boundary
+func TruncatedOutput(file boshsys.File, truncateLength int64) ([]byte, bool, error) {
 	isTruncated := false
 
 	stat, err := file.Stat()
@@ -152,17 +154,17 @@ func (f FileLoggingCmdRunner) getTruncatedOutput(file boshsys.File, truncateLeng
 	}
 
 	// Do not truncate more than 25% of the data
-	data = f.truncateUntilToken(data, truncateLength/int64(4))
+	data = truncateUntilToken(data, truncateLength/int64(4))
 
 	return data, isTruncated, nil
 }
 
-func (f FileLoggingCmdRunner) truncateUntilToken(data []byte, dataLossLimit int64) []byte {
+func truncateUntilToken(data []byte, dataLossLimit int64) []byte {
 	var i int64
 
 	// Cut off until first line break unless it cuts off more allowed data loss
 	if i = int64(bytes.IndexByte(data, '\n')); i >= 0 && i <= dataLossLimit {
-		data = f.dropCR(data[i+1:])
+		data = dropCR(data[i+1:])
 	} else {
 		// Make sure we don't break inside UTF encoded rune
 		for {
@@ -189,7 +191,7 @@ func (f FileLoggingCmdRunner) truncateUntilToken(data []byte, dataLossLimit int6
 	return data
 }
 
-func (f FileLoggingCmdRunner) dropCR(data []byte) []byte {
+func dropCR(data []byte) []byte {
 	if len(data) > 0 && data[0] == '\r' {
 		return data[1:]
 	}
@@ -31,8 +31,9 @@ type Heartbeat struct {
 //      "ephemeral": {"percent" => "5"},
 //      "persistent": {"percent" => "94"}
 //    },
-//  "ntp": {
-//      "offset": "-0.06423",
-//      "timestamp": "14 Oct 11:13:19"
+//    "ntp": {
+//      "offset": "-0.064230",
+//      "synchronized": true
+//    }
 //  }
 //}
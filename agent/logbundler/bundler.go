@@ -0,0 +1,337 @@
+package logbundler
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	bundlerLogTag = "logBundler"
+
+	bundlerReadBytes = 64 * 1024
+)
+
+// Options limit which parts of logs are bundled
+type Options struct {
+	// Since and Until select logs by their modification time. Selected
+	// logs are bundled whole (subject to other limits); lines are not
+	// filtered by their own timestamps since log formats differ.
+	Since *time.Time
+	Until *time.Time
+
+	// MaxBytes limits uncompressed size of bundled logs;
+	// newest logs are kept and older logs are truncated from their start
+	MaxBytes uint64
+
+	// TailLines limits number of last lines bundled from each log
+	TailLines int
+}
+
+// Entry describes how much of a log was bundled
+type Entry struct {
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	OriginalSize int64  `json:"original_size"`
+	Truncated    bool   `json:"truncated"`
+}
+
+type Bundler interface {
+	// Bundle streams logs in dir matching filters as gzipped tarball
+	// into writer. Returned entries list matched logs from newest to
+	// oldest; logs that did not fit into max size are listed with zero size.
+	Bundle(dir string, filters []string, options Options, writer io.Writer) ([]Entry, error)
+}
+
+type concreteBundler struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+}
+
+type bundledLog struct {
+	path  string
+	info  os.FileInfo
+	entry Entry
+	start int64
+}
+
+func NewBundler(fs boshsys.FileSystem, logger boshlog.Logger) Bundler {
+	return concreteBundler{fs: fs, logger: logger}
+}
+
+func (b concreteBundler) Bundle(dir string, filters []string, options Options, writer io.Writer) ([]Entry, error) {
+	logs, err := b.findLogs(dir, filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.limitLogs(logs, options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.writeTarball(logs, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, log := range logs {
+		entries = append(entries, log.entry)
+	}
+
+	return entries, nil
+}
+
+// findLogs finds logs matching filters within time window from newest to oldest
+func (b concreteBundler) findLogs(dir string, filters []string, options Options) ([]*bundledLog, error) {
+	root := filepath.Clean(dir)
+
+	var patterns []string
+
+	for _, filter := range filters {
+		info, err := b.fs.Stat(filepath.Join(root, filter))
+		if err == nil && info.IsDir() {
+			patterns = append(patterns, filepath.Join(filter, "**", "*"))
+		} else {
+			patterns = append(patterns, filepath.Clean(filter))
+		}
+	}
+
+	var logs []*bundledLog
+
+	err := boshlogtail.WalkLogsDir(b.fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			b.logger.Warn(bundlerLogTag, "Skipping %s: %s", path, err.Error())
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+
+		if !matchesAny(patterns, relPath) {
+			return nil
+		}
+
+		if options.Since != nil && info.ModTime().Before(*options.Since) {
+			return nil
+		}
+
+		if options.Until != nil && info.ModTime().After(*options.Until) {
+			return nil
+		}
+
+		logs = append(logs, &bundledLog{
+			path: path,
+			info: info,
+			entry: Entry{
+				Path:         filepath.ToSlash(relPath),
+				Size:         info.Size(),
+				OriginalSize: info.Size(),
+			},
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Finding logs in %s", root)
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].info.ModTime().Equal(logs[j].info.ModTime()) {
+			return logs[i].entry.Path < logs[j].entry.Path
+		}
+		return logs[i].info.ModTime().After(logs[j].info.ModTime())
+	})
+
+	return logs, nil
+}
+
+// limitLogs decides where bundling of each log starts so that
+// only last lines are bundled and newest logs fit into max size
+func (b concreteBundler) limitLogs(logs []*bundledLog, options Options) error {
+	remainingBytes := int64(options.MaxBytes)
+
+	for _, log := range logs {
+		if options.TailLines <= 0 && (options.MaxBytes == 0 || log.entry.Size <= remainingBytes) {
+			remainingBytes -= log.entry.Size
+			continue
+		}
+
+		file, err := b.fs.OpenFile(log.path, os.O_RDONLY, 0)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Opening %s", log.path)
+		}
+
+		size := log.info.Size()
+
+		if options.TailLines > 0 {
+			log.start, err = boshlogtail.LastLinesOffset(file, size, options.TailLines)
+		}
+
+		if err == nil && options.MaxBytes > 0 && size-log.start > remainingBytes {
+			log.start, err = lineStart(file, size-remainingBytes, size)
+		}
+
+		_ = file.Close()
+
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Reading %s", log.path)
+		}
+
+		log.entry.Size = size - log.start
+		log.entry.Truncated = log.start > 0
+		remainingBytes -= log.entry.Size
+	}
+
+	return nil
+}
+
+// writeTarball compresses bundled parts of logs directly into writer
+func (b concreteBundler) writeTarball(logs []*bundledLog, writer io.Writer) error {
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	var err error
+
+	for _, log := range logs {
+		if log.entry.Size == 0 && log.entry.Truncated {
+			continue
+		}
+
+		err = b.writeLog(tarWriter, log)
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = tarWriter.Close()
+	}
+
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Writing logs tarball")
+	}
+
+	return nil
+}
+
+func (b concreteBundler) writeLog(tarWriter *tar.Writer, log *bundledLog) error {
+	file, err := b.fs.OpenFile(log.path, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening %s", log.path)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:     log.entry.Path,
+		Mode:     int64(log.info.Mode().Perm()),
+		Size:     log.entry.Size,
+		ModTime:  log.info.ModTime(),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding %s", log.path)
+	}
+
+	// Lines appended while bundling are not included. Logs truncated in
+	// place meanwhile (e.g. by copytruncate rotation) are padded with zeros
+	// since size in tar header cannot be changed after it was written.
+	copied, err := io.Copy(tarWriter, io.NewSectionReader(file, log.start, log.entry.Size))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding %s", log.path)
+	}
+
+	if copied < log.entry.Size {
+		b.logger.Warn(bundlerLogTag, "Log %s was truncated while bundling, padding %d missing bytes", log.path, log.entry.Size-copied)
+
+		_, err = io.CopyN(tarWriter, zeroReader{}, log.entry.Size-copied)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Adding %s", log.path)
+		}
+	}
+
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
+
+func matchesAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		matched, err := doublestar.PathMatch(pattern, relPath)
+		if err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lineStart returns offset of first line that starts at or after offset
+func lineStart(file io.ReaderAt, offset, size int64) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, bundlerReadBytes)
+
+	// Byte before offset tells whether line starts at offset
+	for pos := offset - 1; pos < size; {
+		readBytes := int64(len(buf))
+		if size-pos < readBytes {
+			readBytes = size - pos
+		}
+
+		n, err := file.ReadAt(buf[:readBytes], pos)
+
+		for i := 0; i < n; i++ {
+			if buf[i] == '\n' {
+				return pos + int64(i) + 1, nil
+			}
+		}
+
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		if n == 0 || err == io.EOF {
+			break
+		}
+
+		pos += int64(n)
+	}
+
+	return size, nil
+}
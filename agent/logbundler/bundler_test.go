@@ -0,0 +1,240 @@
+package logbundler_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logbundler"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("Bundler", func() {
+	var (
+		logsDir string
+		tarball *bytes.Buffer
+		now     time.Time
+		bundler Bundler
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		logsDir, err = ioutil.TempDir("", "log-bundler")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		bundler = NewBundler(boshsys.NewOsFileSystem(logger), logger)
+
+		now = time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+		tarball = &bytes.Buffer{}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logsDir)).To(Succeed())
+	})
+
+	writeLog := func(name, contents string, age time.Duration) {
+		path := filepath.Join(logsDir, name)
+
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0640)).To(Succeed())
+		Expect(os.Chtimes(path, now.Add(-age), now.Add(-age))).To(Succeed())
+	}
+
+	tarballContents := func() map[string]string {
+		gzipReader, err := gzip.NewReader(bytes.NewReader(tarball.Bytes()))
+		Expect(err).ToNot(HaveOccurred())
+
+		tarReader := tar.NewReader(gzipReader)
+		contents := map[string]string{}
+
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+
+			bytes, err := ioutil.ReadAll(tarReader)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents[header.Name] = string(bytes)
+		}
+
+		return contents
+	}
+
+	bundle := func(filters []string, options Options) []Entry {
+		var (
+			entries []Entry
+			err     error
+		)
+
+		entries, err = bundler.Bundle(logsDir, filters, options, tarball)
+		Expect(err).ToNot(HaveOccurred())
+
+		return entries
+	}
+
+	It("bundles logs matching filters from newest to oldest", func() {
+		writeLog("fake-job/fake-job.log", "fake-line-1\n", 1*time.Minute)
+		writeLog("fake-job/fake-job.stderr.log", "fake-error\n", 2*time.Minute)
+		writeLog("fake-job/nested/fake-nested.log", "fake-nested\n", 3*time.Minute)
+		writeLog("other-job/other-job.log", "other-line\n", 0)
+
+		entries := bundle([]string{"fake-job/**/*.log", "other-job"}, Options{})
+
+		Expect(entries).To(Equal([]Entry{
+			{Path: "other-job/other-job.log", Size: 11, OriginalSize: 11},
+			{Path: "fake-job/fake-job.log", Size: 12, OriginalSize: 12},
+			{Path: "fake-job/fake-job.stderr.log", Size: 11, OriginalSize: 11},
+			{Path: "fake-job/nested/fake-nested.log", Size: 12, OriginalSize: 12},
+		}))
+
+		Expect(tarballContents()).To(Equal(map[string]string{
+			"other-job/other-job.log":         "other-line\n",
+			"fake-job/fake-job.log":           "fake-line-1\n",
+			"fake-job/fake-job.stderr.log":    "fake-error\n",
+			"fake-job/nested/fake-nested.log": "fake-nested\n",
+		}))
+	})
+
+	It("bundles empty tarball when no logs match", func() {
+		writeLog("fake-job/fake-job.log", "fake-line\n", 0)
+
+		entries := bundle([]string{"other-job/**/*"}, Options{})
+		Expect(entries).To(BeEmpty())
+		Expect(tarballContents()).To(BeEmpty())
+	})
+
+	It("bundles only logs modified within time window", func() {
+		writeLog("fake-job/fake-job.log", "fake-current\n", 0)
+		writeLog("fake-job/fake-job.log.20261018T110000Z", "fake-recent\n", 1*time.Hour)
+		writeLog("fake-job/fake-job.log.20261017T120000Z", "fake-old\n", 24*time.Hour)
+
+		since := now.Add(-2 * time.Hour)
+		until := now.Add(-30 * time.Minute)
+
+		entries := bundle([]string{"**/*"}, Options{Since: &since, Until: &until})
+		Expect(entries).To(Equal([]Entry{
+			{Path: "fake-job/fake-job.log.20261018T110000Z", Size: 12, OriginalSize: 12},
+		}))
+	})
+
+	It("bundles last lines of each log", func() {
+		writeLog("fake-job/fake-job.log", "fake-line-1\nfake-line-2\nfake-line-3\n", 0)
+		writeLog("fake-job/fake-partial.log", "fake-line-1\nfake-line-2\nfake-par", 0)
+		writeLog("fake-job/fake-short.log", "fake-line-1\n", 0)
+
+		entries := bundle([]string{"**/*"}, Options{TailLines: 2})
+
+		Expect(entries).To(ConsistOf(
+			Entry{Path: "fake-job/fake-job.log", Size: 24, OriginalSize: 36, Truncated: true},
+			Entry{Path: "fake-job/fake-partial.log", Size: 20, OriginalSize: 32, Truncated: true},
+			Entry{Path: "fake-job/fake-short.log", Size: 12, OriginalSize: 12},
+		))
+
+		Expect(tarballContents()).To(Equal(map[string]string{
+			"fake-job/fake-job.log":     "fake-line-2\nfake-line-3\n",
+			"fake-job/fake-partial.log": "fake-line-2\nfake-par",
+			"fake-job/fake-short.log":   "fake-line-1\n",
+		}))
+	})
+
+	It("bundles last lines of logs longer than read buffer", func() {
+		longLine := strings.Repeat("x", 100*1024) + "\n"
+		writeLog("fake-job/fake-job.log", longLine+longLine+"fake-last\n", 0)
+
+		bundle([]string{"**/*"}, Options{TailLines: 2})
+		Expect(tarballContents()["fake-job/fake-job.log"]).To(Equal(longLine + "fake-last\n"))
+	})
+
+	It("keeps newest logs and truncates older logs at line boundaries to fit max size", func() {
+		writeLog("fake-job/fake-job.log", "fake-line-5\n", 0)
+		writeLog("fake-job/fake-job.log.2", "fake-line-3\nfake-line-4\n", 1*time.Hour)
+		writeLog("fake-job/fake-job.log.1", "fake-line-1\nfake-line-2\n", 2*time.Hour)
+
+		entries := bundle([]string{"**/*"}, Options{MaxBytes: 30})
+
+		Expect(entries).To(Equal([]Entry{
+			{Path: "fake-job/fake-job.log", Size: 12, OriginalSize: 12},
+			{Path: "fake-job/fake-job.log.2", Size: 12, OriginalSize: 24, Truncated: true},
+			{Path: "fake-job/fake-job.log.1", Size: 0, OriginalSize: 24, Truncated: true},
+		}))
+
+		Expect(tarballContents()).To(Equal(map[string]string{
+			"fake-job/fake-job.log":   "fake-line-5\n",
+			"fake-job/fake-job.log.2": "fake-line-4\n",
+		}))
+	})
+
+	It("applies max size after taking last lines", func() {
+		writeLog("fake-job/fake-job.log", "fake-line-1\nfake-line-2\nfake-line-3\n", 0)
+
+		entries := bundle([]string{"**/*"}, Options{TailLines: 2, MaxBytes: 20})
+
+		Expect(entries).To(Equal([]Entry{
+			{Path: "fake-job/fake-job.log", Size: 12, OriginalSize: 36, Truncated: true},
+		}))
+
+		Expect(tarballContents()).To(Equal(map[string]string{
+			"fake-job/fake-job.log": "fake-line-3\n",
+		}))
+	})
+
+	It("pads logs that were truncated in place while being bundled", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		bundler = NewBundler(truncatingFs{FileSystem: boshsys.NewOsFileSystem(logger)}, logger)
+
+		writeLog("fake-job/fake-job.log", "fake-line-1\n", 0)
+		writeLog("fake-job/other.log", "fake-line-2\n", time.Minute)
+
+		bundle([]string{"**/*"}, Options{})
+
+		Expect(tarballContents()).To(Equal(map[string]string{
+			"fake-job/fake-job.log": strings.Repeat("\x00", 12),
+			"fake-job/other.log":    strings.Repeat("\x00", 12),
+		}))
+	})
+
+	It("returns error when tarball cannot be written", func() {
+		writeLog("fake-job/fake-job.log", "fake-line\n", 0)
+
+		_, err := bundler.Bundle(logsDir, []string{"**/*"}, Options{}, failingWriter{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Writing logs tarball"))
+	})
+})
+
+// truncatingFs truncates logs right before they are opened for bundling
+// like copytruncate rotation could
+type truncatingFs struct {
+	boshsys.FileSystem
+}
+
+func (fs truncatingFs) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	err := os.Truncate(path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.FileSystem.OpenFile(path, flag, perm)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("fake-write-err")
+}
@@ -0,0 +1,29 @@
+package fakes
+
+import (
+	"io"
+
+	boshlogbundler "github.com/cloudfoundry/bosh-agent/agent/logbundler"
+)
+
+type FakeBundler struct {
+	BundleDir     string
+	BundleFilters []string
+	BundleOptions boshlogbundler.Options
+	BundleContent string
+	BundleEntries []boshlogbundler.Entry
+	BundleErr     error
+}
+
+func (b *FakeBundler) Bundle(dir string, filters []string, options boshlogbundler.Options, writer io.Writer) ([]boshlogbundler.Entry, error) {
+	b.BundleDir = dir
+	b.BundleFilters = filters
+	b.BundleOptions = options
+
+	_, err := io.WriteString(writer, b.BundleContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.BundleEntries, b.BundleErr
+}
@@ -0,0 +1,13 @@
+package logbundler_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogbundler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Bundler Suite")
+}
@@ -0,0 +1,239 @@
+package logforwarder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	bufferLogTag = "logForwarderBuffer"
+
+	// Buffer is split into segments so that
+	// sent messages can be removed from disk
+	maxBufferSegmentSize = 1024 * 1024
+)
+
+// diskBuffer keeps messages in segment files until they are sent
+// so that messages survive outages of syslog server and agent restarts.
+// Oldest segments are dropped when buffer grows over its max size.
+type diskBuffer struct {
+	fs      boshsys.FileSystem
+	dir     string
+	maxSize uint64
+	logger  boshlog.Logger
+
+	lock     sync.Mutex
+	sealed   string
+	notifyCh chan struct{}
+}
+
+type bufferSegment struct {
+	path string
+	size uint64
+}
+
+func newDiskBuffer(fs boshsys.FileSystem, dir string, maxSize uint64, logger boshlog.Logger) *diskBuffer {
+	return &diskBuffer{
+		fs:       fs,
+		dir:      dir,
+		maxSize:  maxSize,
+		logger:   logger,
+		notifyCh: make(chan struct{}, 1),
+	}
+}
+
+// Append adds messages to newest segment and drops oldest
+// segments if buffer is over its max size
+func (b *diskBuffer) Append(messages []string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	err := b.fs.MkdirAll(b.dir, 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating log buffer directory")
+	}
+
+	segments, err := b.segments()
+	if err != nil {
+		return err
+	}
+
+	var segmentPath string
+
+	if len(segments) > 0 {
+		newest := segments[len(segments)-1]
+		if newest.path != b.sealed && newest.size < b.segmentSize() {
+			segmentPath = newest.path
+		}
+	}
+
+	if segmentPath == "" {
+		segmentPath = b.nextSegmentPath(segments)
+	}
+
+	file, err := b.fs.OpenFile(segmentPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening log buffer segment")
+	}
+
+	_, err = file.Write([]byte(strings.Join(messages, "\n") + "\n"))
+	closeErr := file.Close()
+
+	if err != nil {
+		return bosherr.WrapError(err, "Writing log buffer segment")
+	}
+
+	if closeErr != nil {
+		return bosherr.WrapError(closeErr, "Closing log buffer segment")
+	}
+
+	b.dropOverflow()
+
+	select {
+	case b.notifyCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Next returns messages of oldest segment; segment is
+// not appended to anymore until it is removed
+func (b *diskBuffer) Next() (string, []string, bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	segments, err := b.segments()
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	if len(segments) == 0 {
+		return "", nil, false, nil
+	}
+
+	oldest := segments[0]
+
+	contents, err := b.fs.ReadFileString(oldest.path)
+	if err != nil {
+		return "", nil, false, bosherr.WrapError(err, "Reading log buffer segment")
+	}
+
+	b.sealed = oldest.path
+
+	var messages []string
+
+	for _, message := range strings.Split(contents, "\n") {
+		if message != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return oldest.path, messages, true, nil
+}
+
+// Remove removes segment whose messages were sent
+func (b *diskBuffer) Remove(segmentPath string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.sealed == segmentPath {
+		b.sealed = ""
+	}
+
+	err := b.fs.RemoveAll(segmentPath)
+	if err != nil {
+		b.logger.Warn(bufferLogTag, "Failed to remove sent log buffer segment: %s", err.Error())
+	}
+}
+
+// Notify receives when messages are appended
+func (b *diskBuffer) Notify() <-chan struct{} {
+	return b.notifyCh
+}
+
+func (b *diskBuffer) dropOverflow() {
+	segments, err := b.segments()
+	if err != nil {
+		b.logger.Warn(bufferLogTag, "Failed to find log buffer segments: %s", err.Error())
+		return
+	}
+
+	var totalSize uint64
+	for _, segment := range segments {
+		totalSize += segment.size
+	}
+
+	var droppedSize uint64
+
+	for i := 0; i < len(segments)-1 && totalSize > b.maxSize; i++ {
+		err = b.fs.RemoveAll(segments[i].path)
+		if err != nil {
+			b.logger.Warn(bufferLogTag, "Failed to remove log buffer segment: %s", err.Error())
+			continue
+		}
+
+		totalSize -= segments[i].size
+		droppedSize += segments[i].size
+	}
+
+	if droppedSize > 0 {
+		b.logger.Warn(bufferLogTag, "Dropped %d bytes of logs that could not be forwarded since buffer is full", droppedSize)
+	}
+}
+
+func (b *diskBuffer) segmentSize() uint64 {
+	// Small buffers still drop logs gradually
+	if size := b.maxSize / 10; size < maxBufferSegmentSize {
+		return size
+	}
+	return maxBufferSegmentSize
+}
+
+// segments lists segments from oldest to newest
+func (b *diskBuffer) segments() ([]bufferSegment, error) {
+	paths, err := b.fs.Glob(filepath.Join(b.dir, "*.segment"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Globbing log buffer segments")
+	}
+
+	sort.Strings(paths)
+
+	var segments []bufferSegment
+
+	for _, path := range paths {
+		info, err := b.fs.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, bufferSegment{path: path, size: uint64(info.Size())})
+	}
+
+	return segments, nil
+}
+
+func (b *diskBuffer) nextSegmentPath(segments []bufferSegment) string {
+	var next uint64
+
+	if len(segments) > 0 {
+		newest := strings.TrimSuffix(filepath.Base(segments[len(segments)-1].path), ".segment")
+		last, _ := strconv.ParseUint(newest, 10, 64)
+		next = last + 1
+	}
+
+	return filepath.Join(b.dir, fmt.Sprintf("%020d.segment", next))
+}
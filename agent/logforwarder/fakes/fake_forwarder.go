@@ -0,0 +1,28 @@
+package fakes
+
+import (
+	"sync"
+)
+
+type FakeForwarder struct {
+	RunErr error
+
+	runCallCount int
+	lock         sync.Mutex
+}
+
+func (f *FakeForwarder) Run(_ <-chan struct{}) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.runCallCount++
+
+	return f.RunErr
+}
+
+func (f *FakeForwarder) RunCallCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.runCallCount
+}
@@ -0,0 +1,277 @@
+package logforwarder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	forwarderLogTag = "logForwarder"
+
+	// Written logs are read at most this often; all logs
+	// are read this often when they cannot be watched
+	forwarderReadInterval = 1 * time.Second
+
+	// Sending is retried this often while syslog server cannot be reached
+	forwarderRetryInterval = 5 * time.Second
+)
+
+var ErrForwardingNotConfigured = errors.New("Log forwarding is not configured")
+
+type Forwarder interface {
+	// Run forwards lines written to logs in logs directory to syslog
+	// server configured in env settings until stopCh is closed.
+	// Returns ErrForwardingNotConfigured when there is no server and
+	// ErrFileWatchingNotSupported on platforms where logs cannot be watched.
+	Run(stopCh <-chan struct{}) error
+}
+
+type concreteForwarder struct {
+	fs              boshsys.FileSystem
+	dirProvider     boshdirs.Provider
+	settingsService boshsettings.Service
+	specService     boshas.V1Service
+	watcher         boshlogrotator.FileWatcher
+	timeService     clock.Clock
+	logger          boshlog.Logger
+}
+
+func NewForwarder(
+	fs boshsys.FileSystem,
+	dirProvider boshdirs.Provider,
+	settingsService boshsettings.Service,
+	specService boshas.V1Service,
+	watcher boshlogrotator.FileWatcher,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Forwarder {
+	return concreteForwarder{
+		fs:              fs,
+		dirProvider:     dirProvider,
+		settingsService: settingsService,
+		specService:     specService,
+		watcher:         watcher,
+		timeService:     timeService,
+		logger:          logger,
+	}
+}
+
+func (f concreteForwarder) Run(stopCh <-chan struct{}) error {
+	config := f.settingsService.GetSettings().Env.Bosh.LogForwarding
+	if config.IsEmpty() {
+		return ErrForwardingNotConfigured
+	}
+
+	switch config.TransportOrDefault() {
+	case boshsettings.LogForwardingTransportTCP, boshsettings.LogForwardingTransportTLS, boshsettings.LogForwardingTransportUDP:
+	default:
+		return bosherr.Errorf("Log forwarding transport '%s' must be tcp, tls or udp", config.Transport)
+	}
+
+	changeCh, err := f.watcher.Watch(f.dirProvider.LogsDir(), stopCh)
+	if err == boshlogrotator.ErrFileWatchingNotSupported {
+		return err
+	}
+
+	if err != nil {
+		f.logger.Warn(forwarderLogTag, "Failed to watch logs; reading all logs every %s: %s", forwarderReadInterval, err.Error())
+		changeCh = nil
+	}
+
+	tailer := boshlogtail.NewTailer(f.fs, f.dirProvider.LogsDir())
+	defer tailer.Close()
+
+	err = tailer.SkipExisting()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding logs")
+	}
+
+	buffer := newDiskBuffer(f.fs, filepath.Join(f.dirProvider.DataDir(), ".bosh", "log_forwarder"), config.BufferSizeInBytes(), f.logger)
+
+	sendDoneCh := make(chan struct{})
+	defer func() { <-sendDoneCh }()
+
+	go f.send(buffer, newSyslogSender(config), stopCh, sendDoneCh)
+
+	readTicker := f.timeService.NewTicker(forwarderReadInterval)
+	defer readTicker.Stop()
+
+	hostname, _ := os.Hostname()
+	writtenPaths := map[string]bool{}
+
+	for {
+		select {
+		case <-stopCh:
+			return nil
+
+		case path, ok := <-changeCh:
+			if !ok {
+				select {
+				case <-stopCh:
+					return nil
+				default:
+				}
+
+				f.logger.Warn(forwarderLogTag, "Stopped watching logs; reading all logs every %s", forwarderReadInterval)
+				changeCh = nil
+				continue
+			}
+
+			if boshlogtail.IsLog(path) {
+				writtenPaths[path] = true
+			}
+
+		case <-readTicker.C():
+			if changeCh == nil {
+				writtenPaths = f.logPaths()
+			}
+
+			if len(writtenPaths) == 0 {
+				continue
+			}
+
+			f.read(tailer, buffer, writtenPaths, hostname)
+			writtenPaths = map[string]bool{}
+		}
+	}
+}
+
+// logPaths finds all logs in logs directory
+func (f concreteForwarder) logPaths() map[string]bool {
+	paths := map[string]bool{}
+
+	err := boshlogtail.WalkLogsDir(f.fs, f.dirProvider.LogsDir(), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && boshlogtail.IsLog(path) {
+			paths[path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		f.logger.Warn(forwarderLogTag, "Failed to find logs: %s", err.Error())
+	}
+
+	return paths
+}
+
+// read buffers lines written to logs as messages
+func (f concreteForwarder) read(tailer *boshlogtail.Tailer, buffer *diskBuffer, paths map[string]bool, hostname string) {
+	spec, err := f.specService.Get()
+	if err != nil {
+		f.logger.Warn(forwarderLogTag, "Forwarding logs without instance metadata: %s", err.Error())
+	}
+
+	now := f.timeService.Now()
+
+	var messages []string
+
+	for path := range paths {
+		lines, err := tailer.Read(path)
+		if err != nil {
+			f.logger.Warn(forwarderLogTag, "Failed to read %s: %s", path, err.Error())
+		}
+
+		jobName := f.jobOf(path)
+
+		severity := SeverityInfo
+		if strings.Contains(filepath.Base(path), "stderr") {
+			severity = SeverityError
+		}
+
+		for _, line := range lines {
+			message := Message{
+				Severity:  severity,
+				Timestamp: now,
+				Hostname:  hostname,
+				AppName:   jobName,
+				Metadata: Metadata{
+					Deployment:    spec.Deployment,
+					InstanceGroup: spec.Name,
+					Index:         spec.Index,
+					AZ:            spec.AvailabilityZone,
+					ID:            spec.NodeID,
+					Job:           jobName,
+				},
+				Text: line,
+			}
+
+			messages = append(messages, message.String())
+		}
+	}
+
+	err = buffer.Append(messages)
+	if err != nil {
+		f.logger.Error(forwarderLogTag, "Failed to buffer %d log lines: %s", len(messages), err.Error())
+	}
+}
+
+// send sends buffered messages until stopCh is closed; messages
+// are removed from buffer only after they were sent
+func (f concreteForwarder) send(buffer *diskBuffer, sender *syslogSender, stopCh <-chan struct{}, doneCh chan<- struct{}) {
+	defer close(doneCh)
+	defer sender.Close()
+
+	for {
+		segmentPath, messages, found, err := buffer.Next()
+		if err != nil {
+			f.logger.Error(forwarderLogTag, "Failed to read buffered logs: %s", err.Error())
+			found = false
+		}
+
+		if !found {
+			select {
+			case <-stopCh:
+				return
+			case <-buffer.Notify():
+				continue
+			}
+		}
+
+		for len(messages) > 0 {
+			err = sender.Send(messages[0])
+			if err != nil {
+				f.logger.Warn(forwarderLogTag, "Failed to forward logs, retrying in %s: %s", forwarderRetryInterval, err.Error())
+
+				select {
+				case <-stopCh:
+					return
+				case <-f.timeService.After(forwarderRetryInterval):
+					continue
+				}
+			}
+
+			messages = messages[1:]
+		}
+
+		buffer.Remove(segmentPath)
+	}
+}
+
+// jobOf returns name of job that owns log at path;
+// logs directly in logs directory are named after file
+func (f concreteForwarder) jobOf(path string) string {
+	relPath, err := filepath.Rel(f.dirProvider.LogsDir(), path)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(relPath, string(filepath.Separator))
+	if len(parts) == 1 {
+		return strings.TrimSuffix(parts[0], ".log")
+	}
+
+	return parts[0]
+}
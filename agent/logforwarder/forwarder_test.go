@@ -0,0 +1,455 @@
+package logforwarder_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	fakelogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type fakeSyslogServer struct {
+	listener   net.Listener
+	packetConn net.PacketConn
+
+	lock     sync.Mutex
+	messages []string
+}
+
+func newFakeSyslogServer(transport, address string, tlsConfig *tls.Config) *fakeSyslogServer {
+	server := &fakeSyslogServer{}
+
+	var err error
+
+	switch transport {
+	case "udp":
+		server.packetConn, err = net.ListenPacket("udp", address)
+		Expect(err).ToNot(HaveOccurred())
+		go server.receive()
+
+	case "tls":
+		server.listener, err = tls.Listen("tcp", address, tlsConfig)
+		Expect(err).ToNot(HaveOccurred())
+		go server.accept()
+
+	default:
+		server.listener, err = net.Listen("tcp", address)
+		Expect(err).ToNot(HaveOccurred())
+		go server.accept()
+	}
+
+	return server
+}
+
+func (s *fakeSyslogServer) Port() int {
+	if s.packetConn != nil {
+		return s.packetConn.LocalAddr().(*net.UDPAddr).Port
+	}
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSyslogServer) Close() {
+	if s.packetConn != nil {
+		s.packetConn.Close()
+	} else {
+		s.listener.Close()
+	}
+}
+
+func (s *fakeSyslogServer) Messages() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.messages...)
+}
+
+func (s *fakeSyslogServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.read(conn)
+	}
+}
+
+// read parses messages framed with octet counting
+func (s *fakeSyslogServer) read(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return
+		}
+
+		message := make([]byte, n)
+
+		_, err = io.ReadFull(reader, message)
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		s.messages = append(s.messages, string(message))
+		s.lock.Unlock()
+	}
+}
+
+func (s *fakeSyslogServer) receive() {
+	buf := make([]byte, 65536)
+
+	for {
+		n, _, err := s.packetConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		s.messages = append(s.messages, string(buf[:n]))
+		s.lock.Unlock()
+	}
+}
+
+var _ = Describe("Forwarder", func() {
+	var (
+		baseDir         string
+		fs              boshsys.FileSystem
+		dirProvider     boshdirs.Provider
+		settingsService *fakesettings.FakeSettingsService
+		specService     *fakeas.FakeV1Service
+		watcher         *fakelogrotator.FakeFileWatcher
+		timeService     *fakeclock.FakeClock
+		logger          boshlog.Logger
+		server          *fakeSyslogServer
+		forwarder       Forwarder
+
+		stopCh chan struct{}
+		doneCh chan struct{}
+		runErr error
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		baseDir, err = ioutil.TempDir("", "log-forwarder")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		dirProvider = boshdirs.NewProvider(baseDir)
+		Expect(fs.MkdirAll(dirProvider.LogsDir(), 0755)).To(Succeed())
+
+		index := 0
+
+		settingsService = &fakesettings.FakeSettingsService{}
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec = boshas.V1ApplySpec{
+			Deployment:       "fake-deployment",
+			Name:             "fake-group",
+			Index:            &index,
+			NodeID:           "fake-id",
+			AvailabilityZone: "z1",
+		}
+
+		watcher = fakelogrotator.NewFakeFileWatcher()
+		timeService = fakeclock.NewFakeClock(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+
+		server = nil
+		stopCh = nil
+	})
+
+	configure := func(config boshsettings.LogForwarding) {
+		settingsService.Settings.Env.Bosh.LogForwarding = config
+		forwarder = NewForwarder(fs, dirProvider, settingsService, specService, watcher, timeService, logger)
+	}
+
+	listen := func(transport string) {
+		server = newFakeSyslogServer(transport, "127.0.0.1:0", nil)
+		configure(boshsettings.LogForwarding{Host: "127.0.0.1", Port: server.Port(), Transport: transport})
+	}
+
+	start := func() {
+		watcherCount := timeService.WatcherCount()
+
+		stopCh = make(chan struct{})
+		doneCh = make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			runErr = forwarder.Run(stopCh)
+			close(doneCh)
+		}()
+
+		// Reading starts once read ticker is created
+		Eventually(timeService.WatcherCount).Should(Equal(watcherCount + 1))
+	}
+
+	stop := func() {
+		if stopCh != nil {
+			close(stopCh)
+			Eventually(doneCh).Should(BeClosed())
+			stopCh = nil
+		}
+	}
+
+	AfterEach(func() {
+		stop()
+
+		if server != nil {
+			server.Close()
+		}
+
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	logPath := func(name string) string {
+		return filepath.Join(dirProvider.LogsDir(), name)
+	}
+
+	appendLog := func(name, contents string) {
+		Expect(fs.MkdirAll(filepath.Dir(logPath(name)), 0755)).To(Succeed())
+
+		file, err := os.OpenFile(logPath(name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	written := func(names ...string) {
+		for _, name := range names {
+			watcher.ChangeCh <- logPath(name)
+		}
+		timeService.Increment(1 * time.Second)
+	}
+
+	messageTexts := func() []string {
+		var texts []string
+
+		for _, message := range server.Messages() {
+			texts = append(texts, message[strings.LastIndex(message, "] ")+2:])
+		}
+
+		return texts
+	}
+
+	It("returns error when forwarding is not configured", func() {
+		configure(boshsettings.LogForwarding{})
+
+		err := forwarder.Run(make(chan struct{}))
+		Expect(err).To(Equal(ErrForwardingNotConfigured))
+	})
+
+	It("returns error when transport is unknown", func() {
+		configure(boshsettings.LogForwarding{Host: "127.0.0.1", Port: 514, Transport: "fake-transport"})
+
+		err := forwarder.Run(make(chan struct{}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Log forwarding transport 'fake-transport' must be tcp, tls or udp"))
+	})
+
+	It("returns error when watching is not supported", func() {
+		listen("tcp")
+		watcher.WatchErr = boshlogrotator.ErrFileWatchingNotSupported
+
+		err := forwarder.Run(make(chan struct{}))
+		Expect(err).To(Equal(boshlogrotator.ErrFileWatchingNotSupported))
+	})
+
+	It("reads all logs every second when logs cannot be watched", func() {
+		listen("tcp")
+		watcher.WatchErr = errors.New("fake-watch-err")
+		appendLog("fake-job/fake-job.log", "fake-old-line\n")
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line\n")
+		appendLog("other-job/other-job.log", "fake-new-log-line\n")
+		timeService.Increment(1 * time.Second)
+
+		Eventually(messageTexts).Should(ConsistOf("fake-line", "fake-new-log-line"))
+	})
+
+	It("keeps forwarding lines by reading all logs every second once watching logs stops", func() {
+		listen("tcp")
+
+		start()
+
+		close(watcher.ChangeCh)
+
+		appendLog("fake-job/fake-job.log", "fake-line\n")
+
+		Eventually(func() []string {
+			timeService.Increment(1 * time.Second)
+			return messageTexts()
+		}).Should(Equal([]string{"fake-line"}))
+
+		stop()
+		Expect(runErr).ToNot(HaveOccurred())
+	})
+
+	It("forwards lines appended to logs over tcp with instance metadata", func() {
+		listen("tcp")
+		appendLog("fake-job/fake-job.log", "fake-old-line\n")
+
+		start()
+
+		Expect(watcher.WatchedDir()).To(Equal(dirProvider.LogsDir()))
+
+		appendLog("fake-job/fake-job.log", "fake-line-1\nfake-line-2\nfake-par")
+		appendLog("fake-job/fake-job.stderr.log", "fake-error\n")
+		written("fake-job/fake-job.log", "fake-job/fake-job.stderr.log")
+
+		Eventually(server.Messages).Should(HaveLen(3))
+		Expect(messageTexts()).To(ConsistOf("fake-line-1", "fake-line-2", "fake-error"))
+
+		hostname, _ := os.Hostname()
+
+		Expect(server.Messages()).To(ContainElement(`<14>1 2026-10-18T12:00:01.000000Z ` + hostname + ` fake-job - - ` +
+			`[instance@47450 deployment="fake-deployment" group="fake-group" index="0" az="z1" id="fake-id" job="fake-job"] fake-line-1`))
+		Expect(server.Messages()).To(ContainElement(HavePrefix("<11>1 ")))
+
+		appendLog("fake-job/fake-job.log", "tial\n")
+		written("fake-job/fake-job.log")
+
+		Eventually(messageTexts).Should(ContainElement("fake-partial"))
+	})
+
+	It("forwards lines over udp", func() {
+		listen("udp")
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line\n")
+		written("fake-job/fake-job.log")
+
+		Eventually(messageTexts).Should(Equal([]string{"fake-line"}))
+	})
+
+	It("forwards lines over tls verifying server with configured CA", func() {
+		tlsServer := httptest.NewTLSServer(nil)
+		tlsServer.Close()
+
+		server = newFakeSyslogServer("tls", "127.0.0.1:0", tlsServer.TLS)
+		configure(boshsettings.LogForwarding{
+			Host:      "127.0.0.1",
+			Port:      server.Port(),
+			Transport: "tls",
+			CA:        string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})),
+		})
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line\n")
+		written("fake-job/fake-job.log")
+
+		Eventually(messageTexts).Should(Equal([]string{"fake-line"}))
+	})
+
+	It("keeps forwarding lines of logs after they are rotated", func() {
+		listen("tcp")
+		appendLog("fake-job/fake-job.log", "")
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line-1\n")
+		written("fake-job/fake-job.log")
+		Eventually(messageTexts).Should(Equal([]string{"fake-line-1"}))
+
+		// Renamed by signal method; process writes to renamed log until it reopens it
+		Expect(os.Rename(logPath("fake-job/fake-job.log"), logPath("fake-job/fake-job.log.20261018T120000Z"))).To(Succeed())
+		appendLog("fake-job/fake-job.log.20261018T120000Z", "fake-line-2\n")
+		appendLog("fake-job/fake-job.log", "fake-line-3\n")
+		written("fake-job/fake-job.log")
+		Eventually(messageTexts).Should(Equal([]string{"fake-line-1", "fake-line-2", "fake-line-3"}))
+
+		// Truncated by copytruncate method
+		Expect(os.Truncate(logPath("fake-job/fake-job.log"), 0)).To(Succeed())
+		appendLog("fake-job/fake-job.log", "fake-4\n")
+		written("fake-job/fake-job.log")
+		Eventually(messageTexts).Should(Equal([]string{"fake-line-1", "fake-line-2", "fake-line-3", "fake-4"}))
+	})
+
+	It("buffers lines while server cannot be reached and sends them once it can", func() {
+		listen("tcp")
+		port := server.Port()
+		server.Close()
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line-1\nfake-line-2\n")
+		written("fake-job/fake-job.log")
+
+		// Waiting to retry
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+
+		server = newFakeSyslogServer("tcp", "127.0.0.1:"+strconv.Itoa(port), nil)
+		timeService.Increment(5 * time.Second)
+
+		Eventually(messageTexts).Should(Equal([]string{"fake-line-1", "fake-line-2"}))
+	})
+
+	It("sends lines buffered before agent restarted", func() {
+		listen("tcp")
+		port := server.Port()
+		server.Close()
+
+		start()
+
+		appendLog("fake-job/fake-job.log", "fake-line\n")
+		written("fake-job/fake-job.log")
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+
+		stop()
+
+		server = newFakeSyslogServer("tcp", "127.0.0.1:"+strconv.Itoa(port), nil)
+
+		start()
+
+		Eventually(messageTexts).Should(Equal([]string{"fake-line"}))
+	})
+
+	It("stops when stop channel is closed", func() {
+		listen("tcp")
+
+		start()
+		stop()
+
+		Expect(runErr).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,13 @@
+package logforwarder_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogforwarder(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Forwarder Suite")
+}
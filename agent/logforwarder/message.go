@@ -0,0 +1,91 @@
+package logforwarder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Facility user-level messages from RFC 5424
+	facilityUser = 1
+
+	SeverityError = 3
+	SeverityInfo  = 6
+
+	// Private enterprise number used by BOSH for instance metadata
+	instanceSDID = "instance@47450"
+
+	messageTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var sdParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// Metadata identifies instance that logs come from
+type Metadata struct {
+	Deployment    string
+	InstanceGroup string
+	Index         *int
+	AZ            string
+	ID            string
+	Job           string
+}
+
+// Message is a log line of a job
+type Message struct {
+	Severity  int
+	Timestamp time.Time
+	Hostname  string
+	AppName   string
+	Metadata  Metadata
+	Text      string
+}
+
+// String formats message according to RFC 5424 with metadata
+// as structured data, e.g. <14>1 2006-01-02T15:04:05.000000Z host app - - [instance@47450 ...] text
+func (m Message) String() string {
+	return fmt.Sprintf(
+		"<%d>1 %s %s %s - - %s %s",
+		facilityUser*8+m.Severity,
+		m.Timestamp.Format(messageTimeFormat),
+		nilValue(m.Hostname),
+		nilValue(m.AppName),
+		m.Metadata.structuredData(),
+		m.Text,
+	)
+}
+
+func (m Metadata) structuredData() string {
+	index := ""
+	if m.Index != nil {
+		index = strconv.Itoa(*m.Index)
+	}
+
+	params := [][2]string{
+		{"deployment", m.Deployment},
+		{"group", m.InstanceGroup},
+		{"index", index},
+		{"az", m.AZ},
+		{"id", m.ID},
+		{"job", m.Job},
+	}
+
+	sd := "[" + instanceSDID
+
+	for _, param := range params {
+		if param[1] != "" {
+			sd += fmt.Sprintf(` %s="%s"`, param[0], sdParamEscaper.Replace(param[1]))
+		}
+	}
+
+	return sd + "]"
+}
+
+// nilValue replaces empty header fields with "-" as required by RFC 5424
+func nilValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.Replace(value, " ", "_", -1)
+}
@@ -0,0 +1,57 @@
+package logforwarder_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+)
+
+var _ = Describe("Message", func() {
+	timestamp := time.Date(2026, time.October, 18, 12, 0, 0, 123456000, time.UTC)
+
+	It("formats message according to RFC 5424 with instance metadata as structured data", func() {
+		index := 2
+
+		message := Message{
+			Severity:  SeverityInfo,
+			Timestamp: timestamp,
+			Hostname:  "fake-host",
+			AppName:   "fake-job",
+			Metadata: Metadata{
+				Deployment:    "fake-deployment",
+				InstanceGroup: "fake-group",
+				Index:         &index,
+				AZ:            "z1",
+				ID:            "fake-id",
+				Job:           "fake-job",
+			},
+			Text: "fake line",
+		}
+
+		Expect(message.String()).To(Equal(`<14>1 2026-10-18T12:00:00.123456Z fake-host fake-job - - ` +
+			`[instance@47450 deployment="fake-deployment" group="fake-group" index="2" az="z1" id="fake-id" job="fake-job"] fake line`))
+	})
+
+	It("uses error severity and nil values for missing fields", func() {
+		message := Message{
+			Severity:  SeverityError,
+			Timestamp: timestamp,
+			Text:      "fake line",
+		}
+
+		Expect(message.String()).To(Equal(`<11>1 2026-10-18T12:00:00.123456Z - - - - [instance@47450] fake line`))
+	})
+
+	It("escapes structured data values", func() {
+		message := Message{
+			Severity:  SeverityInfo,
+			Timestamp: timestamp,
+			Metadata:  Metadata{Deployment: `fake "deployment" [\]`},
+		}
+
+		Expect(message.String()).To(ContainSubstring(`[instance@47450 deployment="fake \"deployment\" [\\\]"]`))
+	})
+})
@@ -0,0 +1,100 @@
+package logforwarder
+
+import (
+	"crypto/tls"
+	"net"
+	"strconv"
+	"time"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	senderDialTimeout  = 10 * time.Second
+	senderWriteTimeout = 10 * time.Second
+)
+
+// syslogSender sends messages to syslog server reconnecting after failures.
+// Messages sent over TCP and TLS are framed with octet counting (RFC 6587).
+type syslogSender struct {
+	config boshsettings.LogForwarding
+	conn   net.Conn
+}
+
+func newSyslogSender(config boshsettings.LogForwarding) *syslogSender {
+	return &syslogSender{config: config}
+}
+
+func (s *syslogSender) Send(message string) error {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+	}
+
+	framed := message
+	if s.config.TransportOrDefault() != boshsettings.LogForwardingTransportUDP {
+		framed = strconv.Itoa(len(message)) + " " + message
+	}
+
+	err := s.conn.SetWriteDeadline(time.Now().Add(senderWriteTimeout))
+	if err == nil {
+		_, err = s.conn.Write([]byte(framed))
+	}
+
+	if err != nil {
+		s.Close()
+		return bosherr.WrapError(err, "Writing to syslog server")
+	}
+
+	return nil
+}
+
+func (s *syslogSender) Close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *syslogSender) dial() (net.Conn, error) {
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: senderDialTimeout}
+
+	switch s.config.TransportOrDefault() {
+	case boshsettings.LogForwardingTransportTCP, boshsettings.LogForwardingTransportUDP:
+		conn, err := dialer.Dial(s.config.TransportOrDefault(), address)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Connecting to syslog server %s", address)
+		}
+
+		return conn, nil
+
+	case boshsettings.LogForwardingTransportTLS:
+		tlsConfig := &tls.Config{ServerName: s.config.Host}
+
+		if s.config.CA != "" {
+			certPool, err := crypto.CertPoolFromPEM([]byte(s.config.CA))
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Parsing syslog server CA")
+			}
+
+			tlsConfig.RootCAs = certPool
+		}
+
+		conn, err := tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Connecting to syslog server %s over TLS", address)
+		}
+
+		return conn, nil
+
+	default:
+		return nil, bosherr.Errorf("Unknown log forwarding transport '%s'", s.config.Transport)
+	}
+}
@@ -0,0 +1,176 @@
+package logrotator
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	MethodCopyTruncate = "copytruncate"
+	MethodSignal       = "signal"
+)
+
+// FileName is location of log rotation config relative to job directory
+var FileName = path.Join("config", "logrotate.yml")
+
+var (
+	sizeRegexp = regexp.MustCompile(`^([0-9]+)([KMG]?)$`)
+
+	// Signals that processes commonly reopen their logs on
+	signals = map[string]bool{"HUP": true, "USR1": true, "USR2": true}
+)
+
+// Config describes how logs of a job are rotated; jobs without
+// a config are rotated by size using copytruncate
+type Config struct {
+	// MaxSize is a number of bytes with optional K, M or G suffix;
+	// logs are rotated once they grow larger than that.
+	// Defaults to max log file size of the deployment.
+	MaxSize string `yaml:"max_size"`
+
+	// MaxAge is a duration (e.g. 168h) after which rotated logs are removed
+	MaxAge string `yaml:"max_age"`
+
+	// MaxTotalSize limits size of all logs of the job together;
+	// oldest rotated logs are removed to stay under the limit
+	MaxTotalSize string `yaml:"max_total_size"`
+
+	// Keep is a number of rotated logs kept for each log file
+	Keep *int `yaml:"keep"`
+
+	Compress *bool `yaml:"compress"`
+
+	// Method is either copytruncate or signal. With signal logs are
+	// renamed and process from PidFile is sent Signal to reopen them.
+	Method  string `yaml:"method"`
+	Signal  string `yaml:"signal"`
+	PidFile string `yaml:"pid_file"`
+}
+
+// ParseFile reads and validates log rotation config
+func ParseFile(fs boshsys.FileSystem, filePath string) (Config, error) {
+	var config Config
+
+	contents, err := fs.ReadFile(filePath)
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Reading log rotation config %s", filePath)
+	}
+
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Parsing log rotation config %s", filePath)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Validating log rotation config %s", filePath)
+	}
+
+	return config, nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if _, err := ParseSize(c.MaxSize); err != nil {
+		errs = append(errs, bosherr.WrapError(err, "Max size"))
+	}
+
+	if _, err := ParseSize(c.MaxTotalSize); err != nil {
+		errs = append(errs, bosherr.WrapError(err, "Max total size"))
+	}
+
+	if c.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(c.MaxAge); err != nil || maxAge <= 0 {
+			errs = append(errs, bosherr.Errorf("Max age '%s' must be a positive duration", c.MaxAge))
+		}
+	}
+
+	if c.Keep != nil && *c.Keep < 0 {
+		errs = append(errs, bosherr.Error("Keep must not be negative"))
+	}
+
+	switch c.MethodOrDefault() {
+	case MethodCopyTruncate:
+	case MethodSignal:
+		if !path.IsAbs(c.PidFile) {
+			errs = append(errs, bosherr.Errorf("Pid file '%s' must be an absolute path", c.PidFile))
+		}
+
+		if !signals[c.SignalOrDefault()] {
+			errs = append(errs, bosherr.Errorf("Signal '%s' must be HUP, USR1 or USR2", c.Signal))
+		}
+	default:
+		errs = append(errs, bosherr.Errorf("Method '%s' must be copytruncate or signal", c.Method))
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+// MaxAgeDuration returns 0 when rotated logs are kept regardless of age
+func (c Config) MaxAgeDuration() time.Duration {
+	maxAge, _ := time.ParseDuration(c.MaxAge)
+	return maxAge
+}
+
+func (c Config) KeepOrDefault() int {
+	if c.Keep == nil {
+		return 7
+	}
+	return *c.Keep
+}
+
+func (c Config) CompressOrDefault() bool {
+	if c.Compress == nil {
+		return true
+	}
+	return *c.Compress
+}
+
+func (c Config) MethodOrDefault() string {
+	if c.Method == "" {
+		return MethodCopyTruncate
+	}
+	return c.Method
+}
+
+func (c Config) SignalOrDefault() string {
+	if c.Signal == "" {
+		return "HUP"
+	}
+	return strings.ToUpper(c.Signal)
+}
+
+// ParseSize converts a number of bytes with optional K, M or G suffix
+// (as used by logrotate) into bytes; empty value results in 0
+func ParseSize(size string) (uint64, error) {
+	if size == "" {
+		return 0, nil
+	}
+
+	matches := sizeRegexp.FindStringSubmatch(strings.ToUpper(size))
+	if matches == nil {
+		return 0, bosherr.Errorf("Size '%s' must be a number of bytes with optional K, M or G suffix", size)
+	}
+
+	value, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing size '%s'", size)
+	}
+
+	multipliers := map[string]uint64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+
+	return value * multipliers[matches[2]], nil
+}
@@ -0,0 +1,116 @@
+package logrotator_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Config", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	Describe("ParseFile", func() {
+		It("parses log rotation config", func() {
+			fs.WriteFileString("/logrotate.yml", `
+max_size: 10M
+max_age: 168h
+max_total_size: 1G
+keep: 3
+compress: false
+method: signal
+signal: usr1
+pid_file: /var/vcap/sys/run/fake-job/fake-job.pid
+`)
+
+			config, err := ParseFile(fs, "/logrotate.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.MaxSize).To(Equal("10M"))
+			Expect(config.MaxAgeDuration()).To(Equal(168 * time.Hour))
+			Expect(config.MaxTotalSize).To(Equal("1G"))
+			Expect(config.KeepOrDefault()).To(Equal(3))
+			Expect(config.CompressOrDefault()).To(BeFalse())
+			Expect(config.MethodOrDefault()).To(Equal(MethodSignal))
+			Expect(config.SignalOrDefault()).To(Equal("USR1"))
+			Expect(config.PidFile).To(Equal("/var/vcap/sys/run/fake-job/fake-job.pid"))
+		})
+
+		It("defaults to keeping 7 compressed logs rotated with copytruncate", func() {
+			fs.WriteFileString("/logrotate.yml", "{}")
+
+			config, err := ParseFile(fs, "/logrotate.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.MaxAgeDuration()).To(BeZero())
+			Expect(config.KeepOrDefault()).To(Equal(7))
+			Expect(config.CompressOrDefault()).To(BeTrue())
+			Expect(config.MethodOrDefault()).To(Equal(MethodCopyTruncate))
+			Expect(config.SignalOrDefault()).To(Equal("HUP"))
+		})
+
+		It("returns error when file cannot be read", func() {
+			fs.WriteFileString("/logrotate.yml", "")
+			fs.ReadFileError = errors.New("fake-read-err")
+
+			_, err := ParseFile(fs, "/logrotate.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading log rotation config /logrotate.yml"))
+		})
+
+		It("returns error when config is invalid", func() {
+			fs.WriteFileString("/logrotate.yml", "method: signal")
+
+			_, err := ParseFile(fs, "/logrotate.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating log rotation config /logrotate.yml"))
+			Expect(err.Error()).To(ContainSubstring("Pid file '' must be an absolute path"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("returns all errors", func() {
+			keep := -1
+
+			config := Config{
+				MaxSize:      "10MB",
+				MaxTotalSize: "lots",
+				MaxAge:       "-1h",
+				Keep:         &keep,
+				Method:       "fake-method",
+			}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Max size: Size '10MB' must be a number of bytes with optional K, M or G suffix"))
+			Expect(err.Error()).To(ContainSubstring("Max total size: Size 'lots' must be"))
+			Expect(err.Error()).To(ContainSubstring("Max age '-1h' must be a positive duration"))
+			Expect(err.Error()).To(ContainSubstring("Keep must not be negative"))
+			Expect(err.Error()).To(ContainSubstring("Method 'fake-method' must be copytruncate or signal"))
+		})
+
+		It("requires signal to be one processes reopen logs on", func() {
+			config := Config{Method: MethodSignal, Signal: "KILL", PidFile: "/fake.pid"}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Signal 'KILL' must be HUP, USR1 or USR2"))
+		})
+	})
+
+	Describe("ParseSize", func() {
+		It("converts sizes with suffixes into bytes", func() {
+			Expect(ParseSize("")).To(Equal(uint64(0)))
+			Expect(ParseSize("512")).To(Equal(uint64(512)))
+			Expect(ParseSize("100k")).To(Equal(uint64(100 * 1024)))
+			Expect(ParseSize("50M")).To(Equal(uint64(50 * 1024 * 1024)))
+			Expect(ParseSize("2G")).To(Equal(uint64(2 * 1024 * 1024 * 1024)))
+		})
+	})
+})
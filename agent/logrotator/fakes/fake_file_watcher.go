@@ -0,0 +1,40 @@
+package fakes
+
+import (
+	"sync"
+)
+
+type FakeFileWatcher struct {
+	WatchDir    string
+	WatchStopCh <-chan struct{}
+	WatchErr    error
+
+	ChangeCh chan string
+
+	lock sync.Mutex
+}
+
+func NewFakeFileWatcher() *FakeFileWatcher {
+	return &FakeFileWatcher{ChangeCh: make(chan string)}
+}
+
+func (w *FakeFileWatcher) Watch(dir string, stopCh <-chan struct{}) (<-chan string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.WatchDir = dir
+	w.WatchStopCh = stopCh
+
+	if w.WatchErr != nil {
+		return nil, w.WatchErr
+	}
+
+	return w.ChangeCh, nil
+}
+
+func (w *FakeFileWatcher) WatchedDir() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.WatchDir
+}
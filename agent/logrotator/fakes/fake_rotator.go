@@ -0,0 +1,37 @@
+package fakes
+
+import (
+	"sync"
+
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+)
+
+type FakeRotator struct {
+	RunErr error
+
+	UsageUsages []boshlogrotator.Usage
+	UsageErr    error
+
+	runCallCount int
+	lock         sync.Mutex
+}
+
+func (r *FakeRotator) Run(_ <-chan struct{}) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.runCallCount++
+
+	return r.RunErr
+}
+
+func (r *FakeRotator) RunCallCount() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.runCallCount
+}
+
+func (r *FakeRotator) Usage() ([]boshlogrotator.Usage, error) {
+	return r.UsageUsages, r.UsageErr
+}
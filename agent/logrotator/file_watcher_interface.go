@@ -0,0 +1,13 @@
+package logrotator
+
+import (
+	"errors"
+)
+
+var ErrFileWatchingNotSupported = errors.New("Watching files is not supported")
+
+type FileWatcher interface {
+	// Watch sends path of a file every time it is created or written to
+	// in dir or any of its subdirectories until stopCh is closed
+	Watch(dir string, stopCh <-chan struct{}) (<-chan string, error)
+}
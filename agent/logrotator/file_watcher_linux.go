@@ -0,0 +1,160 @@
+// +build linux
+
+package logrotator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	inotifyFileWatcherLogTag = "inotifyFileWatcher"
+
+	// Polling times out periodically so that stopCh is noticed
+	inotifyPollTimeout = 1 * time.Second
+
+	inotifyWatchMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_MOVED_TO
+)
+
+type inotifyFileWatcher struct {
+	logger boshlog.Logger
+}
+
+// NewFileWatcher returns watcher that adds inotify watches for
+// a directory and its subdirectories, including ones created later
+func NewFileWatcher(logger boshlog.Logger) FileWatcher {
+	return inotifyFileWatcher{logger: logger}
+}
+
+func (w inotifyFileWatcher) Watch(dir string, stopCh <-chan struct{}) (<-chan string, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Initializing inotify")
+	}
+
+	dirs := map[int]string{}
+
+	err = w.addDirs(fd, dir, dirs)
+	if err != nil {
+		_ = unix.Close(fd)
+		return nil, bosherr.WrapErrorf(err, "Watching %s", dir)
+	}
+
+	changeCh := make(chan string)
+
+	go w.receive(fd, dirs, changeCh, stopCh)
+
+	return changeCh, nil
+}
+
+// addDirs watches dir and all of its subdirectories; dir itself may be a symlink
+func (w inotifyFileWatcher) addDirs(fd int, dir string, dirs map[int]string) error {
+	root := filepath.Clean(dir)
+
+	return filepath.Walk(root+string(filepath.Separator), func(path string, info os.FileInfo, err error) error {
+		path = filepath.Clean(path)
+
+		if err != nil {
+			// Subdirectories may be removed while walking
+			if path == root {
+				return err
+			}
+			return nil
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		wd, err := unix.InotifyAddWatch(fd, path, inotifyWatchMask)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Adding inotify watch for %s", path)
+		}
+
+		dirs[wd] = path
+
+		return nil
+	})
+}
+
+func (w inotifyFileWatcher) receive(fd int, dirs map[int]string, changeCh chan<- string, stopCh <-chan struct{}) {
+	defer close(changeCh)
+	defer unix.Close(fd)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	pollFds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		_, err := unix.Poll(pollFds, int(inotifyPollTimeout/time.Millisecond))
+		if err == unix.EINTR {
+			continue
+		}
+
+		if err != nil {
+			w.logger.Error(inotifyFileWatcherLogTag, "Polling inotify: %s", err.Error())
+			return
+		}
+
+		n, err := unix.Read(fd, buf)
+		if err == unix.EAGAIN || err == unix.EINTR {
+			continue
+		}
+
+		if err != nil {
+			w.logger.Error(inotifyFileWatcherLogTag, "Reading inotify events: %s", err.Error())
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+				w.logger.Warn(inotifyFileWatcherLogTag, "Missed file changes because inotify queue overflowed")
+				continue
+			}
+
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(dirs, int(event.Wd))
+				continue
+			}
+
+			dir, found := dirs[int(event.Wd)]
+			if !found || name == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+
+			if event.Mask&unix.IN_ISDIR != 0 {
+				err = w.addDirs(fd, path, dirs)
+				if err != nil {
+					w.logger.Warn(inotifyFileWatcherLogTag, "Not watching %s: %s", path, err.Error())
+				}
+				continue
+			}
+
+			select {
+			case changeCh <- path:
+			case <-stopCh:
+				return
+			}
+		}
+	}
+}
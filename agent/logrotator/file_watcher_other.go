@@ -0,0 +1,17 @@
+// +build !linux
+
+package logrotator
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type unsupportedFileWatcher struct{}
+
+func NewFileWatcher(_ boshlog.Logger) FileWatcher {
+	return unsupportedFileWatcher{}
+}
+
+func (unsupportedFileWatcher) Watch(_ string, _ <-chan struct{}) (<-chan string, error) {
+	return nil, ErrFileWatchingNotSupported
+}
@@ -0,0 +1,13 @@
+package logrotator_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogrotator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Rotator Suite")
+}
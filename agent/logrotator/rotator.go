@@ -0,0 +1,548 @@
+package logrotator
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	rotatorLogTag = "logRotator"
+
+	// Written logs are checked at most this often
+	// so that every write does not result in a stat
+	rotatorCheckInterval = 1 * time.Second
+
+	// All logs are checked this often to enforce age and total size
+	// limits and to rotate logs whose changes were not noticed
+	rotatorSweepInterval = 1 * time.Minute
+
+	// Processes that are signalled are given this long to reopen
+	// their logs before rotated logs are compressed
+	rotatorCompressDelay = 1 * time.Minute
+
+	rotatedTimeFormat = "20060102T150405Z"
+)
+
+var rotatedRegexp = regexp.MustCompile(`^(.+\.log)\.([0-9]{8}T[0-9]{6}Z)(\.gz)?$`)
+
+// Matches logs rotated by logrotate before the agent rotated logs itself;
+// they are ordered by modification time since their names have no time
+var logrotateRotatedRegexp = regexp.MustCompile(`^(.+\.log)\.([0-9]+)(\.gz)?$`)
+
+// Usage is disk space taken by logs of a job
+type Usage struct {
+	Job          string `json:"job"`
+	Bytes        uint64 `json:"bytes"`
+	RotatedBytes uint64 `json:"rotated_bytes"`
+	RotatedFiles int    `json:"rotated_files"`
+}
+
+type Rotator interface {
+	// Run rotates logs in logs directory as they are written until stopCh
+	// is closed. Returns ErrFileWatchingNotSupported on platforms where
+	// logs are not rotated by the agent.
+	Run(stopCh <-chan struct{}) error
+
+	// Usage reports disk space taken by logs of each job
+	Usage() ([]Usage, error)
+}
+
+type concreteRotator struct {
+	fs          boshsys.FileSystem
+	cmdRunner   boshsys.CmdRunner
+	dirProvider boshdirs.Provider
+	specService boshas.V1Service
+	watcher     FileWatcher
+	timeService clock.Clock
+	logger      boshlog.Logger
+}
+
+type liveLog struct {
+	path string
+	size uint64
+}
+
+type rotatedLog struct {
+	path       string
+	original   string
+	rotatedAt  time.Time
+	size       uint64
+	compressed bool
+}
+
+// NewRotator returns rotator that rotates logs of each job by size
+// according to logrotate.yml of the job and removes old rotated logs
+func NewRotator(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	dirProvider boshdirs.Provider,
+	specService boshas.V1Service,
+	watcher FileWatcher,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Rotator {
+	return concreteRotator{
+		fs:          fs,
+		cmdRunner:   cmdRunner,
+		dirProvider: dirProvider,
+		specService: specService,
+		watcher:     watcher,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (r concreteRotator) Run(stopCh <-chan struct{}) error {
+	changeCh, err := r.watcher.Watch(r.dirProvider.LogsDir(), stopCh)
+	if err == ErrFileWatchingNotSupported {
+		return err
+	}
+
+	// Logs still have to be rotated since logrotate no longer rotates them
+	if err != nil {
+		r.logger.Warn(rotatorLogTag, "Failed to watch logs; rotating logs every %s: %s", rotatorSweepInterval, err.Error())
+		changeCh = nil
+	}
+
+	checkTicker := r.timeService.NewTicker(rotatorCheckInterval)
+	defer checkTicker.Stop()
+
+	sweepTicker := r.timeService.NewTicker(rotatorSweepInterval)
+	defer sweepTicker.Stop()
+
+	r.sweep()
+
+	writtenJobs := map[string]bool{}
+
+	for {
+		select {
+		case <-stopCh:
+			return nil
+
+		case path, ok := <-changeCh:
+			if !ok {
+				r.logger.Warn(rotatorLogTag, "Stopped watching logs; rotating logs every %s", rotatorSweepInterval)
+				changeCh = nil
+				continue
+			}
+
+			if jobName, found := r.jobOf(path); found && isLiveLog(path) {
+				writtenJobs[jobName] = true
+			}
+
+		case <-checkTicker.C():
+			for jobName := range writtenJobs {
+				r.rotateJob(jobName)
+			}
+
+			writtenJobs = map[string]bool{}
+
+		case <-sweepTicker.C():
+			r.sweep()
+		}
+	}
+}
+
+func (r concreteRotator) Usage() ([]Usage, error) {
+	jobNames, err := r.jobNames()
+	if err != nil {
+		return nil, err
+	}
+
+	usages := []Usage{}
+
+	for _, jobName := range jobNames {
+		live, rotated, err := r.jobLogs(jobName)
+		if err != nil {
+			return nil, err
+		}
+
+		usage := Usage{Job: jobName, RotatedFiles: len(rotated)}
+
+		for _, log := range live {
+			usage.Bytes += log.size
+		}
+
+		for _, log := range rotated {
+			usage.RotatedBytes += log.size
+		}
+
+		usages = append(usages, usage)
+	}
+
+	return usages, nil
+}
+
+func (r concreteRotator) sweep() {
+	jobNames, err := r.jobNames()
+	if err != nil {
+		r.logger.Warn(rotatorLogTag, "Failed to find job logs: %s", err.Error())
+		return
+	}
+
+	// Logs directly in logs directory are not owned by any job
+	for _, jobName := range append([]string{""}, jobNames...) {
+		r.rotateJob(jobName)
+	}
+}
+
+// rotateJob rotates logs of a job that grew too large
+// and removes rotated logs that exceed limits of the job
+func (r concreteRotator) rotateJob(jobName string) {
+	config := r.jobConfig(jobName)
+
+	live, rotated, err := r.jobLogs(jobName)
+	if err != nil {
+		r.logger.Warn(rotatorLogTag, "Failed to find logs of job '%s': %s", jobName, err.Error())
+		return
+	}
+
+	maxSize := r.maxSize(config)
+	rotatedAny := false
+
+	for _, log := range live {
+		if log.size <= maxSize {
+			continue
+		}
+
+		err = r.rotate(log.path, config)
+		if err != nil {
+			r.logger.Warn(rotatorLogTag, "Failed to rotate %s: %s", log.path, err.Error())
+			continue
+		}
+
+		rotatedAny = true
+	}
+
+	if rotatedAny {
+		live, rotated, err = r.jobLogs(jobName)
+		if err != nil {
+			r.logger.Warn(rotatorLogTag, "Failed to find logs of job '%s': %s", jobName, err.Error())
+			return
+		}
+	}
+
+	var liveBytes uint64
+
+	for _, log := range live {
+		liveBytes += log.size
+	}
+
+	r.prune(config, rotated, liveBytes)
+}
+
+func (r concreteRotator) rotate(logPath string, config Config) error {
+	rotatedPath := logPath + "." + r.timeService.Now().UTC().Format(rotatedTimeFormat)
+
+	// Log was already rotated within this second
+	if r.fs.FileExists(rotatedPath) || r.fs.FileExists(rotatedPath+".gz") {
+		return nil
+	}
+
+	r.logger.Debug(rotatorLogTag, "Rotating %s", logPath)
+
+	if config.MethodOrDefault() == MethodSignal {
+		err := r.fs.Rename(logPath, rotatedPath)
+		if err != nil {
+			return bosherr.WrapError(err, "Renaming log")
+		}
+
+		// Rotated log is compressed later once process reopened its logs
+		return r.signal(config)
+	}
+
+	var err error
+
+	if config.CompressOrDefault() {
+		err = r.compress(logPath, rotatedPath+".gz")
+	} else {
+		err = r.fs.CopyFile(logPath, rotatedPath)
+	}
+
+	if err != nil {
+		return bosherr.WrapError(err, "Copying log")
+	}
+
+	file, err := r.fs.OpenFile(logPath, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return bosherr.WrapError(err, "Truncating log")
+	}
+
+	return file.Close()
+}
+
+func (r concreteRotator) signal(config Config) error {
+	pidContents, err := r.fs.ReadFileString(config.PidFile)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading pid file %s", config.PidFile)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(pidContents))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing pid file %s", config.PidFile)
+	}
+
+	_, _, _, err = r.cmdRunner.RunCommand("kill", "-s", config.SignalOrDefault(), strconv.Itoa(pid))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending %s to process %d", config.SignalOrDefault(), pid)
+	}
+
+	return nil
+}
+
+func (r concreteRotator) compress(srcPath, dstPath string) error {
+	src, err := r.fs.OpenFile(srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening %s", srcPath)
+	}
+
+	defer src.Close()
+
+	dst, err := r.fs.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating %s", dstPath)
+	}
+
+	gzipWriter := gzip.NewWriter(dst)
+
+	_, err = io.Copy(gzipWriter, src)
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+
+	closeErr := dst.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = r.fs.RemoveAll(dstPath)
+		return bosherr.WrapErrorf(err, "Compressing %s", srcPath)
+	}
+
+	return nil
+}
+
+// prune removes rotated logs that are over the kept count, too old or
+// over the total size, and compresses remaining ones that are not yet
+func (r concreteRotator) prune(config Config, rotated []rotatedLog, liveBytes uint64) {
+	now := r.timeService.Now()
+	keep := config.KeepOrDefault()
+	maxAge := config.MaxAgeDuration()
+
+	sort.SliceStable(rotated, func(i, j int) bool {
+		return rotated[i].rotatedAt.After(rotated[j].rotatedAt)
+	})
+
+	kept := map[string]int{}
+
+	var remaining []rotatedLog
+
+	for _, log := range rotated {
+		kept[log.original]++
+
+		if kept[log.original] > keep || (maxAge > 0 && now.Sub(log.rotatedAt) > maxAge) {
+			r.remove(log)
+			continue
+		}
+
+		if config.CompressOrDefault() && !log.compressed && now.Sub(log.rotatedAt) >= rotatorCompressDelay {
+			err := r.compress(log.path, log.path+".gz")
+			if err != nil {
+				r.logger.Warn(rotatorLogTag, "Failed to compress %s: %s", log.path, err.Error())
+			} else {
+				r.remove(log)
+				log.path += ".gz"
+				log.size = r.fileSize(log.path)
+			}
+		}
+
+		remaining = append(remaining, log)
+	}
+
+	maxTotalSize, _ := ParseSize(config.MaxTotalSize)
+	if maxTotalSize == 0 {
+		return
+	}
+
+	totalSize := liveBytes
+	for _, log := range remaining {
+		totalSize += log.size
+	}
+
+	for i := len(remaining) - 1; i >= 0 && totalSize > maxTotalSize; i-- {
+		r.remove(remaining[i])
+		totalSize -= remaining[i].size
+	}
+}
+
+func (r concreteRotator) remove(log rotatedLog) {
+	err := r.fs.RemoveAll(log.path)
+	if err != nil {
+		r.logger.Warn(rotatorLogTag, "Failed to remove %s: %s", log.path, err.Error())
+	}
+}
+
+func (r concreteRotator) fileSize(path string) uint64 {
+	info, err := r.fs.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return uint64(info.Size())
+}
+
+// jobLogs finds logs of a job; logs directly in
+// logs directory are found for job with empty name
+func (r concreteRotator) jobLogs(jobName string) ([]liveLog, []rotatedLog, error) {
+	root := filepath.Join(r.dirProvider.LogsDir(), jobName)
+
+	var live []liveLog
+	var rotated []rotatedLog
+
+	err := boshlogtail.WalkLogsDir(r.fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Logs may be removed while walking
+			if path == root {
+				return err
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if jobName == "" && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		if isLiveLog(path) {
+			live = append(live, liveLog{path: path, size: uint64(info.Size())})
+			return nil
+		}
+
+		if matches := rotatedRegexp.FindStringSubmatch(filepath.Base(path)); matches != nil {
+			rotatedAt, err := time.Parse(rotatedTimeFormat, matches[2])
+			if err != nil {
+				return nil
+			}
+
+			rotated = append(rotated, rotatedLog{
+				path:       path,
+				original:   filepath.Join(filepath.Dir(path), matches[1]),
+				rotatedAt:  rotatedAt,
+				size:       uint64(info.Size()),
+				compressed: matches[3] != "",
+			})
+		} else if matches := logrotateRotatedRegexp.FindStringSubmatch(filepath.Base(path)); matches != nil {
+			rotated = append(rotated, rotatedLog{
+				path:       path,
+				original:   filepath.Join(filepath.Dir(path), matches[1]),
+				rotatedAt:  info.ModTime(),
+				size:       uint64(info.Size()),
+				compressed: matches[3] != "",
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, bosherr.WrapErrorf(err, "Walking %s", root)
+	}
+
+	return live, rotated, nil
+}
+
+func (r concreteRotator) jobNames() ([]string, error) {
+	paths, err := r.fs.Glob(filepath.Join(r.dirProvider.LogsDir(), "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Globbing logs directory")
+	}
+
+	var jobNames []string
+
+	for _, path := range paths {
+		info, err := r.fs.Stat(path)
+		if err == nil && info.IsDir() {
+			jobNames = append(jobNames, filepath.Base(path))
+		}
+	}
+
+	return jobNames, nil
+}
+
+// jobOf returns name of job that owns log at path
+func (r concreteRotator) jobOf(path string) (string, bool) {
+	relPath, err := filepath.Rel(r.dirProvider.LogsDir(), path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+
+	parts := strings.Split(relPath, string(filepath.Separator))
+	if len(parts) == 1 {
+		return "", true
+	}
+
+	return parts[0], true
+}
+
+func (r concreteRotator) jobConfig(jobName string) Config {
+	if jobName == "" {
+		return Config{}
+	}
+
+	configPath := filepath.Join(r.dirProvider.JobsDir(), jobName, FileName)
+	if !r.fs.FileExists(configPath) {
+		return Config{}
+	}
+
+	config, err := ParseFile(r.fs, configPath)
+	if err != nil {
+		r.logger.Warn(rotatorLogTag, "Rotating logs of job '%s' by default: %s", jobName, err.Error())
+		return Config{}
+	}
+
+	return config
+}
+
+func (r concreteRotator) maxSize(config Config) uint64 {
+	if config.MaxSize != "" {
+		maxSize, _ := ParseSize(config.MaxSize)
+		return maxSize
+	}
+
+	// Spec that cannot be read still provides default size
+	spec, _ := r.specService.Get()
+
+	maxSize, err := ParseSize(spec.MaxLogFileSize())
+	if err != nil {
+		maxSize, _ = ParseSize(boshas.V1ApplySpec{}.MaxLogFileSize())
+	}
+
+	return maxSize
+}
+
+// isLiveLog is true for logs that are written to by jobs
+// as opposed to logs that were already rotated
+func isLiveLog(path string) bool {
+	return strings.HasSuffix(path, ".log")
+}
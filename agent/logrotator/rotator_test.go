@@ -0,0 +1,339 @@
+package logrotator_test
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	. "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	fakelogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator/fakes"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Rotator", func() {
+	var (
+		baseDir     string
+		fs          boshsys.FileSystem
+		cmdRunner   *fakesys.FakeCmdRunner
+		dirProvider boshdirs.Provider
+		specService *fakeas.FakeV1Service
+		watcher     *fakelogrotator.FakeFileWatcher
+		timeService *fakeclock.FakeClock
+		rotator     Rotator
+
+		stopCh chan struct{}
+		doneCh chan struct{}
+		runErr error
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		baseDir, err = ioutil.TempDir("", "log-rotator")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		dirProvider = boshdirs.NewProvider(baseDir)
+
+		specService = fakeas.NewFakeV1Service()
+		specService.Spec.PropertiesSpec.LoggingSpec.MaxLogFileSize = "1K"
+
+		watcher = fakelogrotator.NewFakeFileWatcher()
+		timeService = fakeclock.NewFakeClock(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+
+		rotator = NewRotator(fs, cmdRunner, dirProvider, specService, watcher, timeService, logger)
+
+		stopCh = nil
+		doneCh = nil
+	})
+
+	start := func() {
+		stopCh = make(chan struct{})
+		doneCh = make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			runErr = rotator.Run(stopCh)
+			close(doneCh)
+		}()
+
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+	}
+
+	stop := func() {
+		if stopCh != nil {
+			close(stopCh)
+			Eventually(doneCh).Should(BeClosed())
+			stopCh = nil
+		}
+	}
+
+	AfterEach(func() {
+		stop()
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	logPath := func(name string) string {
+		return filepath.Join(dirProvider.LogsDir(), name)
+	}
+
+	writeLog := func(name string, size int) {
+		Expect(fs.MkdirAll(filepath.Dir(logPath(name)), 0755)).To(Succeed())
+		Expect(fs.WriteFileString(logPath(name), strings.Repeat("x", size))).To(Succeed())
+	}
+
+	writeConfig := func(jobName, config string) {
+		Expect(fs.WriteFileString(filepath.Join(dirProvider.JobsDir(), jobName, "config", "logrotate.yml"), config)).To(Succeed())
+	}
+
+	readCompressed := func(name string) string {
+		file, err := os.Open(logPath(name))
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		reader, err := gzip.NewReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, err := ioutil.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		return string(contents)
+	}
+
+	exists := func(name string) func() bool {
+		return func() bool { return fs.FileExists(logPath(name)) }
+	}
+
+	Describe("Run", func() {
+		It("watches logs directory", func() {
+			start()
+			Expect(watcher.WatchedDir()).To(Equal(dirProvider.LogsDir()))
+		})
+
+		It("compresses and truncates logs larger than max log file size of deployment", func() {
+			writeLog("fake-job/fake-job.log", 2048)
+			writeLog("fake-job/small.log", 10)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z.gz")).Should(BeTrue())
+			Expect(readCompressed("fake-job/fake-job.log.20261018T120000Z.gz")).To(Equal(strings.Repeat("x", 2048)))
+
+			Eventually(func() (string, error) { return fs.ReadFileString(logPath("fake-job/fake-job.log")) }).Should(BeEmpty())
+
+			Expect(exists("fake-job/small.log.20261018T120000Z.gz")()).To(BeFalse())
+		})
+
+		It("rotates logs in subdirectories of jobs and logs directly in logs directory", func() {
+			writeLog("fake-job/nested/fake.log", 2048)
+			writeLog("fake.log", 2048)
+
+			start()
+
+			Eventually(exists("fake-job/nested/fake.log.20261018T120000Z.gz")).Should(BeTrue())
+			Eventually(exists("fake.log.20261018T120000Z.gz")).Should(BeTrue())
+		})
+
+		It("rotates logs as they are written", func() {
+			writeLog("fake-job/fake-job.log", 10)
+
+			start()
+
+			// Changes are received once logs present at start were checked
+			watcher.ChangeCh <- logPath("fake-job/fake-job.log")
+
+			writeLog("fake-job/fake-job.log", 2048)
+			watcher.ChangeCh <- logPath("fake-job/fake-job.log")
+			timeService.Increment(1 * time.Second)
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120001Z.gz")).Should(BeTrue())
+		})
+
+		It("ignores changes of logs that were already rotated", func() {
+			writeLog("fake-job/fake-job.log", 10)
+
+			start()
+
+			writeLog("fake-job/fake-job.log.20261018T110000Z", 2048)
+			watcher.ChangeCh <- logPath("fake-job/fake-job.log.20261018T110000Z")
+			timeService.Increment(1 * time.Second)
+
+			Consistently(exists("fake-job/fake-job.log.20261018T110000Z.20261018T120001Z.gz")).Should(BeFalse())
+		})
+
+		It("rotates logs by size configured by job without compressing them if disabled", func() {
+			writeConfig("fake-job", "max_size: 100\ncompress: false")
+			writeLog("fake-job/fake-job.log", 200)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z")).Should(BeTrue())
+			Expect(fs.ReadFileString(logPath("fake-job/fake-job.log.20261018T120000Z"))).To(Equal(strings.Repeat("x", 200)))
+		})
+
+		It("rotates logs by default when job config is invalid", func() {
+			writeConfig("fake-job", "method: fake-method")
+			writeLog("fake-job/fake-job.log", 2048)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z.gz")).Should(BeTrue())
+		})
+
+		It("keeps configured number of rotated logs of each log", func() {
+			writeConfig("fake-job", "keep: 2")
+			writeLog("fake-job/fake-job.log", 10)
+			writeLog("fake-job/fake-job.log.20261015T120000Z.gz", 10)
+			writeLog("fake-job/fake-job.log.20261016T120000Z.gz", 10)
+			writeLog("fake-job/fake-job.log.20261017T120000Z.gz", 10)
+			writeLog("fake-job/other.log.20261015T120000Z.gz", 10)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261015T120000Z.gz")).Should(BeFalse())
+			Expect(exists("fake-job/fake-job.log.20261016T120000Z.gz")()).To(BeTrue())
+			Expect(exists("fake-job/fake-job.log.20261017T120000Z.gz")()).To(BeTrue())
+			Expect(exists("fake-job/other.log.20261015T120000Z.gz")()).To(BeTrue())
+		})
+
+		It("removes rotated logs older than max age", func() {
+			writeConfig("fake-job", "max_age: 48h")
+			writeLog("fake-job/fake-job.log.20261015T120000Z.gz", 10)
+			writeLog("fake-job/fake-job.log.20261017T120000Z.gz", 10)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261015T120000Z.gz")).Should(BeFalse())
+			Expect(exists("fake-job/fake-job.log.20261017T120000Z.gz")()).To(BeTrue())
+
+			timeService.Increment(25 * time.Hour)
+
+			Eventually(exists("fake-job/fake-job.log.20261017T120000Z.gz")).Should(BeFalse())
+		})
+
+		It("removes oldest rotated logs of job to stay under max total size", func() {
+			writeConfig("fake-job", "max_total_size: 250")
+			writeLog("fake-job/fake-job.log", 100)
+			writeLog("fake-job/fake-job.log.20261015T120000Z.gz", 100)
+			writeLog("fake-job/other.log.20261016T120000Z.gz", 100)
+			writeLog("fake-job/fake-job.log.20261017T120000Z.gz", 50)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261015T120000Z.gz")).Should(BeFalse())
+			Expect(exists("fake-job/other.log.20261016T120000Z.gz")()).To(BeTrue())
+			Expect(exists("fake-job/fake-job.log.20261017T120000Z.gz")()).To(BeTrue())
+		})
+
+		It("renames logs and signals process when job uses signal method, compressing them later", func() {
+			writeConfig("fake-job", "method: signal\nsignal: USR1\npid_file: "+filepath.Join(baseDir, "fake-job.pid"))
+			Expect(fs.WriteFileString(filepath.Join(baseDir, "fake-job.pid"), "123\n")).To(Succeed())
+			writeLog("fake-job/fake-job.log", 2048)
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z")).Should(BeTrue())
+			Expect(exists("fake-job/fake-job.log")()).To(BeFalse())
+
+			timeService.Increment(1 * time.Minute)
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z.gz")).Should(BeTrue())
+			Eventually(exists("fake-job/fake-job.log.20261018T120000Z")).Should(BeFalse())
+			Expect(readCompressed("fake-job/fake-job.log.20261018T120000Z.gz")).To(Equal(strings.Repeat("x", 2048)))
+
+			stop()
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"kill", "-s", "USR1", "123"}}))
+		})
+
+		It("removes logs rotated by logrotate like logs rotated by the agent", func() {
+			writeConfig("fake-job", "keep: 2")
+			writeLog("fake-job/fake-job.log", 10)
+			writeLog("fake-job/fake-job.log.1.gz", 10)
+			writeLog("fake-job/fake-job.log.2.gz", 10)
+			writeLog("fake-job/fake-job.log.3.gz", 10)
+
+			for i, rotatedAt := range []time.Time{
+				time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC),
+			} {
+				Expect(os.Chtimes(logPath(fmt.Sprintf("fake-job/fake-job.log.%d.gz", i+1)), rotatedAt, rotatedAt)).To(Succeed())
+			}
+
+			start()
+
+			Eventually(exists("fake-job/fake-job.log.3.gz")).Should(BeFalse())
+			Expect(exists("fake-job/fake-job.log.1.gz")()).To(BeTrue())
+			Expect(exists("fake-job/fake-job.log.2.gz")()).To(BeTrue())
+		})
+
+		It("rotates logs every minute when logs cannot be watched", func() {
+			watcher.WatchErr = errors.New("fake-watch-err")
+			writeLog("fake-job/fake-job.log", 10)
+
+			start()
+
+			writeLog("fake-job/fake-job.log", 2048)
+			timeService.Increment(1 * time.Minute)
+
+			Eventually(exists("fake-job/fake-job.log.20261018T120100Z.gz")).Should(BeTrue())
+
+			stop()
+			Expect(runErr).ToNot(HaveOccurred())
+		})
+
+		It("stops when stop channel is closed", func() {
+			start()
+			stop()
+
+			Expect(runErr).ToNot(HaveOccurred())
+		})
+
+		It("returns error when watching is not supported", func() {
+			watcher.WatchErr = ErrFileWatchingNotSupported
+
+			err := rotator.Run(make(chan struct{}))
+			Expect(err).To(Equal(ErrFileWatchingNotSupported))
+		})
+	})
+
+	Describe("Usage", func() {
+		It("reports size of logs and rotated logs of each job", func() {
+			writeLog("fake-job/fake-job.log", 100)
+			writeLog("fake-job/nested/fake.log", 20)
+			writeLog("fake-job/fake-job.log.20261017T120000Z.gz", 30)
+			writeLog("other-job/other-job.log", 5)
+			writeLog("fake.log", 1000)
+
+			usages, err := rotator.Usage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usages).To(Equal([]Usage{
+				{Job: "fake-job", Bytes: 120, RotatedBytes: 30, RotatedFiles: 1},
+				{Job: "other-job", Bytes: 5},
+			}))
+		})
+
+		It("reports no jobs when there are no logs", func() {
+			usages, err := rotator.Usage()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usages).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package fakes
+
+import (
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+)
+
+type FakeStreamer struct {
+	OpenLogType string
+	OpenPaths   []string
+	OpenOptions boshlogtail.Options
+	OpenStream  *FakeStream
+	OpenErr     error
+}
+
+func (s *FakeStreamer) Open(logType string, paths []string, options boshlogtail.Options) (boshlogtail.Stream, error) {
+	s.OpenLogType = logType
+	s.OpenPaths = paths
+	s.OpenOptions = options
+
+	if s.OpenErr != nil {
+		return nil, s.OpenErr
+	}
+
+	return s.OpenStream, nil
+}
+
+type FakeStream struct {
+	// Chunks are sent before Run returns
+	RunChunks []boshlogtail.Chunk
+
+	// Run waits until stopped when set
+	RunUntilStopped bool
+
+	RunSummary boshlogtail.Summary
+	RunErr     error
+}
+
+func (s *FakeStream) Run(sendFunc boshlogtail.SendFunc, stopCh <-chan struct{}) (boshlogtail.Summary, error) {
+	for _, chunk := range s.RunChunks {
+		err := sendFunc(chunk)
+		if err != nil {
+			return s.RunSummary, err
+		}
+	}
+
+	if s.RunUntilStopped {
+		<-stopCh
+	}
+
+	return s.RunSummary, s.RunErr
+}
@@ -0,0 +1,13 @@
+package logtail_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogtail(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Tail Suite")
+}
@@ -0,0 +1,285 @@
+package logtail
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	streamerLogTag = "logStreamer"
+
+	// Written lines are read and sent at most this often
+	streamReadInterval = 1 * time.Second
+
+	// Lines over this rate are dropped so that tailing
+	// busy logs does not flood message bus
+	streamMaxBytesPerInterval = 64 * 1024
+
+	// Streams end after this long even if lines keep being written
+	streamMaxDuration = 1 * time.Hour
+
+	DefaultIdleTimeout = 5 * time.Minute
+	MaxIdleTimeout     = 30 * time.Minute
+
+	MaxStreams     = 5
+	MaxStreamPaths = 20
+	MaxStreamLines = 1000
+)
+
+const (
+	EndReasonStopped     = "stopped"
+	EndReasonIdle        = "idle"
+	EndReasonMaxDuration = "max_duration"
+)
+
+var ErrTooManyStreams = errors.New("Too many logs are already being tailed")
+
+type Options struct {
+	// Lines is number of last lines of each log sent before new lines
+	Lines int
+
+	// Stream ends when no lines are written for this long
+	IdleTimeout time.Duration
+}
+
+// Chunk holds lines written to a log since previous chunk
+type Chunk struct {
+	Path         string   `json:"path"`
+	Lines        []string `json:"lines"`
+	DroppedLines int      `json:"dropped_lines,omitempty"`
+}
+
+// Summary describes stream once it ended
+type Summary struct {
+	Lines        int    `json:"lines"`
+	DroppedLines int    `json:"dropped_lines"`
+	EndReason    string `json:"end_reason"`
+}
+
+type SendFunc func(chunk Chunk) error
+
+type Streamer interface {
+	// Open starts tailing logs of logType ("job" or "agent") at paths
+	// relative to logs directory. Returns ErrTooManyStreams when max number
+	// of streams are open; returned stream must be run to be closed.
+	Open(logType string, paths []string, options Options) (Stream, error)
+}
+
+type Stream interface {
+	// Run sends lines written to logs until stopCh is closed,
+	// logs are idle or stream reaches its max duration
+	Run(sendFunc SendFunc, stopCh <-chan struct{}) (Summary, error)
+}
+
+type concreteStreamer struct {
+	fs          boshsys.FileSystem
+	dirProvider boshdirs.Provider
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock    sync.Mutex
+	streams int
+}
+
+type concreteStream struct {
+	streamer    *concreteStreamer
+	tailer      *Tailer
+	paths       []string
+	relPaths    []string
+	idleTimeout time.Duration
+}
+
+func NewStreamer(
+	fs boshsys.FileSystem,
+	dirProvider boshdirs.Provider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Streamer {
+	return &concreteStreamer{
+		fs:          fs,
+		dirProvider: dirProvider,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (s *concreteStreamer) Open(logType string, paths []string, options Options) (Stream, error) {
+	var logsDir string
+
+	switch logType {
+	case "job":
+		logsDir = s.dirProvider.LogsDir()
+	case "agent":
+		logsDir = s.dirProvider.AgentLogsDir()
+	default:
+		return nil, bosherr.Errorf("Invalid log type '%s'", logType)
+	}
+
+	if len(paths) == 0 {
+		return nil, bosherr.Error("Must tail at least one log")
+	}
+
+	if len(paths) > MaxStreamPaths {
+		return nil, bosherr.Errorf("Cannot tail more than %d logs at once", MaxStreamPaths)
+	}
+
+	stream := &concreteStream{
+		streamer:    s,
+		tailer:      NewTailer(s.fs, logsDir),
+		idleTimeout: options.IdleTimeout,
+	}
+
+	for _, path := range paths {
+		relPath := filepath.Clean(path)
+
+		if filepath.IsAbs(path) || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return nil, bosherr.Errorf("Log path '%s' must be within logs directory", path)
+		}
+
+		stream.paths = append(stream.paths, filepath.Join(logsDir, relPath))
+		stream.relPaths = append(stream.relPaths, filepath.ToSlash(relPath))
+	}
+
+	if stream.idleTimeout <= 0 {
+		stream.idleTimeout = DefaultIdleTimeout
+	} else if stream.idleTimeout > MaxIdleTimeout {
+		stream.idleTimeout = MaxIdleTimeout
+	}
+
+	lines := options.Lines
+	if lines > MaxStreamLines {
+		lines = MaxStreamLines
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.streams >= MaxStreams {
+		return nil, ErrTooManyStreams
+	}
+
+	for _, path := range stream.paths {
+		err := stream.tailer.Follow(path, lines)
+		if err != nil {
+			stream.tailer.Close()
+			return nil, bosherr.WrapError(err, "Tailing logs")
+		}
+	}
+
+	s.streams++
+
+	return stream, nil
+}
+
+func (s *concreteStreamer) release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.streams--
+}
+
+func (s *concreteStream) Run(sendFunc SendFunc, stopCh <-chan struct{}) (Summary, error) {
+	defer s.streamer.release()
+	defer s.tailer.Close()
+
+	timeService := s.streamer.timeService
+
+	ticker := timeService.NewTicker(streamReadInterval)
+	defer ticker.Stop()
+
+	startedAt := timeService.Now()
+	writtenAt := startedAt
+
+	var summary Summary
+
+	// Last lines are sent right away
+	_, err := s.send(sendFunc, &summary)
+	if err != nil {
+		return summary, err
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			summary.EndReason = EndReasonStopped
+			return summary, nil
+
+		case <-ticker.C():
+			written, err := s.send(sendFunc, &summary)
+			if err != nil {
+				return summary, err
+			}
+
+			now := timeService.Now()
+
+			if written {
+				writtenAt = now
+			}
+
+			if now.Sub(writtenAt) >= s.idleTimeout {
+				summary.EndReason = EndReasonIdle
+				return summary, nil
+			}
+
+			if now.Sub(startedAt) >= streamMaxDuration {
+				summary.EndReason = EndReasonMaxDuration
+				return summary, nil
+			}
+		}
+	}
+}
+
+// send sends lines written since last read; lines over
+// rate limit are dropped and only their number is sent
+func (s *concreteStream) send(sendFunc SendFunc, summary *Summary) (bool, error) {
+	var written bool
+
+	remainingBytes := streamMaxBytesPerInterval
+	dropping := false
+
+	for i, path := range s.paths {
+		lines, err := s.tailer.Read(path)
+		if err != nil {
+			s.streamer.logger.Warn(streamerLogTag, "Failed to read %s: %s", path, err.Error())
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		written = true
+
+		chunk := Chunk{Path: s.relPaths[i], Lines: []string{}}
+
+		for _, line := range lines {
+			if dropping || len(line)+1 > remainingBytes {
+				dropping = true
+				chunk.DroppedLines++
+				continue
+			}
+
+			remainingBytes -= len(line) + 1
+			chunk.Lines = append(chunk.Lines, line)
+		}
+
+		summary.Lines += len(chunk.Lines)
+		summary.DroppedLines += chunk.DroppedLines
+
+		err = sendFunc(chunk)
+		if err != nil {
+			return written, bosherr.WrapError(err, "Sending log lines")
+		}
+	}
+
+	return written, nil
+}
@@ -0,0 +1,287 @@
+package logtail_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("Streamer", func() {
+	var (
+		baseDir     string
+		dirProvider boshdirs.Provider
+		timeService *fakeclock.FakeClock
+		streamer    Streamer
+
+		lock    sync.Mutex
+		chunks  []Chunk
+		sendErr error
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		baseDir, err = ioutil.TempDir("", "log-streamer")
+		Expect(err).ToNot(HaveOccurred())
+
+		dirProvider = boshdirs.NewProvider(baseDir)
+		timeService = fakeclock.NewFakeClock(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		streamer = NewStreamer(boshsys.NewOsFileSystem(logger), dirProvider, timeService, logger)
+
+		chunks = nil
+		sendErr = nil
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	appendLog := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	sendFunc := func(chunk Chunk) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		chunks = append(chunks, chunk)
+
+		return sendErr
+	}
+
+	sentChunks := func() []Chunk {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return append([]Chunk{}, chunks...)
+	}
+
+	type result struct {
+		summary Summary
+		err     error
+	}
+
+	run := func(stream Stream, stopCh chan struct{}) chan result {
+		resultCh := make(chan result, 1)
+
+		go func() {
+			summary, err := stream.Run(sendFunc, stopCh)
+			resultCh <- result{summary, err}
+		}()
+
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+
+		return resultCh
+	}
+
+	Describe("Open", func() {
+		It("returns error when log type is unknown", func() {
+			_, err := streamer.Open("fake-type", []string{"fake-job/fake-job.log"}, Options{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid log type 'fake-type'"))
+		})
+
+		It("returns error when there are no paths", func() {
+			_, err := streamer.Open("job", []string{}, Options{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Must tail at least one log"))
+		})
+
+		It("returns error when there are too many paths", func() {
+			paths := make([]string, MaxStreamPaths+1)
+			for i := range paths {
+				paths[i] = "fake-job/fake-job.log"
+			}
+
+			_, err := streamer.Open("job", paths, Options{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Cannot tail more than 20 logs at once"))
+		})
+
+		for _, path := range []string{"/etc/shadow", "../bosh/settings.json", "fake-job/../../bosh/settings.json", "."} {
+			path := path
+
+			It("returns error when path is not within logs directory: "+path, func() {
+				_, err := streamer.Open("job", []string{path}, Options{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must be within logs directory"))
+			})
+		}
+
+		It("returns error when log resolves outside of logs directory", func() {
+			Expect(os.MkdirAll(dirProvider.LogsDir(), 0755)).To(Succeed())
+			Expect(os.Symlink(dirProvider.SettingsDir(), filepath.Join(dirProvider.LogsDir(), "fake-job"))).To(Succeed())
+			appendLog(filepath.Join(dirProvider.SettingsDir(), "settings.log"), "fake-secret\n")
+
+			_, err := streamer.Open("job", []string{"fake-job/settings.log"}, Options{Lines: 1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("outside of " + dirProvider.LogsDir()))
+		})
+
+		It("returns error when too many logs are being tailed", func() {
+			var streams []Stream
+
+			for i := 0; i < MaxStreams; i++ {
+				stream, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{})
+				Expect(err).ToNot(HaveOccurred())
+				streams = append(streams, stream)
+			}
+
+			_, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{})
+			Expect(err).To(Equal(ErrTooManyStreams))
+
+			stopCh := make(chan struct{})
+			close(stopCh)
+
+			_, err = streams[0].Run(sendFunc, stopCh)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Run", func() {
+		var (
+			logPath string
+			stopCh  chan struct{}
+		)
+
+		BeforeEach(func() {
+			logPath = filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.log")
+			stopCh = make(chan struct{})
+		})
+
+		It("sends last lines and then lines written to logs", func() {
+			appendLog(logPath, "fake-line-1\nfake-line-2\nfake-line-3\n")
+
+			stream, err := streamer.Open("job", []string{"fake-job/fake-job.log", "fake-job/fake-job.stderr.log"}, Options{Lines: 2})
+			Expect(err).ToNot(HaveOccurred())
+
+			resultCh := run(stream, stopCh)
+
+			Eventually(sentChunks).Should(Equal([]Chunk{
+				{Path: "fake-job/fake-job.log", Lines: []string{"fake-line-2", "fake-line-3"}},
+			}))
+
+			appendLog(logPath, "fake-line-4\n")
+			appendLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stderr.log"), "fake-error\n")
+			timeService.Increment(1 * time.Second)
+
+			Eventually(sentChunks).Should(HaveLen(3))
+			Expect(sentChunks()[1:]).To(Equal([]Chunk{
+				{Path: "fake-job/fake-job.log", Lines: []string{"fake-line-4"}},
+				{Path: "fake-job/fake-job.stderr.log", Lines: []string{"fake-error"}},
+			}))
+
+			close(stopCh)
+
+			var res result
+			Eventually(resultCh).Should(Receive(&res))
+			Expect(res.err).ToNot(HaveOccurred())
+			Expect(res.summary).To(Equal(Summary{Lines: 4, EndReason: EndReasonStopped}))
+		})
+
+		It("tails agent logs", func() {
+			appendLog(filepath.Join(dirProvider.AgentLogsDir(), "current"), "fake-agent-line\n")
+
+			stream, err := streamer.Open("agent", []string{"current"}, Options{Lines: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			run(stream, stopCh)
+			defer close(stopCh)
+
+			Eventually(sentChunks).Should(Equal([]Chunk{
+				{Path: "current", Lines: []string{"fake-agent-line"}},
+			}))
+		})
+
+		It("drops lines written faster than rate limit", func() {
+			line := strings.Repeat("x", 1023)
+			appendLog(logPath, "")
+
+			stream, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{})
+			Expect(err).ToNot(HaveOccurred())
+
+			resultCh := run(stream, stopCh)
+
+			appendLog(logPath, strings.Repeat(line+"\n", 100))
+			timeService.Increment(1 * time.Second)
+
+			Eventually(sentChunks).Should(HaveLen(1))
+			Expect(sentChunks()[0].Lines).To(HaveLen(64))
+			Expect(sentChunks()[0].DroppedLines).To(Equal(36))
+
+			close(stopCh)
+
+			var res result
+			Eventually(resultCh).Should(Receive(&res))
+			Expect(res.summary).To(Equal(Summary{Lines: 64, DroppedLines: 36, EndReason: EndReasonStopped}))
+		})
+
+		It("ends when no lines are written for idle timeout", func() {
+			stream, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{IdleTimeout: 3 * time.Second})
+			Expect(err).ToNot(HaveOccurred())
+
+			resultCh := run(stream, stopCh)
+
+			timeService.Increment(3 * time.Second)
+
+			var res result
+			Eventually(resultCh).Should(Receive(&res))
+			Expect(res.err).ToNot(HaveOccurred())
+			Expect(res.summary.EndReason).To(Equal(EndReasonIdle))
+		})
+
+		It("ends after max duration even if lines are written", func() {
+			appendLog(logPath, "")
+
+			stream, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{})
+			Expect(err).ToNot(HaveOccurred())
+
+			resultCh := run(stream, stopCh)
+
+			appendLog(logPath, "fake-line\n")
+			timeService.Increment(1 * time.Hour)
+
+			var res result
+			Eventually(resultCh).Should(Receive(&res))
+			Expect(res.err).ToNot(HaveOccurred())
+			Expect(res.summary).To(Equal(Summary{Lines: 1, EndReason: EndReasonMaxDuration}))
+		})
+
+		It("returns error when lines cannot be sent", func() {
+			appendLog(logPath, "fake-line\n")
+			sendErr = errors.New("fake-send-err")
+
+			stream, err := streamer.Open("job", []string{"fake-job/fake-job.log"}, Options{Lines: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = stream.Run(sendFunc, stopCh)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-send-err"))
+		})
+	})
+})
@@ -0,0 +1,313 @@
+package logtail
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	// Longer lines are split so that messages stay within
+	// size that syslog servers commonly accept
+	maxLineBytes = 64 * 1024
+
+	tailerReadBytes = 64 * 1024
+)
+
+// Tailer reads lines appended to logs and keeps reading logs
+// after they are rotated either by renaming or by truncating
+type Tailer struct {
+	fs      boshsys.FileSystem
+	logsDir string
+	files   map[string]*tailedFile
+}
+
+type tailedFile struct {
+	file    boshsys.File
+	offset  int64
+	partial []byte
+}
+
+// NewTailer returns tailer that only reads logs within logsDir;
+// logs that are symlinks or resolve outside of it are not opened
+func NewTailer(fs boshsys.FileSystem, logsDir string) *Tailer {
+	return &Tailer{
+		fs:      fs,
+		logsDir: filepath.Clean(logsDir),
+		files:   map[string]*tailedFile{},
+	}
+}
+
+// SkipExisting starts tailing logs in logs directory from their end
+// so that lines written before forwarding started are not sent again
+func (t *Tailer) SkipExisting() error {
+	err := WalkLogsDir(t.fs, t.logsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !IsLog(path) {
+			return nil
+		}
+
+		file, err := t.open(path)
+		if err != nil {
+			return nil
+		}
+
+		t.files[path] = &tailedFile{file: file, offset: info.Size()}
+
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Walking %s", t.logsDir)
+	}
+
+	return nil
+}
+
+// Follow starts tailing log at path from its last lines;
+// logs that do not exist yet are read from their start once created
+func (t *Tailer) Follow(path string, lines int) error {
+	file, err := t.open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening %s", path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return bosherr.WrapErrorf(err, "Checking %s", path)
+	}
+
+	offset := info.Size()
+
+	if lines > 0 {
+		offset, err = LastLinesOffset(file, info.Size(), lines)
+		if err != nil {
+			_ = file.Close()
+			return bosherr.WrapErrorf(err, "Reading %s", path)
+		}
+	}
+
+	t.files[path] = &tailedFile{file: file, offset: offset}
+
+	return nil
+}
+
+// Read returns complete lines written to log since it was last read
+func (t *Tailer) Read(path string) ([]string, error) {
+	var lines []string
+
+	tailed := t.files[path]
+
+	info, err := t.fs.Stat(path)
+	if err != nil {
+		if tailed != nil {
+			lines = append(lines, t.finish(path, tailed)...)
+		}
+
+		if os.IsNotExist(err) {
+			return lines, nil
+		}
+
+		return lines, bosherr.WrapErrorf(err, "Checking %s", path)
+	}
+
+	if tailed != nil {
+		openInfo, err := tailed.file.Stat()
+		if err != nil || !os.SameFile(openInfo, info) {
+			// Log was renamed; lines written before process reopened it are in renamed file
+			lines = append(lines, t.finish(path, tailed)...)
+			tailed = nil
+		}
+	}
+
+	if tailed == nil {
+		file, err := t.open(path)
+		if err != nil {
+			return lines, bosherr.WrapErrorf(err, "Opening %s", path)
+		}
+
+		tailed = &tailedFile{file: file}
+		t.files[path] = tailed
+	}
+
+	// Log was truncated after it was copied
+	if info.Size() < tailed.offset {
+		tailed.offset = 0
+		tailed.partial = nil
+	}
+
+	readLines, err := tailed.readLines()
+	lines = append(lines, readLines...)
+
+	if err != nil {
+		return lines, bosherr.WrapErrorf(err, "Reading %s", path)
+	}
+
+	return lines, nil
+}
+
+func (t *Tailer) Close() {
+	for path, tailed := range t.files {
+		_ = tailed.file.Close()
+		delete(t.files, path)
+	}
+}
+
+// open opens log at path after checking that it resolves within
+// logs directory so that symlinks placed there by jobs cannot
+// be used to read other files on the VM
+func (t *Tailer) open(path string) (boshsys.File, error) {
+	logsDir, err := t.fs.ReadAndFollowLink(t.logsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedPath, err := t.fs.ReadAndFollowLink(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsWithinDir(logsDir, resolvedPath) {
+		return nil, bosherr.Errorf("Log %s resolves to %s outside of %s", path, resolvedPath, t.logsDir)
+	}
+
+	// Log may have been replaced by symlink after it was resolved
+	file, err := t.fs.OpenFile(path, os.O_RDONLY|openNoFollowFlag, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	openInfo, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	resolvedInfo, err := t.fs.Stat(resolvedPath)
+	if err != nil || !os.SameFile(openInfo, resolvedInfo) {
+		_ = file.Close()
+		return nil, bosherr.Errorf("Log %s changed while it was opened", path)
+	}
+
+	return file, nil
+}
+
+// finish reads rest of log that is no longer at its path
+func (t *Tailer) finish(path string, tailed *tailedFile) []string {
+	lines, _ := tailed.readLines()
+
+	if len(tailed.partial) > 0 {
+		lines = append(lines, string(tailed.partial))
+	}
+
+	_ = tailed.file.Close()
+	delete(t.files, path)
+
+	return lines
+}
+
+func (f *tailedFile) readLines() ([]string, error) {
+	var lines []string
+
+	buf := make([]byte, tailerReadBytes)
+
+	for {
+		n, err := f.file.ReadAt(buf, f.offset)
+		f.offset += int64(n)
+		f.partial = append(f.partial, buf[:n]...)
+
+		for {
+			i := bytes.IndexByte(f.partial, '\n')
+			if i < 0 {
+				break
+			}
+
+			lines = append(lines, strings.TrimSuffix(string(f.partial[:i]), "\r"))
+			f.partial = f.partial[i+1:]
+		}
+
+		for len(f.partial) >= maxLineBytes {
+			lines = append(lines, string(f.partial[:maxLineBytes]))
+			f.partial = f.partial[maxLineBytes:]
+		}
+
+		if err == io.EOF || n == 0 {
+			return lines, nil
+		}
+
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// IsLog is true for logs that are written to by jobs
+// as opposed to logs that were already rotated
+func IsLog(path string) bool {
+	return strings.HasSuffix(path, ".log")
+}
+
+// WalkLogsDir walks logs directory like filepath.Walk and passes
+// cleaned paths to walkFunc; logs directory itself is walked even
+// if it is a symlink (e.g. to data disk) but symlinks within it are not followed
+func WalkLogsDir(fs boshsys.FileSystem, logsDir string, walkFunc filepath.WalkFunc) error {
+	root := filepath.Clean(logsDir)
+
+	return fs.Walk(root+string(filepath.Separator), func(path string, info os.FileInfo, err error) error {
+		return walkFunc(filepath.Clean(path), info, err)
+	})
+}
+
+// IsWithinDir is true when path is dir or is located under it;
+// both are expected to be clean absolute paths
+func IsWithinDir(dir, path string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// LastLinesOffset returns offset at which last lines of file start
+func LastLinesOffset(file io.ReaderAt, size int64, lines int) (int64, error) {
+	buf := make([]byte, tailerReadBytes)
+
+	// Last byte never starts a line whether or not it is a newline
+	end := size - 1
+
+	for end > 0 {
+		n := int64(len(buf))
+		if end < n {
+			n = end
+		}
+
+		start := end - n
+
+		_, err := file.ReadAt(buf[:n], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] == '\n' {
+				lines--
+				if lines == 0 {
+					return start + i + 1, nil
+				}
+			}
+		}
+
+		end = start
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,254 @@
+package logtail_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("Tailer", func() {
+	var (
+		logsDir string
+		logPath string
+		tailer  *Tailer
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		logsDir, err = ioutil.TempDir("", "log-tailer")
+		Expect(err).ToNot(HaveOccurred())
+
+		logPath = filepath.Join(logsDir, "fake-job", "fake-job.log")
+		Expect(os.MkdirAll(filepath.Dir(logPath), 0755)).To(Succeed())
+
+		tailer = NewTailer(boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)), logsDir)
+	})
+
+	AfterEach(func() {
+		tailer.Close()
+		Expect(os.RemoveAll(logsDir)).To(Succeed())
+	})
+
+	appendLog := func(path, contents string) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(contents)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	read := func(path string) []string {
+		lines, err := tailer.Read(path)
+		Expect(err).ToNot(HaveOccurred())
+		return lines
+	}
+
+	Describe("SkipExisting", func() {
+		It("reads only lines written to existing logs afterwards", func() {
+			appendLog(logPath, "fake-old-line\n")
+
+			Expect(tailer.SkipExisting()).To(Succeed())
+
+			appendLog(logPath, "fake-new-line\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-new-line"}))
+		})
+	})
+
+	Context("when logs are symlinks", func() {
+		var outsideDir string
+
+		BeforeEach(func() {
+			var err error
+
+			outsideDir, err = ioutil.TempDir("", "log-tailer-outside")
+			Expect(err).ToNot(HaveOccurred())
+
+			appendLog(filepath.Join(outsideDir, "secret.log"), "fake-secret\n")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(outsideDir)).To(Succeed())
+		})
+
+		It("does not follow log that resolves outside of logs directory", func() {
+			Expect(os.Symlink(filepath.Join(outsideDir, "secret.log"), logPath)).To(Succeed())
+
+			err := tailer.Follow(logPath, 1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("outside of " + logsDir))
+		})
+
+		It("does not follow log in directory that resolves outside of logs directory", func() {
+			linkedDir := filepath.Join(logsDir, "fake-linked-job")
+			Expect(os.Symlink(outsideDir, linkedDir)).To(Succeed())
+
+			err := tailer.Follow(filepath.Join(linkedDir, "secret.log"), 1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("outside of " + logsDir))
+		})
+
+		It("does not follow log that is symlink within logs directory", func() {
+			appendLog(logPath+".1", "fake-line\n")
+			Expect(os.Symlink(logPath+".1", logPath)).To(Succeed())
+
+			Expect(tailer.Follow(logPath, 1)).ToNot(Succeed())
+		})
+
+		It("does not read log that is replaced by symlink after it was followed", func() {
+			Expect(tailer.Follow(logPath, 0)).To(Succeed())
+
+			Expect(os.Symlink(filepath.Join(outsideDir, "secret.log"), logPath)).To(Succeed())
+
+			lines, err := tailer.Read(logPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("outside of " + logsDir))
+			Expect(lines).To(BeEmpty())
+		})
+
+		It("skips logs that resolve outside of logs directory", func() {
+			Expect(os.Symlink(filepath.Join(outsideDir, "secret.log"), logPath)).To(Succeed())
+
+			Expect(tailer.SkipExisting()).To(Succeed())
+
+			appendLog(filepath.Join(outsideDir, "secret.log"), "fake-new-secret\n")
+			_, err := tailer.Read(logPath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Follow", func() {
+		It("reads last lines and lines written afterwards", func() {
+			appendLog(logPath, "fake-line-1\nfake-line-2\nfake-line-3\n")
+
+			Expect(tailer.Follow(logPath, 2)).To(Succeed())
+			Expect(read(logPath)).To(Equal([]string{"fake-line-2", "fake-line-3"}))
+
+			appendLog(logPath, "fake-line-4\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-line-4"}))
+		})
+
+		It("reads logs created afterwards from their start", func() {
+			Expect(tailer.Follow(logPath, 0)).To(Succeed())
+			Expect(read(logPath)).To(BeEmpty())
+
+			appendLog(logPath, "fake-line\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-line"}))
+		})
+	})
+
+	Describe("Read", func() {
+		BeforeEach(func() {
+			appendLog(logPath, "")
+			Expect(tailer.Follow(logPath, 0)).To(Succeed())
+		})
+
+		It("reads partial lines once they are complete", func() {
+			appendLog(logPath, "fake-line-1\r\nfake-par")
+			Expect(read(logPath)).To(Equal([]string{"fake-line-1"}))
+
+			appendLog(logPath, "tial\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-partial"}))
+		})
+
+		It("splits long lines", func() {
+			appendLog(logPath, strings.Repeat("x", 64*1024+1)+"\n")
+			Expect(read(logPath)).To(Equal([]string{strings.Repeat("x", 64*1024), "x"}))
+		})
+
+		It("reads rest of renamed log before reading log at its path", func() {
+			appendLog(logPath, "fake-line-1\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-line-1"}))
+
+			Expect(os.Rename(logPath, logPath+".1")).To(Succeed())
+			appendLog(logPath+".1", "fake-line-2\nfake-unfinished")
+			appendLog(logPath, "fake-line-3\n")
+
+			Expect(read(logPath)).To(Equal([]string{"fake-line-2", "fake-unfinished", "fake-line-3"}))
+		})
+
+		It("reads truncated log from its start", func() {
+			appendLog(logPath, "fake-line-1\n")
+			Expect(read(logPath)).To(Equal([]string{"fake-line-1"}))
+
+			Expect(os.Truncate(logPath, 0)).To(Succeed())
+			appendLog(logPath, "fake-2\n")
+
+			Expect(read(logPath)).To(Equal([]string{"fake-2"}))
+		})
+
+		It("reads rest of removed log", func() {
+			appendLog(logPath, "fake-line-1\n")
+			Expect(os.Remove(logPath)).To(Succeed())
+
+			Expect(read(logPath)).To(Equal([]string{"fake-line-1"}))
+			Expect(read(logPath)).To(BeEmpty())
+		})
+	})
+
+	Describe("WalkLogsDir", func() {
+		It("walks logs directory that is symlink and passes cleaned paths", func() {
+			appendLog(logPath, "")
+
+			linkedLogsDir := logsDir + "-link"
+			Expect(os.Symlink(logsDir, linkedLogsDir)).To(Succeed())
+			defer os.Remove(linkedLogsDir)
+
+			var paths []string
+
+			err := WalkLogsDir(boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)), linkedLogsDir+"/", func(path string, info os.FileInfo, err error) error {
+				Expect(err).ToNot(HaveOccurred())
+				paths = append(paths, path)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(paths).To(Equal([]string{
+				linkedLogsDir,
+				filepath.Join(linkedLogsDir, "fake-job"),
+				filepath.Join(linkedLogsDir, "fake-job", "fake-job.log"),
+			}))
+		})
+	})
+
+	Describe("IsWithinDir", func() {
+		It("is true for dir and paths under it", func() {
+			Expect(IsWithinDir("/fake-logs", "/fake-logs")).To(BeTrue())
+			Expect(IsWithinDir("/fake-logs", "/fake-logs/fake-job/fake-job.log")).To(BeTrue())
+			Expect(IsWithinDir("/fake-logs", "/fake-logs/..fake-job.log")).To(BeTrue())
+		})
+
+		It("is false for paths outside of dir", func() {
+			Expect(IsWithinDir("/fake-logs", "/fake-logs-other/fake-job.log")).To(BeFalse())
+			Expect(IsWithinDir("/fake-logs", "/etc/shadow")).To(BeFalse())
+			Expect(IsWithinDir("/fake-logs", "/")).To(BeFalse())
+		})
+	})
+
+	Describe("LastLinesOffset", func() {
+		It("returns offset of last lines", func() {
+			contents := "fake-line-1\nfake-line-2\nfake-line-3"
+
+			offset, err := LastLinesOffset(strings.NewReader(contents), int64(len(contents)), 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents[offset:]).To(Equal("fake-line-2\nfake-line-3"))
+		})
+
+		It("returns start when there are fewer lines", func() {
+			contents := "fake-line-1\n"
+
+			offset, err := LastLinesOffset(strings.NewReader(contents), int64(len(contents)), 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(offset).To(Equal(int64(0)))
+		})
+	})
+})
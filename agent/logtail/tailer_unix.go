@@ -0,0 +1,9 @@
+// +build !windows
+
+package logtail
+
+import (
+	"syscall"
+)
+
+const openNoFollowFlag = syscall.O_NOFOLLOW
@@ -0,0 +1,5 @@
+package logtail
+
+// Windows has no O_NOFOLLOW; logs are still checked
+// to resolve within logs directory before they are opened
+const openNoFollowFlag = 0
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"time"
 
 	"code.cloudfoundry.org/clock"
 
@@ -13,6 +14,10 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+// Pre-stop scripts are expected to finish promptly
+// since they delay draining and stopping jobs
+const preStopScriptTimeout = 10 * time.Minute
+
 type ConcreteJobScriptProvider struct {
 	cmdRunner   boshsys.CmdRunner
 	fs          boshsys.FileSystem
@@ -55,6 +60,15 @@ func (p ConcreteJobScriptProvider) NewDrainScript(jobName string, params boshdra
 	return boshdrain.NewConcreteScript(p.fs, p.cmdRunner, jobName, path, params, p.timeService, p.logger)
 }
 
+func (p ConcreteJobScriptProvider) NewPreStopScript(jobName string, params boshdrain.ScriptParams) CancellableScript {
+	path := path.Join(p.dirProvider.JobBinDir(jobName), "pre-stop"+ScriptExt)
+
+	stdoutLogPath := filepath.Join(p.dirProvider.LogsDir(), jobName, "pre-stop.stdout.log")
+	stderrLogPath := filepath.Join(p.dirProvider.LogsDir(), jobName, "pre-stop.stderr.log")
+
+	return NewPreStopScript(p.fs, p.cmdRunner, jobName, path, params, preStopScriptTimeout, stdoutLogPath, stderrLogPath, p.timeService, p.logger)
+}
+
 func (p ConcreteJobScriptProvider) NewParallelScript(scriptName string, scripts []Script) CancellableScript {
 	return NewParallelScript(scriptName, scripts, p.logger)
 }
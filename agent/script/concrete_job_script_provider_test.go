@@ -57,6 +57,18 @@ var _ = Describe("ConcreteJobScriptProvider", func() {
 		})
 	})
 
+	Describe("NewPreStopScript", func() {
+		It("returns pre-stop script", func() {
+			params := &fakedrain.FakeScriptParams{}
+			script := scriptProvider.NewPreStopScript("foo", params)
+			Expect(script.Tag()).To(Equal("foo"))
+
+			expPath := "/the/base/dir/jobs/foo/bin/pre-stop" + boshscript.ScriptExt
+			Expect(script.Path()).To(boshassert.MatchPath(expPath))
+			Expect(script.(boshscript.PreStopScript).Params()).To(Equal(params))
+		})
+	})
+
 	Describe("NewParallelScript", func() {
 		It("returns parallel script", func() {
 			scripts := []boshscript.Script{&fakescript.FakeScript{}}
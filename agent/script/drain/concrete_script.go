@@ -1,8 +1,10 @@
 package drain
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -12,6 +14,27 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+// Status is reported by drain script that prints a JSON line
+// such as {"wait": -10, "message": "draining connections", "progress": 40}
+// instead of an integer
+type Status struct {
+	Message string `json:"message,omitempty"`
+
+	// Progress is percentage of draining that is done
+	Progress *int `json:"progress,omitempty"`
+}
+
+type jsonOutput struct {
+	Wait     *int   `json:"wait"`
+	Message  string `json:"message"`
+	Progress *int   `json:"progress"`
+}
+
+type scriptStatus struct {
+	lock   sync.RWMutex
+	status Status
+}
+
 type ConcreteScript struct {
 	fs     boshsys.FileSystem
 	runner boshsys.CmdRunner
@@ -25,6 +48,9 @@ type ConcreteScript struct {
 	logger      boshlog.Logger
 
 	cancelCh chan struct{}
+
+	// Shared by copies of the script since Status() is called while it runs
+	status *scriptStatus
 }
 
 func NewConcreteScript(
@@ -50,6 +76,8 @@ func NewConcreteScript(
 		logger: logger,
 
 		cancelCh: make(chan struct{}, 1),
+
+		status: &scriptStatus{},
 	}
 }
 
@@ -75,6 +103,15 @@ func (s ConcreteScript) Run() error {
 	}
 }
 
+// Status returns last status reported by the script;
+// it is empty if script only returned integers
+func (s ConcreteScript) Status() Status {
+	s.status.lock.RLock()
+	defer s.status.lock.RUnlock()
+
+	return s.status.status
+}
+
 func (s ConcreteScript) Cancel() error {
 	select {
 	case s.cancelCh <- struct{}{}:
@@ -147,10 +184,53 @@ func (s ConcreteScript) runOnce(params ScriptParams) (int, error) {
 		return 0, bosherr.WrapError(result.Error, "Running drain script")
 	}
 
-	value, err := strconv.Atoi(strings.TrimSpace(result.Stdout))
-	if err != nil {
+	return s.parseOutput(result.Stdout)
+}
+
+// parseOutput accepts either a signed integer
+// or a JSON line with wait and optional message and progress
+func (s ConcreteScript) parseOutput(stdout string) (int, error) {
+	output := strings.TrimSpace(stdout)
+
+	value, err := strconv.Atoi(output)
+	if err == nil {
+		s.setStatus(Status{})
+		return value, nil
+	}
+
+	// Only last line is considered so that scripts can print other output before it
+	lines := strings.Split(output, "\n")
+	lastLine := strings.TrimSpace(lines[len(lines)-1])
+
+	if !strings.HasPrefix(lastLine, "{") {
 		return 0, bosherr.WrapError(err, "Script did not return a signed integer")
 	}
 
-	return value, nil
+	var jsonOut jsonOutput
+
+	err = json.Unmarshal([]byte(lastLine), &jsonOut)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Unmarshalling drain script JSON output")
+	}
+
+	if jsonOut.Wait == nil {
+		return 0, bosherr.Error("Drain script JSON output must include 'wait'")
+	}
+
+	if jsonOut.Progress != nil && (*jsonOut.Progress < 0 || *jsonOut.Progress > 100) {
+		return 0, bosherr.Errorf("Drain script progress %d must be between 0 and 100", *jsonOut.Progress)
+	}
+
+	s.setStatus(Status{Message: jsonOut.Message, Progress: jsonOut.Progress})
+
+	s.logger.Debug(s.logTag, "Drain script '%s' reported wait %d: %s", s.tag, *jsonOut.Wait, jsonOut.Message)
+
+	return *jsonOut.Wait, nil
+}
+
+func (s ConcreteScript) setStatus(status Status) {
+	s.status.lock.Lock()
+	defer s.status.lock.Unlock()
+
+	s.status.status = status
 }
@@ -183,6 +183,82 @@ var _ = Describe("ConcreteScript", func() {
 			Expect(fakeClock.SleepArgsForCall(1)).To(Equal(0 * time.Second))
 		})
 
+		Context("when script prints JSON line", func() {
+			It("sleeps as long as wait says and reports message and progress", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"wait": -10, "message": "draining 120 connections", "progress": 40}` + "\n"}})
+				runner.AddProcess(jobCheckStatusFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"wait": 0, "message": "drained", "progress": 100}`}})
+
+				var statuses []Status
+
+				fakeClock.SleepStub = func(_ time.Duration) {
+					statuses = append(statuses, script.Status())
+				}
+
+				err := script.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeClock.SleepCallCount()).To(Equal(2))
+				Expect(fakeClock.SleepArgsForCall(0)).To(Equal(10 * time.Second))
+				Expect(fakeClock.SleepArgsForCall(1)).To(Equal(0 * time.Second))
+
+				progress40, progress100 := 40, 100
+				Expect(statuses).To(Equal([]Status{
+					{Message: "draining 120 connections", Progress: &progress40},
+					{Message: "drained", Progress: &progress100},
+				}))
+			})
+
+			It("only considers last line of stdout", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "stopping listener\n{\"wait\": 3, \"message\": \"done\"}\n"}})
+
+				err := script.Run()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeClock.SleepArgsForCall(0)).To(Equal(3 * time.Second))
+				Expect(script.Status()).To(Equal(Status{Message: "done"}))
+			})
+
+			It("clears status when script later returns an integer", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"wait": -5, "message": "draining"}`}})
+				runner.AddProcess(jobCheckStatusFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "0"}})
+
+				err := script.Run()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(script.Status()).To(Equal(Status{}))
+			})
+
+			It("returns error when wait is missing", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"message": "draining"}`}})
+
+				err := script.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Drain script JSON output must include 'wait'"))
+			})
+
+			It("returns error when progress is not a percentage", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"wait": -5, "progress": 140}`}})
+
+				err := script.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Drain script progress 140 must be between 0 and 100"))
+			})
+
+			It("returns error when JSON is invalid", func() {
+				runner.AddProcess(jobChangedFullCommand,
+					&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: `{"wait": -5.5}`}})
+
+				err := script.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unmarshalling drain script JSON output"))
+			})
+		})
+
 		It("returns error with non integer stdout", func() {
 			runner.AddProcess(jobChangedFullCommand,
 				&fakesys.FakeProcess{WaitResult: boshsys.Result{Stdout: "hello!"}})
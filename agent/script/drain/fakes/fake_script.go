@@ -14,6 +14,8 @@ type FakeScript struct {
 	RunError     error
 	RunStub      func() error
 	WasCanceled  bool
+
+	StatusValue drain.Status
 }
 
 func NewFakeScript(tag string) *FakeScript {
@@ -24,6 +26,8 @@ func (s *FakeScript) Tag() string  { return s.tag }
 func (s *FakeScript) Path() string { return "/fake/path" }
 func (s *FakeScript) Exists() bool { return s.ExistsBool }
 
+func (s *FakeScript) Status() drain.Status { return s.StatusValue }
+
 func (s *FakeScript) Cancel() error {
 	s.WasCanceled = true
 	return nil
@@ -27,6 +27,15 @@ type FakeJobScriptProvider struct {
 	newDrainScriptReturns struct {
 		result1 script.CancellableScript
 	}
+	NewPreStopScriptStub        func(jobName string, params boshdrain.ScriptParams) script.CancellableScript
+	newPreStopScriptMutex       sync.RWMutex
+	newPreStopScriptArgsForCall []struct {
+		jobName string
+		params  boshdrain.ScriptParams
+	}
+	newPreStopScriptReturns struct {
+		result1 script.CancellableScript
+	}
 	NewParallelScriptStub        func(scriptName string, scripts []script.Script) script.CancellableScript
 	newParallelScriptMutex       sync.RWMutex
 	newParallelScriptArgsForCall []struct {
@@ -104,6 +113,39 @@ func (fake *FakeJobScriptProvider) NewDrainScriptReturns(result1 script.Cancella
 	}{result1}
 }
 
+func (fake *FakeJobScriptProvider) NewPreStopScript(jobName string, params boshdrain.ScriptParams) script.CancellableScript {
+	fake.newPreStopScriptMutex.Lock()
+	fake.newPreStopScriptArgsForCall = append(fake.newPreStopScriptArgsForCall, struct {
+		jobName string
+		params  boshdrain.ScriptParams
+	}{jobName, params})
+	fake.newPreStopScriptMutex.Unlock()
+	if fake.NewPreStopScriptStub != nil {
+		return fake.NewPreStopScriptStub(jobName, params)
+	} else {
+		return fake.newPreStopScriptReturns.result1
+	}
+}
+
+func (fake *FakeJobScriptProvider) NewPreStopScriptCallCount() int {
+	fake.newPreStopScriptMutex.RLock()
+	defer fake.newPreStopScriptMutex.RUnlock()
+	return len(fake.newPreStopScriptArgsForCall)
+}
+
+func (fake *FakeJobScriptProvider) NewPreStopScriptArgsForCall(i int) (string, boshdrain.ScriptParams) {
+	fake.newPreStopScriptMutex.RLock()
+	defer fake.newPreStopScriptMutex.RUnlock()
+	return fake.newPreStopScriptArgsForCall[i].jobName, fake.newPreStopScriptArgsForCall[i].params
+}
+
+func (fake *FakeJobScriptProvider) NewPreStopScriptReturns(result1 script.CancellableScript) {
+	fake.NewPreStopScriptStub = nil
+	fake.newPreStopScriptReturns = struct {
+		result1 script.CancellableScript
+	}{result1}
+}
+
 func (fake *FakeJobScriptProvider) NewParallelScript(scriptName string, scripts []script.Script) script.CancellableScript {
 	fake.newParallelScriptMutex.Lock()
 	fake.newParallelScriptArgsForCall = append(fake.newParallelScriptArgsForCall, struct {
@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/agent/script"
+)
+
+type FakeResultsScript struct {
+	FakeCancellableScript
+
+	RunWithResultsResults []script.Result
+	RunWithResultsErr     error
+
+	lock                    sync.Mutex
+	runWithResultsCallCount int
+}
+
+func (s *FakeResultsScript) RunWithResults() ([]script.Result, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.runWithResultsCallCount++
+
+	return s.RunWithResultsResults, s.RunWithResultsErr
+}
+
+func (s *FakeResultsScript) RunWithResultsCallCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.runWithResultsCallCount
+}
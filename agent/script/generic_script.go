@@ -1,8 +1,10 @@
 package script
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-agent/agent/script/cmd"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -11,6 +13,10 @@ import (
 const (
 	fileOpenFlag int         = os.O_RDWR | os.O_CREATE | os.O_APPEND
 	fileOpenPerm os.FileMode = os.FileMode(0640)
+
+	// Results include only last lines of output written by single run
+	resultOutputLines     = 20
+	resultOutputMaxLength = 4 * 1024
 )
 
 type GenericScript struct {
@@ -49,19 +55,27 @@ func (s GenericScript) Path() string { return s.path }
 func (s GenericScript) Exists() bool { return s.fs.FileExists(s.path) }
 
 func (s GenericScript) Run() error {
+	_, err := s.RunWithResult()
+	return err
+}
+
+// RunWithResult runs script and returns its exit status and last lines of output
+func (s GenericScript) RunWithResult() (Result, error) {
+	result := Result{Job: s.tag, ExitStatus: -1}
+
 	err := s.ensureContainingDir(s.stdoutLogPath)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	err = s.ensureContainingDir(s.stderrLogPath)
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	stdoutFile, err := s.fs.OpenFile(s.stdoutLogPath, fileOpenFlag, fileOpenPerm)
 	if err != nil {
-		return err
+		return result, err
 	}
 	defer func() {
 		_ = stdoutFile.Close()
@@ -69,22 +83,70 @@ func (s GenericScript) Run() error {
 
 	stderrFile, err := s.fs.OpenFile(s.stderrLogPath, fileOpenFlag, fileOpenPerm)
 	if err != nil {
-		return err
+		return result, err
 	}
 	defer func() {
 		_ = stderrFile.Close()
 	}()
 
+	// Log files keep output of previous runs
+	stdoutOffset := fileSize(stdoutFile)
+	stderrOffset := fileSize(stderrFile)
+
 	command := cmd.BuildCommand(s.path)
 	command.Stdout = stdoutFile
 	command.Stderr = stderrFile
 
-	_, _, _, err = s.runner.RunComplexCommand(command)
+	_, _, result.ExitStatus, err = s.runner.RunComplexCommand(command)
 
-	return err
+	result.Stdout = lastLines(stdoutFile, stdoutOffset)
+	result.Stderr = lastLines(stderrFile, stderrOffset)
+
+	return result, err
 }
 
 func (s GenericScript) ensureContainingDir(fullLogFilename string) error {
 	dir, _ := filepath.Split(fullLogFilename)
 	return s.fs.MkdirAll(dir, os.FileMode(0750))
 }
+
+func fileSize(file boshsys.File) int64 {
+	stat, err := file.Stat()
+	if err != nil {
+		return 0
+	}
+
+	return stat.Size()
+}
+
+// lastLines returns last lines of file written after offset;
+// output is only informational so failing to read it is ignored
+func lastLines(file boshsys.File, offset int64) string {
+	size := fileSize(file)
+
+	if size-offset > resultOutputMaxLength {
+		offset = size - resultOutputMaxLength
+	}
+
+	if offset >= size {
+		return ""
+	}
+
+	data := make([]byte, size-offset)
+
+	n, err := file.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+
+	if n < len(data) {
+		data = data[:n]
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > resultOutputLines {
+		lines = lines[len(lines)-resultOutputLines:]
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -2,13 +2,19 @@ package script_test
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshenv "github.com/cloudfoundry/bosh-agent/agent/script/pathenv"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	"runtime"
 )
@@ -148,4 +154,67 @@ var _ = Describe("GenericScript", func() {
 			})
 		})
 	})
+
+	Describe("RunWithResult", func() {
+		var (
+			logsDir string
+			osFs    boshsys.FileSystem
+		)
+
+		BeforeEach(func() {
+			var err error
+
+			logsDir, err = ioutil.TempDir("", "generic-script")
+			Expect(err).ToNot(HaveOccurred())
+
+			osFs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+			stdoutLogPath = filepath.Join(logsDir, "fake-job", "run-me.stdout.log")
+			stderrLogPath = filepath.Join(logsDir, "fake-job", "run-me.stderr.log")
+
+			genericScript = boshscript.NewScript(osFs, cmdRunner, "my-tag", "/path-to-script", stdoutLogPath, stderrLogPath)
+
+			Expect(osFs.WriteFileString(stdoutLogPath, "previous-stdout\n")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(logsDir)).To(Succeed())
+		})
+
+		It("returns exit status and last lines of output written by the run", func() {
+			var lines []string
+			for i := 1; i <= 25; i++ {
+				lines = append(lines, fmt.Sprintf("line-%d", i))
+			}
+
+			cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{
+				Stdout:     strings.Join(lines, "\n") + "\n",
+				Stderr:     "fake-stderr\n",
+				ExitStatus: 2,
+				Error:      errors.New("fake-command-error"),
+			})
+
+			result, err := genericScript.RunWithResult()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-command-error"))
+
+			Expect(result).To(Equal(boshscript.Result{
+				Job:        "my-tag",
+				ExitStatus: 2,
+				Stdout:     strings.Join(lines[5:], "\n"),
+				Stderr:     "fake-stderr",
+			}))
+		})
+
+		It("does not include output of previous runs", func() {
+			cmdRunner.AddCmdResult(fullCommand, fakesys.FakeCmdResult{Stdout: "fake-stdout\n"})
+
+			result, err := genericScript.RunWithResult()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Stdout).To(Equal("fake-stdout"))
+
+			stdout, err := osFs.ReadFileString(stdoutLogPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdout).To(Equal("previous-stdout\nfake-stdout\n"))
+		})
+	})
 })
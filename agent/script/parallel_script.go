@@ -2,6 +2,7 @@ package script
 
 import (
 	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -16,7 +17,9 @@ type ParallelScript struct {
 }
 
 type scriptResult struct {
+	Index  int
 	Script Script
+	Result Result
 	Error  error
 }
 
@@ -35,19 +38,28 @@ func (s ParallelScript) Path() string { return "" }
 func (s ParallelScript) Exists() bool { return true }
 
 func (s ParallelScript) Run() error {
+	_, err := s.RunWithResults()
+	return err
+}
+
+// RunWithResults runs existing scripts in parallel and returns result
+// of each script in the order of scripts; error summarizes failed scripts
+func (s ParallelScript) RunWithResults() ([]Result, error) {
 	existingScripts := s.findExistingScripts(s.allScripts)
 
 	s.logger.Info(s.logTag, "Will run %d %s scripts in parallel", len(existingScripts), s.name)
 
 	resultsChan := make(chan scriptResult)
 
-	for _, script := range existingScripts {
-		script := script
-		go func() { resultsChan <- scriptResult{script, script.Run()} }()
+	for i, script := range existingScripts {
+		i, script := i, script
+		go func() { resultsChan <- s.runScript(i, script) }()
 	}
 
 	var failedScripts, passedScripts []string
 
+	results := make([]Result, len(existingScripts))
+
 	for i := 0; i < len(existingScripts); i++ {
 		select {
 		case r := <-resultsChan:
@@ -60,10 +72,12 @@ func (s ParallelScript) Run() error {
 				failedScripts = append(failedScripts, jobName)
 				s.logger.Error(s.logTag, "'%s' script has failed with error: %s", r.Script.Path(), r.Error)
 			}
+
+			results[r.Index] = r.Result
 		}
 	}
 
-	return s.summarizeErrs(passedScripts, failedScripts)
+	return results, s.summarizeErrs(passedScripts, failedScripts)
 }
 
 func (s ParallelScript) Cancel() error {
@@ -82,6 +96,35 @@ func (s ParallelScript) Cancel() error {
 	return nil
 }
 
+// runScript includes output and exit status in result when script reports them
+func (s ParallelScript) runScript(index int, script Script) scriptResult {
+	var (
+		result Result
+		err    error
+	)
+
+	startedAt := time.Now()
+
+	if resultScript, ok := script.(ResultScript); ok {
+		result, err = resultScript.RunWithResult()
+	} else {
+		err = script.Run()
+
+		if err != nil {
+			result.ExitStatus = -1
+		}
+	}
+
+	result.Job = script.Tag()
+	result.Duration = time.Since(startedAt).Seconds()
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	return scriptResult{Index: index, Script: script, Result: result, Error: err}
+}
+
 func (s ParallelScript) findExistingScripts(all []Script) []Script {
 	var existing []Script
 
@@ -206,6 +206,65 @@ var _ = Describe("ParallelScript", func() {
 		})
 	})
 
+	Describe("RunWithResults", func() {
+		var (
+			resultScript *fakeResultScript
+			plainScript  *fakescript.FakeScript
+		)
+
+		BeforeEach(func() {
+			resultScript = &fakeResultScript{FakeScript: &fakescript.FakeScript{}}
+			resultScript.TagReturns("fake-job-1")
+			resultScript.ExistsReturns(true)
+			resultScript.result = boshscript.Result{ExitStatus: 3, Stdout: "fake-stdout", Stderr: "fake-stderr"}
+			resultScript.err = errors.New("fake-error")
+
+			plainScript = &fakescript.FakeScript{}
+			plainScript.TagReturns("fake-job-2")
+			plainScript.ExistsReturns(true)
+
+			nonExistingScript := &fakescript.FakeScript{}
+			nonExistingScript.ExistsReturns(false)
+
+			scripts = append(scripts, resultScript, nonExistingScript, plainScript)
+		})
+
+		It("returns result of each existing script in order of scripts", func() {
+			results, err := parallelScript.RunWithResults()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("1 of 2 run-me scripts failed. Failed Jobs: fake-job-1. Successful Jobs: fake-job-2."))
+
+			Expect(results).To(HaveLen(2))
+
+			Expect(results[0].Job).To(Equal("fake-job-1"))
+			Expect(results[0].ExitStatus).To(Equal(3))
+			Expect(results[0].Stdout).To(Equal("fake-stdout"))
+			Expect(results[0].Stderr).To(Equal("fake-stderr"))
+			Expect(results[0].Error).To(Equal("fake-error"))
+			Expect(results[0].Duration).To(BeNumerically(">=", 0))
+
+			Expect(results[1]).To(Equal(boshscript.Result{Job: "fake-job-2", Duration: results[1].Duration}))
+		})
+
+		It("records duration of each script", func() {
+			plainScript.RunStub = func() error {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			}
+
+			results, _ := parallelScript.RunWithResults()
+			Expect(results[1].Duration).To(BeNumerically(">=", 0.1))
+		})
+
+		It("reports failure of scripts that do not report results", func() {
+			plainScript.RunReturns(errors.New("fake-plain-error"))
+
+			results, _ := parallelScript.RunWithResults()
+			Expect(results[1].ExitStatus).To(Equal(-1))
+			Expect(results[1].Error).To(Equal("fake-plain-error"))
+		})
+	})
+
 	Describe("Cancel", func() {
 		Context("when there are no scripts", func() {
 			BeforeEach(func() {
@@ -275,3 +334,14 @@ var _ = Describe("ParallelScript", func() {
 
 	})
 })
+
+type fakeResultScript struct {
+	*fakescript.FakeScript
+
+	result boshscript.Result
+	err    error
+}
+
+func (s *fakeResultScript) RunWithResult() (boshscript.Result, error) {
+	return s.result, s.err
+}
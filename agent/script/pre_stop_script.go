@@ -0,0 +1,174 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/bosh-agent/agent/script/cmd"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// PreStopScript is run before job is drained or stopped.
+// Unlike drain script it succeeds only when it exits with 0;
+// context of the stop is passed through environment variables.
+type PreStopScript struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+
+	tag     string
+	path    string
+	params  boshdrain.ScriptParams
+	timeout time.Duration
+
+	stdoutLogPath string
+	stderrLogPath string
+
+	timeService clock.Clock
+	logTag      string
+	logger      boshlog.Logger
+
+	cancelCh chan struct{}
+}
+
+func NewPreStopScript(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	tag string,
+	path string,
+	params boshdrain.ScriptParams,
+	timeout time.Duration,
+	stdoutLogPath string,
+	stderrLogPath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) PreStopScript {
+	return PreStopScript{
+		fs:     fs,
+		runner: runner,
+
+		tag:     tag,
+		path:    path,
+		params:  params,
+		timeout: timeout,
+
+		stdoutLogPath: stdoutLogPath,
+		stderrLogPath: stderrLogPath,
+
+		timeService: timeService,
+
+		logTag: "PreStopScript",
+		logger: logger,
+
+		cancelCh: make(chan struct{}, 1),
+	}
+}
+
+func (s PreStopScript) Tag() string                    { return s.tag }
+func (s PreStopScript) Path() string                   { return s.path }
+func (s PreStopScript) Params() boshdrain.ScriptParams { return s.params }
+func (s PreStopScript) Exists() bool                   { return s.fs.FileExists(s.path) }
+
+func (s PreStopScript) Run() error {
+	command, err := s.buildCommand()
+	if err != nil {
+		return err
+	}
+
+	for _, logPath := range []string{s.stdoutLogPath, s.stderrLogPath} {
+		err = s.fs.MkdirAll(filepath.Dir(logPath), os.FileMode(0750))
+		if err != nil {
+			return bosherr.WrapError(err, "Creating pre-stop log dir")
+		}
+	}
+
+	stdoutFile, err := s.fs.OpenFile(s.stdoutLogPath, fileOpenFlag, fileOpenPerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening pre-stop stdout log")
+	}
+	defer func() {
+		_ = stdoutFile.Close()
+	}()
+
+	stderrFile, err := s.fs.OpenFile(s.stderrLogPath, fileOpenFlag, fileOpenPerm)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening pre-stop stderr log")
+	}
+	defer func() {
+		_ = stderrFile.Close()
+	}()
+
+	command.Stdout = stdoutFile
+	command.Stderr = stderrFile
+
+	timer := s.timeService.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	process, err := s.runner.RunComplexCommandAsync(command)
+	if err != nil {
+		return bosherr.WrapError(err, "Running pre-stop script")
+	}
+
+	waitResult := WaitOrTerminate(process, timer.C(), s.cancelCh, s.logTag, s.logger)
+	result := waitResult.Result
+
+	switch {
+	case waitResult.TimedOut:
+		return bosherr.Errorf("Pre-stop script timed out after %s", s.timeout)
+
+	case waitResult.Canceled:
+		return bosherr.Error("Script was cancelled by user request")
+
+	case result.Error != nil && result.ExitStatus == -1:
+		return bosherr.WrapError(result.Error, "Running pre-stop script")
+
+	case result.ExitStatus != 0:
+		return bosherr.Errorf("Pre-stop script exited with %d", result.ExitStatus)
+	}
+
+	return nil
+}
+
+func (s PreStopScript) Cancel() error {
+	select {
+	case s.cancelCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// buildCommand passes same context as drain script arguments
+// and BOSH_JOB_STATE/BOSH_JOB_NEXT_STATE variables do
+func (s PreStopScript) buildCommand() (boshsys.Command, error) {
+	command := cmd.BuildCommand(s.path)
+
+	command.Env["BOSH_JOB_CHANGE"] = s.params.JobChange()
+	command.Env["BOSH_HASH_CHANGE"] = s.params.HashChange()
+	command.Env["BOSH_UPDATED_PACKAGES"] = strings.Join(s.params.UpdatedPackages(), " ")
+
+	jobState, err := s.params.JobState()
+	if err != nil {
+		return command, bosherr.WrapError(err, "Getting job state")
+	}
+
+	if jobState != "" {
+		command.Env["BOSH_JOB_STATE"] = jobState
+	}
+
+	jobNextState, err := s.params.JobNextState()
+	if err != nil {
+		return command, bosherr.WrapError(err, "Getting job next state")
+	}
+
+	if jobNextState != "" {
+		command.Env["BOSH_JOB_NEXT_STATE"] = jobNextState
+	}
+
+	return command, nil
+}
@@ -0,0 +1,182 @@
+package script_test
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	fakedrain "github.com/cloudfoundry/bosh-agent/agent/script/drain/fakes"
+	boshenv "github.com/cloudfoundry/bosh-agent/agent/script/pathenv"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("PreStopScript", func() {
+	var (
+		fs            *fakesys.FakeFileSystem
+		runner        *fakesys.FakeCmdRunner
+		params        *fakedrain.FakeScriptParams
+		timeService   *fakeclock.FakeClock
+		stdoutLogPath string
+		stderrLogPath string
+		script        boshscript.PreStopScript
+		fullCommand   string
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		params = &fakedrain.FakeScriptParams{}
+		params.JobChangeReturns("job_changed")
+		params.HashChangeReturns("hash_changed")
+		params.UpdatedPackagesReturns([]string{"foo", "bar"})
+		params.JobStateReturns("fake-job-state", nil)
+		params.JobNextStateReturns("fake-job-next-state", nil)
+
+		stdoutLogPath = filepath.Join("base", "logdir", "pre-stop.stdout.log")
+		stderrLogPath = filepath.Join("base", "logdir", "pre-stop.stderr.log")
+
+		if runtime.GOOS == "windows" {
+			fullCommand = "powershell /fake/pre-stop"
+		} else {
+			fullCommand = "/fake/pre-stop"
+		}
+	})
+
+	JustBeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		script = boshscript.NewPreStopScript(fs, runner, "my-tag", "/fake/pre-stop", params, 30*time.Second, stdoutLogPath, stderrLogPath, timeService, logger)
+	})
+
+	Describe("Tag", func() {
+		It("returns tag", func() {
+			Expect(script.Tag()).To(Equal("my-tag"))
+		})
+	})
+
+	Describe("Path", func() {
+		It("returns path", func() {
+			Expect(script.Path()).To(Equal("/fake/pre-stop"))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("returns bool", func() {
+			Expect(script.Exists()).To(BeFalse())
+
+			fs.WriteFile("/fake/pre-stop", []byte{})
+			Expect(script.Exists()).To(BeTrue())
+		})
+	})
+
+	Describe("Run", func() {
+		It("passes drain context through environment variables", func() {
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+			Expect(script.Run()).To(Succeed())
+
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+			Expect(runner.RunComplexCommands[0].Env).To(Equal(map[string]string{
+				"PATH":                  boshenv.Path(),
+				"BOSH_JOB_CHANGE":       "job_changed",
+				"BOSH_HASH_CHANGE":      "hash_changed",
+				"BOSH_UPDATED_PACKAGES": "foo bar",
+				"BOSH_JOB_STATE":        "fake-job-state",
+				"BOSH_JOB_NEXT_STATE":   "fake-job-next-state",
+			}))
+		})
+
+		It("does not set job state variables when states are not known", func() {
+			params.JobStateReturns("", nil)
+			params.JobNextStateReturns("", nil)
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+			Expect(script.Run()).To(Succeed())
+
+			Expect(runner.RunComplexCommands[0].Env).ToNot(HaveKey("BOSH_JOB_STATE"))
+			Expect(runner.RunComplexCommands[0].Env).ToNot(HaveKey("BOSH_JOB_NEXT_STATE"))
+		})
+
+		It("writes output to log files", func() {
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{})
+
+			Expect(script.Run()).To(Succeed())
+
+			Expect(runner.RunComplexCommands[0].Stdout.(boshsys.File).Name()).To(Equal(stdoutLogPath))
+			Expect(runner.RunComplexCommands[0].Stderr.(boshsys.File).Name()).To(Equal(stderrLogPath))
+		})
+
+		It("returns error when script exits with non-0 exit code", func() {
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 1, Error: errors.New("fake-exit-error")},
+			})
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Pre-stop script exited with 1"))
+		})
+
+		It("returns error when script fails to execute", func() {
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: -1, Error: errors.New("fake-run-error")},
+			})
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+		})
+
+		It("returns error when job state cannot be determined", func() {
+			params.JobStateReturns("", errors.New("fake-state-error"))
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-state-error"))
+			Expect(runner.RunComplexCommands).To(BeEmpty())
+		})
+
+		It("terminates script and returns error when it times out", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			}
+			runner.AddProcess(fullCommand, process)
+
+			errCh := make(chan error, 1)
+			go func() { errCh <- script.Run() }()
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(30 * time.Second)
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Pre-stop script timed out after 30s"))
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+
+		It("terminates script when it is cancelled", func() {
+			runner.AddProcess(fullCommand, &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			})
+
+			Expect(script.Cancel()).To(Succeed())
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Script was cancelled by user request"))
+		})
+	})
+})
@@ -9,6 +9,7 @@ import (
 type JobScriptProvider interface {
 	NewScript(jobName string, scriptName string) Script
 	NewDrainScript(jobName string, params boshdrain.ScriptParams) CancellableScript
+	NewPreStopScript(jobName string, params boshdrain.ScriptParams) CancellableScript
 	NewParallelScript(scriptName string, scripts []Script) CancellableScript
 }
 
@@ -26,3 +27,37 @@ type CancellableScript interface {
 	Script
 	Cancel() error
 }
+
+// StatusScript is a drain script that reports status of draining while it runs
+type StatusScript interface {
+	Script
+	Status() boshdrain.Status
+}
+
+// Result describes how script of a single job ran
+type Result struct {
+	Job        string `json:"job"`
+	ExitStatus int    `json:"exit_code"`
+
+	// Duration is number of seconds script ran for
+	Duration float64 `json:"duration"`
+
+	// Last lines of output written by the script
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ResultScript is a script that reports its exit status and output
+type ResultScript interface {
+	Script
+	RunWithResult() (Result, error)
+}
+
+// ResultsScript is a script that runs scripts of multiple jobs
+// and reports result of each of them
+type ResultsScript interface {
+	CancellableScript
+	RunWithResults() ([]Result, error)
+}
@@ -0,0 +1,55 @@
+package script
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Processes are killed if they do not exit this long after being terminated
+const terminateKillTimeout = 10 * time.Second
+
+// WaitResult describes how process waited on with WaitOrTerminate ended
+type WaitResult struct {
+	Result   boshsys.Result
+	TimedOut bool
+	Canceled bool
+}
+
+// WaitOrTerminate waits until process exits and terminates it once timeoutCh
+// or cancelCh receives; process is still waited on after being terminated.
+// Nil timeoutCh waits without timeout.
+func WaitOrTerminate(
+	process boshsys.Process,
+	timeoutCh <-chan time.Time,
+	cancelCh <-chan struct{},
+	logTag string,
+	logger boshlog.Logger,
+) WaitResult {
+	var result WaitResult
+
+	// Can only wait once on a process but cancelling can happen multiple times
+	for processExitedCh := process.Wait(); processExitedCh != nil; {
+		select {
+		case result.Result = <-processExitedCh:
+			processExitedCh = nil
+		case <-timeoutCh:
+			result.TimedOut = true
+			terminate(process, logTag, logger)
+		case <-cancelCh:
+			result.Canceled = true
+			terminate(process, logTag, logger)
+		}
+	}
+
+	return result
+}
+
+func terminate(process boshsys.Process, logTag string, logger boshlog.Logger) {
+	// Ignore possible TerminateNicely error since caller waits for process to exit anyway
+	err := process.TerminateNicely(terminateKillTimeout)
+	if err != nil {
+		logger.Error(logTag, "Failed to terminate %s", err.Error())
+	}
+}
@@ -0,0 +1,75 @@
+package script_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("WaitOrTerminate", func() {
+	var (
+		process   *fakesys.FakeProcess
+		timeoutCh chan time.Time
+		cancelCh  chan struct{}
+		logger    boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		process = &fakesys.FakeProcess{}
+		timeoutCh = make(chan time.Time, 1)
+		cancelCh = make(chan struct{}, 1)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	exitOnTerminate := func() {
+		process.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+			p.WaitCh <- boshsys.Result{ExitStatus: 143}
+		}
+	}
+
+	It("returns result of process that exits", func() {
+		process.WaitResult = boshsys.Result{ExitStatus: 1}
+
+		result := boshscript.WaitOrTerminate(process, timeoutCh, cancelCh, "fake-log-tag", logger)
+		Expect(result).To(Equal(boshscript.WaitResult{Result: boshsys.Result{ExitStatus: 1}}))
+		Expect(process.TerminatedNicely).To(BeFalse())
+	})
+
+	It("terminates process and waits for it to exit when it times out", func() {
+		exitOnTerminate()
+		timeoutCh <- time.Now()
+
+		result := boshscript.WaitOrTerminate(process, timeoutCh, cancelCh, "fake-log-tag", logger)
+		Expect(result).To(Equal(boshscript.WaitResult{Result: boshsys.Result{ExitStatus: 143}, TimedOut: true}))
+		Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+	})
+
+	It("terminates process and waits for it to exit when it is canceled", func() {
+		exitOnTerminate()
+		cancelCh <- struct{}{}
+
+		result := boshscript.WaitOrTerminate(process, nil, cancelCh, "fake-log-tag", logger)
+		Expect(result).To(Equal(boshscript.WaitResult{Result: boshsys.Result{ExitStatus: 143}, Canceled: true}))
+	})
+
+	It("keeps waiting for process to exit when it cannot be terminated", func() {
+		process.TerminateNicelyErr = errors.New("fake-terminate-err")
+		process.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				p.WaitCh <- boshsys.Result{ExitStatus: 0}
+			}()
+		}
+		cancelCh <- struct{}{}
+
+		result := boshscript.WaitOrTerminate(process, nil, cancelCh, "fake-log-tag", logger)
+		Expect(result).To(Equal(boshscript.WaitResult{Canceled: true}))
+	})
+})
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
@@ -100,14 +101,20 @@ func (service asyncTaskService) processTasks() {
 	for {
 		task := <-service.taskChan
 
+		logger := agentlogger.WithFields(service.logger, agentlogger.Fields{"task_id": task.ID})
+
 		value, err := task.Func()
+
+		// Value of failed task may describe the failure
+		task.Value = value
+
 		if err != nil {
 			task.Error = err
 			task.State = StateFailed
-			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
+			logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
 		} else {
-			task.Value = value
 			task.State = StateDone
+			logger.Debug("Task Service", "Finished processing task #%s", task.ID)
 		}
 
 		if task.EndFunc != nil {
@@ -118,6 +125,8 @@ func (service asyncTaskService) processTasks() {
 		task.Func = nil
 		task.CancelFunc = nil
 		task.EndFunc = nil
+		task.OutputFunc = nil
+		task.ProgressFunc = nil
 
 		service.taskSem <- func() {
 			service.currentTasks[task.ID] = task
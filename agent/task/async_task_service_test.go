@@ -1,6 +1,7 @@
 package task_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"time"
@@ -9,6 +10,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/task"
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
@@ -60,6 +62,32 @@ func init() {
 				Expect(task.Error).To(Equal(err))
 			})
 
+			It("keeps value describing failure of a failing task", func() {
+				err := errors.New("fake-error")
+				runFunc := func() (interface{}, error) { return "fake-failure-value", err }
+
+				task, createErr := service.CreateTask(runFunc, nil, nil)
+				Expect(createErr).ToNot(HaveOccurred())
+
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.State).To(BeEquivalentTo(StateFailed))
+				Expect(task.Value).To(Equal("fake-failure-value"))
+				Expect(task.Error).To(Equal(err))
+			})
+
+			It("logs failing task with its id", func() {
+				outBuf := new(bytes.Buffer)
+				service = NewAsyncTaskService(uuidGen, agentlogger.NewJSONLogger(boshlog.LevelError, outBuf))
+
+				runFunc := func() (interface{}, error) { return nil, errors.New("fake-error") }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				startAndWaitForTaskCompletion(task)
+
+				Expect(outBuf.String()).To(ContainSubstring(`"task_id":"fake-task-id"`))
+				Expect(outBuf.String()).To(ContainSubstring("fake-error"))
+			})
+
 			It("sets task Func, CancelFunc and EndFunc to nil on a successful task", func() {
 				runFunc := func() (interface{}, error) { return nil, nil }
 				cancelFunc := func(_ Task) error { return nil }
@@ -88,6 +116,30 @@ func init() {
 				Expect(task.EndFunc).To(BeNil())
 			})
 
+			It("sets task OutputFunc to nil once task finishes", func() {
+				runFunc := func() (interface{}, error) { return nil, nil }
+
+				task, createErr := service.CreateTask(runFunc, nil, nil)
+				Expect(createErr).ToNot(HaveOccurred())
+
+				task.OutputFunc = func(_ OutputOffsets) (Output, error) { return Output{}, nil }
+
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.OutputFunc).To(BeNil())
+			})
+
+			It("sets task ProgressFunc to nil once task finishes", func() {
+				runFunc := func() (interface{}, error) { return nil, nil }
+
+				task, createErr := service.CreateTask(runFunc, nil, nil)
+				Expect(createErr).ToNot(HaveOccurred())
+
+				task.ProgressFunc = func() interface{} { return "fake-progress" }
+
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.ProgressFunc).To(BeNil())
+			})
+
 			Describe("CreateTask", func() {
 				It("can run task created with CreateTask which does not have end func", func() {
 					ranFunc := false
@@ -9,6 +9,7 @@ type Info struct {
 	TaskID  string
 	Method  string
 	Payload []byte
+	Caller  string
 }
 
 type ManagerProvider interface {
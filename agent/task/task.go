@@ -6,6 +6,13 @@ type CancelFunc func(task Task) error
 
 type EndFunc func(task Task)
 
+// OutputFunc returns output written by running task after given offsets
+type OutputFunc func(since OutputOffsets) (Output, error)
+
+// ProgressFunc returns progress reported by running task;
+// nil means that task has not reported any progress yet
+type ProgressFunc func() interface{}
+
 type State string
 
 const (
@@ -23,6 +30,12 @@ type Task struct {
 	Func       Func
 	CancelFunc CancelFunc
 	EndFunc    EndFunc
+
+	// OutputFunc is only set for tasks whose output can be read while they run
+	OutputFunc OutputFunc
+
+	// ProgressFunc is only set for tasks that report progress while they run
+	ProgressFunc ProgressFunc
 }
 
 func (t Task) Cancel() error {
@@ -33,6 +46,23 @@ func (t Task) Cancel() error {
 }
 
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string  `json:"agent_task_id"`
+	State       State   `json:"state"`
+	Output      *Output `json:"output,omitempty"`
+
+	Progress interface{} `json:"progress,omitempty"`
+}
+
+// OutputOffsets are byte offsets in stdout and stderr of task
+type OutputOffsets struct {
+	Stdout int64 `json:"stdout"`
+	Stderr int64 `json:"stderr"`
+}
+
+// Output holds output written since requested offsets;
+// Offsets are where the next read should start
+type Output struct {
+	Stdout  string        `json:"stdout"`
+	Stderr  string        `json:"stderr"`
+	Offsets OutputOffsets `json:"offsets"`
 }
@@ -9,6 +9,9 @@ type AgentClient interface {
 	Stop() error
 	Apply(applyspec.ApplySpec) error
 	Start() error
+	StartJob(jobName string) error
+	StopJob(jobName string, drain bool) error
+	RestartJob(jobName string, drain bool) error
 	GetState() (AgentState, error)
 	MountDisk(string) error
 	UnmountDisk(string) error
@@ -36,6 +36,32 @@ type FakeAgentClient struct {
 	startReturns     struct {
 		result1 error
 	}
+	StartJobStub        func(jobName string) error
+	startJobMutex       sync.RWMutex
+	startJobArgsForCall []struct {
+		jobName string
+	}
+	startJobReturns struct {
+		result1 error
+	}
+	StopJobStub        func(jobName string, drain bool) error
+	stopJobMutex       sync.RWMutex
+	stopJobArgsForCall []struct {
+		jobName string
+		drain   bool
+	}
+	stopJobReturns struct {
+		result1 error
+	}
+	RestartJobStub        func(jobName string, drain bool) error
+	restartJobMutex       sync.RWMutex
+	restartJobArgsForCall []struct {
+		jobName string
+		drain   bool
+	}
+	restartJobReturns struct {
+		result1 error
+	}
 	GetStateStub        func() (agentclient.AgentState, error)
 	getStateMutex       sync.RWMutex
 	getStateArgsForCall []struct{}
@@ -213,6 +239,12 @@ func (fake *FakeAgentClient) Start() error {
 func (fake *FakeAgentClient) StartCallCount() int {
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
 	return len(fake.startArgsForCall)
 }
 
@@ -223,6 +255,107 @@ func (fake *FakeAgentClient) StartReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) StartJob(jobName string) error {
+	fake.startJobMutex.Lock()
+	fake.startJobArgsForCall = append(fake.startJobArgsForCall, struct {
+		jobName string
+	}{jobName})
+	fake.recordInvocation("StartJob", []interface{}{jobName})
+	fake.startJobMutex.Unlock()
+	if fake.StartJobStub != nil {
+		return fake.StartJobStub(jobName)
+	} else {
+		return fake.startJobReturns.result1
+	}
+}
+
+func (fake *FakeAgentClient) StartJobCallCount() int {
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	return len(fake.startJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) StartJobArgsForCall(i int) string {
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	return fake.startJobArgsForCall[i].jobName
+}
+
+func (fake *FakeAgentClient) StartJobReturns(result1 error) {
+	fake.StartJobStub = nil
+	fake.startJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) StopJob(jobName string, drain bool) error {
+	fake.stopJobMutex.Lock()
+	fake.stopJobArgsForCall = append(fake.stopJobArgsForCall, struct {
+		jobName string
+		drain   bool
+	}{jobName, drain})
+	fake.recordInvocation("StopJob", []interface{}{jobName, drain})
+	fake.stopJobMutex.Unlock()
+	if fake.StopJobStub != nil {
+		return fake.StopJobStub(jobName, drain)
+	} else {
+		return fake.stopJobReturns.result1
+	}
+}
+
+func (fake *FakeAgentClient) StopJobCallCount() int {
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
+	return len(fake.stopJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) StopJobArgsForCall(i int) (string, bool) {
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
+	return fake.stopJobArgsForCall[i].jobName, fake.stopJobArgsForCall[i].drain
+}
+
+func (fake *FakeAgentClient) StopJobReturns(result1 error) {
+	fake.StopJobStub = nil
+	fake.stopJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) RestartJob(jobName string, drain bool) error {
+	fake.restartJobMutex.Lock()
+	fake.restartJobArgsForCall = append(fake.restartJobArgsForCall, struct {
+		jobName string
+		drain   bool
+	}{jobName, drain})
+	fake.recordInvocation("RestartJob", []interface{}{jobName, drain})
+	fake.restartJobMutex.Unlock()
+	if fake.RestartJobStub != nil {
+		return fake.RestartJobStub(jobName, drain)
+	} else {
+		return fake.restartJobReturns.result1
+	}
+}
+
+func (fake *FakeAgentClient) RestartJobCallCount() int {
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
+	return len(fake.restartJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) RestartJobArgsForCall(i int) (string, bool) {
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
+	return fake.restartJobArgsForCall[i].jobName, fake.restartJobArgsForCall[i].drain
+}
+
+func (fake *FakeAgentClient) RestartJobReturns(result1 error) {
+	fake.RestartJobStub = nil
+	fake.restartJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAgentClient) GetState() (agentclient.AgentState, error) {
 	fake.getStateMutex.Lock()
 	fake.getStateArgsForCall = append(fake.getStateArgsForCall, struct{}{})
@@ -79,6 +79,30 @@ func (c *AgentClient) Start() error {
 	return nil
 }
 
+func (c *AgentClient) StartJob(jobName string) error {
+	var response SimpleTaskResponse
+	err := c.AgentRequest.Send("start_job", []interface{}{jobName}, &response)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting job %s", jobName)
+	}
+
+	if response.Value != "started" {
+		return bosherr.Errorf("Failed to start job %s with response: '%s'", jobName, response.Value)
+	}
+
+	return nil
+}
+
+func (c *AgentClient) StopJob(jobName string, drain bool) error {
+	_, err := c.SendAsyncTaskMessage("stop_job", []interface{}{jobName, map[string]interface{}{"drain": drain}})
+	return err
+}
+
+func (c *AgentClient) RestartJob(jobName string, drain bool) error {
+	_, err := c.SendAsyncTaskMessage("restart_job", []interface{}{jobName, map[string]interface{}{"drain": drain}})
+	return err
+}
+
 func (c *AgentClient) GetState() (agentclient.AgentState, error) {
 	var response StateResponse
 
@@ -385,6 +385,99 @@ var _ = Describe("AgentClient", func() {
 		})
 	})
 
+	Describe("StartJob", func() {
+		Context("when agent responds with a value", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":"started"}`),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "start_job",
+						Arguments: []interface{}{"fake-job"},
+						ReplyTo:   replyToAddress,
+					}),
+				))
+			})
+
+			It("makes a POST request to the endpoint", func() {
+				err := agentClient.StartJob("fake-job")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when agent responds with exception", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"exception":{"message":"bad request"}}`),
+				))
+			})
+
+			It("returns an error", func() {
+				err := agentClient.StartJob("fake-job")
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError(ContainSubstring("Starting job fake-job")))
+				Expect(err).To(MatchError(ContainSubstring("bad request")))
+			})
+		})
+	})
+
+	Describe("StopJob", func() {
+		It("sends a stop_job message to the agent and waits for the task to be finished", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "stop_job",
+						Arguments: []interface{}{"fake-job", map[string]interface{}{"drain": true}},
+						ReplyTo:   replyToAddress,
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":"stopped"}`),
+				),
+			)
+
+			err := agentClient.StopJob("fake-job", true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("returns an error if an error occurs", func() {
+			server.AppendHandlers(disconnectingRequestHandler)
+
+			err := agentClient.StopJob("fake-job", false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RestartJob", func() {
+		It("sends a restart_job message to the agent and waits for the task to be finished", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "restart_job",
+						Arguments: []interface{}{"fake-job", map[string]interface{}{"drain": false}},
+						ReplyTo:   replyToAddress,
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":"restarted"}`),
+				),
+			)
+
+			err := agentClient.RestartJob("fake-job", false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
 	Describe("GetState", func() {
 		Context("when agent responds with a value", func() {
 			BeforeEach(func() {
@@ -11,14 +11,19 @@ import (
 
 	boshagent "github.com/cloudfoundry/bosh-agent/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshaj "github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	boshap "github.com/cloudfoundry/bosh-agent/agent/applier/packages"
+	boshaudit "github.com/cloudfoundry/bosh-agent/agent/audit"
 	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshlogforwarder "github.com/cloudfoundry/bosh-agent/agent/logforwarder"
+	boshlogrotator "github.com/cloudfoundry/bosh-agent/agent/logrotator"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
@@ -122,7 +127,9 @@ func (app *app) Setup(opts Options) error {
 
 	mbusHandlerProvider := boshmbus.NewHandlerProvider(settingsService, app.logger, auditLogger)
 
-	mbusHandler, err := mbusHandlerProvider.Get(app.platform, app.dirProvider)
+	logStreamer := boshlogtail.NewStreamer(app.platform.GetFs(), app.dirProvider, timeService, app.logger)
+
+	mbusHandler, err := mbusHandlerProvider.Get(app.platform, app.dirProvider, logStreamer)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting mbus handler")
 	}
@@ -176,6 +183,23 @@ func (app *app) Setup(opts Options) error {
 		app.logger,
 	)
 
+	logRotator := boshlogrotator.NewRotator(
+		app.platform.GetFs(),
+		app.platform.GetRunner(),
+		app.dirProvider,
+		specService,
+		boshlogrotator.NewFileWatcher(app.logger),
+		timeService,
+		app.logger,
+	)
+
+	auditJournal := boshaudit.NewJournal(
+		filepath.Join(app.dirProvider.BoshDir(), "audit.log"),
+		filepath.Join(app.dirProvider.BoshDir(), "audit.head"),
+		app.platform.GetFs(),
+		timeService,
+	)
+
 	actionFactory := boshaction.NewFactory(
 		settingsService,
 		app.platform,
@@ -188,6 +212,10 @@ func (app *app) Setup(opts Options) error {
 		jobSupervisor,
 		specService,
 		jobScriptProvider,
+		logRotator,
+		logStreamer,
+		mbusHandler,
+		auditJournal,
 		app.logger,
 	)
 
@@ -199,6 +227,23 @@ func (app *app) Setup(opts Options) error {
 		taskManager,
 		actionFactory,
 		actionRunner,
+		auditJournal,
+	)
+
+	alertServer := boshalert.NewUnixSocketServer(
+		filepath.Join(app.dirProvider.BoshDir(), "alerts.sock"),
+		app.platform.GetFs(),
+		app.logger,
+	)
+
+	logForwarder := boshlogforwarder.NewForwarder(
+		app.platform.GetFs(),
+		app.dirProvider,
+		settingsService,
+		specService,
+		boshlogrotator.NewFileWatcher(app.logger),
+		timeService,
+		app.logger,
 	)
 
 	app.agent = boshagent.New(
@@ -212,6 +257,9 @@ func (app *app) Setup(opts Options) error {
 		settingsService,
 		uuidGen,
 		timeService,
+		alertServer,
+		logRotator,
+		logForwarder,
 	)
 
 	return nil
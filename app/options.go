@@ -2,7 +2,11 @@ package app
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
 )
 
 type Options struct {
@@ -12,6 +16,12 @@ type Options struct {
 	JobSupervisor      string
 	ConfigPath         string
 	VersionCheck       bool
+
+	// LogFormat is either "text" or "json"
+	LogFormat string
+
+	// Subcommand runs one-off command (e.g. network-check) instead of agent
+	Subcommand string
 }
 
 func ParseOptions(args []string) (Options, error) {
@@ -26,6 +36,7 @@ func ParseOptions(args []string) (Options, error) {
 	flagSet.StringVar(&opts.JobSupervisor, "M", "monit", "Set jobsupervisor")
 	flagSet.StringVar(&opts.BaseDirectory, "b", "/var/vcap", "Set Base Directory")
 	flagSet.BoolVar(&opts.VersionCheck, "v", false, "version")
+	flagSet.StringVar(&opts.LogFormat, "log-format", agentlogger.FormatText, "Log format (text or json)")
 
 	// The following two options are accepted but ignored for compatibility with the old agent
 	var systemRoot string
@@ -36,7 +47,20 @@ func ParseOptions(args []string) (Options, error) {
 
 	// cannot call flagSet.Parse in the return statement due to gccgo
 	// execution order issues: https://code.google.com/p/go/issues/detail?id=8698&thanks=8698&ts=1410376474
-	err := flagSet.Parse(args[1:])
+	flagArgs := args[1:]
+	if len(flagArgs) > 0 && !strings.HasPrefix(flagArgs[0], "-") {
+		opts.Subcommand = flagArgs[0]
+		flagArgs = flagArgs[1:]
+	}
+
+	err := flagSet.Parse(flagArgs)
+	if err != nil {
+		return opts, err
+	}
+
+	if opts.LogFormat != agentlogger.FormatText && opts.LogFormat != agentlogger.FormatJSON {
+		return opts, fmt.Errorf("unknown log format '%s'", opts.LogFormat)
+	}
 
-	return opts, err
+	return opts, nil
 }
@@ -25,4 +25,31 @@ var _ = Describe("ParseOptions", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(opts.ConfigPath).To(Equal(""))
 	})
+
+	It("parses subcommand followed by options", func() {
+		opts, err := ParseOptions([]string{"bosh-agent", "network-check", "-b", "/fake-base"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.Subcommand).To(Equal("network-check"))
+		Expect(opts.BaseDirectory).To(Equal("/fake-base"))
+
+		opts, err = ParseOptions([]string{"bosh-agent", "-P", "baz"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.Subcommand).To(Equal(""))
+	})
+
+	It("parses log format", func() {
+		opts, err := ParseOptions([]string{"bosh-agent", "-log-format", "json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.LogFormat).To(Equal("json"))
+
+		opts, err = ParseOptions([]string{"bosh-agent"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.LogFormat).To(Equal("text"))
+	})
+
+	It("returns error when log format is unknown", func() {
+		_, err := ParseOptions([]string{"bosh-agent", "-log-format", "xml"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unknown log format 'xml'"))
+	})
 })
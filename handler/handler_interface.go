@@ -10,4 +10,7 @@ type Handler interface {
 	RegisterAdditionalFunc(handlerFunc Func)
 
 	Send(target Target, topic Topic, message interface{}) error
+
+	// Publish sends message to subject chosen by API consumer
+	Publish(subject string, message interface{}) error
 }
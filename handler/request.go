@@ -16,6 +16,10 @@ type Request struct {
 	Method          string
 	Payload         []byte
 	ProtocolVersion ProtocolVersion `json:"protocol"`
+
+	// Caller identifies API consumer that sent the request;
+	// it is set by handlers and not read from the payload
+	Caller string `json:"-"`
 }
 
 func (r Request) GetPayload() []byte {
@@ -25,6 +25,9 @@ func (r valueResponse) Shorten() Response {
 type exceptionResponse struct {
 	Exception struct {
 		Message string `json:"message,omitempty"`
+
+		// Value describes failure in more detail than message
+		Value interface{} `json:"value,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -37,6 +40,14 @@ func NewExceptionResponse(err error) (resp Response) {
 	return r
 }
 
+func NewExceptionResponseWithValue(err error, value interface{}) (resp Response) {
+	r := exceptionResponse{}
+	r.Exception.Message = err.Error()
+	r.Exception.Value = value
+	r.err = err
+	return r
+}
+
 func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
@@ -45,5 +56,12 @@ func (r exceptionResponse) Shorten() Response {
 		return sr
 	}
 
+	if r.Exception.Value != nil {
+		sr := exceptionResponse{}
+		sr.Exception.Message = r.Exception.Message
+		sr.err = r.err
+		return sr
+	}
+
 	return r
 }
@@ -92,3 +92,21 @@ var _ = Describe("NewExceptionResponse", func() {
 		})
 	})
 })
+
+var _ = Describe("NewExceptionResponseWithValue", func() {
+	err := errors.New("fake-msg")
+
+	It("can be serialized to JSON", func() {
+		resp := NewExceptionResponseWithValue(err, map[string]string{"fake-key": "fake-value"})
+		boshassert.MatchesJSONString(
+			GinkgoT(),
+			resp,
+			`{"exception":{"message":"fake-msg","value":{"fake-key":"fake-value"}}}`,
+		)
+	})
+
+	It("drops value when shortened", func() {
+		resp := NewExceptionResponseWithValue(err, map[string]string{"fake-key": "fake-value"})
+		boshassert.MatchesJSONString(GinkgoT(), resp.Shorten(), `{"exception":{"message":"fake-msg"}}`)
+	})
+})
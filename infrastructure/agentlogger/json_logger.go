@@ -0,0 +1,166 @@
+package agentlogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Fields are contextual key/value pairs (e.g. action method, task ID)
+// added to every entry logged through logger returned by WithFields
+type Fields map[string]interface{}
+
+type FieldLogger interface {
+	logger.Logger
+	WithFields(fields Fields) logger.Logger
+}
+
+// WithFields returns logger that adds fields to every entry
+// if given logger supports fields; otherwise given logger is returned
+// since text logs do not have a place for them.
+func WithFields(l logger.Logger, fields Fields) logger.Logger {
+	if fieldLogger, ok := l.(FieldLogger); ok {
+		return fieldLogger.WithFields(fields)
+	}
+	return l
+}
+
+type jsonOutput struct {
+	writer      io.Writer
+	forcedDebug bool
+	lock        sync.Mutex
+}
+
+type jsonLogger struct {
+	level  logger.LogLevel
+	out    *jsonOutput
+	fields Fields
+}
+
+// NewJSONLogger returns logger that writes each entry as single line JSON object
+// with timestamp, level, tag, message and fields
+func NewJSONLogger(level logger.LogLevel, writer io.Writer) FieldLogger {
+	return &jsonLogger{
+		level:  level,
+		out:    &jsonOutput{writer: writer},
+		fields: Fields{},
+	}
+}
+
+func (l *jsonLogger) WithFields(fields Fields) logger.Logger {
+	merged := Fields{}
+
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &jsonLogger{level: l.level, out: l.out, fields: merged}
+}
+
+func (l *jsonLogger) Debug(tag, msg string, args ...interface{}) {
+	l.log(logger.LevelDebug, "DEBUG", tag, fmt.Sprintf(msg, args...), "")
+}
+
+func (l *jsonLogger) DebugWithDetails(tag, msg string, args ...interface{}) {
+	msg, details := l.splitDetails(msg, args)
+	l.log(logger.LevelDebug, "DEBUG", tag, msg, details)
+}
+
+func (l *jsonLogger) Info(tag, msg string, args ...interface{}) {
+	l.log(logger.LevelInfo, "INFO", tag, fmt.Sprintf(msg, args...), "")
+}
+
+func (l *jsonLogger) Warn(tag, msg string, args ...interface{}) {
+	l.log(logger.LevelWarn, "WARN", tag, fmt.Sprintf(msg, args...), "")
+}
+
+func (l *jsonLogger) Error(tag, msg string, args ...interface{}) {
+	l.log(logger.LevelError, "ERROR", tag, fmt.Sprintf(msg, args...), "")
+}
+
+func (l *jsonLogger) ErrorWithDetails(tag, msg string, args ...interface{}) {
+	msg, details := l.splitDetails(msg, args)
+	l.log(logger.LevelError, "ERROR", tag, msg, details)
+}
+
+func (l *jsonLogger) HandlePanic(tag string) {
+	if e := recover(); e != nil {
+		l.ErrorWithDetails(tag, "Panic: %s", fmt.Sprintf("%v", e), debug.Stack())
+		os.Exit(2)
+	}
+}
+
+func (l *jsonLogger) ToggleForcedDebug() {
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+
+	l.out.forcedDebug = !l.out.forcedDebug
+}
+
+// Entries are written synchronously so there is nothing to flush
+func (l *jsonLogger) Flush() error                       { return nil }
+func (l *jsonLogger) FlushTimeout(_ time.Duration) error { return nil }
+
+// splitDetails keeps details (last argument) out of message,
+// similarly to how text logger puts them into a separate block
+func (l *jsonLogger) splitDetails(msg string, args []interface{}) (string, string) {
+	if len(args) == 0 {
+		return msg, ""
+	}
+
+	last := len(args) - 1
+
+	return fmt.Sprintf(msg, args[:last]...), fmt.Sprintf("%s", args[last])
+}
+
+func (l *jsonLogger) log(level logger.LogLevel, levelName, tag, msg, details string) {
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+
+	if l.level > level && !l.out.forcedDebug {
+		return
+	}
+
+	entry := map[string]interface{}{}
+
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+
+	entry["timestamp"] = time.Now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = levelName
+	entry["tag"] = tag
+	entry["message"] = msg
+
+	if details != "" {
+		entry["details"] = details
+	}
+
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		bytes, _ = json.Marshal(map[string]interface{}{
+			"timestamp": entry["timestamp"],
+			"level":     levelName,
+			"tag":       tag,
+			"message":   msg,
+			"error":     fmt.Sprintf("Marshalling log fields: %s", err.Error()),
+		})
+	}
+
+	l.out.writer.Write(append(bytes, '\n'))
+}
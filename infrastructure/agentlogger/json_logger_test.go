@@ -0,0 +1,118 @@
+package agentlogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/infrastructure/agentlogger"
+	"github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON logger", func() {
+	var (
+		outBuf     *bytes.Buffer
+		jsonLogger agentlogger.FieldLogger
+	)
+
+	BeforeEach(func() {
+		outBuf = new(bytes.Buffer)
+		jsonLogger = agentlogger.NewJSONLogger(logger.LevelInfo, outBuf)
+	})
+
+	entries := func() []map[string]interface{} {
+		var entries []map[string]interface{}
+
+		for _, line := range strings.Split(strings.TrimSuffix(outBuf.String(), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var entry map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+
+	It("writes each entry as JSON line with timestamp, level, tag and message", func() {
+		jsonLogger.Info("fake-tag", "fake-message %s", "fake-arg")
+		jsonLogger.Error("fake-tag", "fake-error")
+
+		Expect(entries()).To(HaveLen(2))
+
+		entry := entries()[0]
+		Expect(entry["level"]).To(Equal("INFO"))
+		Expect(entry["tag"]).To(Equal("fake-tag"))
+		Expect(entry["message"]).To(Equal("fake-message fake-arg"))
+
+		timestamp, err := time.Parse(time.RFC3339Nano, entry["timestamp"].(string))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(timestamp).To(BeTemporally("~", time.Now(), time.Minute))
+
+		Expect(entries()[1]["level"]).To(Equal("ERROR"))
+	})
+
+	It("does not write entries below level unless debug is forced", func() {
+		jsonLogger.Debug("fake-tag", "fake-debug-1")
+		Expect(outBuf.String()).To(BeEmpty())
+
+		jsonLogger.ToggleForcedDebug()
+		jsonLogger.Debug("fake-tag", "fake-debug-2")
+
+		Expect(entries()).To(HaveLen(1))
+		Expect(entries()[0]["message"]).To(Equal("fake-debug-2"))
+	})
+
+	It("writes details separately from message", func() {
+		jsonLogger.ErrorWithDetails("fake-tag", "fake-message %s", "fake-arg", []byte("fake-details"))
+
+		Expect(entries()[0]["message"]).To(Equal("fake-message fake-arg"))
+		Expect(entries()[0]["details"]).To(Equal("fake-details"))
+	})
+
+	Describe("WithFields", func() {
+		It("adds fields to entries without changing original logger", func() {
+			requestLogger := agentlogger.WithFields(jsonLogger, agentlogger.Fields{"method": "fake-method"})
+			taskLogger := agentlogger.WithFields(requestLogger, agentlogger.Fields{"task_id": "fake-task-id"})
+
+			taskLogger.Info("fake-tag", "fake-task-message")
+			jsonLogger.Info("fake-tag", "fake-message")
+
+			Expect(entries()[0]).To(HaveKeyWithValue("method", "fake-method"))
+			Expect(entries()[0]).To(HaveKeyWithValue("task_id", "fake-task-id"))
+			Expect(entries()[1]).ToNot(HaveKey("method"))
+		})
+
+		It("does not let fields override timestamp, level, tag or message", func() {
+			agentlogger.WithFields(jsonLogger, agentlogger.Fields{"message": "fake-field"}).Info("fake-tag", "fake-message")
+
+			Expect(entries()[0]["message"]).To(Equal("fake-message"))
+		})
+
+		It("returns given logger when it does not support fields", func() {
+			textLogger := logger.NewWriterLogger(logger.LevelInfo, outBuf)
+
+			Expect(agentlogger.WithFields(textLogger, agentlogger.Fields{"method": "fake-method"})).To(BeIdenticalTo(textLogger))
+		})
+	})
+
+	It("dumps goroutines into JSON entry when SIGSEGV is received", func() {
+		signalChannel := make(chan os.Signal, 1)
+		signalableLogger, doneChannel := agentlogger.NewSignalableLogger(jsonLogger, signalChannel)
+		Expect(signalableLogger).To(BeIdenticalTo(jsonLogger))
+
+		signalChannel <- syscall.SIGSEGV
+		<-doneChannel
+
+		Expect(entries()).To(HaveLen(2))
+		Expect(entries()[0]["message"]).To(Equal("Dumping goroutines..."))
+		Expect(entries()[1]["message"]).To(MatchRegexp(`goroutine (\d+) \[(syscall|running)\]`))
+	})
+})
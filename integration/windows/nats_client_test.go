@@ -445,7 +445,7 @@ func (n *NatsClient) FetchLogs(destinationDir string) {
 
 	fetchLogsCheckFunc := func() (map[string]string, error) {
 		var err error
-		var taskResult map[string]map[string]string
+		var taskResult map[string]map[string]interface{}
 
 		valueResponse, err := n.getTask(fetchLogsResponse["value"]["agent_task_id"])
 		if err != nil {
@@ -457,7 +457,13 @@ func (n *NatsClient) FetchLogs(destinationDir string) {
 			return map[string]string{}, err
 		}
 
-		fetchLogsResult = taskResult["value"]
+		// Result also includes manifest of fetched logs
+		fetchLogsResult = map[string]string{}
+		for key, value := range taskResult["value"] {
+			if stringValue, ok := value.(string); ok {
+				fetchLogsResult[key] = stringValue
+			}
+		}
 
 		return fetchLogsResult, nil
 	}
@@ -0,0 +1,81 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+)
+
+type FakeManager struct {
+	IsSupported bool
+
+	// Dir that contains job cgroups; they do not have to exist
+	Root string
+
+	SetupLimits map[string]cgroup.Limits
+	SetupErr    error
+
+	AddProcessErr error
+
+	Usages   map[string]cgroup.Usage
+	UsageErr error
+
+	RemoveAllCalled bool
+	RemoveAllErr    error
+
+	addedPidsLock sync.Mutex
+	addedPids     map[string][]int
+}
+
+func NewFakeManager() *FakeManager {
+	return &FakeManager{
+		IsSupported: true,
+		Root:        "/fake-cgroup",
+		SetupLimits: map[string]cgroup.Limits{},
+		Usages:      map[string]cgroup.Usage{},
+		addedPids:   map[string][]int{},
+	}
+}
+
+func (m *FakeManager) Supported() bool {
+	return m.IsSupported
+}
+
+func (m *FakeManager) Setup(jobName string, limits cgroup.Limits) error {
+	if m.SetupErr != nil {
+		return m.SetupErr
+	}
+
+	m.SetupLimits[jobName] = limits
+
+	return nil
+}
+
+func (m *FakeManager) AddProcess(jobName string, pid int) error {
+	m.addedPidsLock.Lock()
+	defer m.addedPidsLock.Unlock()
+
+	m.addedPids[jobName] = append(m.addedPids[jobName], pid)
+
+	return m.AddProcessErr
+}
+
+func (m *FakeManager) AddedPids(jobName string) []int {
+	m.addedPidsLock.Lock()
+	defer m.addedPidsLock.Unlock()
+
+	return append([]int{}, m.addedPids[jobName]...)
+}
+
+func (m *FakeManager) ProcsFile(jobName string) string {
+	return m.Root + "/" + jobName + "/cgroup.procs"
+}
+
+func (m *FakeManager) Usage(jobName string) (cgroup.Usage, error) {
+	return m.Usages[jobName], m.UsageErr
+}
+
+func (m *FakeManager) RemoveAll() error {
+	m.RemoveAllCalled = true
+	return m.RemoveAllErr
+}
@@ -0,0 +1,321 @@
+package cgroup
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	fsManagerLogTag = "cgroupManager"
+
+	DefaultRoot    = "/sys/fs/cgroup"
+	DefaultProcDir = "/proc"
+
+	ParentName = "bosh-jobs"
+
+	cpuPeriodUsec = 100000
+	unlimited     = "max"
+)
+
+var controllers = []string{"cpu", "memory", "pids"}
+
+type fsManager struct {
+	fs      boshsys.FileSystem
+	root    string
+	procDir string
+	logger  boshlog.Logger
+}
+
+// NewFsManager returns a manager that works with cgroup v2 unified
+// hierarchy mounted at root; procDir is used to find process descendants
+func NewFsManager(fs boshsys.FileSystem, root, procDir string, logger boshlog.Logger) Manager {
+	return fsManager{
+		fs:      fs,
+		root:    root,
+		procDir: procDir,
+		logger:  logger,
+	}
+}
+
+func (m fsManager) Supported() bool {
+	return m.fs.FileExists(path.Join(m.root, "cgroup.controllers"))
+}
+
+func (m fsManager) Setup(jobName string, limits Limits) error {
+	if !m.Supported() {
+		return ErrNotSupported
+	}
+
+	memoryBytes, err := limits.MemoryBytes()
+	if err != nil {
+		return err
+	}
+
+	// Controllers have to be enabled on every level above job cgroup
+	for _, dir := range []string{m.root, m.parentDir()} {
+		err = m.fs.MkdirAll(dir, 0755)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating cgroup %s", dir)
+		}
+
+		err = m.write(dir, "cgroup.subtree_control", "+"+strings.Join(controllers, " +"))
+		if err != nil {
+			return err
+		}
+	}
+
+	jobDir := m.jobDir(jobName)
+
+	err = m.fs.MkdirAll(jobDir, 0755)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating cgroup %s", jobDir)
+	}
+
+	memoryMax := unlimited
+	if memoryBytes > 0 {
+		memoryMax = strconv.FormatUint(memoryBytes, 10)
+	}
+
+	cpuMax := fmt.Sprintf("%s %d", unlimited, cpuPeriodUsec)
+	if limits.CPU > 0 {
+		cpuMax = fmt.Sprintf("%d %d", int64(limits.CPU*cpuPeriodUsec), cpuPeriodUsec)
+	}
+
+	pidsMax := unlimited
+	if limits.Pids > 0 {
+		pidsMax = strconv.FormatInt(limits.Pids, 10)
+	}
+
+	m.logger.Debug(fsManagerLogTag, "Limiting job %s to memory=%s cpu=%s pids=%s", jobName, memoryMax, cpuMax, pidsMax)
+
+	for fileName, value := range map[string]string{"memory.max": memoryMax, "cpu.max": cpuMax, "pids.max": pidsMax} {
+		err = m.write(jobDir, fileName, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m fsManager) AddProcess(jobName string, pid int) error {
+	if !m.Supported() {
+		return ErrNotSupported
+	}
+
+	pids := append([]int{pid}, m.descendants(pid)...)
+
+	for _, processPid := range pids {
+		err := m.write(m.jobDir(jobName), "cgroup.procs", strconv.Itoa(processPid))
+		if err != nil {
+			// Short-lived processes exit before they can be moved
+			if !m.fs.FileExists(path.Join(m.procDir, strconv.Itoa(processPid))) {
+				continue
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m fsManager) ProcsFile(jobName string) string {
+	return path.Join(m.jobDir(jobName), "cgroup.procs")
+}
+
+func (m fsManager) Usage(jobName string) (Usage, error) {
+	var usage Usage
+
+	if !m.Supported() {
+		return usage, ErrNotSupported
+	}
+
+	jobDir := m.jobDir(jobName)
+
+	var err error
+
+	usage.MemoryBytes, err = m.readUint(jobDir, "memory.current")
+	if err != nil {
+		return usage, err
+	}
+
+	usage.MemoryLimitBytes, err = m.readUint(jobDir, "memory.max")
+	if err != nil {
+		return usage, err
+	}
+
+	usage.Pids, err = m.readUint(jobDir, "pids.current")
+	if err != nil {
+		return usage, err
+	}
+
+	usage.PidsLimit, err = m.readUint(jobDir, "pids.max")
+	if err != nil {
+		return usage, err
+	}
+
+	cpuStat, err := m.read(jobDir, "cpu.stat")
+	if err != nil {
+		return usage, err
+	}
+
+	for _, line := range strings.Split(cpuStat, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usageUsec, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return usage, bosherr.WrapErrorf(err, "Parsing cpu.stat of job %s", jobName)
+			}
+
+			usage.CPUUsage = time.Duration(usageUsec) * time.Microsecond
+		}
+	}
+
+	cpuMax, err := m.read(jobDir, "cpu.max")
+	if err != nil {
+		return usage, err
+	}
+
+	// Format is '$MAX $PERIOD' where $MAX can be 'max'
+	fields := strings.Fields(cpuMax)
+	if len(fields) == 2 && fields[0] != unlimited {
+		quota, quotaErr := strconv.ParseFloat(fields[0], 64)
+		period, periodErr := strconv.ParseFloat(fields[1], 64)
+		if quotaErr == nil && periodErr == nil && period > 0 {
+			usage.CPULimit = quota / period
+		}
+	}
+
+	return usage, nil
+}
+
+func (m fsManager) RemoveAll() error {
+	if !m.Supported() {
+		return nil
+	}
+
+	jobDirs, err := m.fs.Glob(path.Join(m.parentDir(), "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Globbing job cgroups")
+	}
+
+	// Cgroups that still contain processes cannot be removed
+	// but should not keep other cgroups from being removed
+	var errs []error
+
+	for _, jobDir := range jobDirs {
+		if !m.fs.FileExists(path.Join(jobDir, "cgroup.procs")) {
+			continue
+		}
+
+		err = m.fs.RemoveAll(jobDir)
+		if err != nil {
+			errs = append(errs, bosherr.WrapErrorf(err, "Removing cgroup %s", jobDir))
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+// descendants finds children of pid recursively by their parent pid
+func (m fsManager) descendants(pid int) []int {
+	statPaths, err := m.fs.Glob(path.Join(m.procDir, "[0-9]*", "stat"))
+	if err != nil {
+		m.logger.Warn(fsManagerLogTag, "Failed to list processes: %s", err.Error())
+		return nil
+	}
+
+	children := map[int][]int{}
+
+	for _, statPath := range statPaths {
+		stat, err := m.fs.ReadFileString(statPath)
+		if err != nil {
+			continue
+		}
+
+		// Command name in parentheses may contain spaces
+		fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+		if len(fields) < 2 {
+			continue
+		}
+
+		childPid, err := strconv.Atoi(path.Base(path.Dir(statPath)))
+		if err != nil {
+			continue
+		}
+
+		parentPid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		children[parentPid] = append(children[parentPid], childPid)
+	}
+
+	descendants := []int{}
+	queue := children[pid]
+
+	for len(queue) > 0 {
+		descendants = append(descendants, queue[0])
+		queue = append(queue[1:], children[queue[0]]...)
+	}
+
+	return descendants
+}
+
+func (m fsManager) read(dir, fileName string) (string, error) {
+	content, err := m.fs.ReadFileString(path.Join(dir, fileName))
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Reading %s", path.Join(dir, fileName))
+	}
+
+	return strings.TrimSpace(content), nil
+}
+
+// readUint reads a single number treating 'max' as 0
+func (m fsManager) readUint(dir, fileName string) (uint64, error) {
+	content, err := m.read(dir, fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	if content == unlimited {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing %s", path.Join(dir, fileName))
+	}
+
+	return value, nil
+}
+
+func (m fsManager) write(dir, fileName, value string) error {
+	err := m.fs.WriteFileString(path.Join(dir, fileName), value)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", path.Join(dir, fileName))
+	}
+
+	return nil
+}
+
+func (m fsManager) parentDir() string {
+	return path.Join(m.root, ParentName)
+}
+
+func (m fsManager) jobDir(jobName string) string {
+	return path.Join(m.parentDir(), jobName)
+}
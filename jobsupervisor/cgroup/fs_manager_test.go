@@ -0,0 +1,186 @@
+package cgroup_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("fsManager", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		manager Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		manager = NewFsManager(fs, "/cgroup", "/proc", boshlog.NewLogger(boshlog.LevelNone))
+
+		fs.WriteFileString("/cgroup/cgroup.controllers", "cpuset cpu io memory pids")
+	})
+
+	Describe("Supported", func() {
+		It("returns false without unified hierarchy", func() {
+			fs.RemoveAll("/cgroup/cgroup.controllers")
+			Expect(manager.Supported()).To(BeFalse())
+			Expect(manager.Setup("fake-job", Limits{})).To(Equal(ErrNotSupported))
+		})
+	})
+
+	Describe("Setup", func() {
+		It("enables controllers and writes limits", func() {
+			err := manager.Setup("fake-job", Limits{Memory: "1G", CPU: 1.5, Pids: 256})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/cgroup/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/cgroup.subtree_control")).To(Equal("+cpu +memory +pids"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/memory.max")).To(Equal("1073741824"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/cpu.max")).To(Equal("150000 100000"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/pids.max")).To(Equal("256"))
+		})
+
+		It("removes limits that are not set", func() {
+			err := manager.Setup("fake-job", Limits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/memory.max")).To(Equal("max"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/cpu.max")).To(Equal("max 100000"))
+			Expect(fs.ReadFileString("/cgroup/bosh-jobs/fake-job/pids.max")).To(Equal("max"))
+		})
+
+		It("returns error when limits are invalid", func() {
+			err := manager.Setup("fake-job", Limits{Memory: "lots"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Memory limit 'lots'"))
+		})
+
+		It("returns error when writing limits fails", func() {
+			fs.WriteFileErrors["/cgroup/bosh-jobs/fake-job/pids.max"] = errors.New("fake-write-error")
+
+			err := manager.Setup("fake-job", Limits{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+
+	Describe("ProcsFile", func() {
+		It("returns procs file of job cgroup", func() {
+			Expect(manager.ProcsFile("fake-job")).To(Equal("/cgroup/bosh-jobs/fake-job/cgroup.procs"))
+		})
+	})
+
+	Describe("AddProcess", func() {
+		It("moves process with its descendants", func() {
+			recordingFs := &writeRecordingFs{FakeFileSystem: fs}
+			manager = NewFsManager(recordingFs, "/cgroup", "/proc", boshlog.NewLogger(boshlog.LevelNone))
+
+			fs.WriteFileString("/proc/10/stat", "10 (runsv) S 1 10 10 0")
+			fs.WriteFileString("/proc/11/stat", "11 (my (weird) proc) S 10 10 10 0")
+			fs.WriteFileString("/proc/12/stat", "12 (worker) S 11 10 10 0")
+			fs.WriteFileString("/proc/20/stat", "20 (other) S 1 20 20 0")
+			fs.SetGlob("/proc/[0-9]*/stat", []string{"/proc/10/stat", "/proc/11/stat", "/proc/12/stat", "/proc/20/stat"})
+
+			err := manager.AddProcess("fake-job", 10)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(recordingFs.writes).To(Equal([]string{
+				"/cgroup/bosh-jobs/fake-job/cgroup.procs: 10",
+				"/cgroup/bosh-jobs/fake-job/cgroup.procs: 11",
+				"/cgroup/bosh-jobs/fake-job/cgroup.procs: 12",
+			}))
+		})
+
+		It("ignores processes that exited before they could be moved", func() {
+			fs.WriteFileString("/proc/10/stat", "10 (runsv) S 1 10 10 0")
+			fs.WriteFileString("/proc/11/stat", "11 (sleep) S 10 10 10 0")
+			fs.SetGlob("/proc/[0-9]*/stat", []string{"/proc/10/stat", "/proc/11/stat"})
+			fs.RemoveAll("/proc/11")
+
+			fs.WriteFileErrors["/cgroup/bosh-jobs/fake-job/cgroup.procs"] = errors.New("fake-write-error")
+
+			err := manager.AddProcess("fake-job", 11)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = manager.AddProcess("fake-job", 10)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+
+	Describe("Usage", func() {
+		BeforeEach(func() {
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/memory.current", "1048576\n")
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/memory.max", "max\n")
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/pids.current", "7\n")
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/pids.max", "100\n")
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/cpu.stat", "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n")
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/cpu.max", "50000 100000\n")
+		})
+
+		It("reads usage and limits of job cgroup", func() {
+			usage, err := manager.Usage("fake-job")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(Equal(Usage{
+				MemoryBytes:      1048576,
+				MemoryLimitBytes: 0,
+				CPUUsage:         2500 * time.Millisecond,
+				CPULimit:         0.5,
+				Pids:             7,
+				PidsLimit:        100,
+			}))
+		})
+
+		It("returns error when job cgroup does not exist", func() {
+			_, err := manager.Usage("other-job")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("/cgroup/bosh-jobs/other-job/memory.current"))
+		})
+	})
+
+	Describe("RemoveAll", func() {
+		It("removes job cgroups", func() {
+			fs.WriteFileString("/cgroup/bosh-jobs/fake-job/cgroup.procs", "")
+			fs.SetGlob("/cgroup/bosh-jobs/*", []string{"/cgroup/bosh-jobs/cgroup.procs", "/cgroup/bosh-jobs/fake-job"})
+
+			err := manager.RemoveAll()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/cgroup/bosh-jobs/fake-job")).To(BeFalse())
+		})
+
+		It("removes remaining job cgroups when some cannot be removed", func() {
+			for _, jobName := range []string{"fake-job-1", "fake-job-2", "fake-job-3"} {
+				fs.WriteFileString("/cgroup/bosh-jobs/"+jobName+"/cgroup.procs", "")
+			}
+			fs.SetGlob("/cgroup/bosh-jobs/*", []string{"/cgroup/bosh-jobs/fake-job-1", "/cgroup/bosh-jobs/fake-job-2", "/cgroup/bosh-jobs/fake-job-3"})
+
+			fs.RemoveAllStub = func(path string) error {
+				if path != "/cgroup/bosh-jobs/fake-job-2" {
+					return errors.New("fake-remove-error " + path)
+				}
+				return nil
+			}
+
+			err := manager.RemoveAll()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-remove-error /cgroup/bosh-jobs/fake-job-1"))
+			Expect(err.Error()).To(ContainSubstring("fake-remove-error /cgroup/bosh-jobs/fake-job-3"))
+			Expect(fs.FileExists("/cgroup/bosh-jobs/fake-job-2")).To(BeFalse())
+		})
+	})
+})
+
+type writeRecordingFs struct {
+	*fakesys.FakeFileSystem
+	writes []string
+}
+
+func (fs *writeRecordingFs) WriteFileString(path, content string) error {
+	fs.writes = append(fs.writes, path+": "+content)
+	return fs.FakeFileSystem.WriteFileString(path, content)
+}
@@ -0,0 +1,94 @@
+package cgroup
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var memoryRegexp = regexp.MustCompile(`^([0-9]+)([KMGT]?)$`)
+
+// Limits are enforced on all processes of a job together
+type Limits struct {
+	// Memory is a number of bytes with optional K, M, G or T suffix
+	Memory string `json:"memory,omitempty" yaml:"memory"`
+
+	// CPU is a number of CPUs the job may use, e.g. 0.5 or 2
+	CPU float64 `json:"cpu,omitempty" yaml:"cpu"`
+
+	// Pids is the maximum number of processes and threads
+	Pids int64 `json:"pids,omitempty" yaml:"pids"`
+}
+
+func (l Limits) IsEmpty() bool {
+	return l == Limits{}
+}
+
+// Merge returns limits with values set in other taking precedence
+func (l Limits) Merge(other Limits) Limits {
+	if other.Memory != "" {
+		l.Memory = other.Memory
+	}
+
+	if other.CPU != 0 {
+		l.CPU = other.CPU
+	}
+
+	if other.Pids != 0 {
+		l.Pids = other.Pids
+	}
+
+	return l
+}
+
+// MemoryBytes returns memory limit in bytes or 0 if there is no limit
+func (l Limits) MemoryBytes() (uint64, error) {
+	return ParseMemory(l.Memory)
+}
+
+func (l Limits) Validate() error {
+	errs := []error{}
+
+	if _, err := l.MemoryBytes(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if l.CPU < 0 {
+		errs = append(errs, errors.New("CPU limit must not be negative"))
+	}
+
+	if l.Pids < 0 {
+		errs = append(errs, errors.New("Pids limit must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+// ParseMemory converts a number of bytes with optional K, M, G or T suffix
+// into bytes; empty value results in 0
+func ParseMemory(memory string) (uint64, error) {
+	if memory == "" {
+		return 0, nil
+	}
+
+	matches := memoryRegexp.FindStringSubmatch(strings.ToUpper(memory))
+	if matches == nil {
+		return 0, bosherr.Errorf("Memory limit '%s' must be a number of bytes with optional K, M, G or T suffix", memory)
+	}
+
+	value, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing memory limit '%s'", memory)
+	}
+
+	multipliers := map[string]uint64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+	return value * multipliers[matches[2]], nil
+}
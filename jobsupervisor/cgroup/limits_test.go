@@ -0,0 +1,43 @@
+package cgroup_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+)
+
+var _ = Describe("Limits", func() {
+	Describe("Merge", func() {
+		It("prefers values that are set in other limits", func() {
+			limits := Limits{Memory: "1G", CPU: 2, Pids: 100}
+
+			Expect(limits.Merge(Limits{CPU: 0.5})).To(Equal(Limits{Memory: "1G", CPU: 0.5, Pids: 100}))
+			Expect(limits.Merge(Limits{})).To(Equal(limits))
+		})
+	})
+
+	Describe("MemoryBytes", func() {
+		It("converts suffixes", func() {
+			for memory, expectedBytes := range map[string]uint64{"": 0, "512": 512, "4k": 4096, "2M": 2 << 20, "1G": 1 << 30} {
+				bytes, err := Limits{Memory: memory}.MemoryBytes()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(bytes).To(Equal(expectedBytes))
+			}
+		})
+	})
+
+	Describe("Validate", func() {
+		It("returns all errors", func() {
+			err := Limits{Memory: "lots", CPU: -1, Pids: -1}.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Memory limit 'lots' must be a number of bytes"))
+			Expect(err.Error()).To(ContainSubstring("CPU limit must not be negative"))
+			Expect(err.Error()).To(ContainSubstring("Pids limit must not be negative"))
+		})
+
+		It("accepts empty limits", func() {
+			Expect(Limits{}.Validate()).To(Succeed())
+		})
+	})
+})
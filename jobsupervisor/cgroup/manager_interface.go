@@ -0,0 +1,40 @@
+package cgroup
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotSupported = errors.New("cgroup v2 is not available")
+
+// Usage of a job cgroup; limits are 0 when unlimited
+type Usage struct {
+	MemoryBytes      uint64
+	MemoryLimitBytes uint64
+
+	CPUUsage time.Duration
+	CPULimit float64
+
+	Pids      uint64
+	PidsLimit uint64
+}
+
+// Manager maintains a cgroup per job under the bosh-jobs parent cgroup
+type Manager interface {
+	Supported() bool
+
+	// Setup creates job cgroup if necessary and applies limits to it
+	Setup(jobName string, limits Limits) error
+
+	// AddProcess moves process and all of its descendants into job cgroup
+	AddProcess(jobName string, pid int) error
+
+	// ProcsFile returns path of file that a process writes its own pid to
+	// in order to join job cgroup before it starts any children
+	ProcsFile(jobName string) string
+
+	Usage(jobName string) (Usage, error)
+
+	// RemoveAll removes job cgroups that no longer contain processes
+	RemoveAll() error
+}
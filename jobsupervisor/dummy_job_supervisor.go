@@ -1,5 +1,10 @@
 package jobsupervisor
 
+import (
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+)
+
 type dummyJobSupervisor struct {
 	status    string
 	processes []Process
@@ -45,6 +50,26 @@ func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath str
 	return nil
 }
 
+func (s *dummyJobSupervisor) StartProcess(processName string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) StopProcess(processName string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
@@ -5,6 +5,8 @@ import (
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	bosherror "github.com/cloudfoundry/bosh-utils/errors"
 )
 
@@ -74,6 +76,18 @@ func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) Start() error {
 	if d.status == "fail_task" {
 		return bosherror.Error("fake-task-fail-error")
@@ -99,6 +113,14 @@ func (d *dummyNatsJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) StartProcess(processName string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) StopProcess(processName string) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
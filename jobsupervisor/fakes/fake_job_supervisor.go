@@ -3,6 +3,8 @@ package fakes
 import (
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	"sync"
 )
 
@@ -12,6 +14,15 @@ type FakeJobSupervisor struct {
 
 	AddJobArgs []AddJobArgs
 
+	SetJobLimitsArgs []SetJobLimitsArgs
+	SetJobLimitsErr  error
+
+	SetJobDependenciesArgs []SetJobDependenciesArgs
+	SetJobDependenciesErr  error
+
+	AddProcessesArgs []AddProcessesArgs
+	AddProcessesErr  error
+
 	RemovedAllJobs    bool
 	RemovedAllJobsErr error
 
@@ -25,11 +36,18 @@ type FakeJobSupervisor struct {
 	Unmonitored  bool
 	UnmonitorErr error
 
+	StartProcessErr  error
+	StopProcessErr   error
+	processesLock    sync.Mutex
+	startedProcesses []string
+	stoppedProcesses []string
+
 	StatusStatus    string
 	ProcessesStatus []boshjobsuper.Process
 	ProcessesError  error
 
-	JobFailureAlert *boshalert.MonitAlert
+	JobFailureAlert   *boshalert.MonitAlert
+	JobFailureHandler boshjobsuper.JobFailureHandler
 
 	HealthRecorded      int
 	HealthRecordedMutex sync.Mutex
@@ -41,6 +59,22 @@ type AddJobArgs struct {
 	ConfigPath string
 }
 
+type SetJobLimitsArgs struct {
+	Name   string
+	Limits cgroup.Limits
+}
+
+type SetJobDependenciesArgs struct {
+	Name      string
+	DependsOn []string
+}
+
+type AddProcessesArgs struct {
+	Name   string
+	Index  int
+	Config processdef.Config
+}
+
 func NewFakeJobSupervisor() *FakeJobSupervisor {
 	return &FakeJobSupervisor{}
 }
@@ -64,6 +98,61 @@ func (m *FakeJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
+func (m *FakeJobSupervisor) StartProcess(processName string) error {
+	m.processesLock.Lock()
+	defer m.processesLock.Unlock()
+
+	m.startedProcesses = append(m.startedProcesses, processName)
+	return m.StartProcessErr
+}
+
+func (m *FakeJobSupervisor) StopProcess(processName string) error {
+	m.processesLock.Lock()
+	defer m.processesLock.Unlock()
+
+	m.stoppedProcesses = append(m.stoppedProcesses, processName)
+	return m.StopProcessErr
+}
+
+func (m *FakeJobSupervisor) StartedProcesses() []string {
+	m.processesLock.Lock()
+	defer m.processesLock.Unlock()
+
+	return append([]string{}, m.startedProcesses...)
+}
+
+func (m *FakeJobSupervisor) StoppedProcesses() []string {
+	m.processesLock.Lock()
+	defer m.processesLock.Unlock()
+
+	return append([]string{}, m.stoppedProcesses...)
+}
+
+func (m *FakeJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	m.SetJobLimitsArgs = append(m.SetJobLimitsArgs, SetJobLimitsArgs{
+		Name:   jobName,
+		Limits: limits,
+	})
+	return m.SetJobLimitsErr
+}
+
+func (m *FakeJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	m.SetJobDependenciesArgs = append(m.SetJobDependenciesArgs, SetJobDependenciesArgs{
+		Name:      jobName,
+		DependsOn: dependsOn,
+	})
+	return m.SetJobDependenciesErr
+}
+
+func (m *FakeJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	m.AddProcessesArgs = append(m.AddProcessesArgs, AddProcessesArgs{
+		Name:   jobName,
+		Index:  jobIndex,
+		Config: config,
+	})
+	return m.AddProcessesErr
+}
+
 func (m *FakeJobSupervisor) RemoveAllJobs() error {
 	m.RemovedAllJobs = true
 	return m.RemovedAllJobsErr
@@ -99,6 +188,7 @@ func (m *FakeJobSupervisor) Processes() ([]boshjobsuper.Process, error) {
 }
 
 func (m *FakeJobSupervisor) MonitorJobFailures(handler boshjobsuper.JobFailureHandler) error {
+	m.JobFailureHandler = handler
 	if m.JobFailureAlert != nil {
 		return handler(*m.JobFailureAlert)
 	}
@@ -0,0 +1,73 @@
+package jobsupervisor
+
+import (
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// setupJobCgroup creates cgroup of a job; jobs run without
+// limits on hosts that do not have cgroup v2 available
+func setupJobCgroup(manager cgroup.Manager, jobName string, limits cgroup.Limits, logger boshlog.Logger, logTag string) error {
+	err := manager.Setup(jobName, limits)
+	if err == cgroup.ErrNotSupported {
+		if !limits.IsEmpty() {
+			logger.Warn(logTag, "Resource limits of job %s are not enforced: %s", jobName, err.Error())
+		}
+		return nil
+	}
+
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting up cgroup of job %s", jobName)
+	}
+
+	return nil
+}
+
+// removeJobCgroups removes cgroups of jobs without failing since
+// cgroups of processes that are still exiting cannot be removed yet
+func removeJobCgroups(manager cgroup.Manager, logger boshlog.Logger, logTag string) {
+	err := manager.RemoveAll()
+	if err != nil {
+		logger.Warn(logTag, "Failed to remove job cgroups: %s", err.Error())
+	}
+}
+
+// jobCgroupVitals returns usage of job cgroups keyed by job name
+type jobCgroupVitals struct {
+	manager cgroup.Manager
+	vitals  map[string]*CgroupVitals
+}
+
+func newJobCgroupVitals(manager cgroup.Manager) *jobCgroupVitals {
+	return &jobCgroupVitals{manager: manager, vitals: map[string]*CgroupVitals{}}
+}
+
+// Get returns nil if job does not have a cgroup
+func (v *jobCgroupVitals) Get(jobName string) *CgroupVitals {
+	if vitals, found := v.vitals[jobName]; found {
+		return vitals
+	}
+
+	var vitals *CgroupVitals
+
+	usage, err := v.manager.Usage(jobName)
+	if err == nil {
+		vitals = newCgroupVitals(usage)
+	}
+
+	v.vitals[jobName] = vitals
+
+	return vitals
+}
+
+func newCgroupVitals(usage cgroup.Usage) *CgroupVitals {
+	return &CgroupVitals{
+		MemoryKb:      int(usage.MemoryBytes / 1024),
+		MemoryLimitKb: int(usage.MemoryLimitBytes / 1024),
+		CPUSecs:       usage.CPUUsage.Seconds(),
+		CPULimit:      usage.CPULimit,
+		Pids:          int(usage.Pids),
+		PidsLimit:     int(usage.PidsLimit),
+	}
+}
@@ -0,0 +1,380 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	jobCrashLoopsLogTag = "jobCrashLoops"
+
+	// Processes restarted this many times within window are crash looping
+	crashLoopWindow    = 5 * time.Minute
+	crashLoopThreshold = 5
+
+	// Restarts of crash looping processes are held off exponentially longer
+	// while they keep crash looping within window after being started again
+	crashLoopInitialBackoff = 30 * time.Second
+	crashLoopMaxBackoff     = 10 * time.Minute
+
+	crashLoopStderrLines    = 20
+	crashLoopStderrMaxBytes = 16 * 1024
+
+	// Number of restarts kept in history of each process
+	crashLoopMaxHistory = 20
+)
+
+// Native and systemd supervisors include exit status of process in restart
+// alerts. Monit alerts never do since monit only notices that process is
+// gone, so restarts of processes supervised by monit have no exit code.
+var exitStatusRegexp = regexp.MustCompile(`exit status (-?\d+)`)
+
+// jobCrashLoops tracks restarts reported by supervisors and holds off
+// restarting processes that keep crashing, whatever the supervisor backend
+type jobCrashLoops struct {
+	supervisor  JobSupervisor
+	fs          boshsys.FileSystem
+	dirProvider boshdir.Provider
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock      sync.Mutex
+	processes map[string]*processRestarts
+}
+
+type processRestarts struct {
+	history RestartHistory
+
+	backoff    time.Duration
+	resumedAt  time.Time
+	countSince time.Time
+	cancelCh   chan struct{}
+}
+
+func newJobCrashLoops(
+	supervisor JobSupervisor,
+	fs boshsys.FileSystem,
+	dirProvider boshdir.Provider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *jobCrashLoops {
+	return &jobCrashLoops{
+		supervisor:  supervisor,
+		fs:          fs,
+		dirProvider: dirProvider,
+		timeService: timeService,
+		logger:      logger,
+		processes:   map[string]*processRestarts{},
+	}
+}
+
+// isRestartAlert reports whether alert was raised because supervisor restarted a process
+func isRestartAlert(alert boshalert.MonitAlert) bool {
+	return alert.Action == "restart" && alert.Service != ""
+}
+
+// RecordRestart adds restart to history of the process. Once process crash
+// loops it is stopped, handler receives a crash loop alert and process is
+// started again after backoff.
+func (c *jobCrashLoops) RecordRestart(alert boshalert.MonitAlert, handler JobFailureHandler) {
+	now := c.timeService.Now()
+
+	c.lock.Lock()
+
+	restarts, found := c.processes[alert.Service]
+	if !found {
+		restarts = &processRestarts{}
+		c.processes[alert.Service] = restarts
+	}
+
+	event := RestartEvent{Time: now.Unix(), Description: alert.Description}
+	if match := exitStatusRegexp.FindStringSubmatch(alert.Description); match != nil {
+		if exitCode, err := strconv.Atoi(match[1]); err == nil {
+			event.ExitCode = &exitCode
+		}
+	}
+
+	restarts.history.Restarts = append(restarts.history.Restarts, event)
+	if len(restarts.history.Restarts) > crashLoopMaxHistory {
+		restarts.history.Restarts = restarts.history.Restarts[len(restarts.history.Restarts)-crashLoopMaxHistory:]
+	}
+
+	recent := restarts.recent(now)
+	if len(recent) < crashLoopThreshold || restarts.cancelCh != nil {
+		c.lock.Unlock()
+		return
+	}
+
+	if restarts.backoff == 0 || now.Sub(restarts.resumedAt) > crashLoopWindow {
+		restarts.backoff = crashLoopInitialBackoff
+	} else {
+		restarts.backoff *= 2
+		if restarts.backoff > crashLoopMaxBackoff {
+			restarts.backoff = crashLoopMaxBackoff
+		}
+	}
+
+	backoff := restarts.backoff
+	cancelCh := make(chan struct{})
+
+	restarts.cancelCh = cancelCh
+	restarts.history.CrashLoops++
+	restarts.history.BackoffUntil = now.Add(backoff).Unix()
+
+	c.lock.Unlock()
+
+	// Supervisors may report restarts while they wait for alerts to be handled
+	// so process is stopped and started again without blocking them
+	go c.holdOff(alert.Service, recent, backoff, cancelCh, handler)
+}
+
+// CancelBackoffs stops waiting to start processes that are held off,
+// e.g. because all processes are being started or stopped
+func (c *jobCrashLoops) CancelBackoffs() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.timeService.Now()
+
+	for _, restarts := range c.processes {
+		restarts.cancel(now)
+	}
+}
+
+// CancelBackoff stops waiting to start process if it is held off,
+// e.g. because it is being started or stopped on its own
+func (c *jobCrashLoops) CancelBackoff(processName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if restarts, found := c.processes[processName]; found {
+		restarts.cancel(c.timeService.Now())
+	}
+}
+
+// RemoveAll cancels backoffs and forgets restart histories of all processes
+func (c *jobCrashLoops) RemoveAll() {
+	c.CancelBackoffs()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.processes = map[string]*processRestarts{}
+}
+
+// AddHistories sets restart histories on processes that were restarted
+func (c *jobCrashLoops) AddHistories(processes []Process) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range processes {
+		restarts, found := c.processes[processes[i].Name]
+		if !found {
+			continue
+		}
+
+		history := restarts.history
+		history.Restarts = append([]RestartEvent{}, history.Restarts...)
+
+		processes[i].Restarts = &history
+	}
+}
+
+func (c *jobCrashLoops) holdOff(processName string, recent []RestartEvent, backoff time.Duration, cancelCh chan struct{}, handler JobFailureHandler) {
+	defer c.logger.HandlePanic("Job Crash Loops Hold Off")
+
+	c.logger.Warn(jobCrashLoopsLogTag, "Process %s restarted %d times within %s, holding off restarts for %s", processName, len(recent), crashLoopWindow, backoff)
+
+	err := c.supervisor.StopProcess(processName)
+	if err != nil {
+		c.logger.Error(jobCrashLoopsLogTag, "Failed to stop crash looping process %s: %s", processName, err.Error())
+	}
+
+	err = handler(c.alert(processName, recent, backoff))
+	if err != nil {
+		c.logger.Error(jobCrashLoopsLogTag, "Failed to handle crash loop alert for process %s: %s", processName, err.Error())
+	}
+
+	timer := c.timeService.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-cancelCh:
+		return
+	case <-timer.C():
+	}
+
+	c.lock.Lock()
+
+	restarts := c.processes[processName]
+	if restarts == nil || restarts.cancelCh != cancelCh {
+		c.lock.Unlock()
+		return
+	}
+
+	now := c.timeService.Now()
+
+	restarts.cancelCh = nil
+	restarts.resumedAt = now
+	restarts.countSince = now
+	restarts.history.BackoffUntil = 0
+
+	c.lock.Unlock()
+
+	c.logger.Info(jobCrashLoopsLogTag, "Starting process %s after holding off restarts for %s", processName, backoff)
+
+	err = c.supervisor.StartProcess(processName)
+	if err != nil {
+		c.logger.Error(jobCrashLoopsLogTag, "Failed to start crash looping process %s: %s", processName, err.Error())
+	}
+}
+
+func (c *jobCrashLoops) alert(processName string, recent []RestartEvent, backoff time.Duration) boshalert.MonitAlert {
+	now := c.timeService.Now()
+
+	exitCodes := []string{}
+	for _, event := range recent {
+		if event.ExitCode != nil {
+			exitCodes = append(exitCodes, strconv.Itoa(*event.ExitCode))
+		} else {
+			exitCodes = append(exitCodes, "unknown")
+		}
+	}
+
+	description := fmt.Sprintf(
+		"restarted %d times within %s (exit codes: %s), holding off restarts for %s",
+		len(recent), crashLoopWindow, strings.Join(exitCodes, ", "), backoff,
+	)
+
+	if stderrLines := c.lastStderrLines(processName); stderrLines != "" {
+		description += "; last stderr lines:\n" + stderrLines
+	}
+
+	return boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s.crash-loop@localhost", now.Unix(), processName),
+		Service:     processName,
+		Event:       "crash loop detected",
+		Action:      "backoff",
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+}
+
+// lastStderrLines reads end of <process>.stderr.log of the job running
+// the process which is where jobs conventionally write errors
+func (c *jobCrashLoops) lastStderrLines(processName string) string {
+	fileName := processName + ".stderr.log"
+
+	logPaths, err := c.fs.Glob(path.Join(c.dirProvider.JobLogDir("*"), fileName))
+	if err != nil {
+		c.logger.Debug(jobCrashLoopsLogTag, "Failed to find stderr log of process %s: %s", processName, err.Error())
+		return ""
+	}
+
+	processes, err := c.supervisor.Processes()
+	if err == nil {
+		for _, process := range processes {
+			if process.Name == processName && process.Job != "" {
+				logPaths = append([]string{path.Join(c.dirProvider.JobLogDir(process.Job), fileName)}, logPaths...)
+			}
+		}
+	}
+
+	for _, logPath := range logPaths {
+		if !c.fs.FileExists(logPath) {
+			continue
+		}
+
+		lines, err := c.tailFile(logPath)
+		if err != nil {
+			c.logger.Debug(jobCrashLoopsLogTag, "Failed to read %s: %s", logPath, err.Error())
+			continue
+		}
+
+		return lines
+	}
+
+	return ""
+}
+
+func (c *jobCrashLoops) tailFile(filePath string) (string, error) {
+	file, err := c.fs.OpenFile(filePath, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	info, err := c.fs.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	truncated := info.Size() > crashLoopStderrMaxBytes
+	if truncated {
+		_, err = file.Seek(-crashLoopStderrMaxBytes, io.SeekEnd)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	contents, err := ioutil.ReadAll(io.LimitReader(file, crashLoopStderrMaxBytes))
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+
+	// First line is likely partial when reading from the middle of the file
+	if truncated && len(lines) > 1 {
+		lines = lines[1:]
+	}
+
+	if len(lines) > crashLoopStderrLines {
+		lines = lines[len(lines)-crashLoopStderrLines:]
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// recent returns restarts that count towards crash loop detection
+func (r *processRestarts) recent(now time.Time) []RestartEvent {
+	recent := []RestartEvent{}
+
+	for _, event := range r.history.Restarts {
+		eventTime := time.Unix(event.Time, 0)
+		if now.Sub(eventTime) <= crashLoopWindow && !eventTime.Before(r.countSince.Truncate(time.Second)) {
+			recent = append(recent, event)
+		}
+	}
+
+	return recent
+}
+
+func (r *processRestarts) cancel(now time.Time) {
+	if r.cancelCh == nil {
+		return
+	}
+
+	close(r.cancelCh)
+
+	r.cancelCh = nil
+	r.resumedAt = now
+	r.countSince = now
+	r.history.BackoffUntil = 0
+}
@@ -0,0 +1,426 @@
+package jobsupervisor
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"gopkg.in/yaml.v2"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	jobOrderLogTag = "jobOrder"
+
+	// Processes are checked this often while waiting for jobs to start or stop
+	jobOrderPollInterval = 1 * time.Second
+
+	jobOrderStartTimeout = 5 * time.Minute
+	jobOrderStopTimeout  = 5 * time.Minute
+)
+
+// jobOrder starts jobs after jobs they depend on and stops them in reverse
+// order, waiting for processes of each job to be running or stopped before
+// moving on. When no job declares dependencies all jobs are started at once.
+// Dependencies are persisted so that order is kept after agent restarts.
+type jobOrder struct {
+	supervisor  JobSupervisor
+	fs          boshsys.FileSystem
+	statePath   string
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock   sync.Mutex
+	jobs   []*orderedJob
+	loaded bool
+}
+
+type orderedJob struct {
+	Name      string   `yaml:"name"`
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
+	// Processes are known only for jobs added from monit files or process
+	// definitions; supervisors may also report job of each process
+	Processes []string `yaml:"processes,omitempty"`
+}
+
+func newJobOrder(
+	supervisor JobSupervisor,
+	fs boshsys.FileSystem,
+	statePath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *jobOrder {
+	return &jobOrder{
+		supervisor:  supervisor,
+		fs:          fs,
+		statePath:   statePath,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (o *jobOrder) SetDependencies(jobName string, dependsOn []string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.loadLocked()
+	o.findOrAddLocked(jobName).DependsOn = dependsOn
+
+	return o.saveLocked()
+}
+
+func (o *jobOrder) AddProcesses(jobName string, processNames []string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.loadLocked()
+
+	job := o.findOrAddLocked(jobName)
+	job.Processes = append(job.Processes, processNames...)
+
+	return o.saveLocked()
+}
+
+// AddMonitFile records processes of a job from its monit file; files that
+// cannot be parsed leave processes of the job to be reported by supervisor
+func (o *jobOrder) AddMonitFile(jobName, configPath string) error {
+	configContent, err := o.fs.ReadFileString(configPath)
+	if err != nil {
+		o.logger.Debug(jobOrderLogTag, "Not looking up processes of job %s: %s", jobName, err.Error())
+		return nil
+	}
+
+	processes, err := parseMonitFile(configContent)
+	if err != nil {
+		o.logger.Debug(jobOrderLogTag, "Not looking up processes of job %s: %s", jobName, err.Error())
+		return nil
+	}
+
+	var processNames []string
+
+	for _, process := range processes {
+		processNames = append(processNames, process.Name)
+	}
+
+	return o.AddProcesses(jobName, processNames)
+}
+
+func (o *jobOrder) RemoveAll() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.jobs = nil
+	o.loaded = true
+
+	err := o.fs.RemoveAll(o.statePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing job dependencies")
+	}
+
+	return nil
+}
+
+// AddJobNames reports jobs of processes that supervisor does not know jobs of
+func (o *jobOrder) AddJobNames(processes []Process) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.loadLocked()
+
+	jobNames := map[string]string{}
+
+	for _, job := range o.jobs {
+		for _, processName := range job.Processes {
+			jobNames[processName] = job.Name
+		}
+	}
+
+	for i, process := range processes {
+		if process.Job == "" {
+			processes[i].Job = jobNames[process.Name]
+		}
+	}
+}
+
+// Start starts jobs in dependency order and then calls startAll so that
+// supervisor starts remaining processes and keeps monitoring all of them
+func (o *jobOrder) Start(startAll func() error) error {
+	levels, err := o.levels()
+	if err != nil {
+		return err
+	}
+
+	for _, level := range levels {
+		processNames, err := o.processNames(level)
+		if err != nil {
+			return err
+		}
+
+		for _, processName := range processNames {
+			err = o.supervisor.StartProcess(processName)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Starting jobs %s", jobNames(level))
+			}
+		}
+
+		err = o.waitFor(level, isProcessRunning, jobOrderStartTimeout)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Waiting for jobs %s to be running", jobNames(level))
+		}
+	}
+
+	return startAll()
+}
+
+// Stop stops jobs in reverse dependency order and then calls stopAll
+func (o *jobOrder) Stop(stopAll func() error) error {
+	levels, err := o.levels()
+	if err != nil {
+		return err
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		processNames, err := o.processNames(levels[i])
+		if err != nil {
+			return err
+		}
+
+		for _, processName := range processNames {
+			err = o.supervisor.StopProcess(processName)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Stopping jobs %s", jobNames(levels[i]))
+			}
+		}
+
+		err = o.waitFor(levels[i], isProcessStopped, jobOrderStopTimeout)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Waiting for jobs %s to be stopped", jobNames(levels[i]))
+		}
+	}
+
+	return stopAll()
+}
+
+// levels groups jobs so that each job comes after all jobs it depends on;
+// jobs within a level are started together. Returns no levels when
+// no job declares dependencies.
+func (o *jobOrder) levels() ([][]orderedJob, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.loadLocked()
+
+	remaining := map[string]bool{}
+	declared := false
+
+	for _, job := range o.jobs {
+		remaining[job.Name] = true
+		declared = declared || len(job.DependsOn) > 0
+	}
+
+	if !declared {
+		return nil, nil
+	}
+
+	for _, job := range o.jobs {
+		for _, dependency := range job.DependsOn {
+			if !remaining[dependency] {
+				o.logger.Warn(jobOrderLogTag, "Ignoring dependency of job %s on job %s that is not on this instance", job.Name, dependency)
+			}
+		}
+	}
+
+	var levels [][]orderedJob
+
+	for len(remaining) > 0 {
+		var level []orderedJob
+
+		for _, job := range o.jobs {
+			if remaining[job.Name] && !dependsOnAny(*job, remaining) {
+				level = append(level, *job)
+			}
+		}
+
+		if len(level) == 0 {
+			var cycle []string
+			for jobName := range remaining {
+				cycle = append(cycle, jobName)
+			}
+			sort.Strings(cycle)
+
+			return nil, bosherr.Errorf("Dependencies of jobs %s form a cycle", strings.Join(cycle, ", "))
+		}
+
+		for _, job := range level {
+			delete(remaining, job.Name)
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+func (o *jobOrder) processNames(jobs []orderedJob) ([]string, error) {
+	processes, err := o.supervisor.Processes()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting processes")
+	}
+
+	return jobProcessNames(jobs, processes), nil
+}
+
+func (o *jobOrder) waitFor(jobs []orderedJob, done func(state string) bool, timeout time.Duration) error {
+	deadline := o.timeService.Now().Add(timeout)
+
+	for {
+		processes, err := o.supervisor.Processes()
+		if err != nil {
+			return bosherr.WrapError(err, "Getting processes")
+		}
+
+		states := map[string]string{}
+		for _, process := range processes {
+			states[process.Name] = process.State
+		}
+
+		var pending []string
+
+		for _, processName := range jobProcessNames(jobs, processes) {
+			if !done(states[processName]) {
+				pending = append(pending, processName)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if !o.timeService.Now().Before(deadline) {
+			return bosherr.Errorf("Timed out after %s waiting for processes %s", timeout, strings.Join(pending, ", "))
+		}
+
+		o.timeService.Sleep(jobOrderPollInterval)
+	}
+}
+
+// jobProcessNames lists recorded processes of jobs and processes reported for jobs by supervisor
+func jobProcessNames(jobs []orderedJob, processes []Process) []string {
+	var processNames []string
+
+	seen := map[string]bool{}
+
+	for _, job := range jobs {
+		for _, processName := range job.Processes {
+			if !seen[processName] {
+				seen[processName] = true
+				processNames = append(processNames, processName)
+			}
+		}
+
+		for _, process := range processes {
+			if process.Job == job.Name && !seen[process.Name] {
+				seen[process.Name] = true
+				processNames = append(processNames, process.Name)
+			}
+		}
+	}
+
+	return processNames
+}
+
+func (o *jobOrder) findOrAddLocked(jobName string) *orderedJob {
+	for _, job := range o.jobs {
+		if job.Name == jobName {
+			return job
+		}
+	}
+
+	job := &orderedJob{Name: jobName}
+	o.jobs = append(o.jobs, job)
+
+	return job
+}
+
+func (o *jobOrder) loadLocked() {
+	if o.loaded {
+		return
+	}
+
+	o.loaded = true
+
+	if !o.fs.FileExists(o.statePath) {
+		return
+	}
+
+	contents, err := o.fs.ReadFile(o.statePath)
+	if err != nil {
+		o.logger.Error(jobOrderLogTag, "Failed to read job dependencies: %s", err.Error())
+		return
+	}
+
+	var jobs []*orderedJob
+
+	err = yaml.Unmarshal(contents, &jobs)
+	if err != nil {
+		o.logger.Error(jobOrderLogTag, "Failed to parse job dependencies: %s", err.Error())
+		return
+	}
+
+	o.jobs = append(jobs, o.jobs...)
+}
+
+func (o *jobOrder) saveLocked() error {
+	contents, err := yaml.Marshal(o.jobs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling job dependencies")
+	}
+
+	err = o.fs.MkdirAll(path.Dir(o.statePath), 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating job dependencies directory")
+	}
+
+	err = o.fs.WriteFile(o.statePath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing job dependencies")
+	}
+
+	return nil
+}
+
+func dependsOnAny(job orderedJob, jobNames map[string]bool) bool {
+	for _, dependency := range job.DependsOn {
+		if jobNames[dependency] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func jobNames(jobs []orderedJob) string {
+	var names []string
+
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func isProcessRunning(state string) bool {
+	return state == "running"
+}
+
+// isProcessStopped is true for processes that are not reported anymore
+func isProcessStopped(state string) bool {
+	return state != "running" && state != "starting"
+}
@@ -0,0 +1,375 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"gopkg.in/yaml.v2"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	jobProbesLogTag = "jobProbes"
+
+	// Probes are checked for being due this often; each probe runs at its own interval
+	jobProbesTickInterval = 1 * time.Second
+
+	probeKindReadiness = "readiness"
+	probeKindLiveness  = "liveness"
+
+	ProbeStateUnknown = "unknown"
+	ProbeStatePassing = "passing"
+	ProbeStateFailing = "failing"
+)
+
+// jobProbes runs readiness and liveness probes of processes independently
+// of the supervisor backend. Probe definitions are persisted so that probes
+// keep running after agent restarts without jobs being configured again.
+type jobProbes struct {
+	prober      probe.Prober
+	fs          boshsys.FileSystem
+	statePath   string
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock   sync.Mutex
+	probes []*processProbe
+	loaded bool
+}
+
+type processProbe struct {
+	Job        string           `yaml:"job"`
+	Process    string           `yaml:"process"`
+	Kind       string           `yaml:"kind"`
+	Definition processdef.Probe `yaml:"probe"`
+
+	status    ProbeStatus
+	nextCheck time.Time
+	checking  bool
+}
+
+func newJobProbes(
+	prober probe.Prober,
+	fs boshsys.FileSystem,
+	statePath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *jobProbes {
+	return &jobProbes{
+		prober:      prober,
+		fs:          fs,
+		statePath:   statePath,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (p *jobProbes) Add(jobName string, config processdef.Config) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.loadLocked()
+
+	for _, process := range config.Processes {
+		if process.ReadinessProbe != nil {
+			p.probes = append(p.probes, newProcessProbe(jobName, process.Name, probeKindReadiness, *process.ReadinessProbe))
+		}
+
+		if process.LivenessProbe != nil {
+			p.probes = append(p.probes, newProcessProbe(jobName, process.Name, probeKindLiveness, *process.LivenessProbe))
+		}
+	}
+
+	return p.saveLocked()
+}
+
+func (p *jobProbes) RemoveAll() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.probes = nil
+	p.loaded = true
+
+	err := p.fs.RemoveAll(p.statePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing probe definitions")
+	}
+
+	return nil
+}
+
+// Status folds probe results into status reported by supervisor
+func (p *jobProbes) Status(supervisorStatus string) string {
+	if supervisorStatus != "running" {
+		return supervisorStatus
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.loadLocked()
+
+	status := "running"
+
+	for _, processProbe := range p.probes {
+		switch {
+		case processProbe.status.State == ProbeStateFailing:
+			return "failing"
+		case processProbe.Kind == probeKindReadiness && processProbe.status.State == ProbeStateUnknown:
+			status = "starting"
+		}
+	}
+
+	return status
+}
+
+// AddStatuses sets probe statuses on processes with probes
+func (p *jobProbes) AddStatuses(processes []Process) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.loadLocked()
+
+	for i := range processes {
+		for _, processProbe := range p.probes {
+			if processProbe.Process != processes[i].Name {
+				continue
+			}
+
+			status := processProbe.status
+
+			if processProbe.Kind == probeKindReadiness {
+				processes[i].Readiness = &status
+			} else {
+				processes[i].Liveness = &status
+			}
+		}
+	}
+}
+
+// Run checks due probes while supervisor reports processes to be running
+// and reports probes that start failing or recover. It never returns.
+func (p *jobProbes) Run(supervisorStatus func() string, handler JobFailureHandler) {
+	defer p.logger.HandlePanic("Job Probes Run")
+
+	ticker := p.timeService.NewTicker(jobProbesTickInterval)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		if !p.anyDue() {
+			continue
+		}
+
+		status := supervisorStatus()
+		if status != "running" && status != "starting" {
+			// Stopped or failing processes are not probed and are
+			// given their initial delay again once they are running
+			p.reset()
+			continue
+		}
+
+		for _, processProbe := range p.startDue() {
+			go p.check(processProbe, handler)
+		}
+	}
+}
+
+func (p *jobProbes) anyDue() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.loadLocked()
+
+	now := p.timeService.Now()
+
+	for _, processProbe := range p.probes {
+		if processProbe.isDue(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *jobProbes) startDue() []*processProbe {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.timeService.Now()
+	due := []*processProbe{}
+
+	for _, processProbe := range p.probes {
+		if processProbe.nextCheck.IsZero() {
+			processProbe.nextCheck = now.Add(processProbe.Definition.InitialDelayDuration())
+		}
+
+		if processProbe.isDue(now) {
+			processProbe.checking = true
+			due = append(due, processProbe)
+		}
+	}
+
+	return due
+}
+
+func (p *jobProbes) reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, processProbe := range p.probes {
+		if !processProbe.checking {
+			processProbe.reset()
+		}
+	}
+}
+
+func (p *jobProbes) check(processProbe *processProbe, handler JobFailureHandler) {
+	defer p.logger.HandlePanic("Job Probes Check")
+
+	err := p.prober.Probe(processProbe.Definition)
+
+	p.lock.Lock()
+	alert, shouldAlert := processProbe.record(err, p.timeService.Now())
+	p.lock.Unlock()
+
+	if err != nil {
+		p.logger.Debug(jobProbesLogTag, "%s probe of process %s failed: %s", processProbe.Kind, processProbe.Process, err.Error())
+	}
+
+	if shouldAlert {
+		err = handler(alert)
+		if err != nil {
+			p.logger.Error(jobProbesLogTag, "Failed to handle %s probe alert for process %s: %s", processProbe.Kind, processProbe.Process, err.Error())
+		}
+	}
+}
+
+func (p *jobProbes) loadLocked() {
+	if p.loaded {
+		return
+	}
+
+	p.loaded = true
+
+	if !p.fs.FileExists(p.statePath) {
+		return
+	}
+
+	contents, err := p.fs.ReadFile(p.statePath)
+	if err != nil {
+		p.logger.Error(jobProbesLogTag, "Failed to read probe definitions: %s", err.Error())
+		return
+	}
+
+	var probes []*processProbe
+
+	err = yaml.Unmarshal(contents, &probes)
+	if err != nil {
+		p.logger.Error(jobProbesLogTag, "Failed to parse probe definitions: %s", err.Error())
+		return
+	}
+
+	for _, processProbe := range probes {
+		processProbe.reset()
+	}
+
+	p.probes = append(probes, p.probes...)
+}
+
+func (p *jobProbes) saveLocked() error {
+	contents, err := yaml.Marshal(p.probes)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling probe definitions")
+	}
+
+	err = p.fs.MkdirAll(path.Dir(p.statePath), 0700)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating probe definitions directory")
+	}
+
+	err = p.fs.WriteFile(p.statePath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing probe definitions")
+	}
+
+	return nil
+}
+
+func newProcessProbe(jobName, processName, kind string, definition processdef.Probe) *processProbe {
+	processProbe := &processProbe{
+		Job:        jobName,
+		Process:    processName,
+		Kind:       kind,
+		Definition: definition,
+	}
+
+	processProbe.reset()
+
+	return processProbe
+}
+
+func (p *processProbe) isDue(now time.Time) bool {
+	return !p.checking && !now.Before(p.nextCheck)
+}
+
+func (p *processProbe) reset() {
+	p.status = ProbeStatus{Type: p.Definition.Type(), State: ProbeStateUnknown}
+	p.nextCheck = time.Time{}
+}
+
+// record updates status with probe result and returns alert on transitions
+// to failing after reaching failure threshold and from failing to passing
+func (p *processProbe) record(err error, now time.Time) (boshalert.MonitAlert, bool) {
+	p.checking = false
+	p.nextCheck = now.Add(p.Definition.IntervalDuration())
+	p.status.CheckedAt = now.Unix()
+
+	previousState := p.status.State
+
+	if err == nil {
+		p.status.State = ProbeStatePassing
+		p.status.ConsecutiveFailures = 0
+		p.status.Message = ""
+
+		if previousState == ProbeStateFailing {
+			description := fmt.Sprintf("%s probe succeeded", p.status.Type)
+			return p.alert("succeeded", description, now), true
+		}
+
+		return boshalert.MonitAlert{}, false
+	}
+
+	p.status.ConsecutiveFailures++
+	p.status.Message = err.Error()
+
+	threshold := p.Definition.FailureThresholdOrDefault()
+	if previousState == ProbeStateFailing || p.status.ConsecutiveFailures < threshold {
+		return boshalert.MonitAlert{}, false
+	}
+
+	p.status.State = ProbeStateFailing
+
+	description := fmt.Sprintf("%s probe failed %d times: %s", p.status.Type, p.status.ConsecutiveFailures, err.Error())
+
+	return p.alert("failed", description, now), true
+}
+
+func (p *processProbe) alert(result, description string, now time.Time) boshalert.MonitAlert {
+	return boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s.%s@localhost", now.Unix(), p.Process, p.Kind),
+		Service:     p.Process,
+		Event:       fmt.Sprintf("%s probe %s", p.Kind, result),
+		Action:      "alert",
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+}
@@ -2,6 +2,8 @@ package jobsupervisor
 
 import (
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 )
 
 type Process struct {
@@ -10,6 +12,18 @@ type Process struct {
 	Uptime UptimeVitals `json:"uptime,omitempty"`
 	Memory MemoryVitals `json:"mem,omitempty"`
 	CPU    CPUVitals    `json:"cpu,omitempty"`
+
+	// Job is reported when it is known which job the process belongs to;
+	// cgroup usage is reported when the process runs in a job cgroup
+	Job    string        `json:"job,omitempty"`
+	Cgroup *CgroupVitals `json:"cgroup,omitempty"`
+
+	// Probe statuses are reported when the process defines probes
+	Readiness *ProbeStatus `json:"readiness,omitempty"`
+	Liveness  *ProbeStatus `json:"liveness,omitempty"`
+
+	// Restart history is reported when the process was restarted by its supervisor
+	Restarts *RestartHistory `json:"restarts,omitempty"`
 }
 
 type UptimeVitals struct {
@@ -25,6 +39,40 @@ type CPUVitals struct {
 	Total float64 `json:"total"`
 }
 
+// CgroupVitals are shared by all processes of a job; limits are omitted when unlimited
+type CgroupVitals struct {
+	MemoryKb      int     `json:"mem_kb"`
+	MemoryLimitKb int     `json:"mem_limit_kb,omitempty"`
+	CPUSecs       float64 `json:"cpu_secs"`
+	CPULimit      float64 `json:"cpu_limit,omitempty"`
+	Pids          int     `json:"pids"`
+	PidsLimit     int     `json:"pids_limit,omitempty"`
+}
+
+// ProbeStatus reports latest result of a readiness or liveness probe
+type ProbeStatus struct {
+	Type                string `json:"type"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	Message             string `json:"message,omitempty"`
+	CheckedAt           int64  `json:"checked_at,omitempty"`
+}
+
+// RestartHistory lists latest restarts of a process and how many times
+// it was held off restarting because it kept crashing
+type RestartHistory struct {
+	Restarts     []RestartEvent `json:"restarts"`
+	CrashLoops   int            `json:"crash_loops,omitempty"`
+	BackoffUntil int64          `json:"backoff_until,omitempty"`
+}
+
+// RestartEvent has exit code only when supervisor reports it
+type RestartEvent struct {
+	Time        int64  `json:"time"`
+	ExitCode    *int   `json:"exit_code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 type JobFailureHandler func(boshalert.MonitAlert) error
 
 type JobSupervisor interface {
@@ -41,10 +89,25 @@ type JobSupervisor interface {
 	// (Monit complies to above requirements.)
 	Unmonitor() error
 
+	// Actions taken on a single process. A stopped process is
+	// not restarted until it is started again.
+	StartProcess(processName string) error
+	StopProcess(processName string) error
+
 	Status() string
 	Processes() ([]Process, error)
 	// Job management
+	// SetJobLimits is called before adding a job so that its processes are
+	// placed into a cgroup of the job; it is called even without limits
+	SetJobLimits(jobName string, limits cgroup.Limits) error
+	// SetJobDependencies is also called before adding a job; jobs are started
+	// after and stopped before jobs they depend on. Backends start and stop
+	// all jobs at once, ordering is done by the wrapper supervisor.
+	SetJobDependencies(jobName string, dependsOn []string) error
 	AddJob(jobName string, jobIndex int, configPath string) error
+	// AddProcesses is used instead of AddJob for jobs that ship
+	// structured process definitions rather than monit files
+	AddProcesses(jobName string, jobIndex int, config processdef.Config) error
 	RemoveAllJobs() error
 
 	MonitorJobFailures(handler JobFailureHandler) error
@@ -0,0 +1,221 @@
+package jobsupervisor
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// monitProcess is a process definition extracted from a job's monit file
+// for use by job supervisors other than monit itself.
+type monitProcess struct {
+	Name    string
+	PidFile string
+	Group   string
+
+	StartProgram string
+	StartTimeout time.Duration
+	StopProgram  string
+	StopTimeout  time.Duration
+
+	// Set from `as uid X and gid Y` of the start program
+	UID string
+	GID string
+
+	DependsOn []string
+}
+
+// parseMonitFile extracts `check process` entries from monit control file
+// content. Other check types (file, host, etc.) and resource tests are ignored.
+func parseMonitFile(content string) ([]monitProcess, error) {
+	tokens, err := tokenizeMonitFile(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []monitProcess
+	var current *monitProcess
+
+	next := func(i *int) string {
+		*i++
+		if *i < len(tokens) {
+			return tokens[*i]
+		}
+		return ""
+	}
+
+	peek := func(i int) string {
+		if i+1 < len(tokens) {
+			return tokens[i+1]
+		}
+		return ""
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := strings.ToLower(tokens[i])
+
+		if token == "check" {
+			checkType := strings.ToLower(next(&i))
+			if checkType != "process" {
+				current = nil
+				continue
+			}
+
+			name := next(&i)
+			if name == "" {
+				return nil, bosherr.Error("Missing name for check process")
+			}
+
+			processes = append(processes, monitProcess{Name: name})
+			current = &processes[len(processes)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch token {
+		case "pidfile":
+			current.PidFile = next(&i)
+
+		case "group":
+			current.Group = next(&i)
+
+		case "depends":
+			if strings.ToLower(peek(i)) == "on" {
+				next(&i)
+			}
+			for peek(i) != "" && !isMonitKeyword(peek(i)) {
+				current.DependsOn = append(current.DependsOn, next(&i))
+			}
+
+		case "start", "stop":
+			if strings.ToLower(peek(i)) != "program" {
+				continue
+			}
+			next(&i)
+
+			program := next(&i)
+			timeout := time.Duration(0)
+
+			for {
+				option := strings.ToLower(peek(i))
+				if option == "as" || option == "and" || option == "with" {
+					next(&i)
+					continue
+				}
+
+				if option == "uid" || option == "gid" {
+					next(&i)
+					value := next(&i)
+					if token == "start" && option == "uid" {
+						current.UID = value
+					} else if token == "start" {
+						current.GID = value
+					}
+					continue
+				}
+
+				if option == "timeout" {
+					next(&i)
+					seconds, err := strconv.Atoi(next(&i))
+					if err != nil {
+						return nil, bosherr.WrapErrorf(err, "Parsing %s program timeout of process %s", token, current.Name)
+					}
+					timeout = time.Duration(seconds) * time.Second
+					if unit := strings.ToLower(peek(i)); unit == "seconds" || unit == "second" {
+						next(&i)
+					}
+					continue
+				}
+
+				break
+			}
+
+			if token == "start" {
+				current.StartProgram = program
+				current.StartTimeout = timeout
+			} else {
+				current.StopProgram = program
+				current.StopTimeout = timeout
+			}
+
+		case "if":
+			// Resource tests are only understood by monit; skip to their action
+			for peek(i) != "" && strings.ToLower(peek(i)) != "then" {
+				next(&i)
+			}
+			next(&i)
+			if strings.ToLower(next(&i)) == "exec" {
+				next(&i)
+			}
+		}
+	}
+
+	return processes, nil
+}
+
+func isMonitKeyword(token string) bool {
+	switch strings.ToLower(token) {
+	case "check", "with", "pidfile", "start", "stop", "group", "depends", "if", "mode", "every", "matching", "restart", "alert", "noalert":
+		return true
+	}
+	return false
+}
+
+func tokenizeMonitFile(content string) ([]string, error) {
+	var tokens []string
+	var current []rune
+	var quote rune
+	inToken := false
+	inComment := false
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, string(current))
+		}
+		current = current[:0]
+		inToken = false
+	}
+
+	for _, r := range content {
+		switch {
+		case inComment:
+			if r == '\n' {
+				inComment = false
+			}
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+
+		case r == '#' && !inToken:
+			inComment = true
+
+		case r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+
+		default:
+			current = append(current, r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, bosherr.Error("Unterminated quoted string")
+	}
+
+	flush()
+
+	return tokens, nil
+}
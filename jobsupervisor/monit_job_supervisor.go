@@ -1,8 +1,11 @@
 package jobsupervisor
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -10,14 +13,37 @@ import (
 	"github.com/pivotal/go-smtpd/smtpd"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-const monitJobSupervisorLogTag = "monitJobSupervisor"
+const (
+	monitJobSupervisorLogTag = "monitJobSupervisor"
+
+	// Monit starts and restarts processes on its own so their start
+	// programs are run by this script that first joins job cgroup.
+	// Joining fails for programs that monit runs with other uid than root
+	// or for jobs without cgroup (e.g. limits could not be set up); such
+	// programs still run but failure is logged to syslog and stderr.
+	monitCgroupExecScript = `#!/bin/sh
+procs_file=$1
+shift
+if ! { echo $$ > "$procs_file"; } 2>/dev/null; then
+  message="Failed to join cgroup $procs_file as uid $(id -u), running $1 outside of job cgroup"
+  echo "$message" >&2
+  logger -t bosh-cgroup-exec -p user.warning "$message" 2>/dev/null
+fi
+exec "$@"
+`
+)
+
+// Matches start and restart programs up to the opening quote of the command
+var monitStartProgramRegexp = regexp.MustCompile(`(?i)(\b(?:re)?start\s+program\s*=?\s*)(["'])`)
 
 type monitJobSupervisor struct {
 	fs                    boshsys.FileSystem
@@ -28,6 +54,7 @@ type monitJobSupervisor struct {
 	jobFailuresServerPort int
 	reloadOptions         MonitReloadOptions
 	timeService           clock.Clock
+	cgroupManager         cgroup.Manager
 }
 
 type MonitReloadOptions struct {
@@ -51,6 +78,7 @@ func NewMonitJobSupervisor(
 	jobFailuresServerPort int,
 	reloadOptions MonitReloadOptions,
 	timeService clock.Clock,
+	cgroupManager cgroup.Manager,
 ) JobSupervisor {
 	return &monitJobSupervisor{
 		fs:                    fs,
@@ -61,6 +89,7 @@ func NewMonitJobSupervisor(
 		jobFailuresServerPort: jobFailuresServerPort,
 		reloadOptions:         reloadOptions,
 		timeService:           timeService,
+		cgroupManager:         cgroupManager,
 	}
 }
 
@@ -282,6 +311,9 @@ func (m monitJobSupervisor) Processes() (processes []Process, err error) {
 		return processes, bosherr.WrapError(err, "Getting service status")
 	}
 
+	processJobs := m.processJobs()
+	cgroupVitals := newJobCgroupVitals(m.cgroupManager)
+
 	for _, service := range monitStatus.ServicesInGroup("vcap") {
 		process := Process{
 			Name:  service.Name,
@@ -297,6 +329,12 @@ func (m monitJobSupervisor) Processes() (processes []Process, err error) {
 				Total: service.CPUPercentTotal,
 			},
 		}
+
+		if jobName, found := processJobs[service.Name]; found {
+			process.Job = jobName
+			process.Cgroup = cgroupVitals.Get(jobName)
+		}
+
 		processes = append(processes, process)
 	}
 
@@ -312,16 +350,49 @@ func (m monitJobSupervisor) getIncarnation() (int, error) {
 	return monitStatus.GetIncarnation()
 }
 
+// StartProcess starts and re-monitors a single monit service
+func (m monitJobSupervisor) StartProcess(processName string) error {
+	err := m.client.StartService(processName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting service %s", processName)
+	}
+
+	return nil
+}
+
+// StopProcess stops and unmonitors a single monit service
+func (m monitJobSupervisor) StopProcess(processName string) error {
+	err := m.client.StopService(processName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping service %s", processName)
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	return setupJobCgroup(m.cgroupManager, jobName, limits, m.logger, monitJobSupervisorLogTag)
+}
+
+func (m monitJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
 func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	targetFilename := fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName)
 	targetConfigPath := path.Join(m.dirProvider.MonitJobsDir(), targetFilename)
 
-	configContent, err := m.fs.ReadFile(configPath)
+	configContent, err := m.fs.ReadFileString(configPath)
 	if err != nil {
 		return bosherr.WrapError(err, "Reading job config from file")
 	}
 
-	err = m.fs.WriteFile(targetConfigPath, configContent)
+	configContent, err = m.wrapStartPrograms(jobName, configContent)
+	if err != nil {
+		return err
+	}
+
+	err = m.fs.WriteFileString(targetConfigPath, configContent)
 	if err != nil {
 		return bosherr.WrapError(err, "Writing to job config file")
 	}
@@ -329,8 +400,97 @@ func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath stri
 	return nil
 }
 
+// AddProcesses renders a monit control file for process definitions.
+// Monit expects daemonizing start programs so each process gets
+// generated start and stop scripts that manage its pidfile.
+func (m monitJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	scriptsDir := path.Join(m.dirProvider.MonitJobsDir(), fmt.Sprintf("%04d_%s", jobIndex, jobName))
+
+	var monitrc bytes.Buffer
+
+	for _, process := range config.Processes {
+		scripts := newMonitProcessScripts(jobName, process, m.dirProvider)
+
+		startScriptPath := path.Join(scriptsDir, process.Name+".start")
+		stopScriptPath := path.Join(scriptsDir, process.Name+".stop")
+
+		for scriptPath, content := range map[string]string{startScriptPath: scripts.Start(), stopScriptPath: scripts.Stop()} {
+			err := m.fs.WriteFileString(scriptPath, content)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Writing script %s", scriptPath)
+			}
+
+			err = m.fs.Chmod(scriptPath, os.FileMode(0755))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Making script %s executable", scriptPath)
+			}
+		}
+
+		processMonitrc, err := scripts.Monitrc(startScriptPath, stopScriptPath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rendering monit config for process %s", process.Name)
+		}
+
+		monitrc.WriteString(processMonitrc)
+
+		if process.HealthCheck != nil {
+			m.logger.Warn(monitJobSupervisorLogTag, "Health check of process %s is not supported by monit", process.Name)
+		}
+	}
+
+	targetConfigPath := path.Join(m.dirProvider.MonitJobsDir(), fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName))
+
+	configContent, err := m.wrapStartPrograms(jobName, monitrc.String())
+	if err != nil {
+		return err
+	}
+
+	err = m.fs.WriteFileString(targetConfigPath, configContent)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job config file")
+	}
+
+	return nil
+}
+
+// wrapStartPrograms prefixes start programs in monit file with cgroup exec
+// script so that processes are started inside of job cgroup. Monit splits
+// program into arguments itself so the rest of the program stays as is.
+func (m monitJobSupervisor) wrapStartPrograms(jobName, configContent string) (string, error) {
+	if !m.cgroupManager.Supported() {
+		return configContent, nil
+	}
+
+	scriptPath := m.cgroupExecScriptPath()
+
+	err := m.fs.WriteFileString(scriptPath, monitCgroupExecScript)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Writing script %s", scriptPath)
+	}
+
+	err = m.fs.Chmod(scriptPath, os.FileMode(0755))
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Making script %s executable", scriptPath)
+	}
+
+	prefix := scriptPath + " " + m.cgroupManager.ProcsFile(jobName) + " "
+
+	return monitStartProgramRegexp.ReplaceAllString(configContent, "${1}${2}"+strings.Replace(prefix, "$", "$$", -1)), nil
+}
+
+func (m monitJobSupervisor) cgroupExecScriptPath() string {
+	return path.Join(m.dirProvider.MonitDir(), "cgroup-exec")
+}
+
 func (m monitJobSupervisor) RemoveAllJobs() error {
-	return m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
+	err := m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
+	if err != nil {
+		return err
+	}
+
+	removeJobCgroups(m.cgroupManager, m.logger, monitJobSupervisorLogTag)
+
+	return nil
 }
 
 func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err error) {
@@ -355,6 +515,43 @@ func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err e
 	return
 }
 
+// processJobs maps monit services to jobs based on names of job monit files
+func (m monitJobSupervisor) processJobs() map[string]string {
+	processJobs := map[string]string{}
+
+	configPaths, err := m.fs.Glob(path.Join(m.dirProvider.MonitJobsDir(), "*.monitrc"))
+	if err != nil {
+		m.logger.Warn(monitJobSupervisorLogTag, "Failed to find job monit files: %s", err.Error())
+		return processJobs
+	}
+
+	for _, configPath := range configPaths {
+		// Named <index>_<job>.monitrc by AddJob and AddProcesses
+		nameParts := strings.SplitN(strings.TrimSuffix(path.Base(configPath), ".monitrc"), "_", 2)
+		if len(nameParts) != 2 {
+			continue
+		}
+
+		configContent, err := m.fs.ReadFileString(configPath)
+		if err != nil {
+			m.logger.Warn(monitJobSupervisorLogTag, "Failed to read job monit file %s: %s", configPath, err.Error())
+			continue
+		}
+
+		processes, err := parseMonitFile(configContent)
+		if err != nil {
+			m.logger.Debug(monitJobSupervisorLogTag, "Failed to parse job monit file %s: %s", configPath, err.Error())
+			continue
+		}
+
+		for _, process := range processes {
+			processJobs[process.Name] = nameParts[1]
+		}
+	}
+
+	return processJobs
+}
+
 func (m monitJobSupervisor) stoppedFilePath() string {
 	return path.Join(m.dirProvider.MonitDir(), "stopped")
 }
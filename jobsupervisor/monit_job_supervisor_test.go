@@ -17,8 +17,11 @@ import (
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup/fakes"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -34,6 +37,7 @@ var _ = Describe("monitJobSupervisor", func() {
 		jobFailuresServerPort int
 		monit                 JobSupervisor
 		timeService           *fakeclock.FakeClock
+		cgroupManager         *fakecgroup.FakeManager
 	)
 
 	var jobFailureServerPort = 5000
@@ -51,6 +55,7 @@ var _ = Describe("monitJobSupervisor", func() {
 		dirProvider = boshdir.NewProvider("/var/vcap")
 		jobFailuresServerPort = getJobFailureServerPort()
 		timeService = fakeclock.NewFakeClock(time.Now())
+		cgroupManager = fakecgroup.NewFakeManager()
 
 		monit = NewMonitJobSupervisor(
 			fs,
@@ -65,6 +70,7 @@ var _ = Describe("monitJobSupervisor", func() {
 				DelayBetweenCheckTries: 0 * time.Millisecond,
 			},
 			timeService,
+			cgroupManager,
 		)
 	})
 
@@ -199,6 +205,38 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("StartProcess", func() {
+		It("starts monit service", func() {
+			err := monit.StartProcess("fake-srv")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StartServiceNames).To(Equal([]string{"fake-srv"}))
+		})
+
+		It("returns error if starting service fails", func() {
+			client.StartServiceErr = errors.New("fake-start-err")
+
+			err := monit.StartProcess("fake-srv")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+		})
+	})
+
+	Describe("StopProcess", func() {
+		It("stops monit service", func() {
+			err := monit.StopProcess("fake-srv")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StopServiceNames).To(Equal([]string{"fake-srv"}))
+		})
+
+		It("returns error if stopping service fails", func() {
+			client.StopServiceErr = errors.New("fake-stop-err")
+
+			err := monit.StopProcess("fake-srv")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-stop-err"))
+		})
+	})
+
 	Describe("StopAndWait", func() {
 		It("stop stops each monit service in group vcap", func() {
 			err := monit.StopAndWait()
@@ -246,6 +284,7 @@ var _ = Describe("monitJobSupervisor", func() {
 					DelayBetweenCheckTries: 0 * time.Millisecond,
 				},
 				timeService,
+				cgroupManager,
 			)
 
 			err := monit.StopAndWait()
@@ -450,6 +489,7 @@ var _ = Describe("monitJobSupervisor", func() {
 						DelayBetweenCheckTries: 0 * time.Millisecond,
 					},
 					timeService,
+					cgroupManager,
 				)
 
 				err := monit.StopAndWait()
@@ -499,6 +539,7 @@ var _ = Describe("monitJobSupervisor", func() {
 					jobFailuresServerPort,
 					MonitReloadOptions{},
 					timeService,
+					cgroupManager,
 				)
 
 				errchan := make(chan error)
@@ -660,6 +701,38 @@ var _ = Describe("monitJobSupervisor", func() {
 			}))
 		})
 
+		It("reports jobs of processes and usage of their cgroups", func() {
+			jobMonitPath := dirProvider.MonitJobsDir() + "/0000_router.monitrc"
+			fs.WriteFileString(jobMonitPath, "check process fake-service-1\n  with pidfile /var/vcap/sys/run/router.pid\n  group vcap\n")
+			fs.SetGlob(dirProvider.MonitJobsDir()+"/*.monitrc", []string{jobMonitPath})
+
+			cgroupManager.Usages["router"] = cgroup.Usage{
+				MemoryBytes: 4096,
+				CPUUsage:    2 * time.Second,
+				CPULimit:    1.5,
+				Pids:        3,
+			}
+
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					{Name: "fake-service-1", Monitored: true, Status: "running"},
+					{Name: "fake-service-2", Monitored: true, Status: "running"},
+				},
+			}
+
+			processes, err := monit.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Job).To(Equal("router"))
+			Expect(processes[0].Cgroup).To(Equal(&CgroupVitals{
+				MemoryKb: 4,
+				CPUSecs:  2,
+				CPULimit: 1.5,
+				Pids:     3,
+			}))
+			Expect(processes[1].Job).To(BeEmpty())
+			Expect(processes[1].Cgroup).To(BeNil())
+		})
+
 		It("returns error when failing to get service status", func() {
 			client.StatusErr = errors.New("fake-monit-client-error")
 
@@ -716,6 +789,31 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("SetJobLimits", func() {
+		It("sets up job cgroup with limits", func() {
+			limits := cgroup.Limits{Memory: "1G", CPU: 2}
+
+			err := monit.SetJobLimits("router", limits)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cgroupManager.SetupLimits["router"]).To(Equal(limits))
+		})
+
+		It("ignores limits when cgroups are not supported", func() {
+			cgroupManager.SetupErr = cgroup.ErrNotSupported
+
+			err := monit.SetJobLimits("router", cgroup.Limits{Memory: "1G"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if setting up job cgroup fails", func() {
+			cgroupManager.SetupErr = errors.New("fake-setup-error")
+
+			err := monit.SetJobLimits("router", cgroup.Limits{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-setup-error"))
+		})
+	})
+
 	Describe("AddJob", func() {
 		BeforeEach(func() {
 			fs.WriteFileString("/some/config/path", "fake-config")
@@ -754,6 +852,131 @@ var _ = Describe("monitJobSupervisor", func() {
 				Expect(err.Error()).To(ContainSubstring("fake-read-error"))
 			})
 		})
+
+		Context("when job has start programs", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/some/config/path", `check process router
+  with pidfile /var/vcap/sys/run/router/router.pid
+  start program "/var/vcap/jobs/router/bin/ctl start"
+  stop program "/var/vcap/jobs/router/bin/ctl stop"
+  restart program = '/var/vcap/jobs/router/bin/ctl restart'
+  group vcap
+`)
+			})
+
+			It("runs start programs with script that joins job cgroup", func() {
+				err := monit.AddJob("router", 0, "/some/config/path")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")).To(Equal(`check process router
+  with pidfile /var/vcap/sys/run/router/router.pid
+  start program "/var/vcap/monit/cgroup-exec /fake-cgroup/router/cgroup.procs /var/vcap/jobs/router/bin/ctl start"
+  stop program "/var/vcap/jobs/router/bin/ctl stop"
+  restart program = '/var/vcap/monit/cgroup-exec /fake-cgroup/router/cgroup.procs /var/vcap/jobs/router/bin/ctl restart'
+  group vcap
+`))
+
+				script, err := fs.ReadFileString("/var/vcap/monit/cgroup-exec")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(script).To(ContainSubstring(`echo $$ > "$procs_file"`))
+				Expect(script).To(ContainSubstring(`logger -t bosh-cgroup-exec`))
+				Expect(fs.GetFileTestStat("/var/vcap/monit/cgroup-exec").FileMode).To(Equal(os.FileMode(0755)))
+			})
+
+			It("leaves start programs as is when cgroups are not supported", func() {
+				cgroupManager.IsSupported = false
+
+				err := monit.AddJob("router", 0, "/some/config/path")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.ReadFileString(dirProvider.MonitJobsDir() + "/0000_router.monitrc")).To(ContainSubstring(
+					`start program "/var/vcap/jobs/router/bin/ctl start"`,
+				))
+				Expect(fs.FileExists("/var/vcap/monit/cgroup-exec")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("AddProcesses", func() {
+		config := processdef.Config{
+			Processes: []processdef.Process{
+				{
+					Name:       "router",
+					Executable: "/var/vcap/packages/router/bin/router",
+					Args:       []string{"--name", "it's me"},
+					Env:        map[string]string{"B": "2", "A": "1"},
+					User:       "vcap",
+					Group:      "vcap-admin",
+					WorkingDir: "/var/vcap/data/router",
+					Limits:     processdef.Limits{Memory: "1G", OpenFiles: 1024},
+				},
+				{Name: "helper", Executable: "/bin/helper"},
+			},
+		}
+
+		It("writes monit config and scripts that run processes in the background", func() {
+			err := monit.AddProcesses("router", 2, config)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString(dirProvider.MonitJobsDir() + "/0002_router.monitrc")).To(Equal(`check process router
+  with pidfile /var/vcap/data/sys/run/router/router.pid
+  start program "/var/vcap/monit/cgroup-exec /fake-cgroup/router/cgroup.procs /var/vcap/monit/job/0002_router/router.start" as uid vcap and gid vcap-admin
+  stop program "/var/vcap/monit/job/0002_router/router.stop"
+  group vcap
+  if totalmem > 1024 MB then restart
+
+check process helper
+  with pidfile /var/vcap/data/sys/run/router/helper.pid
+  start program "/var/vcap/monit/cgroup-exec /fake-cgroup/router/cgroup.procs /var/vcap/monit/job/0002_router/helper.start"
+  stop program "/var/vcap/monit/job/0002_router/helper.stop"
+  group vcap
+
+`))
+
+			Expect(fs.ReadFileString("/var/vcap/monit/job/0002_router/router.start")).To(Equal(`#!/bin/bash
+set -e
+ulimit -n 1024
+cd '/var/vcap/data/router'
+export A='1'
+export B='2'
+'/var/vcap/packages/router/bin/router' '--name' 'it'\''s me' >> '/var/vcap/data/sys/log/router/router.stdout.log' 2>> '/var/vcap/data/sys/log/router/router.stderr.log' < /dev/null &
+echo $! > '/var/vcap/data/sys/run/router/router.pid'
+`))
+
+			stopScript, err := fs.ReadFileString("/var/vcap/monit/job/0002_router/router.stop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopScript).To(ContainSubstring("pidfile='/var/vcap/data/sys/run/router/router.pid'"))
+			Expect(stopScript).To(ContainSubstring(`kill -TERM "$pid"`))
+
+			Expect(fs.GetFileTestStat("/var/vcap/monit/job/0002_router/router.start").FileMode).To(Equal(os.FileMode(0755)))
+		})
+
+		It("runs processes with primary group of user if group is not set", func() {
+			err := monit.AddProcesses("router", 2, processdef.Config{
+				Processes: []processdef.Process{{Name: "router", Executable: "/bin/router", User: "root"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString(dirProvider.MonitJobsDir() + "/0002_router.monitrc")).To(ContainSubstring(
+				`start program "/var/vcap/monit/cgroup-exec /fake-cgroup/router/cgroup.procs /var/vcap/monit/job/0002_router/router.start" as uid root and gid root`,
+			))
+		})
+
+		It("returns error if user does not exist", func() {
+			err := monit.AddProcesses("router", 2, processdef.Config{
+				Processes: []processdef.Process{{Name: "router", Executable: "/bin/router", User: "fake-missing-user"}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Looking up user fake-missing-user"))
+		})
+
+		It("returns error if writing config fails", func() {
+			fs.WriteFileError = errors.New("fake-write-error")
+
+			err := monit.AddProcesses("router", 0, config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
 	})
 
 	Describe("RemoveAllJobs", func() {
@@ -768,6 +991,7 @@ var _ = Describe("monitJobSupervisor", func() {
 
 				Expect(fs.FileExists(jobsDir)).To(BeFalse())
 				Expect(fs.FileExists(jobsDir + jobBasename)).To(BeFalse())
+				Expect(cgroupManager.RemoveAllCalled).To(BeTrue())
 			})
 		})
 
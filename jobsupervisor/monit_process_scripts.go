@@ -0,0 +1,143 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"os/user"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Seconds to wait for process to exit after SIGTERM;
+// has to stay below monit's default stop program timeout of 30 seconds
+const monitProcessStopWaitSecs = 20
+
+type monitProcessScripts struct {
+	jobName     string
+	process     processdef.Process
+	dirProvider boshdir.Provider
+}
+
+func newMonitProcessScripts(jobName string, process processdef.Process, dirProvider boshdir.Provider) monitProcessScripts {
+	return monitProcessScripts{jobName: jobName, process: process, dirProvider: dirProvider}
+}
+
+func (s monitProcessScripts) PidFile() string {
+	return path.Join(s.dirProvider.JobRunDir(s.jobName), s.process.Name+".pid")
+}
+
+func (s monitProcessScripts) Start() string {
+	lines := []string{"#!/bin/bash", "set -e"}
+
+	if s.process.Limits.OpenFiles > 0 {
+		lines = append(lines, fmt.Sprintf("ulimit -n %d", s.process.Limits.OpenFiles))
+	}
+
+	if s.process.Limits.Processes > 0 {
+		lines = append(lines, fmt.Sprintf("ulimit -u %d", s.process.Limits.Processes))
+	}
+
+	if s.process.WorkingDir != "" {
+		lines = append(lines, "cd "+shellQuote(s.process.WorkingDir))
+	}
+
+	envNames := []string{}
+	for name := range s.process.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+
+	for _, name := range envNames {
+		lines = append(lines, fmt.Sprintf("export %s=%s", name, shellQuote(s.process.Env[name])))
+	}
+
+	command := []string{shellQuote(s.process.Executable)}
+	for _, arg := range s.process.Args {
+		command = append(command, shellQuote(arg))
+	}
+
+	logDir := s.dirProvider.JobLogDir(s.jobName)
+
+	lines = append(lines,
+		fmt.Sprintf("%s >> %s 2>> %s < /dev/null &",
+			strings.Join(command, " "),
+			shellQuote(path.Join(logDir, s.process.Name+".stdout.log")),
+			shellQuote(path.Join(logDir, s.process.Name+".stderr.log")),
+		),
+		"echo $! > "+shellQuote(s.PidFile()),
+	)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (s monitProcessScripts) Stop() string {
+	return fmt.Sprintf(`#!/bin/bash
+pidfile=%s
+if [ ! -f "$pidfile" ]; then
+  exit 0
+fi
+pid=$(cat "$pidfile")
+kill -TERM "$pid" 2>/dev/null || true
+for i in $(seq 1 %d); do
+  kill -0 "$pid" 2>/dev/null || break
+  sleep 1
+done
+kill -KILL "$pid" 2>/dev/null || true
+rm -f "$pidfile"
+`, shellQuote(s.PidFile()), monitProcessStopWaitSecs)
+}
+
+func (s monitProcessScripts) Monitrc(startScriptPath, stopScriptPath string) (string, error) {
+	credentials := ""
+	if s.process.User != "" {
+		group := s.process.Group
+
+		if group == "" {
+			var err error
+
+			group, err = primaryGroupName(s.process.User)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		credentials = fmt.Sprintf(" as uid %s and gid %s", s.process.User, group)
+	}
+
+	monitrc := fmt.Sprintf(`check process %s
+  with pidfile %s
+  start program "%s"%s
+  stop program "%s"
+  group vcap
+`, s.process.Name, s.PidFile(), startScriptPath, credentials, stopScriptPath)
+
+	// Monit cannot cap memory usage but can restart processes exceeding the limit
+	memoryBytes, _ := s.process.Limits.MemoryBytes()
+	if memoryBytes > 0 {
+		monitrc += fmt.Sprintf("  if totalmem > %d MB then restart\n", memoryBytes/(1<<20))
+	}
+
+	return monitrc + "\n", nil
+}
+
+func primaryGroupName(userName string) (string, error) {
+	u, err := user.Lookup(userName)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Looking up user %s", userName)
+	}
+
+	g, err := user.LookupGroupId(u.Gid)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Looking up primary group of user %s", userName)
+	}
+
+	return g.Name, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
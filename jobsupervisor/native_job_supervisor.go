@@ -0,0 +1,578 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const nativeJobSupervisorLogTag = "nativeJobSupervisor"
+
+type NativeHealthCheck struct {
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"`
+
+	// Seconds between checks and seconds before a check is considered failed
+	Interval int `json:"interval"`
+	Timeout  int `json:"timeout"`
+
+	// Number of consecutive failures after which process is restarted
+	FailureThreshold int `json:"failure_threshold"`
+}
+
+type NativeProcess struct {
+	Name       string            `json:"name"`
+	Executable string            `json:"executable"`
+	Args       []string          `json:"args"`
+	Env        map[string]string `json:"env"`
+	User       string            `json:"user"`
+	WorkingDir string            `json:"working_dir"`
+
+	// Group defaults to primary group of User
+	Group string `json:"group"`
+
+	// Keyed by lower case resource name without RLIMIT_ prefix, e.g. nofile.
+	// Applied with prlimit(1) which has to be installed.
+	Rlimits map[string]uint64 `json:"rlimits"`
+
+	// Process is restarted once its resident memory exceeds
+	// this many bytes, like monit does with totalmem
+	MemoryLimit uint64 `json:"memory_limit,omitempty"`
+
+	HealthCheck *NativeHealthCheck `json:"health_check,omitempty"`
+}
+
+type NativeProcessConfig struct {
+	Processes []NativeProcess `json:"processes"`
+}
+
+type NativeSupervisorOptions struct {
+	// Delay before first restart; doubled for every consecutive restart
+	RestartDelay time.Duration
+
+	// Upper bound of restart delay. Processes that stayed up for at least
+	// this long are restarted after RestartDelay again.
+	MaxRestartDelay time.Duration
+
+	// Time between SIGTERM and SIGKILL when stopping processes
+	StopTimeout time.Duration
+
+	// Time between checks of memory used by processes with memory limit
+	MemoryCheckInterval time.Duration
+}
+
+type nativeJobConfig struct {
+	JobName   string          `json:"job_name"`
+	Processes []NativeProcess `json:"processes"`
+}
+
+type nativeJobSupervisor struct {
+	fs          boshsys.FileSystem
+	logger      boshlog.Logger
+	dirProvider boshdir.Provider
+	options     NativeSupervisorOptions
+	timeService clock.Clock
+
+	cgroupManager cgroup.Manager
+
+	processes     []*nativeProcess
+	processesLock sync.Mutex
+
+	alertCh chan boshalert.MonitAlert
+}
+
+// NewNativeJobSupervisor creates a job supervisor that runs job processes
+// as direct children of the agent instead of relying on monit.
+// Jobs describe their processes in a JSON NativeProcessConfig.
+func NewNativeJobSupervisor(
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	options NativeSupervisorOptions,
+	timeService clock.Clock,
+	cgroupManager cgroup.Manager,
+) JobSupervisor {
+	return &nativeJobSupervisor{
+		fs:            fs,
+		logger:        logger,
+		dirProvider:   dirProvider,
+		options:       options,
+		timeService:   timeService,
+		cgroupManager: cgroupManager,
+		alertCh:       make(chan boshalert.MonitAlert, 100),
+	}
+}
+
+func (s *nativeJobSupervisor) Reload() error {
+	configs, err := s.loadJobConfigs()
+	if err != nil {
+		return err
+	}
+
+	s.processesLock.Lock()
+
+	existing := map[string]*nativeProcess{}
+	for _, process := range s.processes {
+		existing[process.key()] = process
+	}
+
+	processes := []*nativeProcess{}
+
+	for _, config := range configs {
+		for _, spec := range config.Processes {
+			process, found := existing[config.JobName+"/"+spec.Name]
+			if found {
+				// Takes effect next time process is started
+				process.setSpec(spec)
+				delete(existing, process.key())
+			} else {
+				process = newNativeProcess(config.JobName, spec, s)
+			}
+
+			processes = append(processes, process)
+		}
+	}
+
+	s.processes = processes
+
+	s.processesLock.Unlock()
+
+	for _, process := range existing {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Stopping removed process %s", process.key())
+		process.stop()
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) Start() error {
+	for _, process := range s.currentProcesses() {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Starting process %s", process.key())
+		process.start()
+	}
+
+	err := s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) Stop() error {
+	var wg sync.WaitGroup
+
+	for _, process := range s.currentProcesses() {
+		wg.Add(1)
+
+		go func(process *nativeProcess) {
+			defer wg.Done()
+			s.logger.Debug(nativeJobSupervisorLogTag, "Stopping process %s", process.key())
+			process.stop()
+		}(process)
+	}
+
+	wg.Wait()
+
+	err := s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) StopAndWait() error {
+	// Stop already waits for processes to exit
+	return s.Stop()
+}
+
+func (s *nativeJobSupervisor) StartProcess(processName string) error {
+	process, err := s.findProcess(processName)
+	if err != nil {
+		return err
+	}
+
+	process.start()
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) StopProcess(processName string) error {
+	process, err := s.findProcess(processName)
+	if err != nil {
+		return err
+	}
+
+	process.stop()
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) findProcess(processName string) (*nativeProcess, error) {
+	for _, process := range s.currentProcesses() {
+		if process.snapshot().name == processName {
+			return process, nil
+		}
+	}
+
+	return nil, bosherr.Errorf("Process %s not found", processName)
+}
+
+func (s *nativeJobSupervisor) Unmonitor() error {
+	for _, process := range s.currentProcesses() {
+		process.unmonitor()
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) Status() string {
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	status := "running"
+
+	for _, process := range s.currentProcesses() {
+		snapshot := process.snapshot()
+		if snapshot.state == "starting" {
+			return "starting"
+		}
+		if !snapshot.monitored || snapshot.state != "running" {
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *nativeJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	now := s.timeService.Now()
+	cgroupVitals := newJobCgroupVitals(s.cgroupManager)
+
+	for _, process := range s.currentProcesses() {
+		snapshot := process.snapshot()
+
+		result := Process{
+			Name:   snapshot.name,
+			State:  snapshot.state,
+			Job:    process.jobName,
+			Cgroup: cgroupVitals.Get(process.jobName),
+		}
+
+		if snapshot.state == "running" {
+			result.Uptime.Secs = int(now.Sub(snapshot.startedAt).Seconds())
+			result.Memory.Kb = nativeProcessMemoryKb(snapshot.pid)
+		}
+
+		processes = append(processes, result)
+	}
+
+	return processes, nil
+}
+
+func (s *nativeJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	return setupJobCgroup(s.cgroupManager, jobName, limits, s.logger, nativeJobSupervisorLogTag)
+}
+
+func (s *nativeJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
+func (s *nativeJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	configContent, err := s.fs.ReadFile(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	if len(strings.TrimSpace(string(configContent))) == 0 {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Skipping job configuration for %q, empty config file %q", jobName, configPath)
+		return nil
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(string(configContent)), "{") {
+		return s.rejectMonitFile(jobName, configPath, string(configContent))
+	}
+
+	var processConfig NativeProcessConfig
+
+	err = json.Unmarshal(configContent, &processConfig)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling process config %s", configPath)
+	}
+
+	for _, process := range processConfig.Processes {
+		err = s.validateProcess(process)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating process config %s", configPath)
+		}
+	}
+
+	return s.writeJobConfig(jobName, jobIndex, processConfig.Processes)
+}
+
+// rejectMonitFile fails for monit files with processes since their start
+// programs usually daemonize and cannot be supervised as direct children
+func (s *nativeJobSupervisor) rejectMonitFile(jobName, configPath, configContent string) error {
+	processes, err := parseMonitFile(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing monit file %s", configPath)
+	}
+
+	if len(processes) == 0 {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Skipping job configuration for %q, no processes in monit file %q", jobName, configPath)
+		return nil
+	}
+
+	names := []string{}
+	for _, process := range processes {
+		names = append(names, process.Name)
+	}
+
+	return bosherr.Errorf(
+		"Job %s defines processes %s only in monit file %s which native job supervisor does not support; define them in a process config instead",
+		jobName, strings.Join(names, ", "), configPath,
+	)
+}
+
+func (s *nativeJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	processes := []NativeProcess{}
+
+	for _, process := range config.Processes {
+		memoryBytes, err := process.Limits.MemoryBytes()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Process %s", process.Name)
+		}
+
+		nativeProcess := NativeProcess{
+			Name:        process.Name,
+			Executable:  process.Executable,
+			Args:        process.Args,
+			Env:         process.Env,
+			User:        process.User,
+			Group:       process.Group,
+			WorkingDir:  process.WorkingDir,
+			Rlimits:     map[string]uint64{},
+			MemoryLimit: memoryBytes,
+		}
+
+		if process.Limits.OpenFiles > 0 {
+			nativeProcess.Rlimits["nofile"] = process.Limits.OpenFiles
+		}
+
+		if process.Limits.Processes > 0 {
+			nativeProcess.Rlimits["nproc"] = process.Limits.Processes
+		}
+
+		if process.HealthCheck != nil {
+			nativeProcess.HealthCheck = &NativeHealthCheck{
+				Executable:       process.HealthCheck.Executable,
+				Args:             process.HealthCheck.Args,
+				Interval:         process.HealthCheck.Interval,
+				Timeout:          process.HealthCheck.Timeout,
+				FailureThreshold: process.HealthCheck.FailureThreshold,
+			}
+		}
+
+		processes = append(processes, nativeProcess)
+	}
+
+	return s.writeJobConfig(jobName, jobIndex, processes)
+}
+
+func (s *nativeJobSupervisor) writeJobConfig(jobName string, jobIndex int, processes []NativeProcess) error {
+	jobConfigBytes, err := json.Marshal(nativeJobConfig{
+		JobName:   jobName,
+		Processes: processes,
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling job config")
+	}
+
+	targetConfigPath := path.Join(s.jobsDir(), fmt.Sprintf("%04d_%s.json", jobIndex, jobName))
+
+	err = s.fs.WriteFile(targetConfigPath, jobConfigBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job config file")
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) RemoveAllJobs() error {
+	err := s.fs.RemoveAll(s.jobsDir())
+	if err != nil {
+		return err
+	}
+
+	removeJobCgroups(s.cgroupManager, s.logger, nativeJobSupervisorLogTag)
+
+	return nil
+}
+
+// MonitorJobFailures resumes processes that were running before the agent
+// restarted and then reports process failures until the agent exits.
+func (s *nativeJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	err := s.resume()
+	if err != nil {
+		s.logger.Error(nativeJobSupervisorLogTag, "Failed to resume processes: %s", err.Error())
+	}
+
+	for alert := range s.alertCh {
+		err = handler(alert)
+		if err != nil {
+			s.logger.Error(nativeJobSupervisorLogTag, "Failed to handle failure of process %s: %s", alert.Service, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) HealthRecorder(status string) {
+}
+
+func (s *nativeJobSupervisor) resume() error {
+	if s.fs.FileExists(s.stoppedFilePath()) || len(s.currentProcesses()) > 0 {
+		return nil
+	}
+
+	err := s.Reload()
+	if err != nil {
+		return err
+	}
+
+	processes := s.currentProcesses()
+	if len(processes) == 0 {
+		return nil
+	}
+
+	for _, process := range processes {
+		process.adoptOrTerminateStale()
+	}
+
+	return s.Start()
+}
+
+func (s *nativeJobSupervisor) alert(processName, event, action, description string) {
+	now := s.timeService.Now()
+
+	alert := boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.Unix(), processName),
+		Service:     processName,
+		Event:       event,
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+
+	select {
+	case s.alertCh <- alert:
+	default:
+		s.logger.Error(nativeJobSupervisorLogTag, "Dropping alert for process %s: too many pending alerts", processName)
+	}
+}
+
+func (s *nativeJobSupervisor) validateProcess(process NativeProcess) error {
+	if process.Name == "" {
+		return bosherr.Error("Missing process name")
+	}
+
+	if process.Executable == "" {
+		return bosherr.Errorf("Missing executable for process %s", process.Name)
+	}
+
+	for name := range process.Rlimits {
+		if _, found := nativeRlimitResources[name]; !found {
+			return bosherr.Errorf("Unknown rlimit '%s' for process %s", name, process.Name)
+		}
+	}
+
+	if process.HealthCheck != nil && process.HealthCheck.Executable == "" {
+		return bosherr.Errorf("Missing health check executable for process %s", process.Name)
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) loadJobConfigs() ([]nativeJobConfig, error) {
+	configPaths, err := s.fs.Glob(path.Join(s.jobsDir(), "*.json"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Globbing job configs")
+	}
+
+	sort.Strings(configPaths)
+
+	configs := []nativeJobConfig{}
+
+	for _, configPath := range configPaths {
+		configBytes, err := s.fs.ReadFile(configPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading job config %s", configPath)
+		}
+
+		var config nativeJobConfig
+
+		err = json.Unmarshal(configBytes, &config)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling job config %s", configPath)
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+func (s *nativeJobSupervisor) currentProcesses() []*nativeProcess {
+	s.processesLock.Lock()
+	defer s.processesLock.Unlock()
+
+	return append([]*nativeProcess{}, s.processes...)
+}
+
+func (s *nativeJobSupervisor) openLogFile(jobName, fileName string) (boshsys.File, error) {
+	logDir := s.dirProvider.JobLogDir(jobName)
+
+	err := s.fs.MkdirAll(logDir, os.FileMode(0750))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating log directory %s", logDir)
+	}
+
+	return s.fs.OpenFile(path.Join(logDir, fileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, os.FileMode(0640))
+}
+
+// pidFilePath is on sys/run which is a tmpfs so that pids recorded
+// before a reboot are never signalled
+func (s *nativeJobSupervisor) pidFilePath(jobName, processName string) string {
+	return path.Join(s.dirProvider.JobRunDir(jobName), processName+".native.pid")
+}
+
+func (s *nativeJobSupervisor) jobsDir() string {
+	return path.Join(s.stateDir(), "jobs")
+}
+
+func (s *nativeJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.stateDir(), "stopped")
+}
+
+func (s *nativeJobSupervisor) stateDir() string {
+	return path.Join(s.dirProvider.BoshDir(), "native_supervisor")
+}
@@ -0,0 +1,634 @@
+// +build linux
+
+package jobsupervisor_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	fakecgroup "github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("nativeJobSupervisor", func() {
+	var (
+		baseDir       string
+		fs            boshsys.FileSystem
+		dirProvider   boshdir.Provider
+		supervisor    JobSupervisor
+		cgroupManager *fakecgroup.FakeManager
+		alertCh       chan boshalert.MonitAlert
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "native-job-supervisor")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		dirProvider = boshdir.NewProvider(baseDir)
+		cgroupManager = fakecgroup.NewFakeManager()
+
+		supervisor = NewNativeJobSupervisor(
+			fs,
+			logger,
+			dirProvider,
+			NativeSupervisorOptions{
+				RestartDelay:        10 * time.Millisecond,
+				MaxRestartDelay:     40 * time.Millisecond,
+				StopTimeout:         500 * time.Millisecond,
+				MemoryCheckInterval: 20 * time.Millisecond,
+			},
+			clock.NewClock(),
+			cgroupManager,
+		)
+
+		alertCh = make(chan boshalert.MonitAlert, 100)
+	})
+
+	AfterEach(func() {
+		supervisor.Stop()
+		os.RemoveAll(baseDir)
+	})
+
+	addJob := func(jobName string, processes ...NativeProcess) {
+		configBytes, err := json.Marshal(NativeProcessConfig{Processes: processes})
+		Expect(err).ToNot(HaveOccurred())
+
+		configPath := filepath.Join(baseDir, jobName+".json")
+		Expect(ioutil.WriteFile(configPath, configBytes, 0644)).To(Succeed())
+
+		Expect(supervisor.AddJob(jobName, 0, configPath)).To(Succeed())
+	}
+
+	monitorFailures := func() {
+		alerts := alertCh
+		go supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+			alerts <- alert
+			return nil
+		})
+	}
+
+	processState := func(name string) func() string {
+		return func() string {
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			for _, process := range processes {
+				if process.Name == name {
+					return process.State
+				}
+			}
+			return ""
+		}
+	}
+
+	Describe("AddJob", func() {
+		It("skips empty config files", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte("\n"), 0644)).To(Succeed())
+
+			Expect(supervisor.AddJob("fake-job", 0, configPath)).To(Succeed())
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Processes()).To(BeEmpty())
+		})
+
+		It("returns error for invalid config", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte(`{"processes":`), 0644)).To(Succeed())
+
+			err := supervisor.AddJob("fake-job", 0, configPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling process config"))
+		})
+
+		It("returns error for monit files with processes", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte("check process foo\n  with pidfile /foo.pid\n  start program \"/bin/foo\"\n"), 0644)).To(Succeed())
+
+			err := supervisor.AddJob("fake-job", 0, configPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Job fake-job defines processes foo only in monit file"))
+		})
+
+		It("skips monit files without processes", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte("check file foo with path /foo\n"), 0644)).To(Succeed())
+
+			Expect(supervisor.AddJob("fake-job", 0, configPath)).To(Succeed())
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Processes()).To(BeEmpty())
+		})
+
+		It("returns error for process without executable", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte(`{"processes":[{"name":"fake-proc"}]}`), 0644)).To(Succeed())
+
+			err := supervisor.AddJob("fake-job", 0, configPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing executable for process fake-proc"))
+		})
+
+		It("returns error for unknown rlimits", func() {
+			configPath := filepath.Join(baseDir, "monit")
+			Expect(ioutil.WriteFile(configPath, []byte(`{"processes":[{"name":"fake-proc","executable":"/bin/true","rlimits":{"bogus":1}}]}`), 0644)).To(Succeed())
+
+			err := supervisor.AddJob("fake-job", 0, configPath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown rlimit 'bogus' for process fake-proc"))
+		})
+	})
+
+	Describe("AddProcesses", func() {
+		It("runs processes from process definitions", func() {
+			err := supervisor.AddProcesses("fake-job", 0, processdef.Config{
+				Processes: []processdef.Process{{
+					Name:       "fake-proc",
+					Executable: "/bin/sleep",
+					Args:       []string{"10"},
+					Limits:     processdef.Limits{OpenFiles: 256},
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+		})
+
+		It("restarts processes exceeding their memory limit", func() {
+			monitorFailures()
+
+			err := supervisor.AddProcesses("fake-job", 0, processdef.Config{
+				Processes: []processdef.Process{{
+					Name:       "fake-proc",
+					Executable: "/bin/sleep",
+					Args:       []string{"10"},
+					Limits:     processdef.Limits{Memory: "4K"},
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Event).To(Equal("resource limit matched"))
+			Expect(alert.Description).To(ContainSubstring("exceeds limit of 4096 bytes"))
+		})
+	})
+
+	Describe("SetJobLimits", func() {
+		It("sets up job cgroup with limits", func() {
+			limits := cgroup.Limits{Memory: "64M", Pids: 10}
+			Expect(supervisor.SetJobLimits("fake-job", limits)).To(Succeed())
+			Expect(cgroupManager.SetupLimits["fake-job"]).To(Equal(limits))
+		})
+
+		It("ignores unsupported cgroups", func() {
+			cgroupManager.SetupErr = cgroup.ErrNotSupported
+			Expect(supervisor.SetJobLimits("fake-job", cgroup.Limits{Memory: "64M"})).To(Succeed())
+		})
+
+		It("returns error when job cgroup cannot be set up", func() {
+			cgroupManager.SetupErr = errors.New("fake-setup-err")
+
+			err := supervisor.SetJobLimits("fake-job", cgroup.Limits{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-setup-err"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("runs processes with args, env and working dir and captures their output", func() {
+			addJob("fake-job", NativeProcess{
+				Name:       "fake-proc",
+				Executable: "/bin/sh",
+				Args:       []string{"-c", `echo "$FAKE_VAR $(pwd)"; echo fake-stderr >&2; exec sleep 10`},
+				Env:        map[string]string{"FAKE_VAR": "fake-value"},
+				WorkingDir: baseDir,
+				Rlimits:    map[string]uint64{"nofile": 512},
+			})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+			Expect(supervisor.Status()).To(Equal("running"))
+
+			logDir := dirProvider.JobLogDir("fake-job")
+			Eventually(func() (string, error) {
+				return fs.ReadFileString(filepath.Join(logDir, "fake-proc.stdout.log"))
+			}).Should(Equal("fake-value " + baseDir + "\n"))
+			Eventually(func() (string, error) {
+				return fs.ReadFileString(filepath.Join(logDir, "fake-proc.stderr.log"))
+			}).Should(Equal("fake-stderr\n"))
+
+			pid := findPid("sleep 10", baseDir)
+			limits, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/limits")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(limits)).To(MatchRegexp(`Max open files\s+512\s+512`))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Memory.Kb).To(BeNumerically(">", 0))
+		})
+
+		It("places processes in job cgroup and reports its usage", func() {
+			cgroupManager.Usages["fake-job"] = cgroup.Usage{
+				MemoryBytes:      2048,
+				MemoryLimitBytes: 4096,
+				CPUUsage:         3 * time.Second,
+				Pids:             2,
+			}
+
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			Expect(cgroupManager.AddedPids("fake-job")).To(HaveLen(1))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Job).To(Equal("fake-job"))
+			Expect(processes[0].Cgroup).To(Equal(&CgroupVitals{
+				MemoryKb:      2,
+				MemoryLimitKb: 4,
+				CPUSecs:       3,
+				Pids:          2,
+			}))
+		})
+
+		It("starts processes inside of job cgroup so that children they fork right away do not escape it", func() {
+			root := cgroup2Root()
+			if root == "" {
+				Skip("cgroup v2 is not mounted")
+			}
+
+			cgroupManager.Root = filepath.Join(root, "bosh-agent-test-"+strconv.Itoa(os.Getpid()))
+			jobCgroupDir := filepath.Join(cgroupManager.Root, "fake-job")
+			if err := os.MkdirAll(jobCgroupDir, 0755); err != nil {
+				Skip("cgroup cannot be created: " + err.Error())
+			}
+			defer os.Remove(cgroupManager.Root)
+			defer os.Remove(jobCgroupDir)
+
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}, WorkingDir: baseDir})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			pid := findPid("/bin/sleep 10", baseDir)
+			Expect(pid).ToNot(BeZero())
+
+			procs, err := ioutil.ReadFile(filepath.Join(jobCgroupDir, "cgroup.procs"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.Fields(string(procs))).To(Equal([]string{strconv.Itoa(pid)}))
+			Expect(cgroupManager.AddedPids("fake-job")).To(BeEmpty())
+
+			Expect(supervisor.Stop()).To(Succeed())
+		})
+
+		It("moves processes into job cgroup once they started when they cannot be started inside of it", func() {
+			cgroupManager.Root = baseDir
+			Expect(os.MkdirAll(filepath.Join(baseDir, "fake-job"), 0755)).To(Succeed())
+
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			Expect(cgroupManager.AddedPids("fake-job")).To(HaveLen(1))
+		})
+
+		It("does not run processes that cannot be placed in job cgroup", func() {
+			monitorFailures()
+			cgroupManager.AddProcessErr = errors.New("fake-add-process-err")
+
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Event).To(Equal("execution failed"))
+			Expect(alert.Description).To(ContainSubstring("fake-add-process-err"))
+		})
+
+		It("restarts processes that exit and raises alerts", func() {
+			monitorFailures()
+
+			addJob("fake-job", NativeProcess{
+				Name:       "fake-proc",
+				Executable: "/bin/sh",
+				Args:       []string{"-c", "echo started >> " + filepath.Join(baseDir, "starts") + "; exit 3"},
+			})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(func() int {
+				content, _ := ioutil.ReadFile(filepath.Join(baseDir, "starts"))
+				return strings.Count(string(content), "started")
+			}, 5*time.Second).Should(BeNumerically(">=", 3))
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("fake-proc"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Description).To(ContainSubstring("exit status 3"))
+			Eventually(supervisor.Status).Should(Equal("failing"))
+		})
+
+		It("alerts when process cannot be started", func() {
+			monitorFailures()
+
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/non-existent"})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Event).To(Equal("execution failed"))
+			Expect(alert.Description).To(ContainSubstring("/non-existent"))
+		})
+
+		It("restarts processes whose health check keeps failing", func() {
+			monitorFailures()
+
+			healthyFile := filepath.Join(baseDir, "healthy")
+			addJob("fake-job", NativeProcess{
+				Name:       "fake-proc",
+				Executable: "/bin/sleep",
+				Args:       []string{"10"},
+				HealthCheck: &NativeHealthCheck{
+					Executable:       "/usr/bin/test",
+					Args:             []string{"-e", healthyFile},
+					Interval:         1,
+					FailureThreshold: 1,
+				},
+			})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh, 5*time.Second).Should(Receive(&alert))
+			Expect(alert.Event).To(Equal("execution failed"))
+			Expect(alert.Description).To(ContainSubstring("health check failed 1 times"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("terminates processes and their children and reports stopped", func() {
+			addJob("fake-job", NativeProcess{
+				Name:       "fake-proc",
+				Executable: "/bin/sh",
+				Args:       []string{"-c", "sleep 11 & wait"},
+				WorkingDir: baseDir,
+			})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(func() int { return findPid("sleep 11", baseDir) }).ShouldNot(BeZero())
+			childPid := findPid("sleep 11", baseDir)
+
+			Expect(supervisor.Stop()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("stopped"))
+			Expect(processState("fake-proc")()).To(Equal("stopped"))
+			Eventually(func() int { return findPid("sleep 11", baseDir) }).Should(BeZero())
+			Expect(childPid).ToNot(BeZero())
+		})
+	})
+
+	Describe("StopProcess", func() {
+		It("stops single process until it is started again", func() {
+			addJob("fake-job",
+				NativeProcess{Name: "fake-proc-1", Executable: "/bin/sleep", Args: []string{"10"}},
+				NativeProcess{Name: "fake-proc-2", Executable: "/bin/sleep", Args: []string{"10"}},
+			)
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc-1")).Should(Equal("running"))
+
+			Expect(supervisor.StopProcess("fake-proc-1")).To(Succeed())
+			Expect(processState("fake-proc-1")()).To(Equal("stopped"))
+			Consistently(processState("fake-proc-1"), 100*time.Millisecond).Should(Equal("stopped"))
+			Expect(processState("fake-proc-2")()).To(Equal("running"))
+
+			Expect(supervisor.StartProcess("fake-proc-1")).To(Succeed())
+			Eventually(processState("fake-proc-1")).Should(Equal("running"))
+		})
+
+		It("returns error for unknown process", func() {
+			err := supervisor.StopProcess("fake-proc")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-proc not found"))
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("stops restarting processes and reports failing", func() {
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			Expect(supervisor.Unmonitor()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("failing"))
+
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("stops processes of removed jobs", func() {
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Processes()).To(BeEmpty())
+			Expect(cgroupManager.RemoveAllCalled).To(BeTrue())
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		It("resumes processes that were configured before agent restarted", func() {
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+
+			monitorFailures()
+
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+		})
+
+		Context("when processes were left running by previous agent run", func() {
+			var (
+				pidFilePath string
+				stale       *exec.Cmd
+				staleExitCh chan error
+			)
+
+			BeforeEach(func() {
+				addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}, WorkingDir: baseDir})
+
+				pidFilePath = filepath.Join(dirProvider.JobRunDir("fake-job"), "fake-proc.native.pid")
+				Expect(fs.MkdirAll(filepath.Dir(pidFilePath), 0750)).To(Succeed())
+
+				stale = exec.Command("/bin/sleep", "11")
+				stale.Dir = baseDir
+				stale.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+				Expect(stale.Start()).To(Succeed())
+
+				exitCh := make(chan error, 1)
+				go func(cmd *exec.Cmd) { exitCh <- cmd.Wait() }(stale)
+				staleExitCh = exitCh
+			})
+
+			AfterEach(func() {
+				stale.Process.Kill()
+			})
+
+			It("adopts them instead of starting processes again", func() {
+				startTime := processStartTime(stale.Process.Pid)
+				Expect(fs.WriteFileString(pidFilePath, fmt.Sprintf("%d %d", stale.Process.Pid, startTime))).To(Succeed())
+
+				monitorFailures()
+
+				Eventually(processState("fake-proc")).Should(Equal("running"))
+				Consistently(staleExitCh, 200*time.Millisecond).ShouldNot(Receive())
+				Expect(findPid("/bin/sleep 10", baseDir)).To(BeZero())
+				Expect(fs.FileExists(pidFilePath)).To(BeTrue())
+
+				Expect(supervisor.Stop()).To(Succeed())
+				Expect(staleExitCh).To(Receive())
+				Expect(fs.FileExists(pidFilePath)).To(BeFalse())
+			})
+
+			It("starts processes again once adopted processes exit", func() {
+				startTime := processStartTime(stale.Process.Pid)
+				Expect(fs.WriteFileString(pidFilePath, fmt.Sprintf("%d %d", stale.Process.Pid, startTime))).To(Succeed())
+
+				monitorFailures()
+
+				Eventually(processState("fake-proc")).Should(Equal("running"))
+
+				Expect(stale.Process.Kill()).To(Succeed())
+				Eventually(func() int { return findPid("/bin/sleep 10", baseDir) }, 3*time.Second).ShouldNot(BeZero())
+			})
+
+			It("does not signal processes whose pid was reused since", func() {
+				startTime := processStartTime(stale.Process.Pid)
+				Expect(fs.WriteFileString(pidFilePath, fmt.Sprintf("%d %d", stale.Process.Pid, startTime+1))).To(Succeed())
+
+				monitorFailures()
+
+				Eventually(processState("fake-proc")).Should(Equal("running"))
+				Eventually(func() int { return findPid("/bin/sleep 10", baseDir) }).ShouldNot(BeZero())
+				Consistently(staleExitCh, 200*time.Millisecond).ShouldNot(Receive())
+			})
+
+			It("terminates children left by processes that exited", func() {
+				leader := exec.Command("/bin/sh", "-c", "/bin/sleep 12 & exit 0")
+				leader.Dir = baseDir
+				leader.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+				Expect(leader.Run()).To(Succeed())
+
+				Eventually(func() int { return findPid("/bin/sleep 12", baseDir) }).ShouldNot(BeZero())
+				Expect(fs.WriteFileString(pidFilePath, fmt.Sprintf("%d 1", leader.Process.Pid))).To(Succeed())
+
+				monitorFailures()
+
+				Eventually(func() int { return findPid("/bin/sleep 12", baseDir) }).Should(BeZero())
+				Eventually(processState("fake-proc")).Should(Equal("running"))
+			})
+		})
+
+		It("records pid of running processes", func() {
+			addJob("fake-job", NativeProcess{Name: "fake-proc", Executable: "/bin/sleep", Args: []string{"10"}})
+
+			monitorFailures()
+
+			Eventually(processState("fake-proc")).Should(Equal("running"))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(HaveLen(1))
+
+			pidFilePath := filepath.Join(dirProvider.JobRunDir("fake-job"), "fake-proc.native.pid")
+			Expect(fs.ReadFileString(pidFilePath)).ToNot(BeEmpty())
+
+			Expect(supervisor.Stop()).To(Succeed())
+			Expect(fs.FileExists(pidFilePath)).To(BeFalse())
+		})
+	})
+})
+
+// cgroup2Root returns mount point of cgroup v2 or empty string
+func cgroup2Root() string {
+	mounts, err := ioutil.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(string(mounts), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 2 && fields[2] == "cgroup2" {
+			return fields[1]
+		}
+	}
+
+	return ""
+}
+
+// processStartTime returns start time of process in clock ticks since boot
+func processStartTime(pid int) uint64 {
+	content, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	Expect(err).ToNot(HaveOccurred())
+
+	fields := strings.Fields(string(content[strings.LastIndex(string(content), ")")+1:]))
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	Expect(err).ToNot(HaveOccurred())
+
+	return startTime
+}
+
+// findPid returns pid of process with given command line running in dir
+func findPid(cmdline, dir string) int {
+	procDirs, _ := filepath.Glob("/proc/[0-9]*")
+	for _, procDir := range procDirs {
+		content, err := ioutil.ReadFile(filepath.Join(procDir, "cmdline"))
+		if err != nil || strings.Replace(strings.TrimRight(string(content), "\x00"), "\x00", " ", -1) != cmdline {
+			continue
+		}
+		cwd, _ := os.Readlink(filepath.Join(procDir, "cwd"))
+		if cwd != dir {
+			continue
+		}
+		pid, _ := strconv.Atoi(filepath.Base(procDir))
+		return pid
+	}
+	return 0
+}
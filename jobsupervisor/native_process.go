@@ -0,0 +1,627 @@
+package jobsupervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	nativeProcessDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+	// Processes adopted from previous agent run are not children
+	// of the agent so their exit is noticed by polling
+	nativeProcessAdoptedPollInterval = 1 * time.Second
+
+	nativeProcessStalePollInterval = 100 * time.Millisecond
+)
+
+type nativeProcess struct {
+	jobName    string
+	supervisor *nativeJobSupervisor
+
+	lock      sync.Mutex
+	spec      NativeProcess
+	state     string
+	pid       int
+	startedAt time.Time
+	monitored bool
+
+	// Set to process left running by previous agent run
+	// so that supervise loop watches it instead of starting process
+	adoptedPid       int
+	adoptedStartTime uint64
+
+	// Non-nil while supervise loop is running; stopCh is closed to end it
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+type nativeProcessSnapshot struct {
+	name      string
+	state     string
+	pid       int
+	startedAt time.Time
+	monitored bool
+}
+
+func newNativeProcess(jobName string, spec NativeProcess, supervisor *nativeJobSupervisor) *nativeProcess {
+	return &nativeProcess{
+		jobName:    jobName,
+		spec:       spec,
+		supervisor: supervisor,
+		state:      "stopped",
+		monitored:  true,
+	}
+}
+
+func (p *nativeProcess) key() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.jobName + "/" + p.spec.Name
+}
+
+func (p *nativeProcess) setSpec(spec NativeProcess) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.spec = spec
+}
+
+func (p *nativeProcess) snapshot() nativeProcessSnapshot {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return nativeProcessSnapshot{
+		name:      p.spec.Name,
+		state:     p.state,
+		pid:       p.pid,
+		startedAt: p.startedAt,
+		monitored: p.monitored,
+	}
+}
+
+func (p *nativeProcess) start() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.monitored = true
+
+	if p.stopCh != nil {
+		return
+	}
+
+	p.state = "starting"
+	p.stopCh = make(chan struct{})
+	p.doneCh = make(chan struct{})
+
+	go p.supervise(p.stopCh, p.doneCh)
+}
+
+// stop terminates process and waits for supervise loop to exit
+func (p *nativeProcess) stop() {
+	p.lock.Lock()
+
+	stopCh, doneCh := p.stopCh, p.doneCh
+	p.stopCh, p.doneCh = nil, nil
+
+	p.lock.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+
+	close(stopCh)
+	<-doneCh
+}
+
+// unmonitor leaves process running but disables restarts and alerts
+func (p *nativeProcess) unmonitor() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.monitored = false
+}
+
+func (p *nativeProcess) supervise(stopCh, doneCh chan struct{}) {
+	defer func() {
+		p.lock.Lock()
+		p.state = "stopped"
+		p.pid = 0
+		if p.doneCh == doneCh {
+			p.stopCh, p.doneCh = nil, nil
+		}
+		p.lock.Unlock()
+
+		close(doneCh)
+	}()
+
+	options := p.supervisor.options
+	delay := options.RestartDelay
+
+	for {
+		pid, exitCh, err := p.runOrAdopt()
+		if err != nil {
+			p.setState("failing")
+			p.alertIfMonitored("execution failed", "alert", fmt.Sprintf("failed to start: %s", err.Error()))
+		} else {
+			startedAt := p.setRunning(pid)
+
+			healthStopCh := make(chan struct{})
+			go p.checkHealth(pid, healthStopCh)
+			go p.checkMemory(pid, healthStopCh)
+
+			select {
+			case <-stopCh:
+				close(healthStopCh)
+				p.terminate(pid, exitCh)
+				return
+
+			case exitErr := <-exitCh:
+				close(healthStopCh)
+
+				if p.supervisor.timeService.Since(startedAt) >= options.MaxRestartDelay {
+					delay = options.RestartDelay
+				}
+
+				p.setState("failing")
+				p.alertIfMonitored("does not exist", "restart", fmt.Sprintf("process exited (%s), restarting in %s", p.describeExit(exitErr), delay))
+			}
+		}
+
+		if !p.isMonitored() {
+			return
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-p.supervisor.timeService.After(delay):
+		}
+
+		delay *= 2
+		if delay > options.MaxRestartDelay {
+			delay = options.MaxRestartDelay
+		}
+	}
+}
+
+// runOrAdopt watches adopted process once and otherwise starts process
+func (p *nativeProcess) runOrAdopt() (int, chan error, error) {
+	p.lock.Lock()
+	pid, startTime := p.adoptedPid, p.adoptedStartTime
+	p.adoptedPid, p.adoptedStartTime = 0, 0
+	p.lock.Unlock()
+
+	if pid == 0 {
+		return p.run()
+	}
+
+	p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Adopting process %s with pid %d left running by previous agent run", p.key(), pid)
+
+	return pid, p.watchAdopted(pid, startTime), nil
+}
+
+// watchAdopted returns channel that receives once adopted process exits;
+// its exit status is reaped by init so it cannot be reported
+func (p *nativeProcess) watchAdopted(pid int, startTime uint64) chan error {
+	p.lock.Lock()
+	pidFilePath := p.supervisor.pidFilePath(p.jobName, p.spec.Name)
+	p.lock.Unlock()
+
+	exitCh := make(chan error, 1)
+
+	go func() {
+		for {
+			currentStartTime, err := nativeProcessStartTime(pid)
+			if err != nil || currentStartTime != startTime {
+				break
+			}
+
+			<-p.supervisor.timeService.After(nativeProcessAdoptedPollInterval)
+		}
+
+		removeErr := p.supervisor.fs.RemoveAll(pidFilePath)
+		if removeErr != nil {
+			p.supervisor.logger.Warn(nativeJobSupervisorLogTag, "Failed to remove pid file of process %s: %s", p.key(), removeErr.Error())
+		}
+
+		exitCh <- bosherr.Error("exit status unknown")
+	}()
+
+	return exitCh
+}
+
+// run starts process and returns channel that receives its exit status
+func (p *nativeProcess) run() (int, chan error, error) {
+	p.lock.Lock()
+	spec := p.spec
+	p.lock.Unlock()
+
+	stdout, err := p.supervisor.openLogFile(p.jobName, spec.Name+".stdout.log")
+	if err != nil {
+		return 0, nil, bosherr.WrapError(err, "Opening stdout log")
+	}
+
+	stderr, err := p.supervisor.openLogFile(p.jobName, spec.Name+".stderr.log")
+	if err != nil {
+		stdout.Close()
+		return 0, nil, bosherr.WrapError(err, "Opening stderr log")
+	}
+
+	closeLogs := func() {
+		stdout.Close()
+		stderr.Close()
+	}
+
+	cmd, startedInCgroup, err := p.startCommand(spec, stdout, stderr)
+	if err != nil {
+		closeLogs()
+		return 0, nil, err
+	}
+
+	pidFilePath := p.supervisor.pidFilePath(p.jobName, spec.Name)
+
+	err = p.writePidFile(pidFilePath, cmd.Process.Pid)
+	if err != nil {
+		p.supervisor.logger.Warn(nativeJobSupervisorLogTag, "Failed to record pid of process %s: %s", p.key(), err.Error())
+	}
+
+	exitCh := make(chan error, 1)
+
+	go func() {
+		err := cmd.Wait()
+		closeLogs()
+
+		// Removed before exit is reported so that next run does not race with it
+		removeErr := p.supervisor.fs.RemoveAll(pidFilePath)
+		if removeErr != nil {
+			p.supervisor.logger.Warn(nativeJobSupervisorLogTag, "Failed to remove pid file of process %s: %s", p.key(), removeErr.Error())
+		}
+
+		exitCh <- err
+	}()
+
+	// Children forked after this point inherit job cgroup
+	if !startedInCgroup && p.supervisor.cgroupManager.Supported() {
+		err = p.supervisor.cgroupManager.AddProcess(p.jobName, cmd.Process.Pid)
+		if err != nil {
+			p.terminate(cmd.Process.Pid, exitCh)
+			return 0, nil, bosherr.WrapError(err, "Moving process into job cgroup")
+		}
+	}
+
+	return cmd.Process.Pid, exitCh, nil
+}
+
+// startCommand starts process inside of job cgroup so that children it forks right
+// away cannot escape it. When kernel cannot do that (e.g. it lacks clone3)
+// process is started as is and has to be moved into job cgroup by caller.
+func (p *nativeProcess) startCommand(spec NativeProcess, stdout, stderr io.Writer) (*exec.Cmd, bool, error) {
+	if p.supervisor.cgroupManager.Supported() {
+		cgroupDir, err := os.Open(path.Dir(p.supervisor.cgroupManager.ProcsFile(p.jobName)))
+		if err == nil {
+			defer cgroupDir.Close()
+
+			cmd, err := p.command(spec, stdout, stderr)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if nativeStartInCgroup(cmd.SysProcAttr, cgroupDir) {
+				err = cmd.Start()
+				if err == nil {
+					return cmd, true, nil
+				}
+
+				p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Failed to start process %s inside of job cgroup: %s", p.key(), err.Error())
+			}
+		}
+	}
+
+	cmd, err := p.command(spec, stdout, stderr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Starting %s", spec.Executable)
+	}
+
+	return cmd, false, nil
+}
+
+func (p *nativeProcess) command(spec NativeProcess, stdout, stderr io.Writer) (*exec.Cmd, error) {
+	executable, args, err := nativeCommand(spec.Executable, spec.Args, spec.Rlimits)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Setting rlimits")
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Env = p.env(spec)
+	cmd.Dir = spec.WorkingDir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	cmd.SysProcAttr, err = nativeSysProcAttr(spec.User, spec.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+// writePidFile records pid with start time of process
+// so that pid reused by other process is not adopted
+func (p *nativeProcess) writePidFile(pidFilePath string, pid int) error {
+	err := p.supervisor.fs.MkdirAll(path.Dir(pidFilePath), os.FileMode(0750))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating pid file directory")
+	}
+
+	startTime, err := nativeProcessStartTime(pid)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting process start time")
+	}
+
+	return p.supervisor.fs.WriteFileString(pidFilePath, fmt.Sprintf("%d %d", pid, startTime))
+}
+
+// adoptOrTerminateStale adopts process that kept running while agent
+// restarted so that agent restarts do not restart jobs. When process itself
+// exited meanwhile, children it left in its process group are terminated
+// so that they do not keep running next to process started by resume.
+func (p *nativeProcess) adoptOrTerminateStale() {
+	p.lock.Lock()
+	pidFilePath := p.supervisor.pidFilePath(p.jobName, p.spec.Name)
+	p.lock.Unlock()
+
+	if !p.supervisor.fs.FileExists(pidFilePath) {
+		return
+	}
+
+	content, err := p.supervisor.fs.ReadFileString(pidFilePath)
+	if err != nil {
+		p.supervisor.logger.Warn(nativeJobSupervisorLogTag, "Failed to read pid file of process %s: %s", p.key(), err.Error())
+		return
+	}
+
+	var (
+		pid       int
+		startTime uint64
+	)
+
+	_, err = fmt.Sscanf(strings.TrimSpace(content), "%d %d", &pid, &startTime)
+	if err == nil && pid > 0 {
+		currentStartTime, startTimeErr := nativeProcessStartTime(pid)
+		if startTimeErr == nil && currentStartTime == startTime {
+			p.lock.Lock()
+			p.adoptedPid, p.adoptedStartTime = pid, startTime
+			p.lock.Unlock()
+			return
+		}
+	}
+
+	// Group is only signalled when its leader is gone since
+	// process with same pid is not one started by previous agent run
+	if err == nil && pid > 0 && !nativeProcessExists(pid) && nativeProcessGroupExists(pid) {
+		p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Terminating process group %d of process %s left from previous agent run", pid, p.key())
+
+		err = signalNativeProcessGroup(pid, false)
+		if err != nil {
+			p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Failed to terminate process group %d: %s", pid, err.Error())
+		}
+
+		deadline := p.supervisor.timeService.Now().Add(p.supervisor.options.StopTimeout)
+
+		for nativeProcessGroupExists(pid) && p.supervisor.timeService.Now().Before(deadline) {
+			<-p.supervisor.timeService.After(nativeProcessStalePollInterval)
+		}
+
+		if nativeProcessGroupExists(pid) {
+			err = signalNativeProcessGroup(pid, true)
+			if err != nil {
+				p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Failed to kill process group %d: %s", pid, err.Error())
+			}
+		}
+	}
+
+	err = p.supervisor.fs.RemoveAll(pidFilePath)
+	if err != nil {
+		p.supervisor.logger.Warn(nativeJobSupervisorLogTag, "Failed to remove pid file of process %s: %s", p.key(), err.Error())
+	}
+}
+
+// terminate sends SIGTERM to process group and SIGKILL after stop timeout
+func (p *nativeProcess) terminate(pid int, exitCh chan error) {
+	err := signalNativeProcessGroup(pid, false)
+	if err != nil {
+		p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Failed to terminate process %d: %s", pid, err.Error())
+	}
+
+	select {
+	case <-exitCh:
+		return
+	case <-p.supervisor.timeService.After(p.supervisor.options.StopTimeout):
+	}
+
+	err = signalNativeProcessGroup(pid, true)
+	if err != nil {
+		p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Failed to kill process %d: %s", pid, err.Error())
+	}
+
+	<-exitCh
+}
+
+func (p *nativeProcess) checkHealth(pid int, stopCh chan struct{}) {
+	p.lock.Lock()
+	healthCheck := p.spec.HealthCheck
+	p.lock.Unlock()
+
+	if healthCheck == nil {
+		return
+	}
+
+	interval := time.Duration(healthCheck.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	timeout := time.Duration(healthCheck.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	threshold := healthCheck.FailureThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+
+	ticker := p.supervisor.timeService.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C():
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		output, err := exec.CommandContext(ctx, healthCheck.Executable, healthCheck.Args...).CombinedOutput()
+		cancel()
+
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+
+		p.supervisor.logger.Debug(nativeJobSupervisorLogTag, "Health check of process %s failed (%d/%d): %s %s", p.key(), failures, threshold, err.Error(), output)
+
+		if failures >= threshold {
+			p.alertIfMonitored("execution failed", "restart", fmt.Sprintf("health check failed %d times: %s", failures, err.Error()))
+
+			// Process exit is picked up by supervise loop which restarts it
+			err = signalNativeProcessGroup(pid, true)
+			if err != nil {
+				p.supervisor.logger.Error(nativeJobSupervisorLogTag, "Failed to kill unhealthy process %s: %s", p.key(), err.Error())
+			}
+
+			return
+		}
+	}
+}
+
+// checkMemory kills process once it uses more memory than its limit;
+// unlike job limits per process limits do not have their own cgroup
+func (p *nativeProcess) checkMemory(pid int, stopCh chan struct{}) {
+	p.lock.Lock()
+	memoryLimit := p.spec.MemoryLimit
+	p.lock.Unlock()
+
+	interval := p.supervisor.options.MemoryCheckInterval
+
+	if memoryLimit == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := p.supervisor.timeService.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C():
+		}
+
+		memoryBytes := uint64(nativeProcessMemoryKb(pid)) * 1024
+		if memoryBytes <= memoryLimit {
+			continue
+		}
+
+		p.alertIfMonitored("resource limit matched", "restart", fmt.Sprintf("memory usage %d bytes exceeds limit of %d bytes", memoryBytes, memoryLimit))
+
+		// Process exit is picked up by supervise loop which restarts it
+		err := signalNativeProcessGroup(pid, true)
+		if err != nil {
+			p.supervisor.logger.Error(nativeJobSupervisorLogTag, "Failed to kill process %s exceeding memory limit: %s", p.key(), err.Error())
+		}
+
+		return
+	}
+}
+
+func (p *nativeProcess) env(spec NativeProcess) []string {
+	env := []string{}
+
+	if _, found := spec.Env["PATH"]; !found {
+		env = append(env, "PATH="+nativeProcessDefaultPath)
+	}
+
+	for name, value := range spec.Env {
+		env = append(env, name+"="+value)
+	}
+
+	sort.Strings(env)
+
+	return env
+}
+
+func (p *nativeProcess) describeExit(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
+
+func (p *nativeProcess) setState(state string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.state = state
+	p.pid = 0
+}
+
+func (p *nativeProcess) setRunning(pid int) time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.state = "running"
+	p.pid = pid
+	p.startedAt = p.supervisor.timeService.Now()
+
+	return p.startedAt
+}
+
+func (p *nativeProcess) isMonitored() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.monitored
+}
+
+func (p *nativeProcess) alertIfMonitored(event, action, description string) {
+	p.lock.Lock()
+	name, monitored := p.spec.Name, p.monitored
+	p.lock.Unlock()
+
+	if monitored {
+		p.supervisor.alert(name, event, action, description)
+	}
+}
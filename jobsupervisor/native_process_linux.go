@@ -0,0 +1,166 @@
+// +build linux
+
+package jobsupervisor
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Names match long options of prlimit(1) that applies them
+var nativeRlimitResources = map[string]bool{
+	"as":      true,
+	"core":    true,
+	"cpu":     true,
+	"data":    true,
+	"fsize":   true,
+	"memlock": true,
+	"nofile":  true,
+	"nproc":   true,
+	"stack":   true,
+}
+
+// nativeSysProcAttr puts process in its own process group so that
+// its children are signalled together with it. Process keeps running
+// when agent exits and is adopted by next agent run.
+func nativeSysProcAttr(userName, groupName string) (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+
+	if userName == "" {
+		return attr, nil
+	}
+
+	u, err := user.Lookup(userName)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Looking up user %s", userName)
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing uid of user %s", userName)
+	}
+
+	groupID := u.Gid
+
+	if groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Looking up group %s", groupName)
+		}
+
+		groupID = g.Gid
+	}
+
+	gid, err := strconv.ParseUint(groupID, 10, 32)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing gid of user %s", userName)
+	}
+
+	attr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+
+	return attr, nil
+}
+
+// nativeCommand returns command line that runs executable with rlimits.
+// Limits are set by prlimit(1) before it execs executable so that they
+// already apply to anything executable does right after it starts.
+func nativeCommand(executable string, args []string, rlimits map[string]uint64) (string, []string, error) {
+	if len(rlimits) == 0 {
+		return executable, args, nil
+	}
+
+	names := []string{}
+	for name := range rlimits {
+		if !nativeRlimitResources[name] {
+			return "", nil, bosherr.Errorf("Unknown rlimit '%s'", name)
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	prlimitArgs := []string{}
+	for _, name := range names {
+		value := strconv.FormatUint(rlimits[name], 10)
+		prlimitArgs = append(prlimitArgs, "--"+name+"="+value+":"+value)
+	}
+
+	prlimitArgs = append(prlimitArgs, "--", executable)
+
+	return "prlimit", append(prlimitArgs, args...), nil
+}
+
+func signalNativeProcessGroup(pid int, kill bool) error {
+	signal := syscall.SIGTERM
+	if kill {
+		signal = syscall.SIGKILL
+	}
+
+	return syscall.Kill(-pid, signal)
+}
+
+func nativeProcessGroupExists(pid int) bool {
+	return syscall.Kill(-pid, 0) == nil
+}
+
+// nativeStartInCgroup makes process start inside of cgroup dir
+// with clone3 so that it never runs outside of it
+func nativeStartInCgroup(attr *syscall.SysProcAttr, cgroupDir *os.File) bool {
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(cgroupDir.Fd())
+	return true
+}
+
+func nativeProcessExists(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// nativeProcessStartTime returns start time of process in clock ticks
+// since boot which tells it apart from later process that reused its pid
+func nativeProcessStartTime(pid int) (uint64, error) {
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0, err
+	}
+
+	// Command name may contain spaces so fields are counted after it
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+
+	// State is 3rd field and start time 22nd
+	if len(fields) < 20 {
+		return 0, bosherr.Errorf("Unexpected format of stat of process %d", pid)
+	}
+
+	if fields[0] == "Z" {
+		return 0, bosherr.Errorf("Process %d has exited", pid)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// nativeProcessMemoryKb returns resident set size of process
+func nativeProcessMemoryKb(pid int) int {
+	statm, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/statm")
+	if err != nil {
+		return 0
+	}
+
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0
+	}
+
+	residentPages, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
+	}
+
+	return residentPages * os.Getpagesize() / 1024
+}
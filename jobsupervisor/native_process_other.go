@@ -0,0 +1,44 @@
+// +build !linux
+
+package jobsupervisor
+
+import (
+	"os"
+	"syscall"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var nativeRlimitResources = map[string]bool{}
+
+func nativeSysProcAttr(userName, groupName string) (*syscall.SysProcAttr, error) {
+	return nil, bosherr.Error("Native job supervisor is only supported on Linux")
+}
+
+func nativeCommand(executable string, args []string, rlimits map[string]uint64) (string, []string, error) {
+	return "", nil, bosherr.Error("Native job supervisor is only supported on Linux")
+}
+
+func signalNativeProcessGroup(pid int, kill bool) error {
+	return bosherr.Error("Native job supervisor is only supported on Linux")
+}
+
+func nativeProcessGroupExists(pid int) bool {
+	return false
+}
+
+func nativeStartInCgroup(attr *syscall.SysProcAttr, cgroupDir *os.File) bool {
+	return false
+}
+
+func nativeProcessExists(pid int) bool {
+	return false
+}
+
+func nativeProcessStartTime(pid int) (uint64, error) {
+	return 0, bosherr.Error("Native job supervisor is only supported on Linux")
+}
+
+func nativeProcessMemoryKb(pid int) int {
+	return 0
+}
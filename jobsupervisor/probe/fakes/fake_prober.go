@@ -0,0 +1,42 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+)
+
+type FakeProber struct {
+	lock sync.Mutex
+
+	probeErrs  map[string]error
+	probeCalls []processdef.Probe
+}
+
+func NewFakeProber() *FakeProber {
+	return &FakeProber{probeErrs: map[string]error{}}
+}
+
+// SetProbeErr makes probes of given type fail with err; nil makes them succeed
+func (p *FakeProber) SetProbeErr(probeType string, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.probeErrs[probeType] = err
+}
+
+func (p *FakeProber) Probe(probe processdef.Probe) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.probeCalls = append(p.probeCalls, probe)
+
+	return p.probeErrs[probe.Type()]
+}
+
+func (p *FakeProber) ProbeCallCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.probeCalls)
+}
@@ -0,0 +1,111 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// maxOutputLength limits how much of exec probe output is kept in errors
+const maxOutputLength = 512
+
+type Prober interface {
+	// Probe runs check defined by probe once and returns why it failed
+	Probe(probe processdef.Probe) error
+}
+
+type prober struct{}
+
+func NewProber() Prober {
+	return prober{}
+}
+
+func (p prober) Probe(probe processdef.Probe) error {
+	timeout := probe.TimeoutDuration()
+
+	switch {
+	case probe.HTTP != nil:
+		return p.probeHTTP(*probe.HTTP, timeout)
+	case probe.TCP != nil:
+		return p.probeTCP(*probe.TCP, timeout)
+	case probe.Exec != nil:
+		return p.probeExec(*probe.Exec, timeout)
+	default:
+		return bosherr.Error("Probe does not define a check")
+	}
+}
+
+func (p prober) probeHTTP(probe processdef.HTTPProbe, timeout time.Duration) error {
+	url := fmt.Sprintf("%s://%s%s", probe.SchemeOrDefault(), net.JoinHostPort(probe.HostOrDefault(), strconv.Itoa(probe.Port)), probe.Path)
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Jobs commonly serve self-signed certificates on local endpoints
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		// Redirects are reported as success without following them
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(url)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Requesting %s", url)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 400 {
+		return bosherr.Errorf("Requesting %s: status %d", url, response.StatusCode)
+	}
+
+	return nil
+}
+
+func (p prober) probeTCP(probe processdef.TCPProbe, timeout time.Duration) error {
+	address := net.JoinHostPort(probe.HostOrDefault(), strconv.Itoa(probe.Port))
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to %s", address)
+	}
+
+	return conn.Close()
+}
+
+func (p prober) probeExec(probe processdef.ExecProbe, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, probe.Executable, probe.Args...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return bosherr.Errorf("Running %s: timed out after %s", probe.Executable, timeout)
+	}
+
+	if err != nil {
+		trimmedOutput := strings.TrimSpace(string(output))
+		if len(trimmedOutput) > maxOutputLength {
+			trimmedOutput = trimmedOutput[len(trimmedOutput)-maxOutputLength:]
+		}
+
+		if trimmedOutput == "" {
+			return bosherr.WrapErrorf(err, "Running %s", probe.Executable)
+		}
+
+		return bosherr.WrapErrorf(err, "Running %s: %s", probe.Executable, trimmedOutput)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// +build !windows
+
+package probe_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+)
+
+var _ = Describe("Prober", func() {
+	var prober Prober
+
+	BeforeEach(func() {
+		prober = NewProber()
+	})
+
+	serverPort := func(server *httptest.Server) int {
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+
+		portNum, err := strconv.Atoi(port)
+		Expect(err).ToNot(HaveOccurred())
+
+		return portNum
+	}
+
+	Describe("http", func() {
+		var (
+			server *httptest.Server
+			status int
+		)
+
+		BeforeEach(func() {
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/ready" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(status)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("succeeds for 2xx and 3xx responses", func() {
+			probe := processdef.Probe{HTTP: &processdef.HTTPProbe{Port: serverPort(server), Path: "/ready"}}
+			Expect(prober.Probe(probe)).To(Succeed())
+
+			status = http.StatusFound
+			Expect(prober.Probe(probe)).To(Succeed())
+		})
+
+		It("fails for other responses", func() {
+			status = http.StatusServiceUnavailable
+
+			err := prober.Probe(processdef.Probe{HTTP: &processdef.HTTPProbe{Port: serverPort(server), Path: "/ready"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("/ready: status 503"))
+		})
+
+		It("fails when server does not respond in time", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(2 * time.Second)
+			})
+
+			err := prober.Probe(processdef.Probe{HTTP: &processdef.HTTPProbe{Port: serverPort(server)}, Timeout: 1})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("tcp", func() {
+		It("succeeds when connection can be established", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+
+			port := listener.Addr().(*net.TCPAddr).Port
+
+			Expect(prober.Probe(processdef.Probe{TCP: &processdef.TCPProbe{Port: port}})).To(Succeed())
+
+			listener.Close()
+
+			err = prober.Probe(processdef.Probe{TCP: &processdef.TCPProbe{Port: port}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Connecting to 127.0.0.1:" + strconv.Itoa(port)))
+		})
+	})
+
+	Describe("exec", func() {
+		It("succeeds when executable exits with 0", func() {
+			Expect(prober.Probe(processdef.Probe{Exec: &processdef.ExecProbe{Executable: "/bin/sh", Args: []string{"-c", "exit 0"}}})).To(Succeed())
+		})
+
+		It("fails with output of executable", func() {
+			err := prober.Probe(processdef.Probe{Exec: &processdef.ExecProbe{Executable: "/bin/sh", Args: []string{"-c", "echo not ready; exit 2"}}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exit status 2"))
+			Expect(err.Error()).To(ContainSubstring("not ready"))
+		})
+
+		It("fails when executable times out", func() {
+			err := prober.Probe(processdef.Probe{Exec: &processdef.ExecProbe{Executable: "/bin/sleep", Args: []string{"5"}}, Timeout: 1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("timed out after 1s"))
+		})
+	})
+})
@@ -0,0 +1,357 @@
+package processdef
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// FileNames lists locations of process definitions relative to job directory.
+// Since JSON is a subset of YAML both formats are parsed the same way.
+var FileNames = []string{
+	path.Join("config", "processes.yml"),
+	path.Join("config", "processes.json"),
+}
+
+var (
+	nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+$`)
+)
+
+type Config struct {
+	Processes []Process `yaml:"processes"`
+
+	// Limits are enforced on all processes of the job together
+	// unlike per process limits which are enforced by supervisors
+	Limits cgroup.Limits `yaml:"limits"`
+
+	// DependsOn lists jobs that must be running before processes
+	// of this job are started; they are stopped in reverse order
+	DependsOn []string `yaml:"depends_on"`
+}
+
+type Process struct {
+	Name       string            `yaml:"name"`
+	Executable string            `yaml:"executable"`
+	Args       []string          `yaml:"args"`
+	Env        map[string]string `yaml:"env"`
+	User       string            `yaml:"user"`
+	WorkingDir string            `yaml:"working_dir"`
+
+	// Group defaults to primary group of User
+	Group string `yaml:"group"`
+
+	Limits      Limits       `yaml:"limits"`
+	HealthCheck *HealthCheck `yaml:"health_check"`
+
+	// Probes are run by the agent whatever the supervisor backend
+	ReadinessProbe *Probe `yaml:"readiness_probe"`
+	LivenessProbe  *Probe `yaml:"liveness_probe"`
+}
+
+type Limits struct {
+	// Memory is a number of bytes with optional K, M, G or T suffix
+	Memory    string `yaml:"memory"`
+	OpenFiles uint64 `yaml:"open_files"`
+	Processes uint64 `yaml:"processes"`
+}
+
+type HealthCheck struct {
+	Executable string   `yaml:"executable"`
+	Args       []string `yaml:"args"`
+
+	Interval         int `yaml:"interval"`
+	Timeout          int `yaml:"timeout"`
+	FailureThreshold int `yaml:"failure_threshold"`
+}
+
+// Probe must define exactly one of HTTP, TCP and exec checks
+type Probe struct {
+	HTTP *HTTPProbe `yaml:"http"`
+	TCP  *TCPProbe  `yaml:"tcp"`
+	Exec *ExecProbe `yaml:"exec"`
+
+	InitialDelay     int `yaml:"initial_delay"`
+	Interval         int `yaml:"interval"`
+	Timeout          int `yaml:"timeout"`
+	FailureThreshold int `yaml:"failure_threshold"`
+}
+
+// HTTPProbe succeeds when server responds with 2xx or 3xx status
+type HTTPProbe struct {
+	Scheme string `yaml:"scheme"`
+	Host   string `yaml:"host"`
+	Port   int    `yaml:"port"`
+	Path   string `yaml:"path"`
+}
+
+// TCPProbe succeeds when connection can be established
+type TCPProbe struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// ExecProbe succeeds when executable exits with 0
+type ExecProbe struct {
+	Executable string   `yaml:"executable"`
+	Args       []string `yaml:"args"`
+}
+
+// FindFile returns path of process definitions in job directory if job has one
+func FindFile(fs boshsys.FileSystem, jobDir string) (string, bool) {
+	for _, fileName := range FileNames {
+		filePath := path.Join(jobDir, fileName)
+		if fs.FileExists(filePath) {
+			return filePath, true
+		}
+	}
+
+	return "", false
+}
+
+// ParseFile reads and validates process definitions
+func ParseFile(fs boshsys.FileSystem, filePath string) (Config, error) {
+	var config Config
+
+	contents, err := fs.ReadFile(filePath)
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Reading process definitions %s", filePath)
+	}
+
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Parsing process definitions %s", filePath)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, bosherr.WrapErrorf(err, "Validating process definitions %s", filePath)
+	}
+
+	return config, nil
+}
+
+func (c Config) Validate() error {
+	if len(c.Processes) == 0 {
+		return bosherr.Error("Must define at least one process")
+	}
+
+	var errs []error
+
+	names := map[string]bool{}
+
+	for i, process := range c.Processes {
+		label := process.Name
+		if label == "" {
+			label = strconv.Itoa(i)
+		}
+
+		for _, err := range process.validate() {
+			errs = append(errs, bosherr.WrapErrorf(err, "Process '%s'", label))
+		}
+
+		if process.Name != "" {
+			if names[process.Name] {
+				errs = append(errs, bosherr.Errorf("Process '%s': Name is not unique", label))
+			}
+			names[process.Name] = true
+		}
+	}
+
+	if err := c.Limits.Validate(); err != nil {
+		errs = append(errs, bosherr.WrapError(err, "Job limits"))
+	}
+
+	for _, jobName := range c.DependsOn {
+		if !nameRegexp.MatchString(jobName) {
+			errs = append(errs, bosherr.Errorf("Dependency '%s' is not a valid job name", jobName))
+		}
+	}
+
+	if len(errs) > 0 {
+		return bosherr.NewMultiError(errs...)
+	}
+
+	return nil
+}
+
+func (p Process) validate() []error {
+	var errs []error
+
+	if p.Name == "" {
+		errs = append(errs, bosherr.Error("Missing name"))
+	} else if !nameRegexp.MatchString(p.Name) {
+		errs = append(errs, bosherr.Error("Name must only contain letters, digits, '_', '.' and '-'"))
+	}
+
+	if !path.IsAbs(p.Executable) {
+		errs = append(errs, bosherr.Errorf("Executable '%s' must be an absolute path", p.Executable))
+	}
+
+	if p.WorkingDir != "" && !path.IsAbs(p.WorkingDir) {
+		errs = append(errs, bosherr.Errorf("Working dir '%s' must be an absolute path", p.WorkingDir))
+	}
+
+	for name := range p.Env {
+		if name == "" || strings.ContainsAny(name, "= ") {
+			errs = append(errs, bosherr.Errorf("Invalid env variable name '%s'", name))
+		}
+	}
+
+	if _, err := p.Limits.MemoryBytes(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if p.ReadinessProbe != nil {
+		for _, err := range p.ReadinessProbe.validate() {
+			errs = append(errs, bosherr.WrapError(err, "Readiness probe"))
+		}
+	}
+
+	if p.LivenessProbe != nil {
+		for _, err := range p.LivenessProbe.validate() {
+			errs = append(errs, bosherr.WrapError(err, "Liveness probe"))
+		}
+	}
+
+	if p.HealthCheck != nil {
+		if !path.IsAbs(p.HealthCheck.Executable) {
+			errs = append(errs, bosherr.Errorf("Health check executable '%s' must be an absolute path", p.HealthCheck.Executable))
+		}
+
+		if p.HealthCheck.Interval < 0 || p.HealthCheck.Timeout < 0 || p.HealthCheck.FailureThreshold < 0 {
+			errs = append(errs, bosherr.Error("Health check interval, timeout and failure threshold must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+func (p Probe) validate() []error {
+	var errs []error
+
+	checks := 0
+
+	if p.HTTP != nil {
+		checks++
+
+		if scheme := p.HTTP.SchemeOrDefault(); scheme != "http" && scheme != "https" {
+			errs = append(errs, bosherr.Errorf("Scheme '%s' must be http or https", scheme))
+		}
+
+		if p.HTTP.Path != "" && !strings.HasPrefix(p.HTTP.Path, "/") {
+			errs = append(errs, bosherr.Errorf("Path '%s' must start with '/'", p.HTTP.Path))
+		}
+
+		if p.HTTP.Port < 1 || p.HTTP.Port > 65535 {
+			errs = append(errs, bosherr.Errorf("Port %d must be between 1 and 65535", p.HTTP.Port))
+		}
+	}
+
+	if p.TCP != nil {
+		checks++
+
+		if p.TCP.Port < 1 || p.TCP.Port > 65535 {
+			errs = append(errs, bosherr.Errorf("Port %d must be between 1 and 65535", p.TCP.Port))
+		}
+	}
+
+	if p.Exec != nil {
+		checks++
+
+		if !path.IsAbs(p.Exec.Executable) {
+			errs = append(errs, bosherr.Errorf("Executable '%s' must be an absolute path", p.Exec.Executable))
+		}
+	}
+
+	if checks != 1 {
+		errs = append(errs, bosherr.Error("Must define exactly one of http, tcp and exec"))
+	}
+
+	if p.InitialDelay < 0 || p.Interval < 0 || p.Timeout < 0 || p.FailureThreshold < 0 {
+		errs = append(errs, bosherr.Error("Initial delay, interval, timeout and failure threshold must not be negative"))
+	}
+
+	return errs
+}
+
+// Type returns name of the check defined by probe
+func (p Probe) Type() string {
+	switch {
+	case p.HTTP != nil:
+		return "http"
+	case p.TCP != nil:
+		return "tcp"
+	case p.Exec != nil:
+		return "exec"
+	default:
+		return ""
+	}
+}
+
+func (p Probe) InitialDelayDuration() time.Duration {
+	return time.Duration(p.InitialDelay) * time.Second
+}
+
+// IntervalDuration defaults to 10 seconds
+func (p Probe) IntervalDuration() time.Duration {
+	if p.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(p.Interval) * time.Second
+}
+
+// TimeoutDuration defaults to 1 second and never exceeds interval
+func (p Probe) TimeoutDuration() time.Duration {
+	timeout := time.Duration(p.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	if interval := p.IntervalDuration(); timeout > interval {
+		return interval
+	}
+
+	return timeout
+}
+
+// FailureThresholdOrDefault defaults to 3 consecutive failures
+func (p Probe) FailureThresholdOrDefault() int {
+	if p.FailureThreshold <= 0 {
+		return 3
+	}
+	return p.FailureThreshold
+}
+
+func (p HTTPProbe) SchemeOrDefault() string {
+	if p.Scheme == "" {
+		return "http"
+	}
+	return p.Scheme
+}
+
+func (p HTTPProbe) HostOrDefault() string {
+	if p.Host == "" {
+		return "127.0.0.1"
+	}
+	return p.Host
+}
+
+func (p TCPProbe) HostOrDefault() string {
+	if p.Host == "" {
+		return "127.0.0.1"
+	}
+	return p.Host
+}
+
+// MemoryBytes returns memory limit in bytes or 0 if there is no limit
+func (l Limits) MemoryBytes() (uint64, error) {
+	return cgroup.ParseMemory(l.Memory)
+}
@@ -0,0 +1,179 @@
+package processdef_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("Config", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	Describe("FindFile", func() {
+		It("finds yaml or json process definitions in job directory", func() {
+			_, found := FindFile(fs, "/fake-job")
+			Expect(found).To(BeFalse())
+
+			fs.WriteFileString("/fake-job/config/processes.json", "{}")
+			filePath, found := FindFile(fs, "/fake-job")
+			Expect(found).To(BeTrue())
+			Expect(filePath).To(Equal("/fake-job/config/processes.json"))
+
+			fs.WriteFileString("/fake-job/config/processes.yml", "")
+			filePath, _ = FindFile(fs, "/fake-job")
+			Expect(filePath).To(Equal("/fake-job/config/processes.yml"))
+		})
+	})
+
+	Describe("ParseFile", func() {
+		It("parses yaml process definitions", func() {
+			fs.WriteFileString("/processes.yml", `
+processes:
+- name: fake-proc
+  executable: /var/vcap/packages/fake/bin/fake
+  args: [--config, /var/vcap/jobs/fake/config/fake.yml]
+  env:
+    FAKE_VAR: fake-value
+  user: vcap
+  group: vcap-admin
+  working_dir: /var/vcap/data/fake
+  limits:
+    memory: 512M
+    open_files: 4096
+    processes: 100
+  health_check:
+    executable: /var/vcap/jobs/fake/bin/healthy
+    interval: 5
+    failure_threshold: 2
+  readiness_probe:
+    http: {port: 8080, path: /ready}
+    initial_delay: 5
+  liveness_probe:
+    exec: {executable: /var/vcap/jobs/fake/bin/alive, args: [--quick]}
+    timeout: 3
+limits:
+  memory: 2G
+  cpu: 1.5
+  pids: 500
+depends_on: [fake-proxy]
+`)
+
+			config, err := ParseFile(fs, "/processes.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(Config{
+				Processes: []Process{{
+					Name:       "fake-proc",
+					Executable: "/var/vcap/packages/fake/bin/fake",
+					Args:       []string{"--config", "/var/vcap/jobs/fake/config/fake.yml"},
+					Env:        map[string]string{"FAKE_VAR": "fake-value"},
+					User:       "vcap",
+					WorkingDir: "/var/vcap/data/fake",
+					Group:      "vcap-admin",
+					Limits:     Limits{Memory: "512M", OpenFiles: 4096, Processes: 100},
+					HealthCheck: &HealthCheck{
+						Executable:       "/var/vcap/jobs/fake/bin/healthy",
+						Interval:         5,
+						FailureThreshold: 2,
+					},
+					ReadinessProbe: &Probe{
+						HTTP:         &HTTPProbe{Port: 8080, Path: "/ready"},
+						InitialDelay: 5,
+					},
+					LivenessProbe: &Probe{
+						Exec:    &ExecProbe{Executable: "/var/vcap/jobs/fake/bin/alive", Args: []string{"--quick"}},
+						Timeout: 3,
+					},
+				}},
+				Limits:    cgroup.Limits{Memory: "2G", CPU: 1.5, Pids: 500},
+				DependsOn: []string{"fake-proxy"},
+			}))
+
+			Expect(config.Processes[0].Limits.MemoryBytes()).To(Equal(uint64(512 * 1024 * 1024)))
+		})
+
+		It("parses json process definitions", func() {
+			fs.WriteFileString("/processes.json", `{"processes": [{"name": "fake-proc", "executable": "/bin/fake"}]}`)
+
+			config, err := ParseFile(fs, "/processes.json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Processes).To(Equal([]Process{{Name: "fake-proc", Executable: "/bin/fake"}}))
+		})
+
+		It("returns error if file cannot be parsed", func() {
+			fs.WriteFileString("/processes.yml", "processes: [")
+
+			_, err := ParseFile(fs, "/processes.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing process definitions /processes.yml"))
+		})
+
+		It("returns all validation errors", func() {
+			fs.WriteFileString("/processes.yml", `
+processes:
+- name: fake-proc
+  executable: fake
+  limits: {memory: lots}
+- name: fake-proc
+  executable: /bin/fake
+  health_check: {executable: healthy}
+  readiness_probe: {http: {port: 0, scheme: ftp, path: ready}}
+  liveness_probe: {tcp: {port: 80}, exec: {executable: alive}, interval: -1}
+- executable: /bin/fake
+limits: {cpu: -1}
+depends_on: ["fake proxy"]
+`)
+
+			_, err := ParseFile(fs, "/processes.yml")
+			Expect(err).To(HaveOccurred())
+
+			message := err.Error()
+			Expect(message).To(ContainSubstring("Validating process definitions /processes.yml"))
+			Expect(message).To(ContainSubstring("Process 'fake-proc': Executable 'fake' must be an absolute path"))
+			Expect(message).To(ContainSubstring("Memory limit 'lots' must be a number of bytes"))
+			Expect(message).To(ContainSubstring("Process 'fake-proc': Name is not unique"))
+			Expect(message).To(ContainSubstring("Health check executable 'healthy' must be an absolute path"))
+			Expect(message).To(ContainSubstring("Readiness probe: Scheme 'ftp' must be http or https"))
+			Expect(message).To(ContainSubstring("Readiness probe: Path 'ready' must start with '/'"))
+			Expect(message).To(ContainSubstring("Readiness probe: Port 0 must be between 1 and 65535"))
+			Expect(message).To(ContainSubstring("Liveness probe: Executable 'alive' must be an absolute path"))
+			Expect(message).To(ContainSubstring("Liveness probe: Must define exactly one of http, tcp and exec"))
+			Expect(message).To(ContainSubstring("Liveness probe: Initial delay, interval, timeout and failure threshold must not be negative"))
+			Expect(message).To(ContainSubstring("Process '2': Missing name"))
+			Expect(message).To(ContainSubstring("Job limits: CPU limit must not be negative"))
+			Expect(message).To(ContainSubstring("Dependency 'fake proxy' is not a valid job name"))
+		})
+
+		It("returns error if no processes are defined", func() {
+			fs.WriteFileString("/processes.yml", "processes: []")
+
+			_, err := ParseFile(fs, "/processes.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Must define at least one process"))
+		})
+	})
+})
+
+var _ = Describe("Probe", func() {
+	It("defaults interval, timeout and failure threshold", func() {
+		probe := Probe{TCP: &TCPProbe{Port: 80}}
+		Expect(probe.Type()).To(Equal("tcp"))
+		Expect(probe.IntervalDuration()).To(Equal(10 * time.Second))
+		Expect(probe.TimeoutDuration()).To(Equal(1 * time.Second))
+		Expect(probe.FailureThresholdOrDefault()).To(Equal(3))
+		Expect(probe.TCP.HostOrDefault()).To(Equal("127.0.0.1"))
+	})
+
+	It("does not let timeout exceed interval", func() {
+		probe := Probe{Exec: &ExecProbe{Executable: "/bin/true"}, Interval: 2, Timeout: 5}
+		Expect(probe.TimeoutDuration()).To(Equal(2 * time.Second))
+	})
+})
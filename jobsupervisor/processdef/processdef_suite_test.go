@@ -0,0 +1,13 @@
+package processdef_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestProcessdef(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Processdef Suite")
+}
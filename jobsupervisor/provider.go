@@ -8,7 +8,10 @@ import (
 	"code.cloudfoundry.org/clock"
 
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	boshsystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -31,6 +34,9 @@ func NewProvider(
 	timeService := clock.NewClock()
 	fs := platform.GetFs()
 	runner := platform.GetRunner()
+	cgroupManager := cgroup.NewFsManager(fs, cgroup.DefaultRoot, cgroup.DefaultProcDir, logger)
+	prober := probe.NewProber()
+
 	monitJobSupervisor := NewMonitJobSupervisor(
 		fs,
 		runner,
@@ -44,10 +50,36 @@ func NewProvider(
 			DelayBetweenCheckTries: 5 * time.Second,
 		},
 		timeService,
+		cgroupManager,
+	)
+
+	systemdJobSupervisor := NewSystemdJobSupervisor(
+		fs,
+		boshsystemd.NewBusctlClient(runner, logger),
+		logger,
+		dirProvider,
+		SystemdUnitsDir,
+		timeService,
+	)
+
+	nativeJobSupervisor := NewNativeJobSupervisor(
+		fs,
+		logger,
+		dirProvider,
+		NativeSupervisorOptions{
+			RestartDelay:        1 * time.Second,
+			MaxRestartDelay:     1 * time.Minute,
+			StopTimeout:         30 * time.Second,
+			MemoryCheckInterval: 10 * time.Second,
+		},
+		timeService,
+		cgroupManager,
 	)
 
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger),
+		"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger, prober, timeService),
+		"systemd":    NewWrapperJobSupervisor(systemdJobSupervisor, fs, dirProvider, logger, prober, timeService),
+		"native":     NewWrapperJobSupervisor(nativeJobSupervisor, fs, dirProvider, logger, prober, timeService),
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
 	}
@@ -8,7 +8,10 @@ import (
 
 	"code.cloudfoundry.org/clock"
 	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	boshsystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -71,6 +74,7 @@ func init() {
 						DelayBetweenCheckTries: 5 * time.Second,
 					},
 					timeService,
+					cgroup.NewFsManager(platform.Fs, cgroup.DefaultRoot, cgroup.DefaultProcDir, logger),
 				)
 
 				expectedSupervisor := NewWrapperJobSupervisor(
@@ -78,12 +82,78 @@ func init() {
 					platform.Fs,
 					dirProvider,
 					logger,
+					probe.NewProber(),
+					timeService,
 				)
 
 				Expect(actualSupervisor).To(Equal(expectedSupervisor))
 			}
 		})
 
+		It("provides a systemd job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("systemd job supervisor is not available on windows")
+			}
+
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).ToNot(HaveOccurred())
+
+			delegateSupervisor := NewSystemdJobSupervisor(
+				platform.Fs,
+				boshsystemd.NewBusctlClient(platform.Runner, logger),
+				logger,
+				dirProvider,
+				SystemdUnitsDir,
+				timeService,
+			)
+
+			expectedSupervisor := NewWrapperJobSupervisor(
+				delegateSupervisor,
+				platform.Fs,
+				dirProvider,
+				logger,
+				probe.NewProber(),
+				timeService,
+			)
+
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
+		It("provides a native job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("native job supervisor is not available on windows")
+			}
+
+			actualSupervisor, err := provider.Get("native")
+			Expect(err).ToNot(HaveOccurred())
+
+			delegateSupervisor := NewNativeJobSupervisor(
+				platform.Fs,
+				logger,
+				dirProvider,
+				NativeSupervisorOptions{
+					RestartDelay:        1 * time.Second,
+					MaxRestartDelay:     1 * time.Minute,
+					StopTimeout:         30 * time.Second,
+					MemoryCheckInterval: 10 * time.Second,
+				},
+				timeService,
+				cgroup.NewFsManager(platform.Fs, cgroup.DefaultRoot, cgroup.DefaultProcDir, logger),
+			)
+
+			expectedSupervisor := NewWrapperJobSupervisor(
+				delegateSupervisor,
+				platform.Fs,
+				dirProvider,
+				logger,
+				probe.NewProber(),
+				timeService,
+			)
+
+			// Supervisors own alert channels so they can only be compared by type
+			Expect(actualSupervisor).To(BeAssignableToTypeOf(expectedSupervisor))
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
@@ -5,8 +5,10 @@ package jobsupervisor
 import (
 	"os"
 
+	"code.cloudfoundry.org/clock"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -28,6 +30,8 @@ func NewProvider(
 ) (p Provider) {
 	fs := platform.GetFs()
 	runner := platform.GetRunner()
+	prober := probe.NewProber()
+	timeService := clock.NewClock()
 
 	network, err := platform.GetDefaultNetwork()
 	var machineIP string
@@ -39,10 +43,10 @@ func NewProvider(
 	}
 
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"monit":      NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger, prober, timeService),
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
-		"windows":    NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"windows":    NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger, prober, timeService),
 	}
 
 	return
@@ -0,0 +1,328 @@
+package systemd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	busctlClientLogTag = "systemdBusctlClient"
+
+	systemdDestination      = "org.freedesktop.systemd1"
+	systemdManagerPath      = "/org/freedesktop/systemd1"
+	systemdManagerInterface = "org.freedesktop.systemd1.Manager"
+	systemdUnitInterface    = "org.freedesktop.systemd1.Unit"
+	systemdServiceInterface = "org.freedesktop.systemd1.Service"
+	systemdSliceInterface   = "org.freedesktop.systemd1.Slice"
+
+	// systemd reports unset uint64 properties (e.g. MemoryCurrent) as max uint64
+	systemdUnsetUint64 = ^uint64(0)
+)
+
+type busctlClient struct {
+	runner boshsys.CmdRunner
+	logger boshlog.Logger
+}
+
+// NewBusctlClient creates a client that talks to systemd's D-Bus API.
+// Calls are made through busctl so that no D-Bus library is required;
+// states of many units are shown by systemctl at once instead.
+func NewBusctlClient(runner boshsys.CmdRunner, logger boshlog.Logger) Client {
+	return busctlClient{runner: runner, logger: logger}
+}
+
+func (c busctlClient) Reload() error {
+	_, err := c.callManager("Reload")
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading systemd")
+	}
+
+	return nil
+}
+
+func (c busctlClient) Version() (int, error) {
+	values, err := c.getProperties(systemdManagerPath, systemdManagerInterface, "Version")
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Getting systemd version")
+	}
+
+	version, err := parseBusctlString(values[0])
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Parsing Version")
+	}
+
+	// Distributions append their own suffixes, e.g. 245.4-4ubuntu3
+	major := strings.TrimSpace(version)
+	if i := strings.IndexFunc(major, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		major = major[:i]
+	}
+
+	majorVersion, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing systemd version '%s'", version)
+	}
+
+	return majorVersion, nil
+}
+
+func (c busctlClient) StartUnit(name string) error {
+	_, err := c.callManager("StartUnit", "ss", name, "replace")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting unit %s", name)
+	}
+
+	return nil
+}
+
+func (c busctlClient) StopUnit(name string) error {
+	_, err := c.callManager("StopUnit", "ss", name, "replace")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping unit %s", name)
+	}
+
+	return nil
+}
+
+func (c busctlClient) ResetFailedUnit(name string) error {
+	_, err := c.callManager("ResetFailedUnit", "s", name)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Resetting failed unit %s", name)
+	}
+
+	return nil
+}
+
+func (c busctlClient) UnitStatus(name string) (UnitStatus, error) {
+	status := UnitStatus{Name: name}
+
+	unitPath, err := c.loadUnit(name)
+	if err != nil {
+		return status, err
+	}
+
+	unitValues, err := c.getProperties(unitPath, systemdUnitInterface, "ActiveState", "SubState", "ActiveEnterTimestamp")
+	if err != nil {
+		return status, bosherr.WrapErrorf(err, "Getting properties of unit %s", name)
+	}
+
+	status.ActiveState, err = parseBusctlString(unitValues[0])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing ActiveState")
+	}
+
+	status.SubState, err = parseBusctlString(unitValues[1])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing SubState")
+	}
+
+	activeEnterUsec, err := parseBusctlUint(unitValues[2])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing ActiveEnterTimestamp")
+	}
+
+	if activeEnterUsec > 0 {
+		status.ActiveEnterTimestamp = time.Unix(0, int64(activeEnterUsec)*int64(time.Microsecond))
+	}
+
+	serviceValues, err := c.getProperties(unitPath, systemdServiceInterface, "MainPID", "MemoryCurrent", "NRestarts", "Slice")
+	if err != nil {
+		return status, bosherr.WrapErrorf(err, "Getting service properties of unit %s", name)
+	}
+
+	mainPID, err := parseBusctlUint(serviceValues[0])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing MainPID")
+	}
+
+	status.MainPID = int(mainPID)
+
+	status.MemoryCurrent, err = parseBusctlUint(serviceValues[1])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing MemoryCurrent")
+	}
+
+	if status.MemoryCurrent == systemdUnsetUint64 {
+		status.MemoryCurrent = 0
+	}
+
+	nRestarts, err := parseBusctlUint(serviceValues[2])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing NRestarts")
+	}
+
+	status.NRestarts = int(nRestarts)
+
+	status.Slice, err = parseBusctlString(serviceValues[3])
+	if err != nil {
+		return status, bosherr.WrapError(err, "Parsing Slice")
+	}
+
+	return status, nil
+}
+
+func (c busctlClient) SliceStatus(name string) (SliceStatus, error) {
+	status := SliceStatus{Name: name}
+
+	slicePath, err := c.loadUnit(name)
+	if err != nil {
+		return status, err
+	}
+
+	properties := []string{"MemoryCurrent", "MemoryMax", "CPUUsageNSec", "CPUQuotaPerSecUSec", "TasksCurrent", "TasksMax"}
+
+	sliceValues, err := c.getProperties(slicePath, systemdSliceInterface, properties...)
+	if err != nil {
+		return status, bosherr.WrapErrorf(err, "Getting slice properties of unit %s", name)
+	}
+
+	values := make([]uint64, len(properties))
+
+	for i, sliceValue := range sliceValues {
+		values[i], err = parseBusctlUint(sliceValue)
+		if err != nil {
+			return status, bosherr.WrapErrorf(err, "Parsing %s", properties[i])
+		}
+
+		if values[i] == systemdUnsetUint64 {
+			values[i] = 0
+		}
+	}
+
+	status.MemoryCurrent = values[0]
+	status.MemoryMax = values[1]
+	status.CPUUsage = time.Duration(values[2])
+	status.CPUQuotaPerSec = time.Duration(values[3]) * time.Microsecond
+	status.TasksCurrent = values[4]
+	status.TasksMax = values[5]
+
+	return status, nil
+}
+
+// UnitStates uses systemctl since D-Bus API can only
+// return properties of a single unit per call
+func (c busctlClient) UnitStates(names []string) ([]UnitState, error) {
+	if len(names) == 0 {
+		return []UnitState{}, nil
+	}
+
+	args := append([]string{"show", "--property=ActiveState,SubState,NRestarts,ExecMainCode,ExecMainStatus"}, names...)
+
+	stdout, stderr, _, err := c.runner.RunCommand("systemctl", args...)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Showing units: %s", strings.TrimSpace(stderr))
+	}
+
+	// Properties of each unit are separated by an empty line
+	blocks := strings.Split(strings.TrimSpace(stdout), "\n\n")
+	if len(blocks) != len(names) {
+		return nil, bosherr.Errorf("Expected properties of %d units but got %d", len(names), len(blocks))
+	}
+
+	states := make([]UnitState, len(names))
+
+	for i, block := range blocks {
+		states[i].Name = names[i]
+
+		for _, line := range strings.Split(block, "\n") {
+			parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			switch parts[0] {
+			case "ActiveState":
+				states[i].ActiveState = parts[1]
+			case "SubState":
+				states[i].SubState = parts[1]
+			case "NRestarts":
+				states[i].NRestarts, err = strconv.Atoi(parts[1])
+			case "ExecMainCode":
+				states[i].ExecMainCode, err = strconv.Atoi(parts[1])
+			case "ExecMainStatus":
+				states[i].ExecMainStatus, err = strconv.Atoi(parts[1])
+			}
+
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Parsing %s of unit %s", parts[0], names[i])
+			}
+		}
+	}
+
+	return states, nil
+}
+
+// loadUnit returns object path of a unit; LoadUnit (unlike GetUnit)
+// succeeds for units that are not currently loaded
+func (c busctlClient) loadUnit(name string) (string, error) {
+	stdout, err := c.callManager("LoadUnit", "s", name)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Loading unit %s", name)
+	}
+
+	unitPath, err := parseBusctlString(stdout)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing object path of unit %s", name)
+	}
+
+	return unitPath, nil
+}
+
+func (c busctlClient) callManager(method string, args ...string) (string, error) {
+	cmdArgs := append([]string{"call", systemdDestination, systemdManagerPath, systemdManagerInterface, method}, args...)
+
+	stdout, stderr, _, err := c.runner.RunCommand("busctl", cmdArgs...)
+	if err != nil {
+		c.logger.Debug(busctlClientLogTag, "Calling %s failed: %s", method, stderr)
+		return "", bosherr.WrapErrorf(err, "Calling %s: %s", method, strings.TrimSpace(stderr))
+	}
+
+	return stdout, nil
+}
+
+func (c busctlClient) getProperties(objectPath, iface string, properties ...string) ([]string, error) {
+	cmdArgs := append([]string{"get-property", systemdDestination, objectPath, iface}, properties...)
+
+	stdout, stderr, _, err := c.runner.RunCommand("busctl", cmdArgs...)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Getting properties: %s", strings.TrimSpace(stderr))
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != len(properties) {
+		return nil, bosherr.Errorf("Expected %d property values but got %d", len(properties), len(lines))
+	}
+
+	return lines, nil
+}
+
+// parseBusctlString parses busctl output of a single string or object path
+// value, e.g. `s "active"` or `o "/org/freedesktop/systemd1/unit/foo"`
+func parseBusctlString(value string) (string, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
+	if len(parts) != 2 || (parts[0] != "s" && parts[0] != "o") {
+		return "", bosherr.Errorf("Unexpected string value '%s'", value)
+	}
+
+	str, err := strconv.Unquote(parts[1])
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Unquoting '%s'", parts[1])
+	}
+
+	return str, nil
+}
+
+// parseBusctlUint parses busctl output of a single unsigned integer value,
+// e.g. `u 1234` or `t 1514764800000000`
+func parseBusctlUint(value string) (uint64, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
+	if len(parts) != 2 || (parts[0] != "u" && parts[0] != "t") {
+		return 0, bosherr.Errorf("Unexpected integer value '%s'", value)
+	}
+
+	return strconv.ParseUint(parts[1], 10, 64)
+}
@@ -0,0 +1,263 @@
+package systemd_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("busctlClient", func() {
+	var (
+		runner *fakesys.FakeCmdRunner
+		client Client
+	)
+
+	const managerCall = "busctl call org.freedesktop.systemd1 /org/freedesktop/systemd1 org.freedesktop.systemd1.Manager"
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		client = NewBusctlClient(runner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("Reload", func() {
+		It("calls Reload on the systemd manager", func() {
+			err := client.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{
+				"busctl", "call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+				"org.freedesktop.systemd1.Manager", "Reload",
+			}}))
+		})
+
+		It("returns error with stderr if call fails", func() {
+			runner.AddCmdResult(managerCall+" Reload", fakesys.FakeCmdResult{
+				Stderr: "Access denied\n",
+				Error:  errors.New("fake-busctl-err"),
+			})
+
+			err := client.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Access denied"))
+			Expect(err.Error()).To(ContainSubstring("fake-busctl-err"))
+		})
+	})
+
+	Describe("Version", func() {
+		const versionCall = "busctl get-property org.freedesktop.systemd1 /org/freedesktop/systemd1 org.freedesktop.systemd1.Manager Version"
+
+		It("returns major version without distribution suffix", func() {
+			runner.AddCmdResult(versionCall, fakesys.FakeCmdResult{Stdout: "s \"245.4-4ubuntu3\"\n"})
+
+			version, err := client.Version()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(245))
+		})
+
+		It("returns error if version cannot be parsed", func() {
+			runner.AddCmdResult(versionCall, fakesys.FakeCmdResult{Stdout: "s \"unknown\"\n"})
+
+			_, err := client.Version()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing systemd version 'unknown'"))
+		})
+	})
+
+	Describe("StartUnit", func() {
+		It("starts unit replacing queued jobs", func() {
+			err := client.StartUnit("fake-unit.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands[0][5:]).To(Equal([]string{"StartUnit", "ss", "fake-unit.service", "replace"}))
+		})
+	})
+
+	Describe("StopUnit", func() {
+		It("stops unit replacing queued jobs", func() {
+			err := client.StopUnit("fake-unit.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands[0][5:]).To(Equal([]string{"StopUnit", "ss", "fake-unit.service", "replace"}))
+		})
+
+		It("returns error if call fails", func() {
+			runner.AddCmdResult(managerCall+" StopUnit ss fake-unit.service replace", fakesys.FakeCmdResult{
+				Error: errors.New("fake-busctl-err"),
+			})
+
+			err := client.StopUnit("fake-unit.service")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Stopping unit fake-unit.service"))
+		})
+	})
+
+	Describe("ResetFailedUnit", func() {
+		It("resets failed state of unit", func() {
+			err := client.ResetFailedUnit("fake-unit.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands[0][5:]).To(Equal([]string{"ResetFailedUnit", "s", "fake-unit.service"}))
+		})
+	})
+
+	Describe("UnitStatus", func() {
+		const unitPath = "/org/freedesktop/systemd1/unit/fake_2dunit_2eservice"
+
+		BeforeEach(func() {
+			runner.AddCmdResult(managerCall+" LoadUnit s fake-unit.service", fakesys.FakeCmdResult{
+				Stdout: `o "` + unitPath + `"` + "\n",
+			})
+		})
+
+		It("returns unit and service properties", func() {
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+unitPath+" org.freedesktop.systemd1.Unit ActiveState SubState ActiveEnterTimestamp", fakesys.FakeCmdResult{
+				Stdout: "s \"active\"\ns \"running\"\nt 1514764800000000\n",
+			})
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+unitPath+" org.freedesktop.systemd1.Service MainPID MemoryCurrent NRestarts Slice", fakesys.FakeCmdResult{
+				Stdout: "u 1234\nt 2048000\nu 2\ns \"bosh-jobs-fake.slice\"\n",
+			})
+
+			status, err := client.UnitStatus("fake-unit.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(UnitStatus{
+				Name:                 "fake-unit.service",
+				ActiveState:          "active",
+				SubState:             "running",
+				MainPID:              1234,
+				ActiveEnterTimestamp: time.Unix(1514764800, 0),
+				MemoryCurrent:        2048000,
+				NRestarts:            2,
+				Slice:                "bosh-jobs-fake.slice",
+			}))
+		})
+
+		It("returns zero values for unset timestamp and memory", func() {
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+unitPath+" org.freedesktop.systemd1.Unit ActiveState SubState ActiveEnterTimestamp", fakesys.FakeCmdResult{
+				Stdout: "s \"inactive\"\ns \"dead\"\nt 0\n",
+			})
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+unitPath+" org.freedesktop.systemd1.Service MainPID MemoryCurrent NRestarts Slice", fakesys.FakeCmdResult{
+				Stdout: "u 0\nt 18446744073709551615\nu 0\ns \"\"\n",
+			})
+
+			status, err := client.UnitStatus("fake-unit.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.ActiveEnterTimestamp.IsZero()).To(BeTrue())
+			Expect(status.MemoryCurrent).To(Equal(uint64(0)))
+		})
+
+		It("returns error if properties cannot be parsed", func() {
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+unitPath+" org.freedesktop.systemd1.Unit ActiveState SubState ActiveEnterTimestamp", fakesys.FakeCmdResult{
+				Stdout: "s \"active\"\n",
+			})
+
+			_, err := client.UnitStatus("fake-unit.service")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected 3 property values but got 1"))
+		})
+
+		It("returns error if unit cannot be loaded", func() {
+			runner.AddCmdResult(managerCall+" LoadUnit s other-unit.service", fakesys.FakeCmdResult{
+				Error: errors.New("fake-busctl-err"),
+			})
+
+			_, err := client.UnitStatus("other-unit.service")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Loading unit other-unit.service"))
+		})
+	})
+
+	Describe("SliceStatus", func() {
+		const slicePath = "/org/freedesktop/systemd1/unit/fake_2eslice"
+
+		BeforeEach(func() {
+			runner.AddCmdResult(managerCall+" LoadUnit s fake.slice", fakesys.FakeCmdResult{
+				Stdout: `o "` + slicePath + `"` + "\n",
+			})
+		})
+
+		It("returns slice resource usage and limits", func() {
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+slicePath+" org.freedesktop.systemd1.Slice MemoryCurrent MemoryMax CPUUsageNSec CPUQuotaPerSecUSec TasksCurrent TasksMax", fakesys.FakeCmdResult{
+				Stdout: "t 2048000\nt 18446744073709551615\nt 1500000000\nt 500000\nt 12\nt 100\n",
+			})
+
+			status, err := client.SliceStatus("fake.slice")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(SliceStatus{
+				Name:           "fake.slice",
+				MemoryCurrent:  2048000,
+				MemoryMax:      0,
+				CPUUsage:       1500 * time.Millisecond,
+				CPUQuotaPerSec: 500 * time.Millisecond,
+				TasksCurrent:   12,
+				TasksMax:       100,
+			}))
+		})
+
+		It("returns error if properties cannot be parsed", func() {
+			runner.AddCmdResult("busctl get-property org.freedesktop.systemd1 "+slicePath+" org.freedesktop.systemd1.Slice MemoryCurrent MemoryMax CPUUsageNSec CPUQuotaPerSecUSec TasksCurrent TasksMax", fakesys.FakeCmdResult{
+				Stdout: "t 2048000\nt 0\ns \"1\"\nt 0\nt 0\nt 0\n",
+			})
+
+			_, err := client.SliceStatus("fake.slice")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing CPUUsageNSec"))
+		})
+	})
+
+	Describe("UnitStates", func() {
+		It("shows states of all units with a single systemctl call", func() {
+			runner.AddCmdResult("systemctl show --property=ActiveState,SubState,NRestarts,ExecMainCode,ExecMainStatus a.service b.service", fakesys.FakeCmdResult{
+				Stdout: "NRestarts=2\nActiveState=active\nSubState=running\nExecMainCode=0\nExecMainStatus=0\n\n" +
+					"NRestarts=0\nActiveState=failed\nSubState=failed\nExecMainCode=1\nExecMainStatus=3\n",
+			})
+
+			states, err := client.UnitStates([]string{"a.service", "b.service"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(Equal([]UnitState{
+				{Name: "a.service", ActiveState: "active", SubState: "running", NRestarts: 2},
+				{Name: "b.service", ActiveState: "failed", SubState: "failed", ExecMainCode: 1, ExecMainStatus: 3},
+			}))
+			Expect(runner.RunCommands).To(HaveLen(1))
+		})
+
+		It("does not call systemctl without units", func() {
+			states, err := client.UnitStates([]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(states).To(BeEmpty())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error if number of units in output does not match", func() {
+			runner.AddCmdResult("systemctl show --property=ActiveState,SubState,NRestarts,ExecMainCode,ExecMainStatus a.service b.service", fakesys.FakeCmdResult{
+				Stdout: "ActiveState=active\nSubState=running\n",
+			})
+
+			_, err := client.UnitStates([]string{"a.service", "b.service"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected properties of 2 units but got 1"))
+		})
+
+		It("returns error if properties cannot be parsed", func() {
+			runner.AddCmdResult("systemctl show --property=ActiveState,SubState,NRestarts,ExecMainCode,ExecMainStatus a.service", fakesys.FakeCmdResult{
+				Stdout: "ExecMainStatus=bogus\n",
+			})
+
+			_, err := client.UnitStates([]string{"a.service"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing ExecMainStatus of unit a.service"))
+		})
+
+		It("returns error with stderr if systemctl fails", func() {
+			runner.AddCmdResult("systemctl show --property=ActiveState,SubState,NRestarts,ExecMainCode,ExecMainStatus a.service", fakesys.FakeCmdResult{
+				Stderr: "Failed to connect to bus\n",
+				Error:  errors.New("fake-systemctl-err"),
+			})
+
+			_, err := client.UnitStates([]string{"a.service"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to connect to bus"))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+package systemd
+
+import (
+	"time"
+)
+
+type Client interface {
+	// Reload makes systemd re-read unit files from disk (daemon-reload)
+	Reload() error
+
+	// Version returns major version of systemd, e.g. 245
+	Version() (int, error)
+
+	StartUnit(name string) error
+	StopUnit(name string) error
+	ResetFailedUnit(name string) error
+
+	UnitStatus(name string) (UnitStatus, error)
+	SliceStatus(name string) (SliceStatus, error)
+
+	// UnitStates returns states of all given units in the same order
+	// at the cost of a single call so that units can be polled often
+	UnitStates(names []string) ([]UnitState, error)
+}
+
+// UnitState is a subset of UnitStatus needed to detect failures
+type UnitState struct {
+	Name        string
+	ActiveState string
+	SubState    string
+
+	// Number of automatic restarts done by systemd since unit was started
+	NRestarts int
+
+	// How main process last exited (one of CLD_* codes, e.g. 1 for
+	// CLD_EXITED) and its exit status or signal number respectively
+	ExecMainCode   int
+	ExecMainStatus int
+}
+
+// CLD_EXITED code of ExecMainCode means main process exited on its own
+const ExecMainCodeExited = 1
+
+type UnitStatus struct {
+	Name        string
+	ActiveState string
+	SubState    string
+
+	MainPID int
+
+	// Zero if unit has never been active
+	ActiveEnterTimestamp time.Time
+
+	// Zero if memory accounting is not enabled for the unit
+	MemoryCurrent uint64
+
+	// Number of automatic restarts done by systemd since unit was started
+	NRestarts int
+
+	// Slice unit that contains the service, e.g. system.slice
+	Slice string
+}
+
+// SliceStatus contains resource usage of all units in a slice.
+// Values are zero if accounting is not enabled or there is no limit.
+type SliceStatus struct {
+	Name string
+
+	MemoryCurrent uint64
+	MemoryMax     uint64
+
+	CPUUsage time.Duration
+
+	// CPU time allowed per second of wall clock time
+	CPUQuotaPerSec time.Duration
+
+	TasksCurrent uint64
+	TasksMax     uint64
+}
@@ -0,0 +1,174 @@
+package fakes
+
+import (
+	"sync"
+
+	boshsystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+)
+
+type FakeClient struct {
+	ReloadCallCount int
+	ReloadErr       error
+
+	SystemdVersion    int
+	SystemdVersionErr error
+
+	StartUnitNames []string
+	StartUnitErr   error
+
+	StopUnitNames []string
+	StopUnitErr   error
+
+	ResetFailedUnitNames []string
+	ResetFailedUnitErr   error
+
+	UnitStatuses    map[string]boshsystemd.UnitStatus
+	UnitStatusErrs  map[string]error
+	UnitStatusNames []string
+
+	SliceStatuses   map[string]boshsystemd.SliceStatus
+	SliceStatusErrs map[string]error
+
+	exitStatuses map[string]int
+
+	UnitStatesErr       error
+	unitStatesCallCount int
+
+	mutex sync.Mutex
+}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		UnitStatuses:   map[string]boshsystemd.UnitStatus{},
+		UnitStatusErrs: map[string]error{},
+		exitStatuses:   map[string]int{},
+
+		SliceStatuses:   map[string]boshsystemd.SliceStatus{},
+		SliceStatusErrs: map[string]error{},
+	}
+}
+
+func (c *FakeClient) Reload() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ReloadCallCount++
+	return c.ReloadErr
+}
+
+func (c *FakeClient) Version() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.SystemdVersion, c.SystemdVersionErr
+}
+
+func (c *FakeClient) StartUnit(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.StartUnitNames = append(c.StartUnitNames, name)
+	return c.StartUnitErr
+}
+
+func (c *FakeClient) StopUnit(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.StopUnitNames = append(c.StopUnitNames, name)
+	return c.StopUnitErr
+}
+
+func (c *FakeClient) ResetFailedUnit(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ResetFailedUnitNames = append(c.ResetFailedUnitNames, name)
+	return c.ResetFailedUnitErr
+}
+
+func (c *FakeClient) SetUnitStatus(status boshsystemd.UnitStatus) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.UnitStatuses[status.Name] = status
+}
+
+func (c *FakeClient) UnitStatusCallCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.UnitStatusNames)
+}
+
+func (c *FakeClient) UnitStatus(name string) (boshsystemd.UnitStatus, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.UnitStatusNames = append(c.UnitStatusNames, name)
+
+	status, found := c.UnitStatuses[name]
+	if !found {
+		status = boshsystemd.UnitStatus{Name: name, ActiveState: "inactive", SubState: "dead"}
+	}
+
+	return status, c.UnitStatusErrs[name]
+}
+
+func (c *FakeClient) SliceStatus(name string) (boshsystemd.SliceStatus, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	status, found := c.SliceStatuses[name]
+	if !found {
+		status = boshsystemd.SliceStatus{Name: name}
+	}
+
+	return status, c.SliceStatusErrs[name]
+}
+
+// SetExitStatus makes UnitStates report that main process of unit exited
+func (c *FakeClient) SetExitStatus(name string, exitStatus int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.exitStatuses[name] = exitStatus
+}
+
+func (c *FakeClient) UnitStatesCallCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.unitStatesCallCount
+}
+
+// UnitStates returns states of statuses set via SetUnitStatus
+func (c *FakeClient) UnitStates(names []string) ([]boshsystemd.UnitState, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.unitStatesCallCount++
+
+	states := []boshsystemd.UnitState{}
+
+	for _, name := range names {
+		status, found := c.UnitStatuses[name]
+		if !found {
+			status = boshsystemd.UnitStatus{Name: name, ActiveState: "inactive", SubState: "dead"}
+		}
+
+		states = append(states, boshsystemd.UnitState{
+			Name:        name,
+			ActiveState: status.ActiveState,
+			SubState:    status.SubState,
+			NRestarts:   status.NRestarts,
+		})
+
+		if exitStatus, found := c.exitStatuses[name]; found {
+			states[len(states)-1].ExecMainCode = boshsystemd.ExecMainCodeExited
+			states[len(states)-1].ExecMainStatus = exitStatus
+		}
+	}
+
+	return states, c.UnitStatesErr
+}
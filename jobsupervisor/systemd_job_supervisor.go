@@ -0,0 +1,909 @@
+package jobsupervisor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	boshsystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	SystemdUnitsDir = "/etc/systemd/system"
+
+	systemdTargetName = "bosh-vcap.target"
+
+	// Target is enabled like `systemctl enable` would do so that jobs start on boot
+	systemdDefaultTargetWantsDir = "multi-user.target.wants"
+	systemdUnitPrefix            = "bosh-vcap-"
+	systemdUnitSuffix            = ".service"
+
+	// Slice names are hierarchical so job slices are nested in bosh-jobs.slice
+	systemdSlicePrefix = "bosh-jobs-"
+	systemdSliceSuffix = ".slice"
+
+	// Monit waits 30 seconds for start and stop programs by default
+	systemdDefaultProgramTimeout = 30 * time.Second
+
+	systemdFailurePollInterval = 1 * time.Second
+
+	// StandardOutput=append: was added in systemd 240
+	systemdAppendOutputMinVersion = 240
+
+	// Used instead of StandardOutput=append: on older systemd. Log paths
+	// are passed as arguments so that they do not have to be shell quoted.
+	systemdOutputRedirectScript = `stdout=$1 stderr=$2; shift 2; exec "$@" >> "$stdout" 2>> "$stderr"`
+)
+
+var systemdUnitNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9:_.\-]+$`)
+
+type systemdJobSupervisor struct {
+	fs          boshsys.FileSystem
+	client      boshsystemd.Client
+	logger      boshlog.Logger
+	dirProvider boshdir.Provider
+	unitsDir    string
+	timeService clock.Clock
+}
+
+// NewSystemdJobSupervisor creates a job supervisor that generates a systemd
+// service unit for each process found in job monit files. All units are
+// PartOf bosh-vcap.target so that jobs can be started and stopped together.
+func NewSystemdJobSupervisor(
+	fs boshsys.FileSystem,
+	client boshsystemd.Client,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	unitsDir string,
+	timeService clock.Clock,
+) JobSupervisor {
+	return &systemdJobSupervisor{
+		fs:          fs,
+		client:      client,
+		logger:      logger,
+		dirProvider: dirProvider,
+		unitsDir:    unitsDir,
+		timeService: timeService,
+	}
+}
+
+func (s systemdJobSupervisor) Reload() error {
+	units, err := s.unitNames()
+	if err != nil {
+		return err
+	}
+
+	targetContent := "[Unit]\nDescription=BOSH jobs\n"
+	if len(units) > 0 {
+		targetContent += fmt.Sprintf("Wants=%s\n", strings.Join(units, " "))
+	}
+
+	targetContent += "\n[Install]\nWantedBy=multi-user.target\n"
+
+	err = s.fs.WriteFileString(path.Join(s.unitsDir, systemdTargetName), targetContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", systemdTargetName)
+	}
+
+	wantsDir := path.Join(s.unitsDir, systemdDefaultTargetWantsDir)
+
+	err = s.fs.MkdirAll(wantsDir, os.FileMode(0755))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating %s", wantsDir)
+	}
+
+	err = s.fs.Symlink(path.Join(s.unitsDir, systemdTargetName), path.Join(wantsDir, systemdTargetName))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Enabling %s", systemdTargetName)
+	}
+
+	err = s.client.Reload()
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading systemd units")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Start() error {
+	units, err := s.unitNames()
+	if err != nil {
+		return err
+	}
+
+	// Units that hit their restart limit need to be reset before they can start again
+	for _, unit := range units {
+		err = s.client.ResetFailedUnit(unit)
+		if err != nil {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Ignoring failure to reset unit %s: %s", unit, err.Error())
+		}
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Starting %s", systemdTargetName)
+
+	err = s.client.StartUnit(systemdTargetName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting %s", systemdTargetName)
+	}
+
+	err = s.fs.RemoveAll(s.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	err = s.fs.RemoveAll(s.unmonitoredFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing unmonitored File")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Stop() error {
+	s.logger.Debug(systemdJobSupervisorLogTag, "Stopping %s", systemdTargetName)
+
+	err := s.client.StopUnit(systemdTargetName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping %s", systemdTargetName)
+	}
+
+	err = s.fs.WriteFileString(s.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) StopAndWait() error {
+	timer := s.timeService.NewTimer(5 * time.Minute)
+
+	err := s.Stop()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Waiting for units to stop")
+
+	for {
+		statuses, err := s.unitStates()
+		if err != nil {
+			return err
+		}
+
+		var failedUnits, unitsToStop []string
+
+		for _, status := range statuses {
+			switch status.ActiveState {
+			case "inactive":
+			case "failed":
+				failedUnits = append(failedUnits, status.Name)
+			default:
+				unitsToStop = append(unitsToStop, status.Name)
+			}
+		}
+
+		if len(failedUnits) > 0 {
+			return bosherr.Errorf("Stopping units '%s' failed", strings.Join(failedUnits, ", "))
+		}
+
+		if len(unitsToStop) == 0 {
+			s.logger.Debug(systemdJobSupervisorLogTag, "Successfully stopped all units")
+			return nil
+		}
+
+		select {
+		case <-timer.C():
+			return bosherr.Errorf("Timed out waiting for units '%s' to stop after 5 minutes", strings.Join(unitsToStop, ", "))
+		default:
+		}
+
+		s.logger.Debug(systemdJobSupervisorLogTag, "Waiting for '%v' to stop", unitsToStop)
+		s.timeService.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Unmonitor suppresses failure alerts until the next Start.
+// systemd keeps restarting processes that exit with a failure
+// since restart policy cannot be changed for loaded units.
+func (s systemdJobSupervisor) Unmonitor() error {
+	err := s.fs.WriteFileString(s.unmonitoredFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating unmonitored File")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Status() string {
+	s.logger.Debug(systemdJobSupervisorLogTag, "Getting systemd status")
+
+	statuses, err := s.unitStates()
+	if err != nil {
+		return "unknown"
+	}
+
+	if s.fs.FileExists(s.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	status := "running"
+
+	for _, unitStatus := range statuses {
+		state := s.processState(unitStatus.ActiveState)
+		if state == "starting" {
+			return "starting"
+		}
+		if state != "running" {
+			status = "failing"
+		}
+	}
+
+	// Matches monit which reports unmonitored services as failing
+	if len(statuses) > 0 && s.fs.FileExists(s.unmonitoredFilePath()) {
+		status = "failing"
+	}
+
+	return status
+}
+
+func (s systemdJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	statuses, err := s.unitStatuses()
+	if err != nil {
+		return processes, bosherr.WrapError(err, "Getting unit status")
+	}
+
+	now := s.timeService.Now()
+	sliceVitals := map[string]*CgroupVitals{}
+
+	for _, status := range statuses {
+		process := Process{
+			Name:  s.processName(status.Name),
+			State: s.processState(status.ActiveState),
+			Memory: MemoryVitals{
+				Kb: int(status.MemoryCurrent / 1024),
+			},
+		}
+
+		if status.ActiveState == "active" && !status.ActiveEnterTimestamp.IsZero() {
+			process.Uptime.Secs = int(now.Sub(status.ActiveEnterTimestamp).Seconds())
+		}
+
+		if jobName, found := s.sliceJobName(status.Slice); found {
+			if _, found := sliceVitals[status.Slice]; !found {
+				sliceVitals[status.Slice] = s.sliceVitals(status.Slice)
+			}
+
+			process.Job = jobName
+			process.Cgroup = sliceVitals[status.Slice]
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+func (s systemdJobSupervisor) StartProcess(processName string) error {
+	unit := systemdUnitPrefix + processName + systemdUnitSuffix
+
+	// Units that hit their restart limit need to be reset before they can start again
+	err := s.client.ResetFailedUnit(unit)
+	if err != nil {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Ignoring failure to reset unit %s: %s", unit, err.Error())
+	}
+
+	err = s.client.StartUnit(unit)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Starting %s", unit)
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) StopProcess(processName string) error {
+	unit := systemdUnitPrefix + processName + systemdUnitSuffix
+
+	err := s.client.StopUnit(unit)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Stopping %s", unit)
+	}
+
+	return nil
+}
+
+// SetJobLimits writes a slice unit for the job. Job services are placed
+// into the slice so that systemd enforces limits on them together.
+func (s systemdJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	err := limits.Validate()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Validating limits of job %s", jobName)
+	}
+
+	memoryBytes, _ := limits.MemoryBytes()
+
+	sliceContent := fmt.Sprintf("[Unit]\nDescription=BOSH job %s\n\n[Slice]\n", jobName)
+	sliceContent += "MemoryAccounting=yes\nCPUAccounting=yes\nTasksAccounting=yes\n"
+
+	if memoryBytes > 0 {
+		sliceContent += fmt.Sprintf("MemoryMax=%d\n", memoryBytes)
+	}
+
+	if limits.CPU > 0 {
+		sliceContent += fmt.Sprintf("CPUQuota=%d%%\n", int(math.Max(1, math.Floor(limits.CPU*100+0.5))))
+	}
+
+	if limits.Pids > 0 {
+		sliceContent += fmt.Sprintf("TasksMax=%d\n", limits.Pids)
+	}
+
+	sliceName := s.sliceName(jobName)
+
+	err = s.fs.WriteFileString(path.Join(s.unitsDir, sliceName), sliceContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing %s", sliceName)
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
+func (s systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	configContent, err := s.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	processes, err := parseMonitFile(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing job config %s", configPath)
+	}
+
+	for _, process := range processes {
+		if !systemdUnitNameRegexp.MatchString(process.Name) {
+			return bosherr.Errorf("Process name '%s' cannot be used as systemd unit name", process.Name)
+		}
+
+		if process.StartProgram == "" {
+			return bosherr.Errorf("Missing start program for process %s", process.Name)
+		}
+
+		unitContent, err := s.renderUnit(jobName, process)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rendering unit for process %s", process.Name)
+		}
+
+		err = s.fs.WriteFileString(path.Join(s.unitsDir, s.unitName(process.Name)), unitContent)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing unit for process %s", process.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	appendOutput := true
+
+	version, err := s.client.Version()
+	if err != nil {
+		s.logger.Warn(systemdJobSupervisorLogTag, "Failed to get systemd version, redirecting output with shell: %s", err.Error())
+		appendOutput = false
+	} else if version < systemdAppendOutputMinVersion {
+		appendOutput = false
+	}
+
+	for _, process := range config.Processes {
+		if process.HealthCheck != nil {
+			s.logger.Warn(systemdJobSupervisorLogTag, "Health check of process %s is not supported by systemd", process.Name)
+		}
+
+		unitContent, err := s.renderProcessUnit(jobName, process, appendOutput)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rendering unit for process %s", process.Name)
+		}
+
+		err = s.fs.WriteFileString(path.Join(s.unitsDir, s.unitName(process.Name)), unitContent)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing unit for process %s", process.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) RemoveAllJobs() error {
+	units, err := s.unitNames()
+	if err != nil {
+		return err
+	}
+
+	slicePaths, err := s.fs.Glob(path.Join(s.unitsDir, systemdSlicePrefix+"*"+systemdSliceSuffix))
+	if err != nil {
+		return bosherr.WrapError(err, "Globbing systemd slices")
+	}
+
+	for _, slicePath := range slicePaths {
+		units = append(units, filepath.Base(slicePath))
+	}
+
+	for _, unit := range units {
+		err = s.fs.RemoveAll(path.Join(s.unitsDir, unit))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing unit %s", unit)
+		}
+	}
+
+	return nil
+}
+
+// MonitorJobFailures polls states of all units with a single query and reports
+// processes that systemd had to restart or that entered failed state.
+// It never returns under normal operation.
+func (s systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	previousStatuses := map[string]boshsystemd.UnitState{}
+
+	ticker := s.timeService.NewTicker(systemdFailurePollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		statuses, err := s.unitStates()
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Failed to get unit statuses: %s", err.Error())
+			continue
+		}
+
+		monitored := !s.fs.FileExists(s.stoppedFilePath()) && !s.fs.FileExists(s.unmonitoredFilePath())
+
+		for _, status := range statuses {
+			previous, found := previousStatuses[status.Name]
+			previousStatuses[status.Name] = status
+
+			if !found || !monitored {
+				continue
+			}
+
+			alert, shouldAlert := s.failureAlert(previous, status)
+			if !shouldAlert {
+				continue
+			}
+
+			err = handler(alert)
+			if err != nil {
+				s.logger.Error(systemdJobSupervisorLogTag, "Failed to handle failure of unit %s: %s", status.Name, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) HealthRecorder(status string) {
+}
+
+func (s systemdJobSupervisor) failureAlert(previous, current boshsystemd.UnitState) (boshalert.MonitAlert, bool) {
+	now := s.timeService.Now()
+
+	alert := boshalert.MonitAlert{
+		ID:      fmt.Sprintf("%d.%s@localhost", now.Unix(), current.Name),
+		Service: s.processName(current.Name),
+		Date:    now.Format(time.RFC1123Z),
+	}
+
+	switch {
+	case current.NRestarts > previous.NRestarts:
+		alert.Event = "does not exist"
+		alert.Action = "restart"
+		alert.Description = fmt.Sprintf("process is not running (restarted by systemd %d time(s))%s", current.NRestarts, s.describeExit(current))
+		return alert, true
+
+	case current.ActiveState == "failed" && previous.ActiveState != "failed":
+		alert.Event = "execution failed"
+		alert.Action = "alert"
+		alert.Description = fmt.Sprintf("unit %s failed (%s)%s", current.Name, current.SubState, s.describeExit(current))
+		return alert, true
+	}
+
+	return alert, false
+}
+
+// describeExit formats exit status like native supervisor does
+// so that it is picked up by crash loop detection
+func (s systemdJobSupervisor) describeExit(state boshsystemd.UnitState) string {
+	if state.ExecMainCode != boshsystemd.ExecMainCodeExited {
+		return ""
+	}
+
+	return fmt.Sprintf(", exit status %d", state.ExecMainStatus)
+}
+
+func (s systemdJobSupervisor) processState(activeState string) string {
+	switch activeState {
+	case "active", "reloading":
+		return "running"
+	case "activating":
+		return "starting"
+	case "inactive", "deactivating":
+		return "stopped"
+	case "failed":
+		return "failing"
+	}
+
+	return "unknown"
+}
+
+func (s systemdJobSupervisor) unitStatuses() ([]boshsystemd.UnitStatus, error) {
+	units, err := s.unitNames()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []boshsystemd.UnitStatus{}
+
+	for _, unit := range units {
+		status, err := s.client.UnitStatus(unit)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting status of unit %s", unit)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s systemdJobSupervisor) unitStates() ([]boshsystemd.UnitState, error) {
+	units, err := s.unitNames()
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.client.UnitStates(units)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting states of units")
+	}
+
+	return states, nil
+}
+
+func (s systemdJobSupervisor) unitNames() ([]string, error) {
+	unitPaths, err := s.fs.Glob(path.Join(s.unitsDir, systemdUnitPrefix+"*"+systemdUnitSuffix))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Globbing systemd units")
+	}
+
+	units := []string{}
+	for _, unitPath := range unitPaths {
+		units = append(units, filepath.Base(unitPath))
+	}
+
+	sort.Strings(units)
+
+	return units, nil
+}
+
+func (s systemdJobSupervisor) unitName(processName string) string {
+	return systemdUnitPrefix + processName + systemdUnitSuffix
+}
+
+func (s systemdJobSupervisor) processName(unitName string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(unitName, systemdUnitPrefix), systemdUnitSuffix)
+}
+
+func (s systemdJobSupervisor) sliceName(jobName string) string {
+	return systemdSlicePrefix + systemdEscape(jobName) + systemdSliceSuffix
+}
+
+func (s systemdJobSupervisor) sliceJobName(sliceName string) (string, bool) {
+	if !strings.HasPrefix(sliceName, systemdSlicePrefix) || !strings.HasSuffix(sliceName, systemdSliceSuffix) {
+		return "", false
+	}
+
+	escapedName := strings.TrimSuffix(strings.TrimPrefix(sliceName, systemdSlicePrefix), systemdSliceSuffix)
+
+	return systemdUnescape(escapedName), true
+}
+
+// sliceVitals returns nil if slice status is not available
+func (s systemdJobSupervisor) sliceVitals(sliceName string) *CgroupVitals {
+	status, err := s.client.SliceStatus(sliceName)
+	if err != nil {
+		s.logger.Debug(systemdJobSupervisorLogTag, "Failed to get status of slice %s: %s", sliceName, err.Error())
+		return nil
+	}
+
+	return newCgroupVitals(cgroup.Usage{
+		MemoryBytes:      status.MemoryCurrent,
+		MemoryLimitBytes: status.MemoryMax,
+		CPUUsage:         status.CPUUsage,
+		CPULimit:         status.CPUQuotaPerSec.Seconds(),
+		Pids:             status.TasksCurrent,
+		PidsLimit:        status.TasksMax,
+	})
+}
+
+func (s systemdJobSupervisor) stoppedFilePath() string {
+	return path.Join(s.stateDir(), "stopped")
+}
+
+func (s systemdJobSupervisor) unmonitoredFilePath() string {
+	return path.Join(s.stateDir(), "unmonitored")
+}
+
+func (s systemdJobSupervisor) stateDir() string {
+	return path.Join(s.dirProvider.BoshDir(), "systemd")
+}
+
+type systemdUnitTemplateData struct {
+	JobName     string
+	ProcessName string
+	After       []string
+
+	Type        string
+	PIDFile     string
+	WorkingDir  string
+	Environment []string
+	Start       string
+	Stop        string
+	StartSecs   int
+	StopSecs    int
+	Restart     string
+
+	User  string
+	Group string
+
+	LimitNOFILE uint64
+	LimitNPROC  uint64
+	MemoryMax   uint64
+
+	StdoutPath string
+	StderrPath string
+
+	Slice string
+}
+
+var systemdUnitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description=BOSH job {{ .JobName }} process {{ .ProcessName }}
+PartOf=bosh-vcap.target
+{{- if .After }}
+After={{ range $i, $unit := .After }}{{ if $i }} {{ end }}{{ $unit }}{{ end }}
+{{- end }}
+
+[Service]
+Type={{ .Type }}
+{{- if .PIDFile }}
+PIDFile={{ .PIDFile }}
+{{- end }}
+{{- if .WorkingDir }}
+WorkingDirectory={{ .WorkingDir }}
+{{- end }}
+{{- range .Environment }}
+Environment={{ . }}
+{{- end }}
+ExecStart={{ .Start }}
+{{- if .Stop }}
+ExecStop={{ .Stop }}
+{{- end }}
+TimeoutStartSec={{ .StartSecs }}
+TimeoutStopSec={{ .StopSecs }}
+Restart={{ .Restart }}
+RestartSec=1
+{{- if .Slice }}
+Slice={{ .Slice }}
+{{- end }}
+{{- if .User }}
+User={{ .User }}
+{{- end }}
+{{- if .Group }}
+Group={{ .Group }}
+{{- end }}
+{{- if .LimitNOFILE }}
+LimitNOFILE={{ .LimitNOFILE }}
+{{- end }}
+{{- if .LimitNPROC }}
+LimitNPROC={{ .LimitNPROC }}
+{{- end }}
+{{- if .MemoryMax }}
+MemoryMax={{ .MemoryMax }}
+{{- end }}
+{{- if .StdoutPath }}
+StandardOutput=append:{{ .StdoutPath }}
+{{- end }}
+{{- if .StderrPath }}
+StandardError=append:{{ .StderrPath }}
+{{- end }}
+`))
+
+func (s systemdJobSupervisor) renderUnit(jobName string, process monitProcess) (string, error) {
+	data := systemdUnitTemplateData{
+		JobName:     jobName,
+		ProcessName: process.Name,
+		Type:        "forking",
+		PIDFile:     process.PidFile,
+		Start:       s.escapeCommand(process.StartProgram),
+		Stop:        s.escapeCommand(process.StopProgram),
+		StartSecs:   int(s.programTimeout(process.StartTimeout).Seconds()),
+		StopSecs:    int(s.programTimeout(process.StopTimeout).Seconds()),
+		Restart:     "on-failure",
+		User:        process.UID,
+		Group:       process.GID,
+		Slice:       s.sliceName(jobName),
+	}
+
+	for _, dependency := range process.DependsOn {
+		data.After = append(data.After, s.unitName(dependency))
+	}
+
+	return s.executeUnitTemplate(data)
+}
+
+// renderProcessUnit renders a unit that runs process in the foreground
+// so that systemd tracks its main pid without a pidfile. Without
+// appendOutput process is exec'd by a shell that redirects its output.
+func (s systemdJobSupervisor) renderProcessUnit(jobName string, process processdef.Process, appendOutput bool) (string, error) {
+	logDir := s.dirProvider.JobLogDir(jobName)
+	stdoutPath := path.Join(logDir, process.Name+".stdout.log")
+	stderrPath := path.Join(logDir, process.Name+".stderr.log")
+
+	memoryBytes, err := process.Limits.MemoryBytes()
+	if err != nil {
+		return "", err
+	}
+
+	command := []string{}
+	if !appendOutput {
+		command = append(command, "/bin/sh", "-c", s.escapeArgument(systemdOutputRedirectScript), "sh",
+			s.escapeArgument(stdoutPath), s.escapeArgument(stderrPath))
+	}
+
+	command = append(command, s.escapeArgument(process.Executable))
+	for _, arg := range process.Args {
+		command = append(command, s.escapeArgument(arg))
+	}
+
+	data := systemdUnitTemplateData{
+		JobName:     jobName,
+		ProcessName: process.Name,
+		Type:        "simple",
+		WorkingDir:  process.WorkingDir,
+		Start:       strings.Join(command, " "),
+		StartSecs:   int(systemdDefaultProgramTimeout.Seconds()),
+		StopSecs:    int(systemdDefaultProgramTimeout.Seconds()),
+		Restart:     "always",
+		User:        process.User,
+		Group:       process.Group,
+		LimitNOFILE: process.Limits.OpenFiles,
+		LimitNPROC:  process.Limits.Processes,
+		MemoryMax:   memoryBytes,
+		Slice:       s.sliceName(jobName),
+	}
+
+	if appendOutput {
+		data.StdoutPath = stdoutPath
+		data.StderrPath = stderrPath
+	}
+
+	envNames := []string{}
+	for name := range process.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+
+	for _, name := range envNames {
+		data.Environment = append(data.Environment, s.escapeEnvironment(name+"="+process.Env[name]))
+	}
+
+	return s.executeUnitTemplate(data)
+}
+
+func (s systemdJobSupervisor) executeUnitTemplate(data systemdUnitTemplateData) (string, error) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := systemdUnitTemplate.Execute(buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+func (s systemdJobSupervisor) programTimeout(timeout time.Duration) time.Duration {
+	if timeout == 0 {
+		return systemdDefaultProgramTimeout
+	}
+	return timeout
+}
+
+// escapeCommand prevents systemd from expanding specifiers and variables
+// that monit would have passed through as is
+func (s systemdJobSupervisor) escapeCommand(command string) string {
+	command = strings.Replace(command, "%", "%%", -1)
+	return strings.Replace(command, "$", "$$", -1)
+}
+
+// escapeEnvironment quotes a NAME=value assignment. Unlike command
+// lines systemd does not expand variables in Environment= so only
+// specifiers have to be escaped.
+func (s systemdJobSupervisor) escapeEnvironment(assignment string) string {
+	return s.quoteWord(strings.Replace(assignment, "%", "%%", -1))
+}
+
+// systemdEscape escapes a string for use in unit names like systemd-escape does
+func systemdEscape(name string) string {
+	var escaped bytes.Buffer
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		isSafe := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == ':' || c == '_' || (c == '.' && i > 0)
+
+		if isSafe {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, `\x%02x`, c)
+		}
+	}
+
+	return escaped.String()
+}
+
+func systemdUnescape(name string) string {
+	var unescaped bytes.Buffer
+
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) && name[i+1] == 'x' {
+			if c, err := strconv.ParseUint(name[i+2:i+4], 16, 8); err == nil {
+				unescaped.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+
+		unescaped.WriteByte(name[i])
+	}
+
+	return unescaped.String()
+}
+
+// escapeArgument quotes a single command line word for systemd
+func (s systemdJobSupervisor) escapeArgument(arg string) string {
+	return s.quoteWord(s.escapeCommand(arg))
+}
+
+func (s systemdJobSupervisor) quoteWord(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\;") {
+		return arg
+	}
+
+	arg = strings.Replace(arg, `\`, `\\`, -1)
+	arg = strings.Replace(arg, `"`, `\"`, -1)
+	arg = strings.Replace(arg, "\n", `\n`, -1)
+
+	return `"` + arg + `"`
+}
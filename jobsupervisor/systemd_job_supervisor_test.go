@@ -0,0 +1,610 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
+	boshsystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd"
+	fakesystemd "github.com/cloudfoundry/bosh-agent/jobsupervisor/systemd/fakes"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		client      *fakesystemd.FakeClient
+		timeService *fakeclock.FakeClock
+		supervisor  JobSupervisor
+	)
+
+	const (
+		unitsGlob  = "/fake-units/bosh-vcap-*.service"
+		slicesGlob = "/fake-units/bosh-jobs-*.slice"
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		client = fakesystemd.NewFakeClient()
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		supervisor = NewSystemdJobSupervisor(
+			fs,
+			client,
+			boshlog.NewLogger(boshlog.LevelNone),
+			boshdir.NewProvider("/var/vcap"),
+			"/fake-units",
+			timeService,
+		)
+	})
+
+	setUnits := func(units ...string) {
+		paths := []string{}
+		slicePaths := []string{}
+		for _, unit := range units {
+			if strings.HasSuffix(unit, ".slice") {
+				slicePaths = append(slicePaths, "/fake-units/"+unit)
+			} else {
+				paths = append(paths, "/fake-units/"+unit)
+			}
+		}
+		fs.GlobStub = func(pattern string) ([]string, error) {
+			if pattern == slicesGlob {
+				return slicePaths, nil
+			}
+			Expect(pattern).To(Equal(unitsGlob))
+			return paths, nil
+		}
+	}
+
+	Describe("AddJob", func() {
+		It("writes a unit for each monitored process", func() {
+			fs.WriteFileString("/fake-job/monit", `
+# Comment with check process ignored
+check process fake-proc
+  with pidfile /var/vcap/sys/run/fake-job/fake-proc.pid
+  start program "/var/vcap/jobs/fake-job/bin/ctl start" as uid vcap and gid vcap
+    with timeout 60 seconds
+  stop program "/bin/sh -c 'kill $(cat /var/vcap/sys/run/fake-job/fake-proc.pid)'"
+  group vcap
+  depends on other-proc, third-proc
+  if totalmem > 100 Mb for 5 cycles then alert
+
+check file fake-file with path /var/vcap/fake-file
+  start program "/bin/false"
+
+check process fake-proc2 matching "fake-proc2"
+  start program "/var/vcap/jobs/fake-job/bin/ctl2 start"
+`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-units/bosh-vcap-fake-proc.service")).To(Equal(`[Unit]
+Description=BOSH job fake-job process fake-proc
+PartOf=bosh-vcap.target
+After=bosh-vcap-other-proc.service bosh-vcap-third-proc.service
+
+[Service]
+Type=forking
+PIDFile=/var/vcap/sys/run/fake-job/fake-proc.pid
+ExecStart=/var/vcap/jobs/fake-job/bin/ctl start
+ExecStop=/bin/sh -c 'kill $$(cat /var/vcap/sys/run/fake-job/fake-proc.pid)'
+TimeoutStartSec=60
+TimeoutStopSec=30
+Restart=on-failure
+RestartSec=1
+Slice=bosh-jobs-fake\x2djob.slice
+User=vcap
+Group=vcap
+`))
+
+			Expect(fs.ReadFileString("/fake-units/bosh-vcap-fake-proc2.service")).To(Equal(`[Unit]
+Description=BOSH job fake-job process fake-proc2
+PartOf=bosh-vcap.target
+
+[Service]
+Type=forking
+ExecStart=/var/vcap/jobs/fake-job/bin/ctl2 start
+TimeoutStartSec=30
+TimeoutStopSec=30
+Restart=on-failure
+RestartSec=1
+Slice=bosh-jobs-fake\x2djob.slice
+`))
+
+			Expect(fs.FileExists("/fake-units/bosh-vcap-fake-file.service")).To(BeFalse())
+		})
+
+		It("returns error if process has no start program", func() {
+			fs.WriteFileString("/fake-job/monit", "check process fake-proc with pidfile /fake.pid")
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Missing start program for process fake-proc"))
+		})
+
+		It("returns error if process name is not a valid unit name", func() {
+			fs.WriteFileString("/fake-job/monit", `check process "fake proc" start program "/bin/true"`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot be used as systemd unit name"))
+		})
+
+		It("returns error if config cannot be read", func() {
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading job config from file"))
+		})
+	})
+
+	Describe("AddProcesses", func() {
+		BeforeEach(func() {
+			client.SystemdVersion = 245
+		})
+
+		It("writes a unit running each process in the foreground", func() {
+			err := supervisor.AddProcesses("fake-job", 0, processdef.Config{
+				Processes: []processdef.Process{{
+					Name:       "fake-proc",
+					Executable: "/var/vcap/packages/fake/bin/fake",
+					Args:       []string{"--name", "with space", "100%", "$HOME"},
+					Env:        map[string]string{"B": "two words", "A": "1", "C": "$5 for 10%"},
+					User:       "vcap",
+					Group:      "vcap-admin",
+					WorkingDir: "/var/vcap/data/fake",
+					Limits:     processdef.Limits{Memory: "512M", OpenFiles: 1024, Processes: 50},
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-units/bosh-vcap-fake-proc.service")).To(Equal(`[Unit]
+Description=BOSH job fake-job process fake-proc
+PartOf=bosh-vcap.target
+
+[Service]
+Type=simple
+WorkingDirectory=/var/vcap/data/fake
+Environment=A=1
+Environment="B=two words"
+Environment="C=$5 for 10%%"
+ExecStart=/var/vcap/packages/fake/bin/fake --name "with space" 100%% $$HOME
+TimeoutStartSec=30
+TimeoutStopSec=30
+Restart=always
+RestartSec=1
+Slice=bosh-jobs-fake\x2djob.slice
+User=vcap
+Group=vcap-admin
+LimitNOFILE=1024
+LimitNPROC=50
+MemoryMax=536870912
+StandardOutput=append:/var/vcap/data/sys/log/fake-job/fake-proc.stdout.log
+StandardError=append:/var/vcap/data/sys/log/fake-job/fake-proc.stderr.log
+`))
+		})
+
+		Context("when systemd does not support appending output to files", func() {
+			BeforeEach(func() {
+				client.SystemdVersion = 237
+			})
+
+			It("redirects output of process with shell", func() {
+				err := supervisor.AddProcesses("fake-job", 0, processdef.Config{
+					Processes: []processdef.Process{{
+						Name:       "fake-proc",
+						Executable: "/var/vcap/packages/fake/bin/fake",
+						Args:       []string{"--name", "with space"},
+					}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				content, err := fs.ReadFileString("/fake-units/bosh-vcap-fake-proc.service")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content).To(ContainSubstring(
+					`ExecStart=/bin/sh -c "stdout=$$1 stderr=$$2; shift 2; exec \"$$@\" >> \"$$stdout\" 2>> \"$$stderr\"" sh ` +
+						`/var/vcap/data/sys/log/fake-job/fake-proc.stdout.log /var/vcap/data/sys/log/fake-job/fake-proc.stderr.log ` +
+						`/var/vcap/packages/fake/bin/fake --name "with space"` + "\n",
+				))
+				Expect(content).ToNot(ContainSubstring("StandardOutput="))
+				Expect(content).ToNot(ContainSubstring("StandardError="))
+			})
+		})
+
+		It("redirects output with shell when systemd version cannot be determined", func() {
+			client.SystemdVersionErr = errors.New("fake-version-err")
+
+			err := supervisor.AddProcesses("fake-job", 0, processdef.Config{
+				Processes: []processdef.Process{{Name: "fake-proc", Executable: "/bin/fake"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := fs.ReadFileString("/fake-units/bosh-vcap-fake-proc.service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(ContainSubstring("ExecStart=/bin/sh -c "))
+			Expect(content).ToNot(ContainSubstring("StandardOutput="))
+		})
+	})
+
+	Describe("SetJobLimits", func() {
+		It("writes a slice for the job with its limits", func() {
+			err := supervisor.SetJobLimits("fake-job", cgroup.Limits{Memory: "512M", CPU: 1.5, Pids: 100})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-units/bosh-jobs-fake\\x2djob.slice")).To(Equal(`[Unit]
+Description=BOSH job fake-job
+
+[Slice]
+MemoryAccounting=yes
+CPUAccounting=yes
+TasksAccounting=yes
+MemoryMax=536870912
+CPUQuota=150%
+TasksMax=100
+`))
+		})
+
+		It("writes a slice without limits so that job usage is accounted", func() {
+			err := supervisor.SetJobLimits("fake_job.v2", cgroup.Limits{})
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := fs.ReadFileString("/fake-units/bosh-jobs-fake_job.v2.slice")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).ToNot(ContainSubstring("Max="))
+			Expect(content).ToNot(ContainSubstring("CPUQuota="))
+		})
+
+		It("returns error if limits are invalid", func() {
+			err := supervisor.SetJobLimits("fake-job", cgroup.Limits{Memory: "lots"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating limits of job fake-job"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes all generated units", func() {
+			fs.WriteFileString("/fake-units/bosh-vcap-a.service", "")
+			fs.WriteFileString("/fake-units/bosh-jobs-a.slice", "")
+			fs.WriteFileString("/fake-units/other.service", "")
+			setUnits("bosh-vcap-a.service", "bosh-jobs-a.slice")
+
+			err := supervisor.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists("/fake-units/bosh-vcap-a.service")).To(BeFalse())
+			Expect(fs.FileExists("/fake-units/bosh-jobs-a.slice")).To(BeFalse())
+			Expect(fs.FileExists("/fake-units/other.service")).To(BeTrue())
+		})
+	})
+
+	Describe("Reload", func() {
+		It("writes target wanting all units and reloads systemd", func() {
+			setUnits("bosh-vcap-b.service", "bosh-vcap-a.service")
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.ReadFileString("/fake-units/bosh-vcap.target")).To(Equal(
+				"[Unit]\nDescription=BOSH jobs\nWants=bosh-vcap-a.service bosh-vcap-b.service\n\n[Install]\nWantedBy=multi-user.target\n",
+			))
+			Expect(client.ReloadCallCount).To(Equal(1))
+		})
+
+		It("enables target so that jobs are started on boot", func() {
+			setUnits("bosh-vcap-a.service")
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			link := fs.GetFileTestStat("/fake-units/multi-user.target.wants/bosh-vcap.target")
+			Expect(link).ToNot(BeNil())
+			Expect(link.FileType).To(Equal(fakesys.FakeFileTypeSymlink))
+			Expect(link.SymlinkTarget).To(Equal("/fake-units/bosh-vcap.target"))
+		})
+
+		It("returns error if target cannot be enabled", func() {
+			fs.SymlinkError = errors.New("fake-symlink-err")
+
+			err := supervisor.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-symlink-err"))
+			Expect(client.ReloadCallCount).To(Equal(0))
+		})
+
+		It("returns error if reloading fails", func() {
+			client.ReloadErr = errors.New("fake-reload-err")
+
+			err := supervisor.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-reload-err"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("resets failed units and starts target", func() {
+			setUnits("bosh-vcap-a.service")
+			fs.WriteFileString("/var/vcap/bosh/systemd/stopped", "")
+			fs.WriteFileString("/var/vcap/bosh/systemd/unmonitored", "")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.ResetFailedUnitNames).To(Equal([]string{"bosh-vcap-a.service"}))
+			Expect(client.StartUnitNames).To(Equal([]string{"bosh-vcap.target"}))
+			Expect(fs.FileExists("/var/vcap/bosh/systemd/stopped")).To(BeFalse())
+			Expect(fs.FileExists("/var/vcap/bosh/systemd/unmonitored")).To(BeFalse())
+		})
+
+		It("returns error if starting target fails", func() {
+			client.StartUnitErr = errors.New("fake-start-err")
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops target and creates stopped file", func() {
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.StopUnitNames).To(Equal([]string{"bosh-vcap.target"}))
+			Expect(fs.FileExists("/var/vcap/bosh/systemd/stopped")).To(BeTrue())
+		})
+	})
+
+	Describe("StartProcess", func() {
+		It("resets failed unit and starts unit of process", func() {
+			err := supervisor.StartProcess("fake-proc")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.ResetFailedUnitNames).To(Equal([]string{"bosh-vcap-fake-proc.service"}))
+			Expect(client.StartUnitNames).To(Equal([]string{"bosh-vcap-fake-proc.service"}))
+		})
+
+		It("returns error if starting unit fails", func() {
+			client.StartUnitErr = errors.New("fake-start-err")
+
+			err := supervisor.StartProcess("fake-proc")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+		})
+	})
+
+	Describe("StopProcess", func() {
+		It("stops unit of process", func() {
+			err := supervisor.StopProcess("fake-proc")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StopUnitNames).To(Equal([]string{"bosh-vcap-fake-proc.service"}))
+		})
+
+		It("returns error if stopping unit fails", func() {
+			client.StopUnitErr = errors.New("fake-stop-err")
+
+			err := supervisor.StopProcess("fake-proc")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-stop-err"))
+		})
+	})
+
+	Describe("StopAndWait", func() {
+		BeforeEach(func() {
+			setUnits("bosh-vcap-a.service")
+		})
+
+		It("waits for units to become inactive", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "deactivating"})
+
+			errCh := make(chan error)
+			go func() { errCh <- supervisor.StopAndWait() }()
+
+			Eventually(timeService.WatcherCount).Should(BeNumerically(">", 0))
+			Consistently(errCh).ShouldNot(Receive())
+
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "inactive"})
+			timeService.Increment(time.Second)
+
+			Eventually(errCh).Should(Receive(BeNil()))
+			Expect(client.StopUnitNames).To(Equal([]string{"bosh-vcap.target"}))
+		})
+
+		It("returns error if unit fails while stopping", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "failed"})
+
+			err := supervisor.StopAndWait()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Stopping units 'bosh-vcap-a.service' failed"))
+		})
+
+		It("times out after 5 minutes", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "deactivating"})
+
+			errCh := make(chan error)
+			go func() { errCh <- supervisor.StopAndWait() }()
+
+			Eventually(timeService.WatcherCount).Should(BeNumerically(">", 0))
+			timeService.Increment(5 * time.Minute)
+			timeService.Increment(time.Second)
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err.Error()).To(ContainSubstring("Timed out waiting for units 'bosh-vcap-a.service' to stop"))
+		})
+	})
+
+	Describe("Status", func() {
+		BeforeEach(func() {
+			setUnits("bosh-vcap-a.service", "bosh-vcap-b.service")
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "active"})
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-b.service", ActiveState: "active"})
+		})
+
+		It("returns running when all units are active", func() {
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns starting when a unit is activating", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-b.service", ActiveState: "activating"})
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("returns failing when a unit is not active", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-b.service", ActiveState: "failed"})
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns failing when units are unmonitored", func() {
+			err := supervisor.Unmonitor()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns stopped after stop", func() {
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+
+		It("returns unknown when unit states cannot be retrieved", func() {
+			client.UnitStatesErr = errors.New("fake-states-err")
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("Processes", func() {
+		It("returns a process for each unit", func() {
+			setUnits("bosh-vcap-a.service", "bosh-vcap-b.service")
+			client.SetUnitStatus(boshsystemd.UnitStatus{
+				Name:                 "bosh-vcap-a.service",
+				ActiveState:          "active",
+				ActiveEnterTimestamp: timeService.Now().Add(-90 * time.Second),
+				MemoryCurrent:        4096 * 1024,
+			})
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-b.service", ActiveState: "failed"})
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{Name: "a", State: "running", Uptime: UptimeVitals{Secs: 90}, Memory: MemoryVitals{Kb: 4096}},
+				{Name: "b", State: "failing"},
+			}))
+		})
+
+		It("reports jobs of units and usage of their slices", func() {
+			setUnits("bosh-vcap-a.service", "bosh-vcap-b.service")
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "active", Slice: "bosh-jobs-fake\\x2djob.slice"})
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-b.service", ActiveState: "active", Slice: "system.slice"})
+			client.SliceStatuses["bosh-jobs-fake\\x2djob.slice"] = boshsystemd.SliceStatus{
+				Name:           "bosh-jobs-fake\\x2djob.slice",
+				MemoryCurrent:  8192,
+				MemoryMax:      16384,
+				CPUUsage:       4 * time.Second,
+				CPUQuotaPerSec: 500 * time.Millisecond,
+				TasksCurrent:   5,
+			}
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Job).To(Equal("fake-job"))
+			Expect(processes[0].Cgroup).To(Equal(&CgroupVitals{
+				MemoryKb:      8,
+				MemoryLimitKb: 16,
+				CPUSecs:       4,
+				CPULimit:      0.5,
+				Pids:          5,
+			}))
+			Expect(processes[1].Job).To(BeEmpty())
+			Expect(processes[1].Cgroup).To(BeNil())
+		})
+
+		It("returns error when unit status cannot be retrieved", func() {
+			setUnits("bosh-vcap-a.service")
+			client.UnitStatusErrs["bosh-vcap-a.service"] = errors.New("fake-status-err")
+
+			_, err := supervisor.Processes()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-status-err"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		var alertCh chan boshalert.MonitAlert
+
+		BeforeEach(func() {
+			setUnits("bosh-vcap-a.service")
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "active", NRestarts: 1})
+
+			alertCh = make(chan boshalert.MonitAlert, 10)
+			alerts := alertCh
+			go supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+				alerts <- alert
+				return nil
+			})
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Second)
+			Eventually(client.UnitStatesCallCount).Should(Equal(1))
+		})
+
+		It("reports processes restarted by systemd", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "active", NRestarts: 2})
+			timeService.Increment(time.Second)
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Service).To(Equal("a"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Date).To(Equal(timeService.Now().Format(time.RFC1123Z)))
+			Expect(alert.ID).To(ContainSubstring("bosh-vcap-a.service@localhost"))
+		})
+
+		It("includes exit status of main process in alerts", func() {
+			client.SetExitStatus("bosh-vcap-a.service", 3)
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "active", NRestarts: 2})
+			timeService.Increment(time.Second)
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Description).To(Equal("process is not running (restarted by systemd 2 time(s)), exit status 3"))
+		})
+
+		It("reports units that failed", func() {
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "failed", SubState: "failed", NRestarts: 1})
+			timeService.Increment(time.Second)
+
+			var alert boshalert.MonitAlert
+			Eventually(alertCh).Should(Receive(&alert))
+			Expect(alert.Event).To(Equal("execution failed"))
+			Expect(alert.Description).To(Equal("unit bosh-vcap-a.service failed (failed)"))
+
+			timeService.Increment(time.Second)
+			Consistently(alertCh).ShouldNot(Receive())
+		})
+
+		It("queries states of all units at once", func() {
+			Expect(client.UnitStatusCallCount()).To(Equal(0))
+		})
+
+		It("does not report failures while unmonitored", func() {
+			err := supervisor.Unmonitor()
+			Expect(err).ToNot(HaveOccurred())
+
+			client.SetUnitStatus(boshsystemd.UnitStatus{Name: "bosh-vcap-a.service", ActiveState: "failed", NRestarts: 2})
+			timeService.Increment(time.Second)
+
+			Consistently(alertCh).ShouldNot(Receive())
+		})
+	})
+})
@@ -21,7 +21,9 @@ import (
 
 	"golang.org/x/sys/windows/svc"
 
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/monitor"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/winsvc"
 
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
@@ -202,13 +204,13 @@ func NewWindowsJobSupervisor(
 	machineIP string,
 ) JobSupervisor {
 	s := &windowsJobSupervisor{
-		cmdRunner:   cmdRunner,
-		dirProvider: dirProvider,
-		fs:          fs,
-		logger:      logger,
-		logTag:      "windowsJobSupervisor",
-		machineIP:   machineIP,
-		msgCh:       make(chan *windowsServiceEvent, 8),
+		cmdRunner:             cmdRunner,
+		dirProvider:           dirProvider,
+		fs:                    fs,
+		logger:                logger,
+		logTag:                "windowsJobSupervisor",
+		machineIP:             machineIP,
+		msgCh:                 make(chan *windowsServiceEvent, 8),
 		jobFailuresServerPort: jobFailuresServerPort,
 		cancelServer:          cancelChan,
 	}
@@ -349,8 +351,55 @@ func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath s
 		return err
 	}
 
+	w.logger.Debug(w.logTag, "Configuring service wrapper for job %q with configPath %q", jobName, configPath)
+
+	return w.addProcesses(jobName, filepath.Dir(configPath), processConfig.Processes)
+}
+
+func (w *windowsJobSupervisor) StartProcess(processName string) error {
+	if err := w.mgr.StartService(processName); err != nil {
+		return bosherr.WrapErrorf(err, "Starting windows job process %s", processName)
+	}
+	return nil
+}
+
+func (w *windowsJobSupervisor) StopProcess(processName string) error {
+	if err := w.mgr.StopService(processName); err != nil {
+		return bosherr.WrapErrorf(err, "Stopping windows job process %s", processName)
+	}
+	return nil
+}
+
+// SetJobLimits does not limit jobs since there are no cgroups on Windows
+func (w *windowsJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	if !limits.IsEmpty() {
+		w.logger.Warn(w.logTag, "Resource limits of job %s are not supported on Windows", jobName)
+	}
+	return nil
+}
+
+func (w *windowsJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	return nil
+}
+
+func (w *windowsJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	processes := []WindowsProcess{}
+
+	for _, process := range config.Processes {
+		processes = append(processes, WindowsProcess{
+			Name:       process.Name,
+			Executable: process.Executable,
+			Args:       process.Args,
+			Env:        process.Env,
+		})
+	}
+
+	return w.addProcesses(jobName, w.dirProvider.JobDir(jobName), processes)
+}
+
+func (w *windowsJobSupervisor) addProcesses(jobName string, jobDir string, processes []WindowsProcess) error {
 	var buf bytes.Buffer
-	for _, process := range processConfig.Processes {
+	for _, process := range processes {
 		logPath := path.Join(w.dirProvider.LogsDir(), jobName, process.Name)
 		err := w.fs.MkdirAll(logPath, os.FileMode(0750))
 		if err != nil {
@@ -363,10 +412,6 @@ func (w *windowsJobSupervisor) AddJob(jobName string, jobIndex int, configPath s
 			return bosherr.WrapErrorf(err, "Rendering service config template for service '%s'", process.Name)
 		}
 
-		w.logger.Debug(w.logTag, "Configuring service wrapper for job %q with configPath %q", jobName, configPath)
-
-		jobDir := filepath.Dir(configPath)
-
 		processDir := filepath.Join(jobDir, process.Name)
 		err = w.fs.MkdirAll(processDir, os.FileMode(0750))
 		if err != nil {
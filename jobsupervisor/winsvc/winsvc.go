@@ -439,6 +439,31 @@ func (m *Mgr) Stop() (first error) {
 	return m.iter(Stop)
 }
 
+// StartService starts the monitored service with the given name.
+func (m *Mgr) StartService(name string) error {
+	return m.withService(name, Start)
+}
+
+// StopService stops the monitored service with the given name and
+// prevents it from being restarted until it is started again.
+func (m *Mgr) StopService(name string) error {
+	return m.withService(name, Stop)
+}
+
+func (m *Mgr) withService(name string, fn func(*mgr.Service) error) error {
+	svcs, err := m.services()
+	if err != nil {
+		return err
+	}
+	defer closeServices(svcs)
+	for _, s := range svcs {
+		if s.Name == name {
+			return fn(s)
+		}
+	}
+	return fmt.Errorf("winsvc: service not monitored: %s", name)
+}
+
 func (m *Mgr) doDelete(s *mgr.Service) error {
 	const Timeout = time.Second * 60
 
@@ -6,7 +6,12 @@ import (
 	//boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	//boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	//boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"code.cloudfoundry.org/clock"
 	"encoding/json"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/cgroup"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/probe"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cloudfoundry/bosh-utils/system"
@@ -20,16 +25,22 @@ type wrapperJobSupervisor struct {
 	fs            system.FileSystem
 	dirProvider   directories.Provider
 	logger        boshlog.Logger
+	probes        *jobProbes
+	crashLoops    *jobCrashLoops
+	order         *jobOrder
 	pollRunning   bool
 	pollUnmonitor bool
 }
 
-func NewWrapperJobSupervisor(delegate JobSupervisor, fs system.FileSystem, dirProvider directories.Provider, logger boshlog.Logger) JobSupervisor {
+func NewWrapperJobSupervisor(delegate JobSupervisor, fs system.FileSystem, dirProvider directories.Provider, logger boshlog.Logger, prober probe.Prober, timeService clock.Clock) JobSupervisor {
 	return &wrapperJobSupervisor{
 		delegate:    delegate,
 		fs:          fs,
 		dirProvider: dirProvider,
 		logger:      logger,
+		probes:      newJobProbes(prober, fs, filepath.Join(dirProvider.BoshDir(), "probes.yml"), timeService, logger),
+		crashLoops:  newJobCrashLoops(delegate, fs, dirProvider, timeService, logger),
+		order:       newJobOrder(delegate, fs, filepath.Join(dirProvider.BoshDir(), "job_order.yml"), timeService, logger),
 	}
 }
 
@@ -37,22 +48,29 @@ func (w *wrapperJobSupervisor) Reload() error {
 	return w.delegate.Reload()
 }
 func (w *wrapperJobSupervisor) Start() error {
+	w.crashLoops.CancelBackoffs()
 
-	err := w.delegate.Start()
+	err := w.order.Start(w.delegate.Start)
 	w.HealthRecorder(w.delegate.Status())
 
 	return err
 }
 func (w *wrapperJobSupervisor) Stop() error {
-	err := w.delegate.Stop()
+	w.crashLoops.CancelBackoffs()
+
+	err := w.order.Stop(w.delegate.Stop)
 	w.HealthRecorder(w.delegate.Status())
 
 	return err
 }
 func (w *wrapperJobSupervisor) StopAndWait() error {
-	return w.delegate.StopAndWait()
+	w.crashLoops.CancelBackoffs()
+
+	return w.order.Stop(w.delegate.StopAndWait)
 }
 func (w *wrapperJobSupervisor) Unmonitor() error {
+	w.crashLoops.CancelBackoffs()
+
 	err := w.delegate.Unmonitor()
 	if err != nil {
 		return err
@@ -61,20 +79,96 @@ func (w *wrapperJobSupervisor) Unmonitor() error {
 	w.HealthRecorder(w.delegate.Status())
 	return err
 }
+func (w *wrapperJobSupervisor) StartProcess(processName string) error {
+	w.crashLoops.CancelBackoff(processName)
+
+	return w.delegate.StartProcess(processName)
+}
+func (w *wrapperJobSupervisor) StopProcess(processName string) error {
+	w.crashLoops.CancelBackoff(processName)
+
+	return w.delegate.StopProcess(processName)
+}
 func (w *wrapperJobSupervisor) Status() string {
-	return w.delegate.Status()
+	return w.probes.Status(w.delegate.Status())
 }
 func (w *wrapperJobSupervisor) Processes() ([]Process, error) {
-	return w.delegate.Processes()
+	processes, err := w.delegate.Processes()
+	if err != nil {
+		return processes, err
+	}
+
+	w.order.AddJobNames(processes)
+	w.probes.AddStatuses(processes)
+	w.crashLoops.AddHistories(processes)
+
+	return processes, nil
 }
 func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
-	return w.delegate.AddJob(jobName, jobIndex, configPath)
+	err := w.delegate.AddJob(jobName, jobIndex, configPath)
+	if err != nil {
+		return err
+	}
+
+	return w.order.AddMonitFile(jobName, configPath)
+}
+func (w *wrapperJobSupervisor) SetJobLimits(jobName string, limits cgroup.Limits) error {
+	return w.delegate.SetJobLimits(jobName, limits)
+}
+func (w *wrapperJobSupervisor) SetJobDependencies(jobName string, dependsOn []string) error {
+	err := w.delegate.SetJobDependencies(jobName, dependsOn)
+	if err != nil {
+		return err
+	}
+
+	return w.order.SetDependencies(jobName, dependsOn)
+}
+
+func (w *wrapperJobSupervisor) AddProcesses(jobName string, jobIndex int, config processdef.Config) error {
+	err := w.delegate.AddProcesses(jobName, jobIndex, config)
+	if err != nil {
+		return err
+	}
+
+	var processNames []string
+	for _, process := range config.Processes {
+		processNames = append(processNames, process.Name)
+	}
+
+	err = w.order.AddProcesses(jobName, processNames)
+	if err != nil {
+		return err
+	}
+
+	return w.probes.Add(jobName, config)
 }
 func (w *wrapperJobSupervisor) RemoveAllJobs() error {
-	return w.delegate.RemoveAllJobs()
+	err := w.delegate.RemoveAllJobs()
+	if err != nil {
+		return err
+	}
+
+	w.crashLoops.RemoveAll()
+
+	err = w.order.RemoveAll()
+	if err != nil {
+		return err
+	}
+
+	return w.probes.RemoveAll()
 }
 func (w *wrapperJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
-	return w.delegate.MonitorJobFailures(handler)
+	go w.probes.Run(w.delegate.Status, handler)
+
+	return w.delegate.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+		err := handler(alert)
+
+		if isRestartAlert(alert) {
+			w.crashLoops.RecordRestart(alert, handler)
+		}
+
+		return err
+	})
 }
 
 func (w *wrapperJobSupervisor) HealthRecorder(status string) {
@@ -6,9 +6,14 @@ import (
 	"encoding/json"
 	"errors"
 	"path/filepath"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-agent/agent/alert"
 	"github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	fakeprobe "github.com/cloudfoundry/bosh-agent/jobsupervisor/probe/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/processdef"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -23,6 +28,8 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		logger         boshlog.Logger
 		dirProvider    boshdir.Provider
 		fakeSupervisor *fakes.FakeJobSupervisor
+		prober         *fakeprobe.FakeProber
+		timeService    *fakeclock.FakeClock
 		wrapper        JobSupervisor
 	)
 
@@ -33,12 +40,16 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		dirProvider = boshdir.NewProvider("/var/vcap")
 
 		fakeSupervisor = fakes.NewFakeJobSupervisor()
+		prober = fakeprobe.NewFakeProber()
+		timeService = fakeclock.NewFakeClock(time.Now())
 
 		wrapper = NewWrapperJobSupervisor(
 			fakeSupervisor,
 			fs,
 			dirProvider,
 			logger,
+			prober,
+			timeService,
 		)
 	})
 
@@ -140,6 +151,16 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		}))
 	})
 
+	It("AddProcesses should delegate to the underlying job supervisor", func() {
+		fakeSupervisor.AddProcessesErr = errors.New("BOOM")
+		config := processdef.Config{Processes: []processdef.Process{{Name: "fake-proc"}}}
+		err := wrapper.AddProcesses("name", 1, config)
+		Expect(err).To(Equal(fakeSupervisor.AddProcessesErr))
+		Expect(fakeSupervisor.AddProcessesArgs).To(Equal([]fakes.AddProcessesArgs{
+			{Name: "name", Index: 1, Config: config},
+		}))
+	})
+
 	It("RemoveAllJobs should delegate to the underlying job supervisor", func() {
 		fakeSupervisor.RemovedAllJobsErr = errors.New("BOOM")
 		err := wrapper.RemoveAllJobs()
@@ -158,4 +179,355 @@ var _ = Describe("WrapperJobSupervisor", func() {
 		})
 		Expect(testAlert).To(Equal(fakeSupervisor.JobFailureAlert))
 	})
+
+	Describe("probes", func() {
+		var alertCh chan alert.MonitAlert
+
+		config := processdef.Config{
+			Processes: []processdef.Process{
+				{
+					Name:           "fake-proc",
+					ReadinessProbe: &processdef.Probe{HTTP: &processdef.HTTPProbe{Port: 8080}},
+					LivenessProbe:  &processdef.Probe{Exec: &processdef.ExecProbe{Executable: "/bin/alive"}, FailureThreshold: 2},
+				},
+				{Name: "other-proc"},
+			},
+		}
+
+		BeforeEach(func() {
+			fakeSupervisor.StatusStatus = "running"
+			fakeSupervisor.ProcessesStatus = []Process{{Name: "fake-proc", State: "running"}, {Name: "other-proc", State: "running"}}
+
+			Expect(wrapper.AddProcesses("fake-job", 0, config)).To(Succeed())
+
+			alertCh = make(chan alert.MonitAlert, 10)
+		})
+
+		monitor := func() {
+			alerts := alertCh
+			go wrapper.MonitorJobFailures(func(a alert.MonitAlert) error {
+				alerts <- a
+				return nil
+			})
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+		}
+
+		checkProbes := func(expectedCalls int) {
+			timeService.Increment(10 * time.Second)
+			Eventually(prober.ProbeCallCount).Should(Equal(expectedCalls))
+		}
+
+		It("reports starting until readiness probes pass", func() {
+			Expect(wrapper.Status()).To(Equal("starting"))
+
+			monitor()
+			checkProbes(2)
+
+			Eventually(wrapper.Status).Should(Equal("running"))
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Readiness.Type).To(Equal("http"))
+			Expect(processes[0].Readiness.State).To(Equal(ProbeStatePassing))
+			Expect(processes[0].Liveness.Type).To(Equal("exec"))
+			Expect(processes[1].Readiness).To(BeNil())
+		})
+
+		It("alerts when probes start failing and when they recover", func() {
+			prober.SetProbeErr("exec", errors.New("fake-probe-err"))
+
+			monitor()
+			checkProbes(2)
+			Consistently(alertCh).ShouldNot(Receive())
+
+			checkProbes(4)
+
+			var failureAlert alert.MonitAlert
+			Eventually(alertCh).Should(Receive(&failureAlert))
+			Expect(failureAlert.Service).To(Equal("fake-proc"))
+			Expect(failureAlert.Event).To(Equal("liveness probe failed"))
+			Expect(failureAlert.Description).To(Equal("exec probe failed 2 times: fake-probe-err"))
+			Expect(wrapper.Status()).To(Equal("failing"))
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Liveness.State).To(Equal(ProbeStateFailing))
+			Expect(processes[0].Liveness.ConsecutiveFailures).To(Equal(2))
+			Expect(processes[0].Liveness.Message).To(Equal("fake-probe-err"))
+
+			prober.SetProbeErr("exec", nil)
+			checkProbes(6)
+
+			var recoveryAlert alert.MonitAlert
+			Eventually(alertCh).Should(Receive(&recoveryAlert))
+			Expect(recoveryAlert.Event).To(Equal("liveness probe succeeded"))
+			Expect(wrapper.Status()).To(Equal("running"))
+		})
+
+		It("does not run probes while processes are not running", func() {
+			fakeSupervisor.StatusStatus = "stopped"
+
+			monitor()
+			timeService.Increment(10 * time.Second)
+
+			Consistently(prober.ProbeCallCount).Should(BeZero())
+			Expect(wrapper.Status()).To(Equal("stopped"))
+		})
+
+		It("keeps probes of jobs configured before agent restarted", func() {
+			restartedWrapper := NewWrapperJobSupervisor(fakeSupervisor, fs, dirProvider, logger, prober, timeService)
+
+			processes, err := restartedWrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Readiness.State).To(Equal(ProbeStateUnknown))
+			Expect(processes[0].Liveness.State).To(Equal(ProbeStateUnknown))
+		})
+
+		It("removes probes with all jobs", func() {
+			Expect(wrapper.RemoveAllJobs()).To(Succeed())
+			Expect(wrapper.Status()).To(Equal("running"))
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Readiness).To(BeNil())
+		})
+	})
+
+	Describe("crash loops", func() {
+		var (
+			alertsLock sync.Mutex
+			alerts     []alert.MonitAlert
+		)
+
+		receivedAlerts := func() []alert.MonitAlert {
+			alertsLock.Lock()
+			defer alertsLock.Unlock()
+			return append([]alert.MonitAlert{}, alerts...)
+		}
+
+		restartAlert := func(description string) alert.MonitAlert {
+			return alert.MonitAlert{Service: "fake-proc", Event: "does not exist", Action: "restart", Description: description}
+		}
+
+		BeforeEach(func() {
+			alerts = nil
+			fakeSupervisor.ProcessesStatus = []Process{{Name: "fake-proc", Job: "fake-job"}, {Name: "other-proc"}}
+
+			Expect(wrapper.MonitorJobFailures(func(a alert.MonitAlert) error {
+				alertsLock.Lock()
+				defer alertsLock.Unlock()
+				alerts = append(alerts, a)
+				return nil
+			})).To(Succeed())
+		})
+
+		restart := func(times int) {
+			for i := 0; i < times; i++ {
+				Expect(fakeSupervisor.JobFailureHandler(restartAlert("process exited: exit status 3"))).To(Succeed())
+				timeService.Increment(10 * time.Second)
+			}
+		}
+
+		It("records restarts and exit codes of processes", func() {
+			Expect(fakeSupervisor.JobFailureHandler(restartAlert("process exited: exit status 3"))).To(Succeed())
+			Expect(fakeSupervisor.JobFailureHandler(restartAlert("process is not running"))).To(Succeed())
+
+			Expect(receivedAlerts()).To(HaveLen(2))
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Restarts.Restarts).To(HaveLen(2))
+			Expect(*processes[0].Restarts.Restarts[0].ExitCode).To(Equal(3))
+			Expect(processes[0].Restarts.Restarts[1].ExitCode).To(BeNil())
+			Expect(processes[0].Restarts.CrashLoops).To(BeZero())
+			Expect(processes[1].Restarts).To(BeNil())
+		})
+
+		It("holds off restarting crash looping processes and alerts with last stderr lines", func() {
+			fs.WriteFileString("/var/vcap/data/sys/log/fake-job/fake-proc.stderr.log", "line 1\nline 2\npanic: fake-panic\n")
+
+			restart(4)
+			Expect(fakeSupervisor.StoppedProcesses()).To(BeEmpty())
+
+			restart(1)
+			Eventually(fakeSupervisor.StoppedProcesses).Should(Equal([]string{"fake-proc"}))
+			Eventually(receivedAlerts).Should(HaveLen(6))
+
+			crashLoopAlert := receivedAlerts()[5]
+			Expect(crashLoopAlert.Event).To(Equal("crash loop detected"))
+			Expect(crashLoopAlert.Service).To(Equal("fake-proc"))
+			Expect(crashLoopAlert.Description).To(HavePrefix("restarted 5 times within 5m0s (exit codes: 3, 3, 3, 3, 3), holding off restarts for 30s"))
+			Expect(crashLoopAlert.Description).To(HaveSuffix("last stderr lines:\nline 1\nline 2\npanic: fake-panic"))
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Restarts.CrashLoops).To(Equal(1))
+			Expect(processes[0].Restarts.BackoffUntil).ToNot(BeZero())
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(30 * time.Second)
+			Eventually(fakeSupervisor.StartedProcesses).Should(Equal([]string{"fake-proc"}))
+		})
+
+		It("backs off exponentially while process keeps crash looping", func() {
+			restart(5)
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(30 * time.Second)
+			Eventually(fakeSupervisor.StartedProcesses).Should(HaveLen(1))
+
+			restart(5)
+			Eventually(receivedAlerts).Should(HaveLen(12))
+			Expect(receivedAlerts()[11].Description).To(ContainSubstring("holding off restarts for 1m0s"))
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(30 * time.Second)
+			Consistently(fakeSupervisor.StartedProcesses).Should(HaveLen(1))
+			timeService.Increment(30 * time.Second)
+			Eventually(fakeSupervisor.StartedProcesses).Should(HaveLen(2))
+		})
+
+		It("does not start held off processes after they are stopped", func() {
+			restart(5)
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+
+			Expect(wrapper.Stop()).To(Succeed())
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Consistently(fakeSupervisor.StartedProcesses).Should(BeEmpty())
+		})
+
+		It("does not start held off process after it is stopped on its own", func() {
+			restart(5)
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+
+			Expect(wrapper.StopProcess("fake-proc")).To(Succeed())
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Consistently(fakeSupervisor.StartedProcesses).Should(BeEmpty())
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Restarts.BackoffUntil).To(BeZero())
+		})
+
+		It("does not start held off process again after it is started on its own", func() {
+			restart(5)
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+
+			Expect(wrapper.StartProcess("fake-proc")).To(Succeed())
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(time.Minute)
+
+			Consistently(fakeSupervisor.StartedProcesses).Should(Equal([]string{"fake-proc"}))
+		})
+	})
+
+	Describe("job dependencies", func() {
+		addJobs := func() {
+			fs.WriteFileString("/fake-proxy.monit", "check process fake-proxy-proc\n  with pidfile /var/vcap/sys/run/fake-proxy.pid\n")
+
+			Expect(wrapper.SetJobDependencies("fake-proxy", nil)).To(Succeed())
+			Expect(wrapper.AddJob("fake-proxy", 0, "/fake-proxy.monit")).To(Succeed())
+
+			Expect(wrapper.SetJobDependencies("fake-app", []string{"fake-proxy", "fake-missing"})).To(Succeed())
+			Expect(wrapper.AddProcesses("fake-app", 1, processdef.Config{
+				Processes: []processdef.Process{{Name: "fake-app-proc"}},
+			})).To(Succeed())
+
+			Expect(wrapper.SetJobDependencies("fake-worker", nil)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			fakeSupervisor.ProcessesStatus = []Process{
+				{Name: "fake-proxy-proc", State: "running"},
+				{Name: "fake-app-proc", State: "running"},
+				{Name: "fake-worker-proc", Job: "fake-worker", State: "running"},
+			}
+		})
+
+		It("starts all jobs at once when no dependencies are declared", func() {
+			Expect(wrapper.SetJobDependencies("fake-proxy", nil)).To(Succeed())
+			Expect(fakeSupervisor.SetJobDependenciesArgs).To(HaveLen(1))
+
+			Expect(wrapper.Start()).To(Succeed())
+			Expect(fakeSupervisor.Started).To(BeTrue())
+			Expect(fakeSupervisor.StartedProcesses()).To(BeEmpty())
+		})
+
+		It("starts jobs after jobs they depend on", func() {
+			addJobs()
+
+			Expect(wrapper.Start()).To(Succeed())
+			Expect(fakeSupervisor.StartedProcesses()).To(Equal([]string{"fake-proxy-proc", "fake-worker-proc", "fake-app-proc"}))
+			Expect(fakeSupervisor.Started).To(BeTrue())
+		})
+
+		It("stops jobs before jobs they depend on", func() {
+			addJobs()
+			fakeSupervisor.ProcessesStatus = []Process{{Name: "fake-proxy-proc", State: "stopped"}}
+
+			Expect(wrapper.StopAndWait()).To(Succeed())
+			Expect(fakeSupervisor.StoppedProcesses()).To(Equal([]string{"fake-app-proc", "fake-proxy-proc"}))
+			Expect(fakeSupervisor.StoppedAndWaited).To(BeTrue())
+		})
+
+		It("keeps order of jobs after agent restarts", func() {
+			addJobs()
+
+			wrapper = NewWrapperJobSupervisor(fakeSupervisor, fs, dirProvider, logger, prober, timeService)
+
+			Expect(wrapper.Start()).To(Succeed())
+			Expect(fakeSupervisor.StartedProcesses()).To(Equal([]string{"fake-proxy-proc", "fake-worker-proc", "fake-app-proc"}))
+		})
+
+		It("does not start jobs whose dependencies do not become running", func() {
+			addJobs()
+			fakeSupervisor.ProcessesStatus[0].State = "starting"
+
+			errCh := make(chan error)
+			go func() { errCh <- wrapper.Start() }()
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(5 * time.Minute)
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Waiting for jobs fake-proxy, fake-worker to be running"))
+			Expect(err.Error()).To(ContainSubstring("Timed out after 5m0s waiting for processes fake-proxy-proc"))
+
+			Expect(fakeSupervisor.StartedProcesses()).To(Equal([]string{"fake-proxy-proc", "fake-worker-proc"}))
+			Expect(fakeSupervisor.Started).To(BeFalse())
+		})
+
+		It("returns error when dependencies form a cycle", func() {
+			addJobs()
+			Expect(wrapper.SetJobDependencies("fake-proxy", []string{"fake-app"})).To(Succeed())
+
+			err := wrapper.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Dependencies of jobs fake-app, fake-proxy form a cycle"))
+			Expect(fakeSupervisor.StartedProcesses()).To(BeEmpty())
+		})
+
+		It("reports jobs of processes", func() {
+			addJobs()
+
+			processes, err := wrapper.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Job).To(Equal("fake-proxy"))
+			Expect(processes[1].Job).To(Equal("fake-app"))
+			Expect(processes[2].Job).To(Equal("fake-worker"))
+		})
+
+		It("forgets dependencies when all jobs are removed", func() {
+			addJobs()
+			Expect(wrapper.RemoveAllJobs()).To(Succeed())
+
+			Expect(wrapper.Start()).To(Succeed())
+			Expect(fakeSupervisor.StartedProcesses()).To(BeEmpty())
+		})
+	})
 })
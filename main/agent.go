@@ -49,18 +49,20 @@ func runAgent(opts boshapp.Options, logger logger.Logger) chan error {
 	return errCh
 }
 
-func startAgent(logger logger.Logger) error {
-	opts, err := boshapp.ParseOptions(os.Args)
-	if err != nil {
-		logger.Error(mainLogTag, "Parsing options %s", err.Error())
-		return err
-	}
-
+func startAgent(opts boshapp.Options, logger logger.Logger) error {
 	if opts.VersionCheck {
 		fmt.Println(VersionLabel)
 		os.Exit(0)
 	}
 
+	if opts.Subcommand == networkCheckSubcommand {
+		return runNetworkCheck(opts, logger)
+	}
+
+	if opts.Subcommand != "" {
+		return fmt.Errorf("unknown subcommand '%s'", opts.Subcommand)
+	}
+
 	sigCh := make(chan os.Signal, 8)
 	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt, os.Kill)
 	errCh := runAgent(opts, logger)
@@ -75,11 +77,16 @@ func startAgent(logger logger.Logger) error {
 }
 
 func main() {
-	asyncLog := boshlog.NewAsyncWriterLogger(boshlog.LevelDebug, os.Stderr)
-	logger := newSignalableLogger(asyncLog)
+	// Options are parsed before logger is created since they pick log format
+	opts, optsErr := boshapp.ParseOptions(os.Args)
+
+	logger := newSignalableLogger(newLogger(opts.LogFormat))
 
 	exitCode := 0
-	if err := startAgent(logger); err != nil {
+	if optsErr != nil {
+		logger.Error(mainLogTag, "Parsing options %s", optsErr.Error())
+		exitCode = 1
+	} else if err := startAgent(opts, logger); err != nil {
 		logger.Error(mainLogTag, "Agent exited with error: %s", err)
 		exitCode = 1
 	}
@@ -87,6 +94,13 @@ func main() {
 	os.Exit(exitCode)
 }
 
+func newLogger(format string) logger.Logger {
+	if format == agentlogger.FormatJSON {
+		return agentlogger.NewJSONLogger(boshlog.LevelDebug, os.Stderr)
+	}
+	return boshlog.NewAsyncWriterLogger(boshlog.LevelDebug, os.Stderr)
+}
+
 func newSignalableLogger(logger logger.Logger) logger.Logger {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGSEGV)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	boshapp "github.com/cloudfoundry/bosh-agent/app"
+	"github.com/cloudfoundry/bosh-agent/platform"
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshnetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const networkCheckSubcommand = "network-check"
+
+// runNetworkCheck checks connectivity using last settings saved by the agent
+// so that it can be used to troubleshoot VMs on which agent cannot start.
+// Report is printed to stdout as JSON.
+func runNetworkCheck(opts boshapp.Options, logger boshlog.Logger) error {
+	fs := boshsys.NewOsFileSystem(logger)
+	runner := boshsys.NewExecCmdRunner(logger)
+
+	settingsPath := filepath.Join(boshdirs.NewProvider(opts.BaseDirectory).BoshDir(), "settings.json")
+
+	contents, err := fs.ReadFile(settingsPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading settings from %s", settingsPath)
+	}
+
+	var settings boshsettings.Settings
+
+	err = json.Unmarshal(contents, &settings)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling settings from %s", settingsPath)
+	}
+
+	checker := boshnetcheck.NewChecker(
+		runner,
+		boshnetcheck.NewDNSResolver(boshnetcheck.Timeout),
+		bosharp.NewArpingDuplicateAddressDetector(runner, logger, platform.ArpDuplicateProbes),
+		boship.NewSystemInterfaceAddressesProvider(),
+		boshnetcheck.Timeout,
+		logger,
+	)
+
+	report := checker.Check(settings)
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling network check report")
+	}
+
+	fmt.Println(string(reportJSON))
+
+	if !report.Passed {
+		return errors.New("network check failed")
+	}
+
+	return nil
+}
@@ -23,6 +23,10 @@ type FakeHandler struct {
 
 	SendCallback func(SendInput)
 	SendErr      error
+
+	publishLock   sync.Mutex
+	publishInputs []PublishInput
+	PublishErr    error
 }
 
 type SendInput struct {
@@ -31,6 +35,11 @@ type SendInput struct {
 	Message interface{}
 }
 
+type PublishInput struct {
+	Subject string
+	Message interface{}
+}
+
 func NewFakeHandler() *FakeHandler {
 	return &FakeHandler{sendInputs: []SendInput{}}
 }
@@ -89,3 +98,19 @@ func (h *FakeHandler) SendInputs() []SendInput {
 
 	return h.sendInputs
 }
+
+func (h *FakeHandler) Publish(subject string, message interface{}) error {
+	h.publishLock.Lock()
+	defer h.publishLock.Unlock()
+
+	h.publishInputs = append(h.publishInputs, PublishInput{Subject: subject, Message: message})
+
+	return h.PublishErr
+}
+
+func (h *FakeHandler) PublishInputs() []PublishInput {
+	h.publishLock.Lock()
+	defer h.publishLock.Unlock()
+
+	return append([]PublishInput{}, h.publishInputs...)
+}
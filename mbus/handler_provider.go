@@ -6,6 +6,7 @@ import (
 	"github.com/cloudfoundry/yagnats"
 
 	"code.cloudfoundry.org/clock"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -35,6 +36,7 @@ func NewHandlerProvider(
 func (p HandlerProvider) Get(
 	platform boshplatform.Platform,
 	dirProvider boshdir.Provider,
+	logStreamer boshlogtail.Streamer,
 ) (handler boshhandler.Handler, err error) {
 	if p.handler != nil {
 		handler = p.handler
@@ -53,7 +55,7 @@ func (p HandlerProvider) Get(
 		handler = NewNatsHandler(p.settingsService, natsClient, p.logger, platform)
 	case "https":
 		mbusKeyPair := p.settingsService.GetSettings().Env.Bosh.Mbus.Cert
-		handler = NewHTTPSHandler(mbusURL, mbusKeyPair, p.logger, platform.GetFs(), dirProvider, p.auditLogger)
+		handler = NewHTTPSHandler(mbusURL, mbusKeyPair, p.logger, platform.GetFs(), dirProvider, p.auditLogger, logStreamer)
 	default:
 		err = bosherr.Errorf("Message Bus Handler with scheme %s could not be found", mbusURL.Scheme)
 	}
@@ -2,16 +2,21 @@ package mbus
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/settings"
 	"github.com/cloudfoundry/bosh-utils/blobstore"
 
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -28,6 +33,7 @@ type HTTPSHandler struct {
 	fs          boshsys.FileSystem
 	dirProvider boshdir.Provider
 	auditLogger platform.AuditLogger
+	logStreamer boshlogtail.Streamer
 }
 
 func NewHTTPSHandler(
@@ -37,6 +43,7 @@ func NewHTTPSHandler(
 	fs boshsys.FileSystem,
 	dirProvider boshdir.Provider,
 	auditLogger platform.AuditLogger,
+	logStreamer boshlogtail.Streamer,
 ) HTTPSHandler {
 	return HTTPSHandler{
 		parsedURL:   parsedURL,
@@ -45,6 +52,7 @@ func NewHTTPSHandler(
 		dirProvider: dirProvider,
 		dispatcher:  NewHTTPSDispatcher(parsedURL, keyPair, logger),
 		auditLogger: auditLogger,
+		logStreamer: logStreamer,
 	}
 }
 
@@ -59,6 +67,7 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.Func) error {
 func (h HTTPSHandler) Start(handlerFunc boshhandler.Func) error {
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
+	h.dispatcher.AddRoute("/logs", h.logsHandler())
 	return h.dispatcher.Start()
 }
 
@@ -74,6 +83,10 @@ func (h HTTPSHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	return nil
 }
 
+func (h HTTPSHandler) Publish(subject string, message interface{}) error {
+	return bosherr.Error("HTTPSHandler does not support publishing to subjects")
+}
+
 func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -95,7 +108,11 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 
 		respBytes, _, err := boshhandler.PerformHandlerWithJSON(
 			rawJSONPayload,
-			handlerFunc,
+			func(req boshhandler.Request) boshhandler.Response {
+				username, _, _ := r.BasicAuth()
+				req.Caller = fmt.Sprintf("%s@%s", username, r.RemoteAddr)
+				return handlerFunc(req)
+			},
 			boshhandler.UnlimitedResponseLength,
 			h.logger,
 		)
@@ -176,6 +193,96 @@ func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	h.generateCEFLog(r, statusCode, "")
 }
 
+// logsHandler streams lines written to logs as server-sent events
+// until client disconnects or stream ends
+func (h HTTPSHandler) logsHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(404)
+			h.generateCEFLog(r, 404, "")
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(500)
+			h.generateCEFLog(r, 500, "")
+			return
+		}
+
+		query := r.URL.Query()
+
+		var options boshlogtail.Options
+		var err error
+
+		if lines := query.Get("lines"); lines != "" {
+			options.Lines, err = strconv.Atoi(lines)
+		}
+
+		if idleTimeout := query.Get("idle_timeout"); idleTimeout != "" && err == nil {
+			var seconds int
+			seconds, err = strconv.Atoi(idleTimeout)
+			options.IdleTimeout = time.Duration(seconds) * time.Second
+		}
+
+		var stream boshlogtail.Stream
+
+		if err == nil {
+			stream, err = h.logStreamer.Open(query.Get("type"), query["path"], options)
+		}
+
+		if err != nil {
+			statusCode := 400
+			if err == boshlogtail.ErrTooManyStreams {
+				statusCode = 429
+			}
+
+			h.logger.Error(httpsHandlerLogTag, "Failed to tail logs: %s", err.Error())
+			w.WriteHeader(statusCode)
+			h.generateCEFLog(r, statusCode, "")
+
+			if _, wErr := w.Write([]byte(err.Error())); wErr != nil {
+				h.logger.Error(httpsHandlerLogTag, "Failed to write response body: %s", wErr.Error())
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(200)
+		flusher.Flush()
+		h.generateCEFLog(r, 200, "")
+
+		writeEvent := func(event string, value interface{}) error {
+			bytes, err := json.Marshal(value)
+			if err != nil {
+				return bosherr.WrapError(err, "Marshalling event")
+			}
+
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, bytes)
+			if err != nil {
+				return bosherr.WrapError(err, "Writing event")
+			}
+
+			flusher.Flush()
+
+			return nil
+		}
+
+		summary, err := stream.Run(func(chunk boshlogtail.Chunk) error {
+			return writeEvent("chunk", chunk)
+		}, r.Context().Done())
+		if err != nil {
+			h.logger.Error(httpsHandlerLogTag, "Failed to tail logs: %s", err.Error())
+			return
+		}
+
+		if err = writeEvent("end", summary); err != nil {
+			h.logger.Error(httpsHandlerLogTag, "Failed to end tailing logs: %s", err.Error())
+		}
+	}
+}
+
 func (h HTTPSHandler) generateCEFLog(r *http.Request, respStatusCode int, respJSON string) {
 	cef := boshhandler.NewCommonEventFormat()
 
@@ -1,6 +1,7 @@
 package mbus_test
 
 import (
+	"bufio"
 	"crypto/tls"
 	"errors"
 	"io/ioutil"
@@ -14,6 +15,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"crypto/x509"
+	boshlogtail "github.com/cloudfoundry/bosh-agent/agent/logtail"
+	fakelogtail "github.com/cloudfoundry/bosh-agent/agent/logtail/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	"github.com/cloudfoundry/bosh-agent/platform/fakes"
 	"github.com/cloudfoundry/bosh-agent/settings"
@@ -29,6 +32,7 @@ var _ = Describe("HTTPSHandler", func() {
 		fs              *fakesys.FakeFileSystem
 		receivedRequest boshhandler.Request
 		httpClient      http.Client
+		logStreamer     *fakelogtail.FakeStreamer
 	)
 	AfterEach(func() {
 		handler.Stop()
@@ -42,6 +46,7 @@ var _ = Describe("HTTPSHandler", func() {
 			logger := boshlog.NewLogger(boshlog.LevelNone)
 			fs = fakesys.NewFakeFileSystem()
 			dirProvider := boshdir.NewProvider("/var/vcap")
+			logStreamer = &fakelogtail.FakeStreamer{}
 
 			//this test will start to fail on 15 May 2018. see test-assets/readme
 			configCert, err := ioutil.ReadFile("test_assets/custom_cert.pem")
@@ -54,7 +59,7 @@ var _ = Describe("HTTPSHandler", func() {
 				PrivateKey:  string(configPrivateKey),
 			}
 
-			handler = NewHTTPSHandler(mbusURL, mbusKeyPair, logger, fs, dirProvider, fakes.NewFakeAuditLogger())
+			handler = NewHTTPSHandler(mbusURL, mbusKeyPair, logger, fs, dirProvider, fakes.NewFakeAuditLogger(), logStreamer)
 
 			go handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 				receivedRequest = req
@@ -100,7 +105,8 @@ var _ = Describe("HTTPSHandler", func() {
 			logger := boshlog.NewLogger(boshlog.LevelNone)
 			fs = fakesys.NewFakeFileSystem()
 			dirProvider := boshdir.NewProvider("/var/vcap")
-			handler = NewHTTPSHandler(mbusURL, settings.CertKeyPair{}, logger, fs, dirProvider, fakes.NewFakeAuditLogger())
+			logStreamer = &fakelogtail.FakeStreamer{}
+			handler = NewHTTPSHandler(mbusURL, settings.CertKeyPair{}, logger, fs, dirProvider, fakes.NewFakeAuditLogger(), logStreamer)
 
 			go handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 				receivedRequest = req
@@ -128,6 +134,7 @@ var _ = Describe("HTTPSHandler", func() {
 				Expect(receivedRequest.ReplyTo).To(Equal("reply to me!"))
 				Expect(receivedRequest.Method).To(Equal("ping"))
 				Expect(receivedRequest.GetPayload()).To(Equal([]byte(postBody)))
+				Expect(receivedRequest.Caller).To(MatchRegexp(`^user@.+:\d+$`))
 
 				httpBody, readErr := ioutil.ReadAll(httpResponse.Body)
 				Expect(readErr).ToNot(HaveOccurred())
@@ -275,6 +282,103 @@ var _ = Describe("HTTPSHandler", func() {
 			})
 		})
 
+		Describe("GET /logs", func() {
+			It("streams chunks of tailed logs as server-sent events", func() {
+				logStreamer.OpenStream = &fakelogtail.FakeStream{
+					RunChunks: []boshlogtail.Chunk{
+						{Path: "fake-job/fake-job.log", Lines: []string{"fake-line-1", "fake-line-2"}},
+						{Path: "fake-job/fake-job.stderr.log", Lines: []string{}, DroppedLines: 2},
+					},
+					RunSummary: boshlogtail.Summary{Lines: 2, DroppedLines: 2, EndReason: "idle"},
+				}
+
+				httpResponse, err := httpClient.Get(serverURL + "/logs?type=job&path=fake-job/fake-job.log&path=fake-job/fake-job.stderr.log&lines=10&idle_timeout=60")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(200))
+				Expect(httpResponse.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+				httpBody, err := ioutil.ReadAll(httpResponse.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(httpBody)).To(Equal(
+					"event: chunk\ndata: {\"path\":\"fake-job/fake-job.log\",\"lines\":[\"fake-line-1\",\"fake-line-2\"]}\n\n" +
+						"event: chunk\ndata: {\"path\":\"fake-job/fake-job.stderr.log\",\"lines\":[],\"dropped_lines\":2}\n\n" +
+						"event: end\ndata: {\"lines\":2,\"dropped_lines\":2,\"end_reason\":\"idle\"}\n\n",
+				))
+
+				Expect(logStreamer.OpenLogType).To(Equal("job"))
+				Expect(logStreamer.OpenPaths).To(Equal([]string{"fake-job/fake-job.log", "fake-job/fake-job.stderr.log"}))
+				Expect(logStreamer.OpenOptions).To(Equal(boshlogtail.Options{Lines: 10, IdleTimeout: 60 * time.Second}))
+			})
+
+			It("returns a 400 when logs cannot be tailed", func() {
+				logStreamer.OpenErr = errors.New("fake-open-err")
+
+				httpResponse, err := httpClient.Get(serverURL + "/logs?type=job&path=../fake")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(400))
+
+				httpBody, err := ioutil.ReadAll(httpResponse.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(httpBody)).To(Equal("fake-open-err"))
+			})
+
+			It("returns a 400 when options are not numbers", func() {
+				httpResponse, err := httpClient.Get(serverURL + "/logs?type=job&path=fake&lines=lots")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(400))
+				Expect(logStreamer.OpenLogType).To(BeEmpty())
+			})
+
+			It("returns a 429 when too many logs are being tailed", func() {
+				logStreamer.OpenErr = boshlogtail.ErrTooManyStreams
+
+				httpResponse, err := httpClient.Get(serverURL + "/logs?type=job&path=fake")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(429))
+			})
+
+			It("stops streaming when client disconnects", func() {
+				stream := &fakelogtail.FakeStream{
+					RunChunks:       []boshlogtail.Chunk{{Path: "fake", Lines: []string{"fake-line"}}},
+					RunUntilStopped: true,
+				}
+				logStreamer.OpenStream = stream
+
+				httpResponse, err := httpClient.Get(serverURL + "/logs?type=job&path=fake")
+				Expect(err).ToNot(HaveOccurred())
+
+				reader := bufio.NewReader(httpResponse.Body)
+				event, err := reader.ReadString('\n')
+				Expect(err).ToNot(HaveOccurred())
+				Expect(event).To(Equal("event: chunk\n"))
+
+				Expect(httpResponse.Body.Close()).To(Succeed())
+			})
+
+			Context("when incorrect http method is used", func() {
+				It("returns a 404", func() {
+					httpResponse, err := httpClient.Post(serverURL+"/logs", "application/json", strings.NewReader(""))
+					Expect(err).ToNot(HaveOccurred())
+
+					defer httpResponse.Body.Close()
+
+					Expect(httpResponse.StatusCode).To(Equal(404))
+				})
+			})
+		})
+
 		Describe("routing and auth", func() {
 			Context("when an incorrect uri is specificed", func() {
 				It("returns a 404", func() {
@@ -27,6 +27,10 @@ import (
 	"regexp"
 )
 
+// Only director and inbox subjects can be published to so that
+// API consumers cannot impersonate agents or health monitor
+var publishableSubjectRegexp = regexp.MustCompile(`^(director|_INBOX)\.[a-zA-Z0-9_\-.]+$`)
+
 const (
 	responseMaxLength        = 1024 * 1024
 	natsHandlerLogTag        = "NATS Handler"
@@ -38,6 +42,7 @@ type Handler interface {
 	Start(boshhandler.Func) error
 	RegisterAdditionalFunc(boshhandler.Func)
 	Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error
+	Publish(subject string, message interface{}) error
 	Stop()
 }
 
@@ -166,6 +171,25 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	return h.client.Publish(subject, bytes)
 }
 
+func (h *natsHandler) Publish(subject string, message interface{}) error {
+	if !publishableSubjectRegexp.MatchString(subject) {
+		return bosherr.Errorf("Publishing to subject '%s' is not allowed", subject)
+	}
+
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling message (subject=%s): %#v", subject, message)
+	}
+
+	if len(bytes) > responseMaxLength {
+		return bosherr.Errorf("Message for subject '%s' is longer than %d bytes", subject, responseMaxLength)
+	}
+
+	h.logger.Debug(h.logTag, "Publishing message to '%s'", subject)
+
+	return h.client.Publish(subject, bytes)
+}
+
 func (h *natsHandler) Stop() {
 	h.client.Disconnect()
 }
@@ -187,7 +211,12 @@ func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains []
 func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.Func) {
 	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
 		natsMsg.Payload,
-		handlerFunc,
+		func(req boshhandler.Request) boshhandler.Response {
+			// Director replies on subjects that include its id,
+			// same as user in CEF logs
+			req.Caller = req.ReplyTo
+			return handlerFunc(req)
+		},
 		responseMaxLength,
 		h.logger,
 	)
@@ -3,6 +3,7 @@ package mbus_test
 import (
 	"bytes"
 	"errors"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -77,6 +78,7 @@ func init() {
 					ReplyTo: "reply to me!",
 					Method:  "ping",
 					Payload: expectedPayload,
+					Caller:  "reply to me!",
 				}))
 
 				Expect(client.PublishedMessageCount()).To(Equal(1))
@@ -203,12 +205,14 @@ func init() {
 					ReplyTo: "fake-reply-to",
 					Method:  "ping",
 					Payload: expectedPayload,
+					Caller:  "fake-reply-to",
 				}))
 
 				Expect(secondHandlerRequest).To(Equal(boshhandler.Request{
 					ReplyTo: "fake-reply-to",
 					Method:  "ping",
 					Payload: expectedPayload,
+					Caller:  "fake-reply-to",
 				}))
 
 				// Bosh handler responses were sent
@@ -481,6 +485,7 @@ func init() {
 						ReplyTo: "reply to me!",
 						Method:  "ping",
 						Payload: expectedPayload,
+						Caller:  "reply to me!",
 					}))
 
 					Expect(client.PublishedMessageCount()).To(Equal(1))
@@ -540,6 +545,35 @@ func init() {
 				))
 			})
 		})
+
+		Describe("Publish", func() {
+			It("publishes the message over nats to given director or inbox subject", func() {
+				err := handler.Publish("director.fake-reply-id", map[string]string{"key1": "value1"})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = handler.Publish("_INBOX.fake-reply-id", map[string]string{"key2": "value2"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.PublishedMessages("director.fake-reply-id")[0].Payload).To(Equal([]byte(`{"key1":"value1"}`)))
+				Expect(client.PublishedMessages("_INBOX.fake-reply-id")[0].Payload).To(Equal([]byte(`{"key2":"value2"}`)))
+			})
+
+			It("does not publish to subjects of agents and health monitor", func() {
+				for _, subject := range []string{"agent.other-agent-id", "hm.agent.alert.my-agent-id", "director", "director.*", "director.>", ""} {
+					err := handler.Publish(subject, map[string]string{})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("is not allowed"))
+				}
+
+				Expect(client.PublishedMessageCount()).To(Equal(0))
+			})
+
+			It("returns error when message is too long", func() {
+				err := handler.Publish("director.fake-reply-id", strings.Repeat("x", 1024*1024))
+				Expect(err).To(HaveOccurred())
+				Expect(client.PublishedMessageCount()).To(Equal(0))
+			})
+		})
 	})
 }
 
@@ -9,6 +9,9 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -49,6 +52,9 @@ type dummyPlatform struct {
 	devicePathResolver boshdpresolv.DevicePathResolver
 	logger             boshlog.Logger
 	certManager        boshcert.Manager
+	firewallManager    boshfirewall.Manager
+	ntpManager         boshntp.Manager
+	addressWatcher     boshnet.AddressWatcher
 	auditLogger        AuditLogger
 }
 
@@ -61,6 +67,8 @@ func NewDummyPlatform(
 	logger boshlog.Logger,
 	auditLogger AuditLogger,
 ) Platform {
+	ntpManager := boshntp.NewDummyManager()
+
 	return &dummyPlatform{
 		fs:                 fs,
 		cmdRunner:          cmdRunner,
@@ -69,8 +77,11 @@ func NewDummyPlatform(
 		copier:             boshcmd.NewGenericCpCopier(fs, logger),
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
-		vitalsService:      boshvitals.NewService(collector, dirProvider),
+		vitalsService:      boshvitals.NewService(collector, dirProvider, ntpManager),
 		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
+		firewallManager:    boshfirewall.NewDummyManager(),
+		ntpManager:         ntpManager,
+		addressWatcher:     boshnet.NewDummyAddressWatcher(),
 		logger:             logger,
 		auditLogger:        auditLogger,
 	}
@@ -168,6 +179,18 @@ func (p dummyPlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
+func (p dummyPlatform) GetFirewallManager() boshfirewall.Manager {
+	return p.firewallManager
+}
+
+func (p dummyPlatform) GetNtpManager() boshntp.Manager {
+	return p.ntpManager
+}
+
+func (p dummyPlatform) GetAddressWatcher() boshnet.AddressWatcher {
+	return p.addressWatcher
+}
+
 func (p dummyPlatform) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
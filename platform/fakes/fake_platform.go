@@ -8,6 +8,12 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
+	fakefirewall "github.com/cloudfoundry/bosh-agent/platform/firewall/fakes"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	fakevitals "github.com/cloudfoundry/bosh-agent/platform/vitals/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -157,6 +163,12 @@ type FakePlatform struct {
 
 	certManager boshcert.Manager
 
+	FirewallManager *fakefirewall.FakeManager
+
+	NtpManager *fakentp.FakeManager
+
+	AddressWatcher *fakenet.FakeAddressWatcher
+
 	GetHostPublicKeyValue string
 	GetHostPublicKeyError error
 
@@ -184,6 +196,9 @@ func NewFakePlatform() (platform *FakePlatform) {
 	platform.GetFileContentsFromDiskContents = map[string][]byte{}
 	platform.GetFileContentsFromDiskErrs = map[string]error{}
 	platform.certManager = new(fakecert.FakeManager)
+	platform.FirewallManager = &fakefirewall.FakeManager{}
+	platform.NtpManager = &fakentp.FakeManager{}
+	platform.AddressWatcher = fakenet.NewFakeAddressWatcher()
 	platform.SetupRawEphemeralDisksCallCount = 0
 	platform.SetupRawEphemeralDisksDevices = nil
 	platform.SetupRawEphemeralDisksErr = nil
@@ -303,6 +318,18 @@ func (p *FakePlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
+func (p *FakePlatform) GetFirewallManager() boshfirewall.Manager {
+	return p.FirewallManager
+}
+
+func (p *FakePlatform) GetNtpManager() boshntp.Manager {
+	return p.NtpManager
+}
+
+func (p *FakePlatform) GetAddressWatcher() boshnet.AddressWatcher {
+	return p.AddressWatcher
+}
+
 func (p *FakePlatform) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
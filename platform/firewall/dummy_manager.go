@@ -0,0 +1,21 @@
+package firewall
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type dummyManager struct{}
+
+// NewDummyManager returns a Manager for platforms that do not support
+// host level filtering; rules are accepted but never applied.
+func NewDummyManager() Manager {
+	return dummyManager{}
+}
+
+func (m dummyManager) SetupFirewall(_ boshsettings.Firewall, _ []boshsettings.FirewallRule) error {
+	return nil
+}
+
+func (m dummyManager) ActiveRuleset() (string, error) {
+	return "", nil
+}
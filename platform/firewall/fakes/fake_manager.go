@@ -0,0 +1,29 @@
+package fakes
+
+import (
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeManager struct {
+	SetupFirewallCalled   bool
+	SetupFirewallBaseline boshsettings.Firewall
+	SetupFirewallJobRules []boshsettings.FirewallRule
+	SetupFirewallErr      error
+
+	ActiveRulesetRuleset string
+	ActiveRulesetErr     error
+}
+
+func (m *FakeManager) SetupFirewall(baseline boshsettings.Firewall, jobRules []boshsettings.FirewallRule) error {
+	m.SetupFirewallCalled = true
+	m.SetupFirewallBaseline = baseline
+	m.SetupFirewallJobRules = jobRules
+	return m.SetupFirewallErr
+}
+
+func (m *FakeManager) ActiveRuleset() (string, error) {
+	return m.ActiveRulesetRuleset, m.ActiveRulesetErr
+}
+
+var _ boshfirewall.Manager = new(FakeManager)
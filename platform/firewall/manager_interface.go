@@ -0,0 +1,19 @@
+package firewall
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+// Manager configures host level inbound filtering on any OS platform.
+type Manager interface {
+	// SetupFirewall replaces the agent managed ruleset with one built from
+	// the baseline firewall settings and the rules declared by jobs.
+	//
+	// Calling this method again replaces the previous ruleset as a whole,
+	// so rules that are no longer declared are removed.
+	SetupFirewall(baseline boshsettings.Firewall, jobRules []boshsettings.FirewallRule) error
+
+	// ActiveRuleset returns the currently active agent managed ruleset,
+	// or an empty string if the agent does not manage any rules.
+	ActiveRuleset() (string, error)
+}
@@ -0,0 +1,233 @@
+package firewall
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	nftablesTableFamily = "inet"
+	nftablesTableName   = "bosh_agent"
+)
+
+type nftablesManager struct {
+	fs          boshsys.FileSystem
+	runner      boshsys.CmdRunner
+	rulesetPath string
+	logger      boshlog.Logger
+	logTag      string
+}
+
+// NewNftablesManager returns a Manager that keeps all agent managed rules in
+// a dedicated nftables table so that rules installed by the stemcell or
+// by operators are left untouched.
+func NewNftablesManager(fs boshsys.FileSystem, runner boshsys.CmdRunner, rulesetPath string, logger boshlog.Logger) Manager {
+	return nftablesManager{
+		fs:          fs,
+		runner:      runner,
+		rulesetPath: rulesetPath,
+		logger:      logger,
+		logTag:      "nftablesManager",
+	}
+}
+
+func (m nftablesManager) SetupFirewall(baseline boshsettings.Firewall, jobRules []boshsettings.FirewallRule) error {
+	if baseline.IsEmpty() && len(jobRules) == 0 {
+		return m.removeRuleset()
+	}
+
+	ruleset, err := m.renderRuleset(baseline, jobRules)
+	if err != nil {
+		return bosherr.WrapError(err, "Rendering nftables ruleset")
+	}
+
+	err = m.fs.WriteFileString(m.rulesetPath, ruleset)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing nftables ruleset to %s", m.rulesetPath)
+	}
+
+	m.logger.Info(m.logTag, "Applying nftables ruleset from %s", m.rulesetPath)
+
+	// nft applies the whole file in a single transaction,
+	// so the table is never observed half-configured
+	_, stderr, _, err := m.runner.RunCommand("nft", "-f", m.rulesetPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Applying nftables ruleset: %s", stderr)
+	}
+
+	return nil
+}
+
+func (m nftablesManager) ActiveRuleset() (string, error) {
+	if !m.fs.FileExists(m.rulesetPath) {
+		return "", nil
+	}
+
+	stdout, stderr, _, err := m.runner.RunCommand("nft", "list", "table", nftablesTableFamily, nftablesTableName)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Listing nftables table: %s", stderr)
+	}
+
+	return stdout, nil
+}
+
+func (m nftablesManager) removeRuleset() error {
+	// Nothing to clean up if rules were never applied. This also keeps
+	// stemcells without nft working when no firewall is configured.
+	if !m.fs.FileExists(m.rulesetPath) {
+		return nil
+	}
+
+	m.logger.Info(m.logTag, "Removing nftables table %s", nftablesTableName)
+
+	err := m.fs.WriteFileString(m.rulesetPath, nftablesTableReset)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing nftables ruleset to %s", m.rulesetPath)
+	}
+
+	_, stderr, _, err := m.runner.RunCommand("nft", "-f", m.rulesetPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing nftables table: %s", stderr)
+	}
+
+	return m.fs.RemoveAll(m.rulesetPath)
+}
+
+func (m nftablesManager) renderRuleset(baseline boshsettings.Firewall, jobRules []boshsettings.FirewallRule) (string, error) {
+	policy := baseline.DefaultPolicy
+	if policy == "" {
+		policy = boshsettings.FirewallPolicyAccept
+	}
+
+	if policy != boshsettings.FirewallPolicyAccept && policy != boshsettings.FirewallPolicyDrop {
+		return "", bosherr.Errorf("Unknown default policy '%s'", policy)
+	}
+
+	var statements []string
+
+	for _, rule := range append(append([]boshsettings.FirewallRule{}, baseline.Rules...), jobRules...) {
+		ruleStatements, err := m.renderRule(rule)
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, ruleStatements...)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	t := template.Must(template.New("nftables-ruleset").Parse(nftablesRulesetTemplate))
+
+	type rulesetArgs struct {
+		Family     string
+		Table      string
+		Policy     string
+		Statements []string
+	}
+
+	err := t.Execute(buffer, rulesetArgs{nftablesTableFamily, nftablesTableName, policy, statements})
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+func (m nftablesManager) renderRule(rule boshsettings.FirewallRule) ([]string, error) {
+	protocol := rule.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	if protocol != "tcp" && protocol != "udp" {
+		return nil, bosherr.Errorf("Unsupported firewall rule protocol '%s'", protocol)
+	}
+
+	if len(rule.Ports) == 0 {
+		return nil, bosherr.Error("Firewall rule must specify at least one port")
+	}
+
+	ports := []string{}
+	for _, port := range rule.Ports {
+		if port < 1 || port > 65535 {
+			return nil, bosherr.Errorf("Invalid firewall rule port %d", port)
+		}
+		ports = append(ports, strconv.Itoa(port))
+	}
+
+	match := fmt.Sprintf("%s dport { %s }", protocol, strings.Join(ports, ", "))
+
+	if len(rule.Sources) == 0 {
+		return []string{match + " accept"}, nil
+	}
+
+	var ipv4Sources, ipv6Sources []string
+
+	for _, source := range rule.Sources {
+		ip := net.ParseIP(source)
+		if ip == nil {
+			var err error
+			ip, _, err = net.ParseCIDR(source)
+			if err != nil {
+				return nil, bosherr.Errorf("Invalid firewall rule source '%s'", source)
+			}
+		}
+
+		if ip.To4() != nil {
+			ipv4Sources = append(ipv4Sources, source)
+		} else {
+			ipv6Sources = append(ipv6Sources, source)
+		}
+	}
+
+	sort.Strings(ipv4Sources)
+	sort.Strings(ipv6Sources)
+
+	var statements []string
+
+	if len(ipv4Sources) > 0 {
+		statements = append(statements, fmt.Sprintf("%s ip saddr { %s } accept", match, strings.Join(ipv4Sources, ", ")))
+	}
+
+	if len(ipv6Sources) > 0 {
+		statements = append(statements, fmt.Sprintf("%s ip6 saddr { %s } accept", match, strings.Join(ipv6Sources, ", ")))
+	}
+
+	// Restricted ports are closed for everybody else
+	// regardless of the default policy
+	statements = append(statements, match+" drop")
+
+	return statements, nil
+}
+
+// Declaring the table before deleting it makes the delete
+// succeed even if the table does not exist yet
+const nftablesTableReset = `# Generated by bosh-agent
+table inet bosh_agent
+delete table inet bosh_agent
+`
+
+const nftablesRulesetTemplate = `# Generated by bosh-agent
+table {{ .Family }} {{ .Table }}
+delete table {{ .Family }} {{ .Table }}
+
+table {{ .Family }} {{ .Table }} {
+	chain input {
+		type filter hook input priority 0; policy {{ .Policy }};
+
+		ct state established,related accept
+		iifname "lo" accept
+		meta l4proto { icmp, ipv6-icmp } accept
+{{ range .Statements }}
+		{{ . }}{{ end }}
+	}
+}
+`
@@ -0,0 +1,179 @@
+package firewall_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/firewall"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("nftablesManager", func() {
+	const rulesetPath = "/var/vcap/bosh/firewall.nft"
+
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		manager   Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		manager = NewNftablesManager(fs, cmdRunner, rulesetPath, logger)
+	})
+
+	Describe("SetupFirewall", func() {
+		It("renders baseline and job rules into a dedicated table and applies it", func() {
+			baseline := boshsettings.Firewall{
+				DefaultPolicy: "drop",
+				Rules: []boshsettings.FirewallRule{
+					{Ports: []int{22}, Sources: []string{"10.0.0.0/8", "fd00::/8", "192.168.1.5"}},
+				},
+			}
+			jobRules := []boshsettings.FirewallRule{
+				{Protocol: "tcp", Ports: []int{80, 443}},
+				{Protocol: "udp", Ports: []int{53}},
+			}
+
+			err := manager.SetupFirewall(baseline, jobRules)
+			Expect(err).ToNot(HaveOccurred())
+
+			ruleset, err := fs.ReadFileString(rulesetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ruleset).To(Equal(`# Generated by bosh-agent
+table inet bosh_agent
+delete table inet bosh_agent
+
+table inet bosh_agent {
+	chain input {
+		type filter hook input priority 0; policy drop;
+
+		ct state established,related accept
+		iifname "lo" accept
+		meta l4proto { icmp, ipv6-icmp } accept
+
+		tcp dport { 22 } ip saddr { 10.0.0.0/8, 192.168.1.5 } accept
+		tcp dport { 22 } ip6 saddr { fd00::/8 } accept
+		tcp dport { 22 } drop
+		tcp dport { 80, 443 } accept
+		udp dport { 53 } accept
+	}
+}
+`))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"nft", "-f", rulesetPath}}))
+		})
+
+		It("defaults to accept policy", func() {
+			err := manager.SetupFirewall(boshsettings.Firewall{}, []boshsettings.FirewallRule{{Ports: []int{8080}}})
+			Expect(err).ToNot(HaveOccurred())
+
+			ruleset, err := fs.ReadFileString(rulesetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ruleset).To(ContainSubstring("policy accept;"))
+			Expect(ruleset).To(ContainSubstring("tcp dport { 8080 } accept"))
+		})
+
+		It("returns error if nft fails", func() {
+			cmdRunner.AddCmdResult("nft -f "+rulesetPath, fakesys.FakeCmdResult{
+				Stderr: "fake-stderr",
+				Error:  errors.New("fake-nft-err"),
+			})
+
+			err := manager.SetupFirewall(boshsettings.Firewall{DefaultPolicy: "drop"}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-nft-err"))
+			Expect(err.Error()).To(ContainSubstring("fake-stderr"))
+		})
+
+		It("returns error if writing ruleset fails", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := manager.SetupFirewall(boshsettings.Firewall{DefaultPolicy: "drop"}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		Context("when rules are invalid", func() {
+			itRejects := func(description string, baseline boshsettings.Firewall, errMsg string) {
+				It("rejects "+description, func() {
+					err := manager.SetupFirewall(baseline, nil)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring(errMsg))
+					Expect(fs.FileExists(rulesetPath)).To(BeFalse())
+					Expect(cmdRunner.RunCommands).To(BeEmpty())
+				})
+			}
+
+			itRejects("unknown policy", boshsettings.Firewall{DefaultPolicy: "reject"}, "Unknown default policy 'reject'")
+
+			itRejects("unknown protocol", boshsettings.Firewall{
+				Rules: []boshsettings.FirewallRule{{Protocol: "sctp", Ports: []int{80}}},
+			}, "Unsupported firewall rule protocol 'sctp'")
+
+			itRejects("rules without ports", boshsettings.Firewall{
+				Rules: []boshsettings.FirewallRule{{Protocol: "tcp"}},
+			}, "at least one port")
+
+			itRejects("out of range ports", boshsettings.Firewall{
+				Rules: []boshsettings.FirewallRule{{Ports: []int{70000}}},
+			}, "Invalid firewall rule port 70000")
+
+			itRejects("malformed sources", boshsettings.Firewall{
+				Rules: []boshsettings.FirewallRule{{Ports: []int{22}, Sources: []string{"not-an-ip"}}},
+			}, "Invalid firewall rule source 'not-an-ip'")
+		})
+
+		Context("when there are no rules", func() {
+			It("does nothing if ruleset was never applied", func() {
+				err := manager.SetupFirewall(boshsettings.Firewall{}, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+
+			It("removes previously applied table", func() {
+				fs.WriteFileString(rulesetPath, "fake-ruleset")
+
+				err := manager.SetupFirewall(boshsettings.Firewall{DefaultPolicy: "accept"}, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"nft", "-f", rulesetPath}}))
+				Expect(fs.FileExists(rulesetPath)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("ActiveRuleset", func() {
+		It("returns empty ruleset if firewall was never set up", func() {
+			ruleset, err := manager.ActiveRuleset()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ruleset).To(BeEmpty())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("lists agent table", func() {
+			fs.WriteFileString(rulesetPath, "fake-ruleset")
+			cmdRunner.AddCmdResult("nft list table inet bosh_agent", fakesys.FakeCmdResult{Stdout: "fake-active-ruleset"})
+
+			ruleset, err := manager.ActiveRuleset()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ruleset).To(Equal("fake-active-ruleset"))
+		})
+
+		It("returns error if listing fails", func() {
+			fs.WriteFileString(rulesetPath, "fake-ruleset")
+			cmdRunner.AddCmdResult("nft list table inet bosh_agent", fakesys.FakeCmdResult{Error: errors.New("fake-list-err")})
+
+			_, err := manager.ActiveRuleset()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-list-err"))
+		})
+	})
+})
@@ -15,7 +15,9 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -94,6 +96,9 @@ type linux struct {
 	diskManager            boshdisk.Manager
 	netManager             boshnet.Manager
 	certManager            boshcert.Manager
+	firewallManager        boshfirewall.Manager
+	ntpManager             boshntp.Manager
+	addressWatcher         boshnet.AddressWatcher
 	monitRetryStrategy     boshretry.RetryStrategy
 	devicePathResolver     boshdpresolv.DevicePathResolver
 	options                LinuxOptions
@@ -116,6 +121,9 @@ func NewLinuxPlatform(
 	diskManager boshdisk.Manager,
 	netManager boshnet.Manager,
 	certManager boshcert.Manager,
+	firewallManager boshfirewall.Manager,
+	ntpManager boshntp.Manager,
+	addressWatcher boshnet.AddressWatcher,
 	monitRetryStrategy boshretry.RetryStrategy,
 	devicePathResolver boshdpresolv.DevicePathResolver,
 	state *BootstrapState,
@@ -137,6 +145,9 @@ func NewLinuxPlatform(
 		diskManager:            diskManager,
 		netManager:             netManager,
 		certManager:            certManager,
+		firewallManager:        firewallManager,
+		ntpManager:             ntpManager,
+		addressWatcher:         addressWatcher,
 		monitRetryStrategy:     monitRetryStrategy,
 		devicePathResolver:     devicePathResolver,
 		state:                  state,
@@ -224,6 +235,18 @@ func (p linux) GetCertManager() boshcert.Manager {
 	return p.certManager
 }
 
+func (p linux) GetFirewallManager() boshfirewall.Manager {
+	return p.firewallManager
+}
+
+func (p linux) GetNtpManager() boshntp.Manager {
+	return p.ntpManager
+}
+
+func (p linux) GetAddressWatcher() boshnet.AddressWatcher {
+	return p.addressWatcher
+}
+
 func (p linux) GetHostPublicKey() (string, error) {
 	hostPublicKeyPath := "/etc/ssh/ssh_host_rsa_key.pub"
 	hostPublicKey, err := p.fs.ReadFileString(hostPublicKeyPath)
@@ -500,44 +523,34 @@ func (p linux) SetupHostname(hostname string) error {
 	return nil
 }
 
+// SetupLogrotate removes logrotate config written by older agents
+// since job logs are rotated by the agent as they are written
 func (p linux) SetupLogrotate(groupName, basePath, size string) (err error) {
-	buffer := bytes.NewBuffer([]byte{})
-	t := template.Must(template.New("logrotate-d-config").Parse(etcLogrotateDTemplate))
-
-	type logrotateArgs struct {
-		BasePath string
-		Size     string
-	}
-
-	err = t.Execute(buffer, logrotateArgs{basePath, size})
-	if err != nil {
-		err = bosherr.WrapError(err, "Generating logrotate config")
-		return
-	}
-
-	err = p.fs.WriteFile(path.Join("/etc/logrotate.d", groupName), buffer.Bytes())
+	err = p.fs.RemoveAll(path.Join("/etc/logrotate.d", groupName))
 	if err != nil {
-		err = bosherr.WrapError(err, "Writing to /etc/logrotate.d")
+		err = bosherr.WrapError(err, "Removing /etc/logrotate.d config")
 		return
 	}
 
 	return
 }
 
-// Logrotate config file - /etc/logrotate.d/<group-name>
-// Stemcell stage logrotate_config configures logrotate to run every hour
-const etcLogrotateDTemplate = `# Generated by bosh-agent
+func (p linux) SetTimeWithNtpServers(servers []string) (err error) {
+	err = p.ntpManager.SetupNtpServers(servers)
+	if err == boshntp.ErrNotSupported {
+		return p.syncTimeWithNtpdate(servers)
+	}
 
-{{ .BasePath }}/data/sys/log/*.log {{ .BasePath }}/data/sys/log/.*.log {{ .BasePath }}/data/sys/log/*/*.log {{ .BasePath }}/data/sys/log/*/.*.log {{ .BasePath }}/data/sys/log/*/*/*.log {{ .BasePath }}/data/sys/log/*/*/.*.log {
-  missingok
-  rotate 7
-  compress
-  copytruncate
-  size={{ .Size }}
+	// Time synchronization is best effort so that VM can still come up
+	if err != nil {
+		p.logger.Warn(logTag, "Failed to set up NTP servers: %s", err.Error())
+	}
+
+	return nil
 }
-`
 
-func (p linux) SetTimeWithNtpServers(servers []string) (err error) {
+// syncTimeWithNtpdate is used on stemcells that do not have chrony
+func (p linux) syncTimeWithNtpdate(servers []string) (err error) {
 	serversFilePath := path.Join(p.dirProvider.BaseDir(), "/bosh/etc/ntpserver")
 	if len(servers) == 0 {
 		return
@@ -19,7 +19,10 @@ import (
 	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
 	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	fakefirewall "github.com/cloudfoundry/bosh-agent/platform/firewall/fakes"
 	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	fakeretry "github.com/cloudfoundry/bosh-utils/retrystrategy/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -50,6 +53,9 @@ func describeLinuxPlatform() {
 		vitalsService              boshvitals.Service
 		netManager                 *fakenet.FakeManager
 		certManager                *fakecert.FakeManager
+		firewallManager            *fakefirewall.FakeManager
+		ntpManager                 *fakentp.FakeManager
+		addressWatcher             *fakenet.FakeAddressWatcher
 		monitRetryStrategy         *fakeretry.FakeRetryStrategy
 		fakeDefaultNetworkResolver *fakenet.FakeDefaultNetworkResolver
 		fakeAuditLogger            *fakeplat.FakeAuditLogger
@@ -74,9 +80,12 @@ func describeLinuxPlatform() {
 		cdutil = fakecdrom.NewFakeCDUtil()
 		compressor = boshcmd.NewTarballCompressor(cmdRunner, fs)
 		copier = boshcmd.NewGenericCpCopier(fs, logger)
-		vitalsService = boshvitals.NewService(collector, dirProvider)
+		vitalsService = boshvitals.NewService(collector, dirProvider, ntpManager)
 		netManager = &fakenet.FakeManager{}
 		certManager = new(fakecert.FakeManager)
+		firewallManager = &fakefirewall.FakeManager{}
+		ntpManager = &fakentp.FakeManager{}
+		addressWatcher = fakenet.NewFakeAddressWatcher()
 		monitRetryStrategy = fakeretry.NewFakeRetryStrategy()
 		devicePathResolver = fakedpresolv.NewFakeDevicePathResolver()
 		fakeDefaultNetworkResolver = &fakenet.FakeDefaultNetworkResolver{}
@@ -113,6 +122,9 @@ func describeLinuxPlatform() {
 			diskManager,
 			netManager,
 			certManager,
+			firewallManager,
+			ntpManager,
+			addressWatcher,
 			monitRetryStrategy,
 			devicePathResolver,
 			state,
@@ -321,6 +333,9 @@ bosh_foobar:...`
 					diskManager,
 					netManager,
 					certManager,
+					firewallManager,
+					ntpManager,
+					addressWatcher,
 					monitRetryStrategy,
 					devicePathResolver,
 					state,
@@ -521,44 +536,58 @@ ff02::3 ip6-allhosts
 	})
 
 	Describe("SetupLogrotate", func() {
-		const expectedEtcLogrotate = `# Generated by bosh-agent
-
-fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-path/data/sys/log/*/*.log fake-base-path/data/sys/log/*/.*.log fake-base-path/data/sys/log/*/*/*.log fake-base-path/data/sys/log/*/*/.*.log {
-  missingok
-  rotate 7
-  compress
-  copytruncate
-  size=fake-size
-}
-`
-
-		It("sets up logrotate", func() {
-			platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size")
+		It("removes logrotate config since logs are rotated by the agent", func() {
+			fs.WriteFileString("/etc/logrotate.d/fake-group-name", "fake-config")
 
-			logrotateFileContent, err := fs.ReadFileString("/etc/logrotate.d/fake-group-name")
+			err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(logrotateFileContent).To(Equal(expectedEtcLogrotate))
+			Expect(fs.FileExists("/etc/logrotate.d/fake-group-name")).To(BeFalse())
+		})
+
+		It("returns error if logrotate config cannot be removed", func() {
+			fs.RemoveAllStub = func(_ string) error { return errors.New("fake-remove-err") }
+
+			err := platform.SetupLogrotate("fake-group-name", "fake-base-path", "fake-size")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-remove-err"))
 		})
 	})
 
 	Describe("SetTimeWithNtpServers", func() {
-		It("sets time with ntp servers", func() {
-			platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
+		It("sets up ntp servers via ntp manager", func() {
+			err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ntpManager.SetupNtpServersServers).To(Equal([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"}))
+		})
 
-			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
-			Expect(ntpConfig.StringContents()).To(Equal("0.north-america.pool.ntp.org 1.north-america.pool.ntp.org"))
-			Expect(ntpConfig.FileType).To(Equal(fakesys.FakeFileTypeFile))
+		It("does not return error if ntp manager fails", func() {
+			ntpManager.SetupNtpServersErr = errors.New("fake-ntp-err")
 
-			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sync-time"}))
+			err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
 		})
 
-		It("sets time with ntp servers is noop when no ntp server provided", func() {
-			platform.SetTimeWithNtpServers([]string{})
-			Expect(len(cmdRunner.RunCommands)).To(Equal(0))
+		Context("when ntp manager is not supported", func() {
+			BeforeEach(func() {
+				ntpManager.SetupNtpServersErr = boshntp.ErrNotSupported
+			})
+
+			It("writes ntp servers and runs sync-time", func() {
+				err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
+				Expect(err).ToNot(HaveOccurred())
+
+				ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
+				Expect(ntpConfig.StringContents()).To(Equal("0.north-america.pool.ntp.org 1.north-america.pool.ntp.org"))
+				Expect(cmdRunner.RunCommands).To(ContainElement([]string{"sync-time"}))
+			})
+
+			It("returns error if writing ntp servers fails", func() {
+				fs.WriteFileError = errors.New("fake-write-file-error")
 
-			ntpConfig := fs.GetFileTestStat("/fake-dir/bosh/etc/ntpserver")
-			Expect(ntpConfig).To(BeNil())
+				err := platform.SetTimeWithNtpServers([]string{"0.north-america.pool.ntp.org"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-file-error"))
+			})
 		})
 	})
 
@@ -2764,6 +2793,9 @@ from-device-path  dm-0 NETAPP  ,LUN C-Mode
 					diskManager,
 					netManager,
 					certManager,
+					firewallManager,
+					ntpManager,
+					addressWatcher,
 					monitRetryStrategy,
 					devicePathResolver,
 					state,
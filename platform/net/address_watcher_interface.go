@@ -0,0 +1,13 @@
+package net
+
+import (
+	"errors"
+)
+
+var ErrAddressWatchingNotSupported = errors.New("Watching network addresses is not supported")
+
+type AddressWatcher interface {
+	// Watch sends name of the interface every time an address is added to
+	// or removed from it (e.g. when DHCP lease changes) until stopCh is closed
+	Watch(stopCh <-chan struct{}) (<-chan string, error)
+}
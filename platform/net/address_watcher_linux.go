@@ -0,0 +1,123 @@
+// +build linux
+
+package net
+
+import (
+	gonet "net"
+	"syscall"
+	"time"
+	"unsafe"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	netlinkAddressWatcherLogTag = "netlinkAddressWatcher"
+
+	// Receiving times out periodically so that stopCh is noticed
+	netlinkReceiveTimeout = 1 * time.Second
+
+	// Multicast groups from linux/rtnetlink.h (not defined by syscall package)
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+type netlinkAddressWatcher struct {
+	logger boshlog.Logger
+}
+
+// NewAddressWatcher returns watcher that subscribes to kernel
+// rtnetlink IPv4 and IPv6 address notifications
+func NewAddressWatcher(logger boshlog.Logger) AddressWatcher {
+	return netlinkAddressWatcher{logger: logger}
+}
+
+func (w netlinkAddressWatcher) Watch(stopCh <-chan struct{}) (<-chan string, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening netlink socket")
+	}
+
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+
+	err = syscall.Bind(fd, addr)
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, bosherr.WrapError(err, "Binding netlink socket")
+	}
+
+	timeout := syscall.NsecToTimeval(netlinkReceiveTimeout.Nanoseconds())
+
+	err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout)
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, bosherr.WrapError(err, "Setting netlink socket receive timeout")
+	}
+
+	changeCh := make(chan string)
+
+	go w.receive(fd, changeCh, stopCh)
+
+	return changeCh, nil
+}
+
+func (w netlinkAddressWatcher) receive(fd int, changeCh chan<- string, stopCh <-chan struct{}) {
+	defer close(changeCh)
+	defer syscall.Close(fd)
+
+	buf := make([]byte, syscall.Getpagesize())
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			continue
+		}
+
+		if err != nil {
+			w.logger.Error(netlinkAddressWatcherLogTag, "Receiving netlink message: %s", err.Error())
+			return
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			w.logger.Warn(netlinkAddressWatcherLogTag, "Ignoring malformed netlink message: %s", err.Error())
+			continue
+		}
+
+		for _, msg := range msgs {
+			if msg.Header.Type != syscall.RTM_NEWADDR && msg.Header.Type != syscall.RTM_DELADDR {
+				continue
+			}
+
+			if len(msg.Data) < syscall.SizeofIfAddrmsg {
+				continue
+			}
+
+			ifAddrMsg := (*syscall.IfAddrmsg)(unsafe.Pointer(&msg.Data[0]))
+
+			iface, err := gonet.InterfaceByIndex(int(ifAddrMsg.Index))
+			if err != nil {
+				w.logger.Warn(netlinkAddressWatcherLogTag, "Ignoring address change of unknown interface %d: %s", ifAddrMsg.Index, err.Error())
+				continue
+			}
+
+			w.logger.Debug(netlinkAddressWatcherLogTag, "Address of %s changed", iface.Name)
+
+			select {
+			case changeCh <- iface.Name:
+			case <-stopCh:
+				return
+			}
+		}
+	}
+}
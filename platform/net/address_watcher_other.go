@@ -0,0 +1,11 @@
+// +build !linux
+
+package net
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+func NewAddressWatcher(_ boshlog.Logger) AddressWatcher {
+	return NewDummyAddressWatcher()
+}
@@ -0,0 +1,154 @@
+package arp
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	arpingDADLogTag = "arpingDuplicateAddressDetector"
+
+	// arping exits with 1 in DAD mode when some host replied
+	arpingDADDuplicateExitStatus = 1
+)
+
+var (
+	arpingReplyRegexp = regexp.MustCompile(`reply from \S+ \[([0-9A-Fa-f:]+)\]`)
+	neighLladdrRegexp = regexp.MustCompile(`lladdr ([0-9A-Fa-f:]+)`)
+)
+
+// Reported when kernel detected a duplicate IPv6 address
+// but neighbor table does not tell who owns it
+const unknownConflictingMAC = "unknown"
+
+type arpingDuplicateAddressDetector struct {
+	cmdRunner boshsys.CmdRunner
+	logger    boshlog.Logger
+
+	probes        int
+	dadCheckDelay time.Duration
+}
+
+// NewArpingDuplicateAddressDetector returns detector that sends RFC 5227
+// ARP probes (sender IP set to 0.0.0.0) via `arping -D` so that probing
+// does not update ARP caches of other hosts. IPv6 addresses are checked
+// via state of kernel DAD (RFC 4862) reported by `ip`.
+func NewArpingDuplicateAddressDetector(cmdRunner boshsys.CmdRunner, logger boshlog.Logger, probes int) DuplicateAddressDetector {
+	return arpingDuplicateAddressDetector{
+		cmdRunner:     cmdRunner,
+		logger:        logger,
+		probes:        probes,
+		dadCheckDelay: 1 * time.Second,
+	}
+}
+
+func (d arpingDuplicateAddressDetector) Detect(interfaceName string, ip string) (string, error) {
+	if parsedIP := net.ParseIP(ip); parsedIP != nil && parsedIP.To4() == nil {
+		return d.detectIPv6(interfaceName, ip)
+	}
+
+	return d.detectIPv4(interfaceName, ip)
+}
+
+func (d arpingDuplicateAddressDetector) detectIPv4(interfaceName string, ip string) (string, error) {
+	d.logger.Debug(arpingDADLogTag, "Probing for duplicate address %s on %s", ip, interfaceName)
+
+	// Probes are sent before interfaces are brought up by networking scripts;
+	// arping fails to send anything on a link that is down
+	_, stderr, _, err := d.cmdRunner.RunCommand("ip", "link", "set", "dev", interfaceName, "up")
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Bringing up %s: %s", interfaceName, stderr)
+	}
+
+	// Deadline gives last probe a second to be answered
+	count := strconv.Itoa(d.probes)
+	deadline := strconv.Itoa(d.probes + 1)
+
+	stdout, stderr, exitStatus, err := d.cmdRunner.RunCommand("arping", "-D", "-c", count, "-w", deadline, "-I", interfaceName, ip)
+	if err == nil {
+		return "", nil
+	}
+
+	if exitStatus != arpingDADDuplicateExitStatus {
+		return "", bosherr.WrapErrorf(err, "Probing for duplicate address %s on %s: %s", ip, interfaceName, stderr)
+	}
+
+	matches := arpingReplyRegexp.FindStringSubmatch(stdout)
+	if len(matches) != 2 {
+		return "", bosherr.Errorf("Probing for duplicate address %s on %s: unexpected arping output '%s'", ip, interfaceName, stdout)
+	}
+
+	return matches[1], nil
+}
+
+func (d arpingDuplicateAddressDetector) detectIPv6(interfaceName string, ip string) (string, error) {
+	d.logger.Debug(arpingDADLogTag, "Checking duplicate address detection state of %s on %s", ip, interfaceName)
+
+	// Kernel marks address as tentative while DAD is in progress
+	for i := 0; i <= d.probes; i++ {
+		stdout, stderr, _, err := d.cmdRunner.RunCommand("ip", "-6", "-o", "addr", "show", "dev", interfaceName)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Getting IPv6 addresses of %s: %s", interfaceName, stderr)
+		}
+
+		flags, found := ipv6AddressFlags(stdout, ip)
+		if !found {
+			return "", bosherr.Errorf("IPv6 address %s is not assigned to %s", ip, interfaceName)
+		}
+
+		if strings.Contains(flags, "dadfailed") {
+			return d.ipv6NeighborMAC(interfaceName, ip), nil
+		}
+
+		if !strings.Contains(flags, "tentative") {
+			return "", nil
+		}
+
+		time.Sleep(d.dadCheckDelay)
+	}
+
+	return "", bosherr.Errorf("Timed out waiting for duplicate address detection of %s on %s", ip, interfaceName)
+}
+
+func (d arpingDuplicateAddressDetector) ipv6NeighborMAC(interfaceName string, ip string) string {
+	stdout, _, _, err := d.cmdRunner.RunCommand("ip", "-6", "neigh", "show", ip, "dev", interfaceName)
+	if err != nil {
+		d.logger.Info(arpingDADLogTag, "Ignoring failure to get neighbor %s: %s", ip, err.Error())
+		return unknownConflictingMAC
+	}
+
+	matches := neighLladdrRegexp.FindStringSubmatch(stdout)
+	if len(matches) != 2 {
+		return unknownConflictingMAC
+	}
+
+	return matches[1]
+}
+
+// ipv6AddressFlags returns flags that follow given address in `ip -o addr show` output
+// e.g. '2: eth0    inet6 fd00::5/64 scope global tentative dadfailed \       valid_lft forever'
+func ipv6AddressFlags(output string, ip string) (string, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+
+		for i, field := range fields {
+			if field != "inet6" || i+1 >= len(fields) {
+				continue
+			}
+
+			address := strings.SplitN(fields[i+1], "/", 2)[0]
+			if net.ParseIP(address).Equal(net.ParseIP(ip)) {
+				return strings.Join(fields[i+2:], " "), true
+			}
+		}
+	}
+
+	return "", false
+}
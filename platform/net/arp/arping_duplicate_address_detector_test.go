@@ -0,0 +1,160 @@
+package arp_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("arpingDuplicateAddressDetector", func() {
+	const arpingCmd = "arping -D -c 3 -w 4 -I eth0 10.0.0.5"
+
+	var (
+		cmdRunner *fakesys.FakeCmdRunner
+		detector  DuplicateAddressDetector
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		detector = NewArpingDuplicateAddressDetector(cmdRunner, logger, 3)
+	})
+
+	It("brings link up and sends ARP probes and returns no MAC if nobody replies", func() {
+		cmdRunner.AddCmdResult(arpingCmd, fakesys.FakeCmdResult{
+			Stdout: "ARPING 10.0.0.5 from 0.0.0.0 eth0\nSent 3 probes (3 broadcast(s))\nReceived 0 response(s)\n",
+		})
+
+		mac, err := detector.Detect("eth0", "10.0.0.5")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mac).To(BeEmpty())
+
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{
+			{"ip", "link", "set", "dev", "eth0", "up"},
+			{"arping", "-D", "-c", "3", "-w", "4", "-I", "eth0", "10.0.0.5"},
+		}))
+	})
+
+	It("returns error without probing if link cannot be brought up", func() {
+		cmdRunner.AddCmdResult("ip link set dev eth0 up", fakesys.FakeCmdResult{
+			Stderr: "Cannot find device \"eth0\"",
+			Error:  errors.New("fake-link-err"),
+		})
+
+		_, err := detector.Detect("eth0", "10.0.0.5")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-link-err"))
+
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{
+			{"ip", "link", "set", "dev", "eth0", "up"},
+		}))
+	})
+
+	It("returns MAC address of the host that replied", func() {
+		cmdRunner.AddCmdResult(arpingCmd, fakesys.FakeCmdResult{
+			Stdout:     "ARPING 10.0.0.5 from 0.0.0.0 eth0\nUnicast reply from 10.0.0.5 [FA:16:3E:11:22:33]  0.735ms\nSent 1 probes (1 broadcast(s))\nReceived 1 response(s)\n",
+			ExitStatus: 1,
+			Error:      errors.New("fake-exit-1"),
+		})
+
+		mac, err := detector.Detect("eth0", "10.0.0.5")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mac).To(Equal("FA:16:3E:11:22:33"))
+	})
+
+	It("returns error if arping fails", func() {
+		cmdRunner.AddCmdResult(arpingCmd, fakesys.FakeCmdResult{
+			Stderr:     "arping: unknown iface eth0",
+			ExitStatus: 2,
+			Error:      errors.New("fake-exit-2"),
+		})
+
+		_, err := detector.Detect("eth0", "10.0.0.5")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-exit-2"))
+		Expect(err.Error()).To(ContainSubstring("unknown iface eth0"))
+	})
+
+	It("returns error if reply cannot be parsed", func() {
+		cmdRunner.AddCmdResult(arpingCmd, fakesys.FakeCmdResult{
+			Stdout:     "fake-output",
+			ExitStatus: 1,
+			Error:      errors.New("fake-exit-1"),
+		})
+
+		_, err := detector.Detect("eth0", "10.0.0.5")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unexpected arping output 'fake-output'"))
+	})
+
+	Context("when address is IPv6", func() {
+		const (
+			addrCmd  = "ip -6 -o addr show dev eth0"
+			neighCmd = "ip -6 neigh show fd00::5 dev eth0"
+		)
+
+		It("returns no MAC if kernel did not detect duplicate address", func() {
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{
+				Stdout: "2: eth0    inet6 fd00::5/64 scope global \\       valid_lft forever preferred_lft forever\n" +
+					"2: eth0    inet6 fe80::1/64 scope link \\       valid_lft forever preferred_lft forever\n",
+			})
+
+			mac, err := detector.Detect("eth0", "fd00::5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mac).To(BeEmpty())
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"ip", "-6", "-o", "addr", "show", "dev", "eth0"},
+			}))
+		})
+
+		It("waits for tentative address and returns MAC of neighbor that owns the address", func() {
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{
+				Stdout: "2: eth0    inet6 fd00:0::5/64 scope global tentative \\       valid_lft forever preferred_lft forever\n",
+			})
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{
+				Stdout: "2: eth0    inet6 fd00::5/64 scope global tentative dadfailed \\       valid_lft forever preferred_lft forever\n",
+			})
+			cmdRunner.AddCmdResult(neighCmd, fakesys.FakeCmdResult{
+				Stdout: "fd00::5 lladdr fa:16:3e:11:22:33 STALE\n",
+			})
+
+			mac, err := detector.Detect("eth0", "fd00::5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mac).To(Equal("fa:16:3e:11:22:33"))
+		})
+
+		It("returns unknown MAC if neighbor is not known", func() {
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{
+				Stdout: "2: eth0    inet6 fd00::5/64 scope global dadfailed tentative\n",
+			})
+
+			mac, err := detector.Detect("eth0", "fd00::5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mac).To(Equal("unknown"))
+		})
+
+		It("returns error if address is not assigned to the interface", func() {
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{
+				Stdout: "2: eth0    inet6 fe80::1/64 scope link\n",
+			})
+
+			_, err := detector.Detect("eth0", "fd00::5")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("IPv6 address fd00::5 is not assigned to eth0"))
+		})
+
+		It("returns error if addresses cannot be listed", func() {
+			cmdRunner.AddCmdResult(addrCmd, fakesys.FakeCmdResult{Error: errors.New("fake-ip-err")})
+
+			_, err := detector.Detect("eth0", "fd00::5")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-ip-err"))
+		})
+	})
+})
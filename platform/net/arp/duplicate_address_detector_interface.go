@@ -0,0 +1,10 @@
+package arp
+
+type DuplicateAddressDetector interface {
+	// Detect checks whether another host on the network attached to given
+	// interface already uses the address. IPv4 addresses are actively probed;
+	// for IPv6 addresses result of kernel duplicate address detection is used
+	// hence IPv6 address has to be assigned to the interface beforehand.
+	// It returns MAC address of conflicting host or empty string if address is free.
+	Detect(interfaceName string, ip string) (conflictingMAC string, err error)
+}
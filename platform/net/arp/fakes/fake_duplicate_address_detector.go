@@ -0,0 +1,28 @@
+package fakes
+
+import (
+	"sync"
+)
+
+type DetectInput struct {
+	InterfaceName string
+	IP            string
+}
+
+type FakeDuplicateAddressDetector struct {
+	detectLock sync.Mutex
+
+	DetectInputs []DetectInput
+
+	// Conflicting MACs by IP
+	DetectMACs map[string]string
+	DetectErr  error
+}
+
+func (d *FakeDuplicateAddressDetector) Detect(interfaceName string, ip string) (string, error) {
+	d.detectLock.Lock()
+	defer d.detectLock.Unlock()
+
+	d.DetectInputs = append(d.DetectInputs, DetectInput{InterfaceName: interfaceName, IP: ip})
+	return d.DetectMACs[ip], d.DetectErr
+}
@@ -25,6 +25,7 @@ type centosNetManager struct {
 	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	dnsValidator                  DNSValidator
 	addressBroadcaster            bosharp.AddressBroadcaster
+	duplicateAddressDetector      bosharp.DuplicateAddressDetector
 	logger                        boshlog.Logger
 }
 
@@ -36,6 +37,7 @@ func NewCentosNetManager(
 	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	dnsValidator DNSValidator,
 	addressBroadcaster bosharp.AddressBroadcaster,
+	duplicateAddressDetector bosharp.DuplicateAddressDetector,
 	logger boshlog.Logger,
 ) Manager {
 	return centosNetManager{
@@ -46,6 +48,7 @@ func NewCentosNetManager(
 		interfaceAddressesValidator:   interfaceAddressesValidator,
 		dnsValidator:                  dnsValidator,
 		addressBroadcaster:            addressBroadcaster,
+		duplicateAddressDetector:      duplicateAddressDetector,
 		logger:                        logger,
 	}
 }
@@ -83,7 +86,17 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, errC
 	}
 
 	if interfacesChanged || dhcpChanged {
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticInterfaceConfigurations, false)
+		if err != nil {
+			return err
+		}
+
 		net.restartNetworkingInterfaces()
+
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticInterfaceConfigurations, true)
+		if err != nil {
+			return err
+		}
 	}
 
 	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticInterfaceConfigurations, dhcpInterfaceConfigurations)
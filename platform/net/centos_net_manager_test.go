@@ -25,6 +25,7 @@ func describeCentosNetManager() {
 		ipResolver                    *fakeip.FakeResolver
 		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 		addressBroadcaster            *fakearp.FakeAddressBroadcaster
+		duplicateAddressDetector      *fakearp.FakeDuplicateAddressDetector
 		netManager                    Manager
 		interfaceConfigurationCreator InterfaceConfigurationCreator
 	)
@@ -39,6 +40,7 @@ func describeCentosNetManager() {
 		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
 		dnsValidator := NewDNSValidator(fs)
 		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		duplicateAddressDetector = &fakearp.FakeDuplicateAddressDetector{}
 		netManager = NewCentosNetManager(
 			fs,
 			cmdRunner,
@@ -47,6 +49,7 @@ func describeCentosNetManager() {
 			interfaceAddrsValidator,
 			dnsValidator,
 			addressBroadcaster,
+			duplicateAddressDetector,
 			logger,
 		)
 	})
@@ -400,6 +403,35 @@ request subnet-mask, broadcast-address, time-offset, routers,
 			})
 		})
 
+		It("probes static addresses for duplicates before restarting networks", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			duplicateAddressDetector.DetectMACs = map[string]string{"1.2.3.4": "fa:16:3e:11:22:33"}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("IP address 1.2.3.4 on ethstatic is already in use by host with MAC address fa:16:3e:11:22:33"))
+
+			Expect(duplicateAddressDetector.DetectInputs).To(Equal([]fakearp.DetectInput{
+				{InterfaceName: "ethstatic", IP: "1.2.3.4"},
+			}))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("does not fail if duplicate address detection fails", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethstatic": staticNetwork,
+			})
+
+			duplicateAddressDetector.DetectErr = errors.New("fake-detect-err")
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("broadcasts MAC addresses for all interfaces", func() {
 			stubInterfaces(map[string]boshsettings.Network{
 				"ethdhcp":   dhcpNetwork,
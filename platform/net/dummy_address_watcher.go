@@ -0,0 +1,11 @@
+package net
+
+type dummyAddressWatcher struct{}
+
+func NewDummyAddressWatcher() AddressWatcher {
+	return dummyAddressWatcher{}
+}
+
+func (dummyAddressWatcher) Watch(_ <-chan struct{}) (<-chan string, error) {
+	return nil, ErrAddressWatchingNotSupported
+}
@@ -0,0 +1,37 @@
+package net
+
+import (
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// detectDuplicateAddresses fails if any of static addresses of given IP version
+// is already used by another host. IPv4 addresses should be checked before
+// interfaces are brought up; IPv6 addresses right after (kernel performs DAD).
+// Detection itself is best effort: if probing fails, e.g. because link
+// has no carrier yet, address is assumed not to be duplicate.
+func detectDuplicateAddresses(
+	detector bosharp.DuplicateAddressDetector,
+	logger boshlog.Logger,
+	staticConfigs []StaticInterfaceConfiguration,
+	version6 bool,
+) error {
+	for _, config := range staticConfigs {
+		if config.IsVersion6() != version6 {
+			continue
+		}
+
+		mac, err := detector.Detect(config.Name, config.Address)
+		if err != nil {
+			logger.Warn("duplicateAddressDetection", "Skipping duplicate address detection of %s on %s: %s", config.Address, config.Name, err.Error())
+			continue
+		}
+
+		if mac != "" {
+			return bosherr.Errorf("IP address %s on %s is already in use by host with MAC address %s", config.Address, config.Name, mac)
+		}
+	}
+
+	return nil
+}
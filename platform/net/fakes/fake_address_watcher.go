@@ -0,0 +1,21 @@
+package fakes
+
+type FakeAddressWatcher struct {
+	WatchStopCh   <-chan struct{}
+	WatchChangeCh chan string
+	WatchErr      error
+}
+
+func NewFakeAddressWatcher() *FakeAddressWatcher {
+	return &FakeAddressWatcher{WatchChangeCh: make(chan string)}
+}
+
+func (w *FakeAddressWatcher) Watch(stopCh <-chan struct{}) (<-chan string, error) {
+	w.WatchStopCh = stopCh
+
+	if w.WatchErr != nil {
+		return nil, w.WatchErr
+	}
+
+	return w.WatchChangeCh, nil
+}
@@ -0,0 +1,41 @@
+package netcheck
+
+import (
+	"time"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+const (
+	CheckGateway     = "gateway"
+	CheckDNS         = "dns"
+	CheckMbus        = "mbus"
+	CheckBlobstore   = "blobstore"
+	CheckDuplicateIP = "duplicate_ip"
+
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Timeout limits each DNS lookup and connection attempt of a check
+const Timeout = 5 * time.Second
+
+type Report struct {
+	Passed bool     `json:"passed"`
+	Checks []Result `json:"checks"`
+}
+
+type Result struct {
+	Check   string `json:"check"`
+	Network string `json:"network,omitempty"`
+	Target  string `json:"target"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type Checker interface {
+	// Check never fails as a whole; problems
+	// are reported as failed checks instead.
+	Check(settings boshsettings.Settings) Report
+}
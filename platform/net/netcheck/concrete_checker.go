@@ -0,0 +1,334 @@
+package netcheck
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const concreteCheckerLogTag = "concreteChecker"
+
+type concreteChecker struct {
+	cmdRunner                  boshsys.CmdRunner
+	resolver                   Resolver
+	duplicateAddressDetector   bosharp.DuplicateAddressDetector
+	interfaceAddressesProvider boship.InterfaceAddressesProvider
+	timeout                    time.Duration
+	logger                     boshlog.Logger
+}
+
+func NewChecker(
+	cmdRunner boshsys.CmdRunner,
+	resolver Resolver,
+	duplicateAddressDetector bosharp.DuplicateAddressDetector,
+	interfaceAddressesProvider boship.InterfaceAddressesProvider,
+	timeout time.Duration,
+	logger boshlog.Logger,
+) Checker {
+	return concreteChecker{
+		cmdRunner:                  cmdRunner,
+		resolver:                   resolver,
+		duplicateAddressDetector:   duplicateAddressDetector,
+		interfaceAddressesProvider: interfaceAddressesProvider,
+		timeout:                    timeout,
+		logger:                     logger,
+	}
+}
+
+func (c concreteChecker) Check(settings boshsettings.Settings) Report {
+	var checks []func() Result
+
+	networkNames := []string{}
+	for name := range settings.Networks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+
+	mbusTarget, mbusTLS, mbusErr := c.mbusTarget(settings.GetMbusURL())
+	blobstoreTarget, blobstoreTLS, blobstoreErr := c.blobstoreTarget(settings.GetBlobstore())
+
+	hostnames := []string{}
+	for _, target := range []string{mbusTarget, blobstoreTarget} {
+		host, _, err := net.SplitHostPort(target)
+		if err == nil && net.ParseIP(host) == nil {
+			hostnames = append(hostnames, host)
+		}
+	}
+
+	for _, name := range networkNames {
+		networkName := name
+		network := settings.Networks[name]
+
+		if network.Gateway != "" {
+			checks = append(checks, func() Result { return c.checkGateway(networkName, network.Gateway) })
+		}
+
+		for _, server := range network.DNS {
+			dnsServer := server
+			checks = append(checks, func() Result { return c.checkDNS(networkName, dnsServer, hostnames) })
+		}
+
+		if ip := net.ParseIP(network.IP); ip != nil && ip.To4() != nil {
+			checks = append(checks, func() Result { return c.checkDuplicateIP(networkName, network.IP) })
+		}
+	}
+
+	checks = append(checks, func() Result {
+		return c.checkEndpoint(CheckMbus, mbusTarget, mbusTLS, mbusErr)
+	})
+
+	checks = append(checks, func() Result {
+		return c.checkEndpoint(CheckBlobstore, blobstoreTarget, blobstoreTLS, blobstoreErr)
+	})
+
+	// Checks may take up to a timeout each so run them concurrently;
+	// results keep the order checks were listed in
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func(i int, check func() Result) {
+			defer wg.Done()
+			results[i] = check()
+		}(i, check)
+	}
+
+	wg.Wait()
+
+	report := Report{Passed: true, Checks: results}
+
+	for _, result := range results {
+		if result.Status == StatusFailed {
+			c.logger.Warn(concreteCheckerLogTag, "Network check %s of %s failed: %s", result.Check, result.Target, result.Message)
+			report.Passed = false
+		}
+	}
+
+	return report
+}
+
+func (c concreteChecker) checkGateway(networkName, gateway string) Result {
+	result := Result{Check: CheckGateway, Network: networkName, Target: gateway}
+
+	ping := "ping"
+	if ip := net.ParseIP(gateway); ip != nil && ip.To4() == nil {
+		ping = "ping6"
+	}
+
+	timeoutSecs := strconv.Itoa(int(c.timeout.Seconds()))
+
+	_, _, _, err := c.cmdRunner.RunCommand(ping, "-c", "1", "-W", timeoutSecs, gateway)
+	if err != nil {
+		return failed(result, err)
+	}
+
+	return passed(result)
+}
+
+func (c concreteChecker) checkDNS(networkName, server string, hostnames []string) Result {
+	result := Result{Check: CheckDNS, Network: networkName, Target: server}
+
+	if len(hostnames) == 0 {
+		result.Status = StatusSkipped
+		result.Message = "No host names to resolve"
+		return result
+	}
+
+	messages := []string{}
+
+	for _, hostname := range hostnames {
+		addresses, err := c.resolver.LookupHost(server, hostname)
+		if err != nil {
+			return failed(result, bosherr.WrapErrorf(err, "Resolving %s", hostname))
+		}
+
+		messages = append(messages, fmt.Sprintf("%s => %s", hostname, strings.Join(addresses, ", ")))
+	}
+
+	result = passed(result)
+	result.Message = strings.Join(messages, "; ")
+
+	return result
+}
+
+func (c concreteChecker) checkDuplicateIP(networkName, ip string) Result {
+	result := Result{Check: CheckDuplicateIP, Network: networkName, Target: ip}
+
+	addresses, err := c.interfaceAddressesProvider.Get()
+	if err != nil {
+		return failed(result, bosherr.WrapError(err, "Getting interface addresses"))
+	}
+
+	interfaceName := ""
+
+	for _, address := range addresses {
+		addressIP, err := address.GetIP()
+		if err == nil && addressIP == ip {
+			interfaceName = address.GetInterfaceName()
+			break
+		}
+	}
+
+	if interfaceName == "" {
+		return failed(result, bosherr.Error("IP is not assigned to any interface"))
+	}
+
+	mac, err := c.duplicateAddressDetector.Detect(interfaceName, ip)
+	if err != nil {
+		return failed(result, err)
+	}
+
+	if mac != "" {
+		return failed(result, bosherr.Errorf("IP is also used by host with MAC address %s", mac))
+	}
+
+	return passed(result)
+}
+
+func (c concreteChecker) checkEndpoint(check, target string, useTLS bool, targetErr error) Result {
+	result := Result{Check: check, Target: target}
+
+	if targetErr != nil {
+		return failed(result, targetErr)
+	}
+
+	if target == "" {
+		result.Status = StatusSkipped
+		result.Message = "No endpoint to check"
+		return result
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+
+	if !useTLS {
+		conn, err := dialer.Dial("tcp", target)
+		if err != nil {
+			return failed(result, err)
+		}
+
+		_ = conn.Close()
+
+		return passed(result)
+	}
+
+	// Only network path and TLS negotiation are verified;
+	// certificates are validated by actual clients
+	conn, err := tls.DialWithDialer(dialer, "tcp", target, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return failed(result, err)
+	}
+
+	_ = conn.Close()
+
+	result = passed(result)
+	result.Message = "TLS handshake succeeded"
+
+	return result
+}
+
+func (c concreteChecker) mbusTarget(mbusURL string) (string, bool, error) {
+	if mbusURL == "" {
+		return "", false, nil
+	}
+
+	parsedURL, err := url.Parse(mbusURL)
+	if err != nil {
+		return "", false, bosherr.WrapError(err, "Parsing mbus URL")
+	}
+
+	// NATS negotiates TLS after plain text INFO message
+	// hence only https mbus can be checked with a TLS handshake
+	switch parsedURL.Scheme {
+	case "https":
+		return hostWithDefaultPort(parsedURL.Host, "443"), true, nil
+	case "nats":
+		return hostWithDefaultPort(parsedURL.Host, "4222"), false, nil
+	default:
+		return "", false, bosherr.Errorf("Unsupported mbus URL scheme '%s'", parsedURL.Scheme)
+	}
+}
+
+func (c concreteChecker) blobstoreTarget(blobstore boshsettings.Blobstore) (string, bool, error) {
+	switch blobstore.Type {
+	case "dav":
+		endpoint, _ := blobstore.Options["endpoint"].(string)
+		if endpoint == "" {
+			return "", false, bosherr.Error("Missing blobstore endpoint")
+		}
+
+		parsedURL, err := url.Parse(endpoint)
+		if err != nil {
+			return "", false, bosherr.WrapError(err, "Parsing blobstore endpoint")
+		}
+
+		if parsedURL.Scheme == "https" {
+			return hostWithDefaultPort(parsedURL.Host, "443"), true, nil
+		}
+
+		return hostWithDefaultPort(parsedURL.Host, "80"), false, nil
+
+	case "s3":
+		host, _ := blobstore.Options["host"].(string)
+		if host == "" {
+			host = "s3.amazonaws.com"
+		}
+
+		useSSL, found := blobstore.Options["use_ssl"].(bool)
+		if !found {
+			useSSL = true
+		}
+
+		port := "80"
+		if useSSL {
+			port = "443"
+		}
+
+		if configuredPort, found := blobstore.Options["port"].(float64); found && configuredPort > 0 {
+			port = strconv.Itoa(int(configuredPort))
+		}
+
+		return net.JoinHostPort(host, port), useSSL, nil
+
+	case "gcs":
+		return "storage.googleapis.com:443", true, nil
+
+	default:
+		// Local blobstores do not need network
+		return "", false, nil
+	}
+}
+
+func hostWithDefaultPort(host, defaultPort string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(strings.Trim(host, "[]"), defaultPort)
+}
+
+func passed(result Result) Result {
+	result.Status = StatusPassed
+	return result
+}
+
+func failed(result Result, err error) Result {
+	result.Status = StatusFailed
+	result.Message = err.Error()
+	return result
+}
@@ -0,0 +1,16 @@
+package fakes
+
+import (
+	boshnetcheck "github.com/cloudfoundry/bosh-agent/platform/net/netcheck"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeChecker struct {
+	CheckSettings boshsettings.Settings
+	CheckReport   boshnetcheck.Report
+}
+
+func (c *FakeChecker) Check(settings boshsettings.Settings) boshnetcheck.Report {
+	c.CheckSettings = settings
+	return c.CheckReport
+}
@@ -0,0 +1,12 @@
+package fakes
+
+type FakeResolver struct {
+	// Addresses and errors by server and host, e.g. "8.8.8.8 example.com"
+	LookupHostAddresses map[string][]string
+	LookupHostErrs      map[string]error
+}
+
+func (r *FakeResolver) LookupHost(server string, host string) ([]string, error) {
+	key := server + " " + host
+	return r.LookupHostAddresses[key], r.LookupHostErrs[key]
+}
@@ -0,0 +1,13 @@
+package netcheck_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestNetcheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Netcheck Suite")
+}
@@ -0,0 +1,38 @@
+package netcheck
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+type Resolver interface {
+	// LookupHost resolves host using only given DNS server
+	LookupHost(server string, host string) ([]string, error)
+}
+
+type dnsResolver struct {
+	timeout time.Duration
+}
+
+func NewDNSResolver(timeout time.Duration) Resolver {
+	return dnsResolver{timeout: timeout}
+}
+
+func (r dnsResolver) LookupHost(server string, host string) ([]string, error) {
+	dialer := net.Dialer{Timeout: r.timeout}
+
+	resolver := &net.Resolver{
+		// Go resolver has to be used since cgo resolver
+		// always consults system configured servers
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, net.JoinHostPort(server, "53"))
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	return resolver.LookupHost(ctx, host)
+}
@@ -26,6 +26,7 @@ type opensuseNetManager struct {
 	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	dnsValidator                  DNSValidator
 	addressBroadcaster            bosharp.AddressBroadcaster
+	duplicateAddressDetector      bosharp.DuplicateAddressDetector
 	logger                        boshlog.Logger
 }
 
@@ -42,6 +43,7 @@ func NewOpensuseNetManager(
 	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	dnsValidator DNSValidator,
 	addressBroadcaster bosharp.AddressBroadcaster,
+	duplicateAddressDetector bosharp.DuplicateAddressDetector,
 	logger boshlog.Logger,
 ) Manager {
 	return opensuseNetManager{
@@ -52,6 +54,7 @@ func NewOpensuseNetManager(
 		interfaceAddressesValidator:   interfaceAddressesValidator,
 		dnsValidator:                  dnsValidator,
 		addressBroadcaster:            addressBroadcaster,
+		duplicateAddressDetector:      duplicateAddressDetector,
 		logger:                        logger,
 	}
 }
@@ -83,7 +86,17 @@ func (net opensuseNetManager) SetupNetworking(networks boshsettings.Networks, er
 	}
 
 	if interfacesChanged || dhcpChanged {
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticConfigs, false)
+		if err != nil {
+			return err
+		}
+
 		net.restartNetworkingInterfaces()
+
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticConfigs, true)
+		if err != nil {
+			return err
+		}
 	}
 
 	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
@@ -26,6 +26,7 @@ type UbuntuNetManager struct {
 	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	dnsValidator                  DNSValidator
 	addressBroadcaster            bosharp.AddressBroadcaster
+	duplicateAddressDetector      bosharp.DuplicateAddressDetector
 	kernelIPv6                    KernelIPv6
 	logger                        boshlog.Logger
 }
@@ -38,6 +39,7 @@ func NewUbuntuNetManager(
 	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	dnsValidator DNSValidator,
 	addressBroadcaster bosharp.AddressBroadcaster,
+	duplicateAddressDetector bosharp.DuplicateAddressDetector,
 	kernelIPv6 KernelIPv6,
 	logger boshlog.Logger,
 ) Manager {
@@ -49,6 +51,7 @@ func NewUbuntuNetManager(
 		interfaceAddressesValidator:   interfaceAddressesValidator,
 		dnsValidator:                  dnsValidator,
 		addressBroadcaster:            addressBroadcaster,
+		duplicateAddressDetector:      duplicateAddressDetector,
 		kernelIPv6:                    kernelIPv6,
 		logger:                        logger,
 	}
@@ -120,6 +123,11 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 	}
 
 	if changed {
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticConfigs, false)
+		if err != nil {
+			return err
+		}
+
 		err = net.removeDhcpDNSConfiguration()
 		if err != nil {
 			return err
@@ -133,6 +141,11 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 		}
 
 		net.startNetworkingInterfaces(dhcpConfigs, staticConfigs)
+
+		err = detectDuplicateAddresses(net.duplicateAddressDetector, net.logger, staticConfigs, true)
+		if err != nil {
+			return err
+		}
 	}
 
 	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
@@ -24,6 +24,7 @@ var _ = Describe("UbuntuNetManager (IPv6)", func() {
 		cmdRunner                     *fakesys.FakeCmdRunner
 		ipResolver                    *fakeip.FakeResolver
 		addressBroadcaster            *fakearp.FakeAddressBroadcaster
+		duplicateAddressDetector      *fakearp.FakeDuplicateAddressDetector
 		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 		kernelIPv6                    *fakenet.FakeKernelIPv6
 		netManager                    UbuntuNetManager
@@ -37,6 +38,7 @@ var _ = Describe("UbuntuNetManager (IPv6)", func() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		interfaceConfigurationCreator = NewInterfaceConfigurationCreator(logger)
 		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		duplicateAddressDetector = &fakearp.FakeDuplicateAddressDetector{}
 		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
 		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
 		dnsValidator := NewDNSValidator(fs)
@@ -49,6 +51,7 @@ var _ = Describe("UbuntuNetManager (IPv6)", func() {
 			interfaceAddrsValidator,
 			dnsValidator,
 			addressBroadcaster,
+			duplicateAddressDetector,
 			kernelIPv6,
 			logger,
 		).(UbuntuNetManager)
@@ -145,6 +148,48 @@ var _ = Describe("UbuntuNetManager (IPv6)", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-err"))
 		})
 
+		It("checks IPv6 addresses for duplicates after bringing up interfaces", func() {
+			static1Net := boshsettings.Network{
+				Type:    "manual",
+				IP:      "2601:646:100:e8e8::103",
+				Netmask: "ffff:ffff:ffff:ffff:0000:0000:0000:0000",
+				Gateway: "2601:646:100:e8e8::",
+				Default: []string{"gateway", "dns"},
+				DNS:     []string{"8.8.8.8", "9.9.9.9"},
+				Mac:     "mac1",
+			}
+
+			static2Net := boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.4",
+				Netmask: "255.255.255.0",
+				Gateway: "3.4.5.6",
+				Mac:     "mac2",
+			}
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethstatic1": static1Net,
+				"ethstatic2": static2Net,
+			})
+
+			cmdRunner.SetCmdCallback("ifup --force ethstatic1 ethstatic2", func() {
+				Expect(duplicateAddressDetector.DetectInputs).To(Equal([]fakearp.DetectInput{
+					{InterfaceName: "ethstatic2", IP: "1.2.3.4"},
+				}))
+			})
+
+			duplicateAddressDetector.DetectMACs = map[string]string{"2601:646:100:e8e8::103": "fa:16:3e:11:22:33"}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"net1": static1Net, "net2": static2Net}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("IP address 2601:646:100:e8e8::103 on ethstatic1 is already in use by host with MAC address fa:16:3e:11:22:33"))
+
+			Expect(duplicateAddressDetector.DetectInputs).To(Equal([]fakearp.DetectInput{
+				{InterfaceName: "ethstatic2", IP: "1.2.3.4"},
+				{InterfaceName: "ethstatic1", IP: "2601:646:100:e8e8::103"},
+			}))
+		})
+
 		It("does not enable IPv6 if there aren't any IPv6 addresses", func() {
 			static1Net := boshsettings.Network{
 				Type:    "manual",
@@ -26,6 +26,7 @@ func describeUbuntuNetManager() {
 		cmdRunner                     *fakesys.FakeCmdRunner
 		ipResolver                    *fakeip.FakeResolver
 		addressBroadcaster            *fakearp.FakeAddressBroadcaster
+		duplicateAddressDetector      *fakearp.FakeDuplicateAddressDetector
 		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 		kernelIPv6                    *fakenet.FakeKernelIPv6
 		netManager                    UbuntuNetManager
@@ -68,6 +69,7 @@ func describeUbuntuNetManager() {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		interfaceConfigurationCreator = NewInterfaceConfigurationCreator(logger)
 		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		duplicateAddressDetector = &fakearp.FakeDuplicateAddressDetector{}
 		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
 		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
 		dnsValidator := NewDNSValidator(fs)
@@ -80,6 +82,7 @@ func describeUbuntuNetManager() {
 			interfaceAddrsValidator,
 			dnsValidator,
 			addressBroadcaster,
+			duplicateAddressDetector,
 			kernelIPv6,
 			logger,
 		).(UbuntuNetManager)
@@ -793,6 +796,63 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 			Expect(fs.ReadFileString("/etc/dhcp/dhclient.conf")).ToNot(Equal(initialDhcpConfig))
 		})
 
+		It("probes static addresses for duplicates before bringing up interfaces", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(duplicateAddressDetector.DetectInputs).To(Equal([]fakearp.DetectInput{
+				{InterfaceName: "ethstatic", IP: "1.2.3.4"},
+			}))
+		})
+
+		It("returns error naming conflicting MAC address and does not bring up interfaces if static address is in use", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			duplicateAddressDetector.DetectMACs = map[string]string{"1.2.3.4": "fa:16:3e:11:22:33"}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("IP address 1.2.3.4 on ethstatic is already in use by host with MAC address fa:16:3e:11:22:33"))
+
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+			Expect(fs.FileExists("/etc/network/interfaces")).To(BeFalse())
+		})
+
+		It("does not fail if duplicate address detection fails", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethstatic": staticNetwork,
+			})
+
+			duplicateAddressDetector.DetectErr = errors.New("fake-detect-err")
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not probe static addresses if network configuration did not change", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			duplicateAddressDetector.DetectInputs = nil
+
+			err = netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(duplicateAddressDetector.DetectInputs).To(BeEmpty())
+		})
+
 		It("broadcasts MAC addresses for all interfaces", func() {
 			stubInterfaces(map[string]boshsettings.Network{
 				"ethdhcp":   dhcpNetwork,
@@ -0,0 +1,127 @@
+package ntp
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	chronyTrackingFieldsCount = 14
+
+	chronyTrackingSystemTimeField = 4
+	chronyTrackingLeapStatusField = 13
+
+	chronyLeapStatusNotSynchronised = "Not synchronised"
+)
+
+type chronyManager struct {
+	fs          boshsys.FileSystem
+	runner      boshsys.CmdRunner
+	configPath  string
+	serviceName string
+	logger      boshlog.Logger
+	logTag      string
+}
+
+// NewChronyManager returns a Manager that renders chrony configuration
+// to configPath and restarts chrony via given init service name.
+// (Service is called "chrony" on Ubuntu and "chronyd" on CentOS & openSUSE)
+func NewChronyManager(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	configPath string,
+	serviceName string,
+	logger boshlog.Logger,
+) Manager {
+	return chronyManager{
+		fs:          fs,
+		runner:      runner,
+		configPath:  configPath,
+		serviceName: serviceName,
+		logger:      logger,
+		logTag:      "chronyManager",
+	}
+}
+
+func (m chronyManager) SetupNtpServers(servers []string) error {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	// Older stemcells sync time with ntpdate instead of running chrony
+	if !m.runner.CommandExists("chronyc") {
+		return ErrNotSupported
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	t := template.Must(template.New("chrony-conf").Parse(chronyConfTemplate))
+
+	err := t.Execute(buffer, servers)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating chrony config")
+	}
+
+	err = m.fs.WriteFile(m.configPath, buffer.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to %s", m.configPath)
+	}
+
+	m.logger.Info(m.logTag, "Restarting %s with NTP servers %v", m.serviceName, servers)
+
+	_, stderr, _, err := m.runner.RunCommand("service", m.serviceName, "restart")
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Restarting %s: %s", m.serviceName, stderr)
+	}
+
+	// Make sure that chronyd came back and responds to queries;
+	// it's fine if it did not synchronize with servers yet
+	_, err = m.Status()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Checking %s", m.serviceName)
+	}
+
+	return nil
+}
+
+func (m chronyManager) Status() (Status, error) {
+	if !m.runner.CommandExists("chronyc") {
+		return Status{}, ErrNotSupported
+	}
+
+	stdout, stderr, _, err := m.runner.RunCommand("chronyc", "-c", "tracking")
+	if err != nil {
+		return Status{}, bosherr.WrapErrorf(err, "Getting chrony tracking status: %s", stderr)
+	}
+
+	fields := strings.Split(strings.TrimSpace(stdout), ",")
+	if len(fields) != chronyTrackingFieldsCount {
+		return Status{}, bosherr.Errorf("Unexpected chrony tracking output '%s'", stdout)
+	}
+
+	// chrony reports how much system clock needs to be corrected,
+	// i.e. positive value means that system clock is slow
+	correction, err := strconv.ParseFloat(fields[chronyTrackingSystemTimeField], 64)
+	if err != nil {
+		return Status{}, bosherr.WrapErrorf(err, "Parsing chrony system time offset '%s'", fields[chronyTrackingSystemTimeField])
+	}
+
+	return Status{
+		Offset:       time.Duration(-correction * float64(time.Second)),
+		Synchronized: fields[chronyTrackingLeapStatusField] != chronyLeapStatusNotSynchronised,
+	}, nil
+}
+
+const chronyConfTemplate = `# Generated by bosh-agent
+{{ range . }}server {{ . }} iburst
+{{ end }}
+driftfile /var/lib/chrony/drift
+makestep 1.0 3
+rtcsync
+`
@@ -0,0 +1,158 @@
+package ntp_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("chronyManager", func() {
+	const (
+		syncedTracking   = "A9FEA97B,169.254.169.123,4,1539882356.123456789,-0.000250000,-0.000001,0.000010,-11.2,-0.001,0.04,0.000543,0.000123,64.2,Normal\n"
+		unsyncedTracking = "00000000,,0,0.000000000,0.000000000,0.000000000,0.000000000,0.000,0.000,0.000,1.000000000,1.000000000,0.0,Not synchronised\n"
+	)
+
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		manager   Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		cmdRunner.AvailableCommands["chronyc"] = true
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		manager = NewChronyManager(fs, cmdRunner, "/etc/chrony/chrony.conf", "chrony", logger)
+	})
+
+	Describe("SetupNtpServers", func() {
+		It("writes chrony config, restarts chrony and checks that it responds", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: unsyncedTracking})
+
+			err := manager.SetupNtpServers([]string{"0.north-america.pool.ntp.org", "1.north-america.pool.ntp.org"})
+			Expect(err).ToNot(HaveOccurred())
+
+			config, err := fs.ReadFileString("/etc/chrony/chrony.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(`# Generated by bosh-agent
+server 0.north-america.pool.ntp.org iburst
+server 1.north-america.pool.ntp.org iburst
+
+driftfile /var/lib/chrony/drift
+makestep 1.0 3
+rtcsync
+`))
+
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{
+				{"service", "chrony", "restart"},
+				{"chronyc", "-c", "tracking"},
+			}))
+		})
+
+		It("is noop when no ntp servers are provided", func() {
+			err := manager.SetupNtpServers([]string{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/chrony/chrony.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns ErrNotSupported without restarting anything when chrony is not installed", func() {
+			cmdRunner.AvailableCommands["chronyc"] = false
+
+			err := manager.SetupNtpServers([]string{"fake-server"})
+			Expect(err).To(Equal(ErrNotSupported))
+
+			Expect(fs.FileExists("/etc/chrony/chrony.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error if writing config fails", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := manager.SetupNtpServers([]string{"fake-server"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error if restarting chrony fails", func() {
+			cmdRunner.AddCmdResult("service chrony restart", fakesys.FakeCmdResult{
+				Stderr: "fake-stderr",
+				Error:  errors.New("fake-restart-err"),
+			})
+
+			err := manager.SetupNtpServers([]string{"fake-server"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-restart-err"))
+			Expect(err.Error()).To(ContainSubstring("fake-stderr"))
+		})
+
+		It("returns error if chrony does not respond after restart", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Error: errors.New("fake-chronyc-err")})
+
+			err := manager.SetupNtpServers([]string{"fake-server"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-chronyc-err"))
+		})
+	})
+
+	Describe("Status", func() {
+		It("returns offset and synchronized state", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: syncedTracking})
+
+			status, err := manager.Status()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(Status{Offset: 250 * time.Microsecond, Synchronized: true}))
+		})
+
+		It("returns not synchronized state", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: unsyncedTracking})
+
+			status, err := manager.Status()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Synchronized).To(BeFalse())
+		})
+
+		It("returns ErrNotSupported when chrony is not installed", func() {
+			cmdRunner.AvailableCommands["chronyc"] = false
+
+			_, err := manager.Status()
+			Expect(err).To(Equal(ErrNotSupported))
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns error if chronyc fails", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Error: errors.New("fake-chronyc-err")})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-chronyc-err"))
+		})
+
+		It("returns error if output cannot be parsed", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{Stdout: "506 Cannot talk to daemon"})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected chrony tracking output"))
+		})
+
+		It("returns error if offset cannot be parsed", func() {
+			cmdRunner.AddCmdResult("chronyc -c tracking", fakesys.FakeCmdResult{
+				Stdout: "A9FEA97B,169.254.169.123,4,1539882356.1,bad,-0.000001,0.000010,-11.2,-0.001,0.04,0.000543,0.000123,64.2,Normal",
+			})
+
+			_, err := manager.Status()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing chrony system time offset 'bad'"))
+		})
+	})
+})
@@ -0,0 +1,15 @@
+package ntp
+
+type dummyManager struct{}
+
+func NewDummyManager() Manager {
+	return dummyManager{}
+}
+
+func (m dummyManager) SetupNtpServers(_ []string) error {
+	return nil
+}
+
+func (m dummyManager) Status() (Status, error) {
+	return Status{}, ErrNotSupported
+}
@@ -0,0 +1,22 @@
+package fakes
+
+import (
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+)
+
+type FakeManager struct {
+	SetupNtpServersServers []string
+	SetupNtpServersErr     error
+
+	StatusStatus boshntp.Status
+	StatusErr    error
+}
+
+func (m *FakeManager) SetupNtpServers(servers []string) error {
+	m.SetupNtpServersServers = servers
+	return m.SetupNtpServersErr
+}
+
+func (m *FakeManager) Status() (boshntp.Status, error) {
+	return m.StatusStatus, m.StatusErr
+}
@@ -0,0 +1,27 @@
+package ntp
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotSupported is returned when time synchronization daemon is not installed
+var ErrNotSupported = errors.New("Time synchronization daemon is not supported on this platform")
+
+type Status struct {
+	// Difference between system time and NTP time;
+	// positive value means that system clock is fast
+	Offset       time.Duration
+	Synchronized bool
+}
+
+type Manager interface {
+	// SetupNtpServers configures time synchronization daemon
+	// to use given servers and makes sure that it is running;
+	// returns ErrNotSupported if daemon is not installed.
+	SetupNtpServers(servers []string) error
+
+	// Status returns ErrNotSupported if platform
+	// cannot report synchronization status.
+	Status() (Status, error)
+}
@@ -2,6 +2,9 @@ package platform
 
 import (
 	"github.com/cloudfoundry/bosh-agent/platform/cert"
+	"github.com/cloudfoundry/bosh-agent/platform/firewall"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	"github.com/cloudfoundry/bosh-agent/platform/ntp"
 
 	"log"
 
@@ -86,6 +89,12 @@ type Platform interface {
 
 	GetCertManager() cert.Manager
 
+	GetFirewallManager() firewall.Manager
+
+	GetNtpManager() ntp.Manager
+
+	GetAddressWatcher() boshnet.AddressWatcher
+
 	GetHostPublicKey() (string, error)
 
 	RemoveDevTools(packageFileListPath string) error
@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"path/filepath"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -9,9 +10,11 @@ import (
 	boshcdrom "github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshiscsi "github.com/cloudfoundry/bosh-agent/platform/openiscsi"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshudev "github.com/cloudfoundry/bosh-agent/platform/udevdevice"
@@ -29,6 +32,7 @@ const (
 	ArpIterations          = 20
 	ArpIterationDelay      = 5 * time.Second
 	ArpInterfaceCheckDelay = 100 * time.Millisecond
+	ArpDuplicateProbes     = 3
 )
 
 const (
@@ -68,21 +72,29 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Kick of stats collection as soon as possible
 	statsCollector.StartCollecting(SigarStatsCollectionInterval, nil)
 
-	vitalsService := boshvitals.NewService(statsCollector, dirProvider)
+	centosNtpManager := boshntp.NewChronyManager(fs, runner, "/etc/chrony.conf", "chronyd", logger)
+	ubuntuNtpManager := boshntp.NewChronyManager(fs, runner, "/etc/chrony/chrony.conf", "chrony", logger)
+	opensuseNtpManager := boshntp.NewChronyManager(fs, runner, "/etc/chrony.conf", "chronyd", logger)
+
+	centosVitalsService := boshvitals.NewService(statsCollector, dirProvider, centosNtpManager)
+	ubuntuVitalsService := boshvitals.NewService(statsCollector, dirProvider, ubuntuNtpManager)
+	opensuseVitalsService := boshvitals.NewService(statsCollector, dirProvider, opensuseNtpManager)
 
 	ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
 	arping := bosharp.NewArping(runner, fs, logger, ArpIterations, ArpIterationDelay, ArpInterfaceCheckDelay)
+	duplicateAddressDetector := bosharp.NewArpingDuplicateAddressDetector(runner, logger, ArpDuplicateProbes)
 	interfaceConfigurationCreator := boshnet.NewInterfaceConfigurationCreator(logger)
 
 	interfaceAddressesProvider := boship.NewSystemInterfaceAddressesProvider()
 	interfaceAddressesValidator := boship.NewInterfaceAddressesValidator(interfaceAddressesProvider)
 	dnsValidator := boshnet.NewDNSValidator(fs)
 	kernelIPv6 := boshnet.NewKernelIPv6Impl(fs, runner, logger)
+	addressWatcher := boshnet.NewAddressWatcher(logger)
 
-	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
-	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, kernelIPv6, logger)
-	opensuseNetManager := boshnet.NewOpensuseNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
+	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, duplicateAddressDetector, logger)
+	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, duplicateAddressDetector, kernelIPv6, logger)
+	opensuseNetManager := boshnet.NewOpensuseNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, duplicateAddressDetector, logger)
 
 	windowsNetManager := boshnet.NewWindowsNetManager(
 		runner,
@@ -99,6 +111,8 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	windowsCertManager := boshcert.NewWindowsCertManager(fs, runner, dirProvider, logger)
 	opensuseCertManager := boshcert.NewOpensuseOSCertManager(fs, runner, 0, logger)
 
+	firewallManager := boshfirewall.NewNftablesManager(fs, runner, filepath.Join(dirProvider.BoshDir(), "firewall.nft"), logger)
+
 	interfaceManager := boshnet.NewInterfaceManager()
 
 	routesSearcher := boshnet.NewRoutesSearcher(runner, interfaceManager)
@@ -139,11 +153,14 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			compressor,
 			copier,
 			dirProvider,
-			vitalsService,
+			centosVitalsService,
 			linuxCdutil,
 			linuxDiskManager,
 			centosNetManager,
 			centosCertManager,
+			firewallManager,
+			centosNtpManager,
+			addressWatcher,
 			monitRetryStrategy,
 			devicePathResolver,
 			bootstrapState,
@@ -163,11 +180,14 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			compressor,
 			copier,
 			dirProvider,
-			vitalsService,
+			ubuntuVitalsService,
 			linuxCdutil,
 			linuxDiskManager,
 			ubuntuNetManager,
 			ubuntuCertManager,
+			firewallManager,
+			ubuntuNtpManager,
+			addressWatcher,
 			monitRetryStrategy,
 			devicePathResolver,
 			bootstrapState,
@@ -215,11 +235,14 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 			compressor,
 			copier,
 			dirProvider,
-			vitalsService,
+			opensuseVitalsService,
 			linuxCdutil,
 			linuxDiskManager,
 			opensuseNetManager,
 			opensuseCertManager,
+			firewallManager,
+			opensuseNtpManager,
+			addressWatcher,
 			monitRetryStrategy,
 			devicePathResolver,
 			bootstrapState,
@@ -5,6 +5,7 @@ import (
 
 	"github.com/cloudfoundry/gosigar"
 
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -17,12 +18,14 @@ type Service interface {
 type concreteService struct {
 	statsCollector boshstats.Collector
 	dirProvider    boshdirs.Provider
+	ntpManager     boshntp.Manager
 }
 
-func NewService(statsCollector boshstats.Collector, dirProvider boshdirs.Provider) Service {
+func NewService(statsCollector boshstats.Collector, dirProvider boshdirs.Provider, ntpManager boshntp.Manager) Service {
 	return concreteService{
 		statsCollector: statsCollector,
 		dirProvider:    dirProvider,
+		ntpManager:     ntpManager,
 	}
 }
 
@@ -83,13 +86,32 @@ func (s concreteService) Get() (vitals Vitals, err error) {
 		Swap:   createMemVitals(swapStats),
 		Disk:   diskStats,
 		Uptime: UptimeVitals{Secs: uptimeStats.Secs},
+		NTP:    s.getNTPVitals(),
 	}
 	return
 }
 
+func (s concreteService) getNTPVitals() *NTPVitals {
+	status, err := s.ntpManager.Status()
+	if err == boshntp.ErrNotSupported {
+		return nil
+	}
+
+	// Time synchronization daemon not responding
+	// should not prevent reporting other vitals
+	if err != nil {
+		return &NTPVitals{Synchronized: false}
+	}
+
+	return &NTPVitals{
+		Offset:       fmt.Sprintf("%.6f", status.Offset.Seconds()),
+		Synchronized: status.Synchronized,
+	}
+}
+
 func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	disks := map[string]string{
-		"/": "system",
+		"/":                      "system",
 		s.dirProvider.DataDir():  "ephemeral",
 		s.dirProvider.StoreDir(): "persistent",
 	}
@@ -1,12 +1,15 @@
 package vitals_test
 
 import (
+	"errors"
 	"runtime"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
+	fakentp "github.com/cloudfoundry/bosh-agent/platform/ntp/fakes"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	. "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -16,7 +19,7 @@ import (
 
 const Windows = runtime.GOOS == "windows"
 
-func buildVitalsService() (statsCollector *fakestats.FakeCollector, service Service) {
+func buildVitalsService() (statsCollector *fakestats.FakeCollector, ntpManager *fakentp.FakeManager, service Service) {
 	dirProvider := boshdirs.NewProvider("/fake/base/dir")
 	statsCollector = &fakestats.FakeCollector{
 		CPULoad: boshstats.CPULoad{
@@ -57,14 +60,16 @@ func buildVitalsService() (statsCollector *fakestats.FakeCollector, service Serv
 		},
 	}
 
-	service = NewService(statsCollector, dirProvider)
+	ntpManager = &fakentp.FakeManager{StatusErr: boshntp.ErrNotSupported}
+
+	service = NewService(statsCollector, dirProvider, ntpManager)
 	statsCollector.StartCollecting(1*time.Millisecond, nil)
 	return
 }
 
 var _ = Describe("Vitals service", func() {
 	It("vitals construction", func() {
-		_, service := buildVitalsService()
+		_, _, service := buildVitalsService()
 		vitals, err := service.Get()
 
 		expectedVitals := map[string]interface{}{
@@ -112,7 +117,7 @@ var _ = Describe("Vitals service", func() {
 
 	It("getting vitals when missing disks", func() {
 
-		statsCollector, service := buildVitalsService()
+		statsCollector, _, service := buildVitalsService()
 		statsCollector.DiskStats = map[string]boshstats.DiskStats{
 			"/": boshstats.DiskStats{
 				DiskUsage:  boshstats.Usage{Used: 100, Total: 200},
@@ -128,10 +133,39 @@ var _ = Describe("Vitals service", func() {
 	})
 	It("get getting vitals on system disk error", func() {
 
-		statsCollector, service := buildVitalsService()
+		statsCollector, _, service := buildVitalsService()
 		statsCollector.DiskStats = map[string]boshstats.DiskStats{}
 
 		_, err := service.Get()
 		Expect(err).To(HaveOccurred())
 	})
+
+	Describe("ntp vitals", func() {
+		It("includes clock offset and sync state", func() {
+			_, ntpManager, service := buildVitalsService()
+			ntpManager.StatusErr = nil
+			ntpManager.StatusStatus = boshntp.Status{Offset: -1500 * time.Millisecond, Synchronized: true}
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.NTP).To(Equal(&NTPVitals{Offset: "-1.500000", Synchronized: true}))
+		})
+
+		It("reports clock as not synchronized if status cannot be retrieved", func() {
+			_, ntpManager, service := buildVitalsService()
+			ntpManager.StatusErr = errors.New("fake-status-err")
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.NTP).To(Equal(&NTPVitals{Synchronized: false}))
+		})
+
+		It("omits ntp vitals if platform does not support it", func() {
+			_, _, service := buildVitalsService()
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.LacksJSONKey(GinkgoT(), vitals, "ntp")
+		})
+	})
 })
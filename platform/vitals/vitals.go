@@ -7,6 +7,7 @@ type Vitals struct {
 	Mem    MemoryVitals `json:"mem"`
 	Swap   MemoryVitals `json:"swap"`
 	Uptime UptimeVitals `json:"uptime"`
+	NTP    *NTPVitals   `json:"ntp,omitempty"`
 }
 
 type CPUVitals struct {
@@ -27,6 +28,12 @@ type MemoryVitals struct {
 	Percent string `json:"percent,omitempty"`
 }
 
+type NTPVitals struct {
+	// Offset of system clock from NTP time in seconds
+	Offset       string `json:"offset,omitempty"`
+	Synchronized bool   `json:"synchronized"`
+}
+
 type UptimeVitals struct {
 	Secs uint64 `json:"secs,omitempty"`
 }
@@ -12,7 +12,9 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshfirewall "github.com/cloudfoundry/bosh-agent/platform/firewall"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -40,6 +42,9 @@ type WindowsPlatform struct {
 	netManager             boshnet.Manager
 	devicePathResolver     boshdpresolv.DevicePathResolver
 	certManager            boshcert.Manager
+	firewallManager        boshfirewall.Manager
+	ntpManager             boshntp.Manager
+	addressWatcher         boshnet.AddressWatcher
 	defaultNetworkResolver boshsettings.DefaultNetworkResolver
 	auditLogger            AuditLogger
 	uuidGenerator          boshuuid.Generator
@@ -58,6 +63,8 @@ func NewWindowsPlatform(
 	auditLogger AuditLogger,
 	uuidGenerator boshuuid.Generator,
 ) Platform {
+	ntpManager := boshntp.NewDummyManager()
+
 	return &WindowsPlatform{
 		fs:                     fs,
 		cmdRunner:              cmdRunner,
@@ -67,8 +74,11 @@ func NewWindowsPlatform(
 		dirProvider:            dirProvider,
 		netManager:             netManager,
 		devicePathResolver:     devicePathResolver,
-		vitalsService:          boshvitals.NewService(collector, dirProvider),
+		vitalsService:          boshvitals.NewService(collector, dirProvider, ntpManager),
 		certManager:            certManager,
+		firewallManager:        boshfirewall.NewDummyManager(),
+		ntpManager:             ntpManager,
+		addressWatcher:         boshnet.NewDummyAddressWatcher(),
 		defaultNetworkResolver: defaultNetworkResolver,
 		auditLogger:            auditLogger,
 		uuidGenerator:          uuidGenerator,
@@ -280,6 +290,18 @@ func (p WindowsPlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
+func (p WindowsPlatform) GetFirewallManager() boshfirewall.Manager {
+	return p.firewallManager
+}
+
+func (p WindowsPlatform) GetNtpManager() boshntp.Manager {
+	return p.ntpManager
+}
+
+func (p WindowsPlatform) GetAddressWatcher() boshnet.AddressWatcher {
+	return p.addressWatcher
+}
+
 func (p WindowsPlatform) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
@@ -15,6 +15,10 @@ type FakeSettingsService struct {
 	SettingsWereInvalidated bool
 
 	Settings boshsettings.Settings
+
+	RefreshDynamicNetworksCallCount int
+	RefreshDynamicNetworksChanged   []string
+	RefreshDynamicNetworksErr       error
 }
 
 func (service *FakeSettingsService) InvalidateSettings() error {
@@ -34,3 +38,8 @@ func (service *FakeSettingsService) LoadSettings() error {
 func (service FakeSettingsService) GetSettings() boshsettings.Settings {
 	return service.Settings
 }
+
+func (service *FakeSettingsService) RefreshDynamicNetworks() ([]string, error) {
+	service.RefreshDynamicNetworksCallCount++
+	return service.RefreshDynamicNetworksChanged, service.RefreshDynamicNetworksErr
+}
@@ -2,6 +2,7 @@ package settings
 
 import (
 	"encoding/json"
+	"sort"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -18,6 +19,10 @@ type Service interface {
 	PublicSSHKeyForUsername(string) (string, error)
 
 	InvalidateSettings() error
+
+	// RefreshDynamicNetworks re-resolves dynamic networks and saves settings
+	// if their addresses changed. It returns names of changed networks.
+	RefreshDynamicNetworks() ([]string, error)
 }
 
 const settingsServiceLogTag = "settingsService"
@@ -123,18 +128,11 @@ func (s *settingsService) GetSettings() Settings {
 	}
 	s.settingsMutex.Unlock()
 
-	for networkName, network := range settingsCopy.Networks {
-		if !network.IsDHCP() {
-			continue
-		}
-
-		resolvedNetwork, err := s.resolveNetwork(network)
-		if err != nil {
-			break
-		}
-
-		settingsCopy.Networks[networkName] = resolvedNetwork
+	resolvedNetworks, _, err := s.resolveDynamicNetworks(settingsCopy.Networks)
+	if err == nil {
+		settingsCopy.Networks = resolvedNetworks
 	}
+
 	return settingsCopy
 }
 
@@ -147,6 +145,76 @@ func (s *settingsService) InvalidateSettings() error {
 	return nil
 }
 
+func (s *settingsService) RefreshDynamicNetworks() ([]string, error) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+
+	resolvedNetworks, changedNetworks, err := s.resolveDynamicNetworks(s.settings.Networks)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changedNetworks) == 0 {
+		return changedNetworks, nil
+	}
+
+	for _, networkName := range changedNetworks {
+		s.logger.Info(settingsServiceLogTag, "Address of dynamic network '%s' changed from '%s' to '%s'",
+			networkName, s.settings.Networks[networkName].IP, resolvedNetworks[networkName].IP)
+	}
+
+	s.settings.Networks = resolvedNetworks
+
+	settingsJSON, err := json.Marshal(s.settings)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling settings json")
+	}
+
+	err = s.fs.WriteFileQuietly(s.settingsPath, settingsJSON)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing setting json")
+	}
+
+	return changedNetworks, nil
+}
+
+// resolveDynamicNetworks returns a copy of networks with addresses of dynamic
+// networks resolved and sorted names of dynamic networks whose addresses changed
+func (s *settingsService) resolveDynamicNetworks(networks Networks) (Networks, []string, error) {
+	changedNetworks := []string{}
+
+	if networks == nil {
+		return nil, changedNetworks, nil
+	}
+
+	resolvedNetworks := Networks{}
+
+	for networkName, network := range networks {
+		resolvedNetworks[networkName] = network
+
+		if !network.IsDHCP() {
+			continue
+		}
+
+		resolvedNetwork, err := s.resolveNetwork(network)
+		if err != nil {
+			return nil, nil, bosherr.WrapErrorf(err, "Resolving dynamic network '%s'", networkName)
+		}
+
+		resolvedNetworks[networkName] = resolvedNetwork
+
+		if resolvedNetwork.IP != network.IP ||
+			resolvedNetwork.Netmask != network.Netmask ||
+			resolvedNetwork.Gateway != network.Gateway {
+			changedNetworks = append(changedNetworks, networkName)
+		}
+	}
+
+	sort.Strings(changedNetworks)
+
+	return resolvedNetworks, changedNetworks, nil
+}
+
 func (s *settingsService) resolveNetwork(network Network) (Network, error) {
 	// Ideally this would be GetNetworkByMACAddress(mac string)
 	// Currently, we are relying that if the default network does not contain
@@ -315,5 +315,103 @@ func init() {
 				})
 			})
 		})
+
+		Describe("RefreshDynamicNetworks", func() {
+			var service Service
+
+			BeforeEach(func() {
+				fakeSettingsSource.SettingsValue = Settings{
+					AgentID: "fake-agent-id",
+					Networks: map[string]Network{
+						"fake-static-net": Network{
+							IP:      "fake-static-ip",
+							Netmask: "fake-static-netmask",
+							Mac:     "fake-static-mac",
+						},
+						"fake-dynamic-net": Network{
+							Type: NetworkTypeDynamic,
+							DNS:  []string{"fake-dns"},
+						},
+					},
+				}
+
+				fakeDefaultNetworkResolver.GetDefaultNetworkNetwork = Network{
+					IP:      "fake-resolved-ip",
+					Netmask: "fake-resolved-netmask",
+					Gateway: "fake-resolved-gateway",
+				}
+
+				service, fs = buildService()
+				err := service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("updates and saves dynamic networks which addresses changed", func() {
+				changedNetworks, err := service.RefreshDynamicNetworks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changedNetworks).To(Equal([]string{"fake-dynamic-net"}))
+
+				expectedNetwork := Network{
+					Type:     NetworkTypeDynamic,
+					IP:       "fake-resolved-ip",
+					Netmask:  "fake-resolved-netmask",
+					Gateway:  "fake-resolved-gateway",
+					DNS:      []string{"fake-dns"},
+					Resolved: true,
+				}
+
+				Expect(service.GetSettings().Networks["fake-dynamic-net"]).To(Equal(expectedNetwork))
+
+				var savedSettings Settings
+
+				savedJSON, err := fs.ReadFile("/setting/path.json")
+				Expect(err).ToNot(HaveOccurred())
+				err = json.Unmarshal(savedJSON, &savedSettings)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(savedSettings.AgentID).To(Equal("fake-agent-id"))
+				Expect(savedSettings.Networks["fake-dynamic-net"]).To(Equal(expectedNetwork))
+				Expect(savedSettings.Networks["fake-static-net"].IP).To(Equal("fake-static-ip"))
+			})
+
+			It("does not save settings if addresses did not change", func() {
+				_, err := service.RefreshDynamicNetworks()
+				Expect(err).ToNot(HaveOccurred())
+
+				fs.WriteFileError = errors.New("fake-write-err")
+
+				changedNetworks, err := service.RefreshDynamicNetworks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changedNetworks).To(BeEmpty())
+			})
+
+			It("reports network again when its address changes later", func() {
+				_, err := service.RefreshDynamicNetworks()
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeDefaultNetworkResolver.GetDefaultNetworkNetwork.IP = "fake-new-ip"
+
+				changedNetworks, err := service.RefreshDynamicNetworks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changedNetworks).To(Equal([]string{"fake-dynamic-net"}))
+				Expect(service.GetSettings().Networks["fake-dynamic-net"].IP).To(Equal("fake-new-ip"))
+			})
+
+			It("returns error if dynamic network cannot be resolved", func() {
+				fakeDefaultNetworkResolver.GetDefaultNetworkErr = errors.New("fake-get-default-network-err")
+
+				_, err := service.RefreshDynamicNetworks()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-default-network-err"))
+			})
+
+			It("returns error if saving settings fails", func() {
+				fs.WriteFileError = errors.New("fake-write-err")
+
+				_, err := service.RefreshDynamicNetworks()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			})
+		})
 	})
 }
@@ -257,17 +257,19 @@ func (e Env) IsNATSMutualTLSEnabled() bool {
 }
 
 type BoshEnv struct {
-	Password              string      `json:"password"`
-	KeepRootPassword      bool        `json:"keep_root_password"`
-	RemoveDevTools        bool        `json:"remove_dev_tools"`
-	RemoveStaticLibraries bool        `json:"remove_static_libraries"`
-	AuthorizedKeys        []string    `json:"authorized_keys"`
-	SwapSizeInMB          *uint64     `json:"swap_size"`
-	Mbus                  MBus        `json:"mbus"`
-	IPv6                  IPv6        `json:"ipv6"`
-	Blobstores            []Blobstore `json:"blobstores"`
-	NTP                   []string    `json:"ntp"`
-	Parallel              *int        `json:"parallel"`
+	Password              string        `json:"password"`
+	KeepRootPassword      bool          `json:"keep_root_password"`
+	RemoveDevTools        bool          `json:"remove_dev_tools"`
+	RemoveStaticLibraries bool          `json:"remove_static_libraries"`
+	AuthorizedKeys        []string      `json:"authorized_keys"`
+	SwapSizeInMB          *uint64       `json:"swap_size"`
+	Mbus                  MBus          `json:"mbus"`
+	IPv6                  IPv6          `json:"ipv6"`
+	Blobstores            []Blobstore   `json:"blobstores"`
+	NTP                   []string      `json:"ntp"`
+	Parallel              *int          `json:"parallel"`
+	Firewall              Firewall      `json:"firewall"`
+	LogForwarding         LogForwarding `json:"log_forwarding"`
 }
 
 type MBus struct {
@@ -285,6 +287,74 @@ type IPv6 struct {
 	Enable bool `json:"enable"`
 }
 
+const (
+	FirewallPolicyAccept = "accept"
+	FirewallPolicyDrop   = "drop"
+)
+
+// Firewall describes baseline inbound filtering rules for the VM.
+// Jobs may declare additional listening ports via the apply spec.
+type Firewall struct {
+	// Either "accept" (default) or "drop"; applies to inbound
+	// traffic that does not match any rule
+	DefaultPolicy string         `json:"default_policy"`
+	Rules         []FirewallRule `json:"rules"`
+}
+
+// FirewallRule allows inbound traffic to the given ports.
+// When Sources is not empty only those addresses (or CIDRs) may
+// reach the ports; traffic from anywhere else is dropped.
+type FirewallRule struct {
+	Protocol string   `json:"protocol"`
+	Ports    []int    `json:"ports"`
+	Sources  []string `json:"sources"`
+}
+
+func (f Firewall) IsEmpty() bool {
+	return len(f.Rules) == 0 && (f.DefaultPolicy == "" || f.DefaultPolicy == FirewallPolicyAccept)
+}
+
+const (
+	LogForwardingTransportTCP = "tcp"
+	LogForwardingTransportTLS = "tls"
+	LogForwardingTransportUDP = "udp"
+)
+
+// LogForwarding configures forwarding of job logs to a syslog server.
+// Logs are buffered on disk while the server cannot be reached.
+type LogForwarding struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+
+	// Either "tcp" (default), "tls" or "udp"
+	Transport string `json:"transport"`
+
+	// CA verifies certificate of the server when transport is "tls";
+	// system CAs are used when not set
+	CA string `json:"ca"`
+
+	// BufferSizeInMB limits logs kept on disk while server is unreachable
+	BufferSizeInMB uint64 `json:"buffer_size"`
+}
+
+func (l LogForwarding) IsEmpty() bool {
+	return l.Host == ""
+}
+
+func (l LogForwarding) TransportOrDefault() string {
+	if l.Transport == "" {
+		return LogForwardingTransportTCP
+	}
+	return l.Transport
+}
+
+func (l LogForwarding) BufferSizeInBytes() uint64 {
+	if l.BufferSizeInMB == 0 {
+		return 100 * 1024 * 1024
+	}
+	return l.BufferSizeInMB * 1024 * 1024
+}
+
 type DNSRecords struct {
 	Version uint64      `json:"Version"`
 	Records [][2]string `json:"records"`
@@ -781,6 +781,55 @@ var _ = Describe("Settings", func() {
 			Expect(env.Bosh.IPv6).To(Equal(IPv6{Enable: true}))
 		})
 
+		It("can specify firewall rules", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {} }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Firewall.IsEmpty()).To(BeTrue())
+
+			env = Env{}
+			err = json.Unmarshal([]byte(`{"bosh": {"firewall": {
+				"default_policy": "drop",
+				"rules": [{"protocol": "tcp", "ports": [6868, 2822], "sources": ["10.0.0.5", "10.0.1.0/24"]}]
+			} } }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Firewall).To(Equal(Firewall{
+				DefaultPolicy: "drop",
+				Rules: []FirewallRule{
+					{Protocol: "tcp", Ports: []int{6868, 2822}, Sources: []string{"10.0.0.5", "10.0.1.0/24"}},
+				},
+			}))
+			Expect(env.Bosh.Firewall.IsEmpty()).To(BeFalse())
+		})
+
+		It("can specify log forwarding", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {} }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.LogForwarding.IsEmpty()).To(BeTrue())
+			Expect(env.Bosh.LogForwarding.TransportOrDefault()).To(Equal("tcp"))
+			Expect(env.Bosh.LogForwarding.BufferSizeInBytes()).To(Equal(uint64(100 * 1024 * 1024)))
+
+			env = Env{}
+			err = json.Unmarshal([]byte(`{"bosh": {"log_forwarding": {
+				"host": "syslog.example.com",
+				"port": 6514,
+				"transport": "tls",
+				"ca": "fake-ca",
+				"buffer_size": 10
+			} } }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.LogForwarding).To(Equal(LogForwarding{
+				Host:           "syslog.example.com",
+				Port:           6514,
+				Transport:      "tls",
+				CA:             "fake-ca",
+				BufferSizeInMB: 10,
+			}))
+			Expect(env.Bosh.LogForwarding.IsEmpty()).To(BeFalse())
+			Expect(env.Bosh.LogForwarding.BufferSizeInBytes()).To(Equal(uint64(10 * 1024 * 1024)))
+		})
+
 		Context("when swap_size is not specified in the json", func() {
 			It("unmarshalls correctly", func() {
 				var env Env